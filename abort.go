@@ -0,0 +1,175 @@
+package gogs
+
+import (
+	"sync"
+	"time"
+)
+
+// pending is a struct that holds the shutdown triggered with Trigger and held for the
+// window set with WithAbortWindow, during which Abort cancels it.
+type pending struct {
+	// mu guards the fields below.
+	mu sync.Mutex
+
+	// timer starts the held shutdown once the window has elapsed. Nil means no shutdown is
+	// held.
+	timer *time.Timer
+
+	// intakes is the list of intakes registered with AddPausableIntake.
+	intakes []pausableIntake
+
+	// paused reports whether the intakes are paused by the held shutdown.
+	paused bool
+
+	// aborted is closed once the held shutdown is canceled with Abort, and created lazily
+	// again afterwards, see abortedChan.
+	aborted chan struct{}
+}
+
+// pausableIntake is an intake registered with AddPausableIntake.
+type pausableIntake struct {
+	pause  func() error
+	resume func() error
+}
+
+// WithAbortWindow is an option that makes Trigger hold the shutdown created by New for the
+// provided window before starting it, e.g. when an operator drains the instance from an
+// admin endpoint, so that a shutdown triggered by mistake on a healthy instance can be
+// canceled with Abort. While the shutdown is held, the intakes registered with
+// AddPausableIntake are paused, while the context created by New is not canceled and the
+// hooks are not executed. Abort re-arms the triggers set with WithTrigger. The signals,
+// the parent context and the cancel function returned by New are never held, since they
+// usually precede a kill.
+//
+//	gs, ctx, cancel := New(context.Background(), WithAbortWindow(30*time.Second))
+//	admin.HandleFunc("/drain", func(http.ResponseWriter, *http.Request) {
+//		gs.Trigger(ReasonCancel)
+//	})
+//	admin.HandleFunc("/undrain", func(w http.ResponseWriter, _ *http.Request) {
+//		if err := gs.Abort(); err != nil {
+//			http.Error(w, err.Error(), http.StatusConflict)
+//		}
+//	})
+//
+// This example drains the instance 30 seconds after the call to /drain, unless /undrain is
+// called before.
+func WithAbortWindow(window time.Duration) Option {
+	return func(cfg *config) {
+		cfg.abortWindow = window
+	}
+}
+
+// AddPausableIntake is a method of the GracefulShutdown struct. It registers a named
+// intake of new work that can be paused and resumed, e.g. a consumer of a queue. The pause
+// function is executed when the shutdown is held by Trigger, see WithAbortWindow, and the
+// resume function when it is aborted. Otherwise, the pause function stops the intake like
+// a function registered with AddIntake, unless the intake is already paused. The
+// functions must not block.
+func (gs *GracefulShutdown) AddPausableIntake(name string, pauseFn, resumeFn func() error) {
+	gs.pending.mu.Lock()
+	gs.pending.intakes = append(gs.pending.intakes, pausableIntake{pause: pauseFn, resume: resumeFn})
+	gs.pending.mu.Unlock()
+
+	gs.AddIntake(name, func() error {
+		gs.pending.mu.Lock()
+		defer gs.pending.mu.Unlock()
+
+		if gs.pending.paused {
+			return nil
+		}
+		return pauseFn()
+	})
+}
+
+// Abort is a method of the GracefulShutdown struct. It cancels the shutdown held by
+// Trigger within the window set with WithAbortWindow, and resumes the intakes registered
+// with AddPausableIntake in order of registration, so that the instance returns to the
+// running state. It returns ErrNotPending if no shutdown is held, e.g. once it has
+// started, and the first error returned by the resume functions otherwise.
+func (gs *GracefulShutdown) Abort() error {
+	gs.pending.mu.Lock()
+	defer gs.pending.mu.Unlock()
+
+	if gs.pending.timer == nil {
+		return ErrNotPending
+	}
+	gs.pending.timer.Stop()
+	gs.pending.timer = nil
+	if gs.ctx.Err() != nil {
+		return ErrNotPending
+	}
+
+	var err error
+	for _, in := range gs.pending.intakes {
+		if resumeErr := in.resume(); resumeErr != nil && err == nil {
+			err = resumeErr
+		}
+	}
+	gs.pending.paused = false
+	if gs.pending.aborted != nil {
+		close(gs.pending.aborted)
+		gs.pending.aborted = nil
+	}
+	gs.debugf("shutdown aborted")
+
+	return err
+}
+
+// abortedChan is a method of the GracefulShutdown struct. It returns the channel closed
+// by the next call to Abort canceling a held shutdown.
+func (gs *GracefulShutdown) abortedChan() <-chan struct{} {
+	gs.pending.mu.Lock()
+	defer gs.pending.mu.Unlock()
+
+	if gs.pending.aborted == nil {
+		gs.pending.aborted = make(chan struct{})
+	}
+
+	return gs.pending.aborted
+}
+
+// hold is a method of the GracefulShutdown struct. It holds the shutdown triggered for the
+// provided reason for the window set with WithAbortWindow, pausing the intakes registered
+// with AddPausableIntake, and reports whether it is held. A shutdown triggered while
+// another one is held is not held, so that a second Trigger starts it at once.
+func (gs *GracefulShutdown) hold(reason Reason) bool {
+	if gs.cfg.abortWindow <= 0 || gs.ctx == nil {
+		return false
+	}
+
+	gs.pending.mu.Lock()
+	defer gs.pending.mu.Unlock()
+
+	if gs.pending.timer != nil {
+		gs.pending.timer.Stop()
+		gs.pending.timer = nil
+		return false
+	}
+	if gs.ctx.Err() != nil {
+		return false
+	}
+
+	for i := len(gs.pending.intakes) - 1; i >= 0; i-- {
+		_ = gs.pending.intakes[i].pause()
+	}
+	gs.pending.paused = true
+	gs.debugf("shutdown held for %s", gs.cfg.abortWindow)
+
+	var timer *time.Timer
+	timer = time.AfterFunc(gs.cfg.abortWindow, func() {
+		gs.pending.mu.Lock()
+		held := gs.pending.timer == timer
+		if held {
+			gs.pending.timer = nil
+		}
+		gs.pending.mu.Unlock()
+
+		if held {
+			gs.start(reason)
+			gs.cancel()
+		}
+	})
+	gs.pending.timer = timer
+
+	return true
+}
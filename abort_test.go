@@ -0,0 +1,91 @@
+package gogs
+
+import (
+	"context"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GracefulShutdown_Abort(t *testing.T) {
+	t.Parallel()
+	gs, ctx, cancel := New(context.Background(), WithSignals(syscall.SIGINT), WithAbortWindow(LongDelay))
+	defer cancel()
+
+	var mu sync.Mutex
+	var calls []string
+	record := func(call string) func() error {
+		return func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			calls = append(calls, call)
+			return nil
+		}
+	}
+	gs.AddPausableIntake("consumer", record("pause"), record("resume"))
+	gs.AddIntake("http", record("close"))
+
+	assert.ErrorIs(t, gs.Abort(), ErrNotPending)
+
+	gs.Trigger(ReasonHealth)
+	time.Sleep(ShortDelay)
+	assert.NoError(t, ctx.Err())
+	assert.Empty(t, gs.Reason())
+
+	assert.NoError(t, gs.Abort())
+	assert.ErrorIs(t, gs.Abort(), ErrNotPending)
+	time.Sleep(LongDelay)
+	assert.NoError(t, ctx.Err())
+
+	gs.Trigger(ReasonHealth)
+	gs.Trigger(ReasonHealth)
+	<-ctx.Done()
+	gs.Wait()
+
+	assert.ErrorIs(t, gs.Abort(), ErrNotPending)
+	assert.Equal(t, ReasonHealth, gs.Reason())
+	assert.Equal(t, []string{"pause", "resume", "pause", "close"}, calls)
+}
+
+func Test_GracefulShutdown_Abort_Window(t *testing.T) {
+	t.Parallel()
+	gs, ctx, cancel := New(context.Background(), WithSignals(syscall.SIGINT), WithAbortWindow(ShortDelay))
+	defer cancel()
+
+	var paused, stopped int
+	gs.AddPausableIntake("consumer", func() error {
+		paused++
+		return nil
+	}, func() error {
+		return nil
+	})
+	gs.AddHook("db", func(context.Context) error {
+		stopped++
+		return nil
+	})
+
+	start := time.Now()
+	gs.Trigger(ReasonCancel)
+	<-ctx.Done()
+	assert.GreaterOrEqual(t, time.Since(start), ShortDelay)
+	gs.Wait()
+
+	assert.ErrorIs(t, gs.Abort(), ErrNotPending)
+	assert.Equal(t, 1, paused)
+	assert.Equal(t, 1, stopped)
+}
+
+func Test_GracefulShutdown_Abort_Cancel(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background(), WithSignals(syscall.SIGINT), WithAbortWindow(LongDelay))
+
+	gs.Trigger(ReasonCancel)
+	cancel()
+	gs.Wait()
+
+	assert.ErrorIs(t, gs.Abort(), ErrNotPending)
+	assert.Equal(t, ReasonCancel, gs.Reason())
+}
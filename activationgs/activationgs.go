@@ -0,0 +1,16 @@
+// Package activationgs receives the listeners passed by systemd socket activation and
+// manages them with a GracefulShutdowner: they stop accepting connections as soon as the
+// shutdown starts and, optionally, are handed back to systemd, so that the next instance
+// of the service receives them without a connection being refused in between.
+package activationgs
+
+import "net"
+
+// Listener is a struct that describes a listener passed by systemd.
+type Listener struct {
+	net.Listener
+
+	// Name is the name of the listener set with FileDescriptorName= in the socket unit, or
+	// with FDNAME= when it was stored, "unknown" by default.
+	Name string
+}
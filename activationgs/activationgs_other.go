@@ -0,0 +1,11 @@
+//go:build !unix
+
+package activationgs
+
+import gogs "github.com/dsbasko/go-gs"
+
+// Listeners is a function that returns the listeners passed by systemd socket activation.
+// Socket activation is not available on this platform, so it returns none.
+func Listeners(_ gogs.IntakeRegistrar, _ bool) ([]Listener, error) {
+	return nil, nil
+}
@@ -0,0 +1,94 @@
+//go:build unix
+
+package activationgs
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+func Test_activate(t *testing.T) {
+	t.Parallel()
+
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer tcp.Close()
+	f, err := tcp.(*net.TCPListener).File()
+	if !assert.NoError(t, err) {
+		return
+	}
+	fd, err := syscall.Dup(int(f.Fd()))
+	_ = f.Close()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	lns, err := activate("1", "1", "", fd)
+	assert.NoError(t, err)
+	assert.Empty(t, lns)
+
+	lns, err = activate(strconv.Itoa(os.Getpid()), "x", "", fd)
+	assert.EqualError(t, err, `activationgs: invalid LISTEN_FDS "x"`)
+	assert.Empty(t, lns)
+
+	lns, err = activate(strconv.Itoa(os.Getpid()), "1", "http", fd)
+	if assert.NoError(t, err) && assert.Len(t, lns, 1) {
+		assert.Equal(t, "http", lns[0].Name)
+		assert.Equal(t, tcp.Addr().String(), lns[0].Addr().String())
+		_ = lns[0].Close()
+	}
+}
+
+func Test_register(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := gogs.New(context.Background())
+
+	socket := filepath.Join(t.TempDir(), "notify")
+	notify, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer notify.Close()
+
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	register(gs, Listener{Listener: tcp, Name: "http"}, true, socket)
+
+	cancel()
+	gs.Wait()
+	assert.NoError(t, gs.Report().Err())
+
+	msg, oob := make([]byte, 64), make([]byte, 64)
+	n, oobn, _, _, err := notify.ReadMsgUnix(msg, oob)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "FDSTORE=1\nFDNAME=http\n", string(msg[:n]))
+
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if assert.NoError(t, err) && assert.Len(t, msgs, 1) {
+		fds, err := syscall.ParseUnixRights(&msgs[0])
+		if assert.NoError(t, err) && assert.Len(t, fds, 1) {
+			f := os.NewFile(uintptr(fds[0]), "http")
+			stored, err := net.FileListener(f)
+			_ = f.Close()
+			if assert.NoError(t, err) {
+				assert.Equal(t, tcp.Addr().String(), stored.Addr().String())
+				_ = stored.Close()
+			}
+		}
+	}
+}
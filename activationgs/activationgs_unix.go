@@ -0,0 +1,158 @@
+//go:build unix
+
+package activationgs
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+// listenFDsStart is the first file descriptor passed by systemd.
+const listenFDsStart = 3
+
+// Listeners is a function that returns the listeners passed by systemd socket activation,
+// in order, or none if the process was not activated, i.e. if LISTEN_PID is not its PID.
+// The variables describing them are unset, so that the child processes do not inherit
+// them. The listeners are registered as intake of the GracefulShutdowner, see
+// gogs.AddListener, so they stop accepting connections as soon as the shutdown starts.
+// If store is set, the listeners are sent to the file descriptor store of systemd before
+// they are closed, so that the next instance of the service receives them, including the
+// ones the socket unit does not declare. The unit then needs FileDescriptorStoreMax= and
+// NotifyAccess=.
+//
+//	lns, err := Listeners(gs, true)
+//	if err != nil {
+//		return err
+//	}
+//	for _, ln := range lns {
+//		go srv.Serve(ln)
+//	}
+//
+// This example serves the sockets of the socket unit and stores them at shutdown.
+func Listeners(gs gogs.IntakeRegistrar, store bool) ([]Listener, error) {
+	lns, err := activate(
+		os.Getenv("LISTEN_PID"), os.Getenv("LISTEN_FDS"), os.Getenv("LISTEN_FDNAMES"), listenFDsStart,
+	)
+	_ = os.Unsetenv("LISTEN_PID")
+	_ = os.Unsetenv("LISTEN_FDS")
+	_ = os.Unsetenv("LISTEN_FDNAMES")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ln := range lns {
+		register(gs, ln, store, "")
+	}
+
+	return lns, nil
+}
+
+// activate is a function that returns the listeners described by the provided values of
+// the LISTEN_PID, LISTEN_FDS and LISTEN_FDNAMES variables, starting at the provided file
+// descriptor.
+func activate(pid, count, names string, first int) ([]Listener, error) {
+	if pid == "" || pid != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("activationgs: invalid LISTEN_FDS %q", count)
+	}
+
+	var fdNames []string
+	if names != "" {
+		fdNames = strings.Split(names, ":")
+	}
+
+	lns := make([]Listener, 0, n)
+	for fd := first; fd < first+n; fd++ {
+		name := "unknown"
+		if i := fd - first; i < len(fdNames) {
+			name = fdNames[i]
+		}
+
+		syscall.CloseOnExec(fd)
+		f := os.NewFile(uintptr(fd), name)
+		ln, err := net.FileListener(f)
+		_ = f.Close()
+		if err != nil {
+			for _, l := range lns {
+				_ = l.Close()
+			}
+			return nil, fmt.Errorf("activationgs: file descriptor %d (%s): %w", fd, name, err)
+		}
+
+		lns = append(lns, Listener{Listener: ln, Name: name})
+	}
+
+	return lns, nil
+}
+
+// register is a function that registers the provided listener as intake, stored to the
+// provided notification socket, or the NOTIFY_SOCKET variable if empty, before it is
+// closed if store is set.
+func register(gs gogs.IntakeRegistrar, ln Listener, store bool, socket string) {
+	gs.AddIntake(ln.Name, func() error {
+		var err error
+		if store {
+			err = storeFD(socket, ln)
+		}
+		if closeErr := ln.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	})
+}
+
+// filer is an interface that describes the listeners whose file descriptor can be
+// duplicated, e.g. *net.TCPListener and *net.UnixListener.
+type filer interface {
+	File() (*os.File, error)
+}
+
+// storeFD is a function that sends the file descriptor of the provided listener to the
+// file descriptor store of systemd through the provided notification socket, or the
+// NOTIFY_SOCKET variable if empty.
+func storeFD(socket string, ln Listener) error {
+	if socket == "" {
+		socket = os.Getenv("NOTIFY_SOCKET")
+	}
+	if socket == "" {
+		return errors.New("activationgs: NOTIFY_SOCKET is not set")
+	}
+	if strings.HasPrefix(socket, "@") {
+		socket = "\x00" + socket[1:]
+	}
+
+	fl, ok := ln.Listener.(filer)
+	if !ok {
+		return fmt.Errorf("activationgs: listener %s has no file descriptor", ln.Name)
+	}
+	f, err := fl.File()
+	if err != nil {
+		return fmt.Errorf("activationgs: %w", err)
+	}
+	defer f.Close()
+
+	sock, err := syscall.Socket(syscall.AF_UNIX, syscall.SOCK_DGRAM, 0)
+	if err != nil {
+		return fmt.Errorf("activationgs: %w", err)
+	}
+	defer syscall.Close(sock)
+
+	msg := "FDSTORE=1\nFDNAME=" + ln.Name + "\n"
+	rights := syscall.UnixRights(int(f.Fd()))
+	if err = syscall.Sendmsg(sock, []byte(msg), rights, &syscall.SockaddrUnix{Name: socket}, 0); err != nil {
+		return fmt.Errorf("activationgs: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+package gogs
+
+import "context"
+
+// TrySubscribe is a method of the GracefulShutdown struct. It increments the count of
+// active shutdown events by one, and returns ErrShuttingDown instead if the drain is
+// completing or has completed. Unlike SubscribeCtx, it admits the events once the shutdown
+// has started, as long as the drain waits for other events, so the event joins the
+// current drain. It is safe to call concurrently with the start of the drain.
+//
+//	if err := gs.TrySubscribe(); err != nil {
+//		return err
+//	}
+//	defer gs.Unsubscribe()
+//	ack(msg)
+//
+// This example acks the message received before the shutdown, unless the drain is over.
+func (gs *GracefulShutdown) TrySubscribe() error {
+	count, ok := gs.admit(1, true, false)
+	if !ok {
+		return ErrShuttingDown
+	}
+	gs.ages.push(1)
+	gs.notify(gs.cfg.observer.OnSubscribe, 1, count)
+
+	return nil
+}
+
+// TryUnsubscribe is a method of the GracefulShutdown struct. It decrements the count of
+// active shutdown events by one, and returns ErrNotSubscribed instead if there is no active
+// shutdown event, which Unsubscribe silently ignores. The check and the decrement are
+// atomic.
+func (gs *GracefulShutdown) TryUnsubscribe() error {
+	if !gs.unsubscribe() {
+		return ErrNotSubscribed
+	}
+	gs.ages.pop(1)
+
+	return nil
+}
+
+// TryAddHook is a method of the GracefulShutdown struct. It registers a named cleanup
+// function like AddHook, and returns ErrShuttingDown instead if the hooks are already
+// being executed, in which case the hook would never be.
+func (gs *GracefulShutdown) TryAddHook(
+	name string,
+	hookFn func(ctx context.Context) error,
+	opts ...HookOption,
+) error {
+	h := hook{name: name, fn: hookFn}
+	for _, opt := range opts {
+		opt(&h)
+	}
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if gs.hooksStarted {
+		return ErrShuttingDown
+	}
+	gs.appendHook(h)
+
+	return nil
+}
+
+// admit is a method of the GracefulShutdown struct. It adds the provided count to the
+// active shutdown events and returns the new count. While the drain waits for other
+// events, the events join it. Once the count has dropped to zero, the drain is completing:
+// strict admissions are rejected, and the others wait for the drain to complete and are
+// counted afterwards, so that the WaitGroup is never added to from zero while it is
+// waited for. Strict admissions are rejected after the drain as well. The critical
+// events are reserved along with their admission, see releaseLocked.
+func (gs *GracefulShutdown) admit(count int32, strict, critical bool) (int32, bool) {
+	for {
+		gs.subMu.Lock()
+		if gs.list.Load() > 0 || (!gs.waiting && !(strict && gs.drained)) {
+			list := gs.list.Add(count)
+			gs.waitGroup().Add(int(count))
+			if critical {
+				gs.reserved += count
+			}
+			gs.subMu.Unlock()
+			return list, true
+		}
+		if strict {
+			gs.subMu.Unlock()
+			return 0, false
+		}
+		waitDone := gs.waitDone
+		gs.subMu.Unlock()
+
+		<-waitDone
+	}
+}
+
+// waitDrain is a method of the GracefulShutdown struct. It waits for the WaitGroup,
+// rejecting the strict admissions from zero meanwhile and afterwards, see admit. The
+// concurrent calls, e.g. of concurrent calls to Wait, share the channel the admissions
+// wait on, which the first call to return closes.
+func (gs *GracefulShutdown) waitDrain() {
+	gs.subMu.Lock()
+	if !gs.waiting {
+		gs.waiting = true
+		gs.waitDone = make(chan struct{})
+	}
+	gs.subMu.Unlock()
+
+	gs.waitGroup().Wait()
+
+	gs.subMu.Lock()
+	if gs.waiting {
+		gs.waiting = false
+		gs.drained = true
+		close(gs.waitDone)
+	}
+	gs.subMu.Unlock()
+}
+
+// release is a method of the GracefulShutdown struct. It removes at most the provided
+// count from the active shutdown events, and returns the new count and the count removed.
+func (gs *GracefulShutdown) release(count int32) (list, released int32) {
+	gs.subMu.Lock()
+	defer gs.subMu.Unlock()
+
+	return gs.releaseLocked(count)
+}
+
+// releaseCritical is a method of the GracefulShutdown struct. It removes a completed
+// critical event from the active shutdown events along with its reservation.
+func (gs *GracefulShutdown) releaseCritical() (list, released int32) {
+	gs.subMu.Lock()
+	defer gs.subMu.Unlock()
+
+	if gs.reserved > 0 {
+		gs.reserved--
+	}
+	return gs.releaseLocked(1)
+}
+
+// releaseAbove is a method of the GracefulShutdown struct. It removes the active shutdown
+// events beyond the provided count of critical events kept, which is read along with the
+// removal so that a concurrent unsubscription never makes it remove the events kept, and
+// drops the reservations of the others, see abandonSubscribers.
+func (gs *GracefulShutdown) releaseAbove(kept int32) (list, released int32) {
+	gs.subMu.Lock()
+	defer gs.subMu.Unlock()
+
+	if gs.reserved > kept {
+		gs.reserved = kept
+	}
+	return gs.releaseLocked(gs.list.Load() - kept)
+}
+
+// releaseLocked is a method of the GracefulShutdown struct. It implements release with
+// subMu held. The events reserved by the critical events are never removed, so that the
+// unsubscriptions of other events, e.g. the ones abandoned by the drain, do not complete
+// them.
+func (gs *GracefulShutdown) releaseLocked(count int32) (list, released int32) {
+	list = gs.list.Load()
+	if list-gs.reserved < count {
+		count = list - gs.reserved
+	}
+	if count <= 0 {
+		return list, 0
+	}
+
+	list = gs.list.Add(-count)
+	gs.unsubscribed.Add(int64(count))
+	for i := int32(0); i < count; i++ {
+		gs.waitGroup().Done()
+	}
+
+	return list, count
+}
@@ -0,0 +1,204 @@
+package gogs
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GracefulShutdown_TrySubscribe(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background())
+
+	assert.NoError(t, gs.TrySubscribe())
+	assert.Equal(t, int32(1), gs.Count())
+
+	cancel()
+	go func() {
+		shortDelay()
+		assert.NoError(t, gs.TrySubscribe())
+		gs.Unsubscribe()
+		gs.Unsubscribe()
+	}()
+	gs.Wait()
+
+	assert.ErrorIs(t, gs.TrySubscribe(), ErrShuttingDown)
+	assert.Equal(t, int32(0), gs.Count())
+
+	gs.Subscribe()
+	assert.Equal(t, int32(1), gs.Count())
+	gs.Unsubscribe()
+}
+
+func Test_GracefulShutdown_TryUnsubscribe(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background())
+	defer cancel()
+
+	assert.ErrorIs(t, gs.TryUnsubscribe(), ErrNotSubscribed)
+	gs.Subscribe()
+	assert.NoError(t, gs.TryUnsubscribe())
+	assert.ErrorIs(t, gs.TryUnsubscribe(), ErrNotSubscribed)
+	assert.Equal(t, int32(0), gs.Count())
+}
+
+func Test_GracefulShutdown_TrySubscribe_Stress(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 50; i++ {
+		gs, _, cancel := New(context.Background())
+
+		var drained atomic.Bool
+		var active, late atomic.Int32
+		var wg sync.WaitGroup
+		for j := 0; j < 20; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for k := 0; k < 20; k++ {
+					after := drained.Load()
+					if err := gs.TrySubscribe(); err != nil {
+						assert.ErrorIs(t, err, ErrShuttingDown)
+						continue
+					}
+					if after {
+						late.Add(1)
+					}
+					active.Add(1)
+					runtime.Gosched()
+					active.Add(-1)
+					gs.Unsubscribe()
+				}
+			}()
+		}
+
+		cancel()
+		gs.Wait()
+		assert.Equal(t, int32(0), active.Load())
+		drained.Store(true)
+		wg.Wait()
+
+		assert.Equal(t, int32(0), late.Load())
+		assert.Equal(t, int32(0), gs.Count())
+	}
+}
+
+func Test_GracefulShutdown_Subscribe_Stress(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 50; i++ {
+		gs, _, cancel := New(context.Background())
+
+		var wg sync.WaitGroup
+		for j := 0; j < 20; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for k := 0; k < 20; k++ {
+					gs.Subscribe()
+					gs.SubscribeN(2)
+					gs.UnsubscribeN(3)
+					gs.Unsubscribe()
+				}
+			}()
+		}
+
+		cancel()
+		gs.Wait()
+		wg.Wait()
+
+		assert.Equal(t, int32(0), gs.Count())
+	}
+}
+
+func Test_GracefulShutdown_waitDrain_Concurrent(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background())
+
+	gs.Subscribe()
+	cancel()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			gs.waitDrain()
+		}()
+	}
+	time.Sleep(ShortDelay)
+	gs.Unsubscribe()
+	wg.Wait()
+
+	assert.ErrorIs(t, gs.TrySubscribe(), ErrShuttingDown)
+	gs.Wait()
+}
+
+func Test_GracefulShutdown_TryAddHook_Stress(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 50; i++ {
+		gs, _, cancel := New(context.Background())
+
+		var registered, executed atomic.Int32
+		var wg sync.WaitGroup
+		for j := 0; j < 10; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for k := 0; k < 10; k++ {
+					err := gs.TryAddHook("hook", func(context.Context) error {
+						executed.Add(1)
+						return nil
+					})
+					if err != nil {
+						assert.ErrorIs(t, err, ErrShuttingDown)
+						return
+					}
+					registered.Add(1)
+				}
+			}()
+		}
+
+		cancel()
+		gs.Wait()
+		wg.Wait()
+
+		assert.Equal(t, registered.Load(), executed.Load())
+	}
+}
+
+func Test_GracefulShutdown_TryAddHook_LateEarlyStart(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background(), WithPipelining())
+
+	started := make(chan struct{})
+	gs.AddHook("metrics", func(context.Context) error {
+		close(started)
+		return nil
+	}, WithEarlyStart())
+
+	gs.Subscribe()
+	cancel()
+	doneCh := make(chan struct{})
+	go func() {
+		gs.Wait()
+		close(doneCh)
+	}()
+	<-started
+
+	assert.NoError(t, gs.TryAddHook("traces", func(context.Context) error { return nil }, WithEarlyStart()))
+	gs.Unsubscribe()
+	<-doneCh
+
+	var names []string
+	for _, res := range gs.Report().Hooks {
+		names = append(names, res.Name)
+	}
+	assert.Equal(t, []string{"metrics", "traces"}, names)
+}
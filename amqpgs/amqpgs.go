@@ -0,0 +1,213 @@
+// Package amqpgs provides the graceful teardown of AMQP consumers, channels and
+// connections, e.g. of RabbitMQ. The package depends on no client: *amqp.Channel and
+// *amqp.Connection of amqp091-go are described by the interfaces of this package.
+package amqpgs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+const (
+	// maxPollInterval is the maximum interval between two checks of the unsettled
+	// deliveries.
+	maxPollInterval = 100 * time.Millisecond
+
+	// reportMargin is the time before the deadline of the hook at which the teardown stops
+	// waiting for the deliveries, so that the channels are closed and the error listing the
+	// unsettled deliveries reaches the report.
+	reportMargin = 5 * time.Millisecond
+)
+
+// Channel is an interface that describes an *amqp.Channel.
+type Channel interface {
+	// Cancel stops the deliveries to the consumer.
+	Cancel(consumer string, noWait bool) error
+
+	// Close closes the channel.
+	Close() error
+}
+
+// Connection is an interface that describes an *amqp.Connection.
+type Connection interface {
+	// Close closes the connection.
+	Close() error
+}
+
+// Drainer is a struct that tears an AMQP connection down in steps along the phases of the
+// shutdown: the consumers are canceled as soon as the shutdown is triggered, then a hook
+// waits for the deliveries received by the consumers to be acked or nacked, closes the
+// channels in reverse order of registration and closes the connection.
+//
+//	d := Register(gs, "rabbitmq", conn, gogs.WithTimeout(10*time.Second))
+//	orders := d.AddChannel("orders", ch, "orders-consumer")
+//
+//	for delivery := range deliveries {
+//		orders.Received()
+//		process(delivery)
+//		_ = delivery.Ack(false)
+//		orders.Settled()
+//	}
+//
+// This example stops consuming orders once the shutdown starts, and closes the channel
+// once the deliveries in progress are acked.
+type Drainer struct {
+	// gs is the GracefulShutdowner the consumers are registered with.
+	gs *gogs.GracefulShutdown
+
+	// conn is the connection closed last.
+	conn Connection
+
+	// channels is the list of channels. It is guarded by mu.
+	mu       sync.Mutex
+	channels []*ChannelDrainer
+}
+
+// ChannelDrainer is a struct that tracks the unsettled deliveries of a channel registered
+// with Drainer.AddChannel.
+type ChannelDrainer struct {
+	// name is the name of the channel.
+	name string
+
+	// ch is the channel.
+	ch Channel
+
+	// unsettled is the count of deliveries received but not acked or nacked yet.
+	unsettled atomic.Int64
+}
+
+// Register is a function that creates a new Drainer for the provided connection. It
+// registers the teardown of the channels and the connection as a named hook configured
+// with the provided hook options. The hook fails with an error listing the channels with
+// unsettled deliveries if they are not acked or nacked before the deadline of the hook.
+func Register(
+	gs *gogs.GracefulShutdown,
+	name string,
+	conn Connection,
+	opts ...gogs.HookOption,
+) *Drainer {
+	d := &Drainer{gs: gs, conn: conn}
+	gs.AddHook(name, d.teardown, opts...)
+
+	return d
+}
+
+// AddChannel is a method of the Drainer struct. It registers the provided channel under
+// the provided name and cancels its consumers as soon as the shutdown is triggered, see
+// gogs.AddIntake. The returned ChannelDrainer tracks the unsettled deliveries.
+func (d *Drainer) AddChannel(name string, ch Channel, consumers ...string) *ChannelDrainer {
+	c := &ChannelDrainer{name: name, ch: ch}
+
+	d.mu.Lock()
+	d.channels = append(d.channels, c)
+	d.mu.Unlock()
+
+	d.gs.AddIntake(name, func() error {
+		var errs []string
+		for _, consumer := range consumers {
+			if err := ch.Cancel(consumer, true); err != nil {
+				errs = append(errs, consumer+": "+err.Error())
+			}
+		}
+
+		if len(errs) > 0 {
+			return fmt.Errorf("amqpgs: cancel consumers: %s", strings.Join(errs, "; "))
+		}
+
+		return nil
+	})
+
+	return c
+}
+
+// Received is a method of the ChannelDrainer struct. It counts a delivery received and
+// not settled yet.
+func (c *ChannelDrainer) Received() {
+	c.unsettled.Add(1)
+}
+
+// Settled is a method of the ChannelDrainer struct. It counts a delivery acked or nacked.
+func (c *ChannelDrainer) Settled() {
+	c.unsettled.Add(-1)
+}
+
+// Unsettled is a method of the ChannelDrainer struct. It returns the count of deliveries
+// received but not acked or nacked yet.
+func (c *ChannelDrainer) Unsettled() int {
+	return int(c.unsettled.Load())
+}
+
+// teardown is a method of the Drainer struct. It waits for the deliveries to be settled,
+// shortly before the deadline of the context at the latest, closes the channels in
+// reverse order of registration and closes the connection.
+func (d *Drainer) teardown(ctx context.Context) error {
+	d.mu.Lock()
+	channels := make([]*ChannelDrainer, len(d.channels))
+	copy(channels, d.channels)
+	d.mu.Unlock()
+
+	waitCtx := ctx
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithDeadline(ctx, deadline.Add(-reportMargin))
+		defer cancel()
+	}
+	waitErr := waitSettled(waitCtx, channels)
+
+	var errs []string
+	for i := len(channels) - 1; i >= 0; i-- {
+		if err := channels[i].ch.Close(); err != nil {
+			errs = append(errs, "close "+channels[i].name+": "+err.Error())
+		}
+	}
+	if err := d.conn.Close(); err != nil {
+		errs = append(errs, "close connection: "+err.Error())
+	}
+
+	closeErr := strings.Join(errs, "; ")
+	switch {
+	case waitErr != nil && closeErr != "":
+		return fmt.Errorf("amqpgs: %w; %s", waitErr, closeErr)
+	case waitErr != nil:
+		return fmt.Errorf("amqpgs: %w", waitErr)
+	case closeErr != "":
+		return fmt.Errorf("amqpgs: %s", closeErr)
+	default:
+		return nil
+	}
+}
+
+// waitSettled is a function that polls the provided channels with backoff until none has
+// unsettled deliveries or the context is done.
+func waitSettled(ctx context.Context, channels []*ChannelDrainer) error {
+	interval := time.Millisecond
+	for {
+		var unsettled []string
+		for _, c := range channels {
+			if n := c.Unsettled(); n > 0 {
+				unsettled = append(unsettled, fmt.Sprintf("%s (%d)", c.name, n))
+			}
+		}
+		if len(unsettled) == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w, unsettled deliveries: %s", ctx.Err(), strings.Join(unsettled, ", "))
+		case <-timer.C:
+		}
+
+		if interval *= 2; interval > maxPollInterval {
+			interval = maxPollInterval
+		}
+	}
+}
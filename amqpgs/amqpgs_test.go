@@ -0,0 +1,86 @@
+package amqpgs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+type testChannel struct {
+	name     string
+	order    *[]string
+	canceled []string
+	closeErr error
+}
+
+func (c *testChannel) Cancel(consumer string, _ bool) error {
+	c.canceled = append(c.canceled, consumer)
+	return nil
+}
+
+func (c *testChannel) Close() error {
+	*c.order = append(*c.order, c.name)
+	return c.closeErr
+}
+
+type testConnection struct {
+	order *[]string
+}
+
+func (c testConnection) Close() error {
+	*c.order = append(*c.order, "connection")
+	return nil
+}
+
+func Test_Register(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := gogs.New(context.Background())
+
+	var order []string
+	ordersCh := &testChannel{name: "orders", order: &order}
+	auditCh := &testChannel{name: "audit", order: &order}
+
+	d := Register(gs, "rabbitmq", testConnection{order: &order})
+	orders := d.AddChannel("orders", ordersCh, "orders-1", "orders-2")
+	d.AddChannel("audit", auditCh)
+
+	orders.Received()
+	orders.Received()
+	orders.Settled()
+	assert.Equal(t, 1, orders.Unsettled())
+
+	cancel()
+	assert.Equal(t, []string{"orders-1", "orders-2"}, ordersCh.canceled)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		orders.Settled()
+	}()
+	gs.Wait()
+
+	assert.Equal(t, []string{"audit", "orders", "connection"}, order)
+	assert.NoError(t, gs.Report().Err())
+}
+
+func Test_Register_Unsettled(t *testing.T) {
+	t.Parallel()
+	gs, _, _ := gogs.New(context.Background())
+
+	var order []string
+	errFailed := errors.New("failed")
+	d := Register(gs, "rabbitmq", testConnection{order: &order}, gogs.WithTimeout(20*time.Millisecond))
+	d.AddChannel("orders", &testChannel{name: "orders", order: &order}).Received()
+	d.AddChannel("audit", &testChannel{name: "audit", order: &order, closeErr: errFailed})
+
+	gs.Wait()
+
+	err := gs.Report().Hooks[0].Err
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.EqualError(t, err, "amqpgs: context deadline exceeded, unsettled deliveries: orders (1); close audit: failed")
+	assert.Equal(t, []string{"audit", "orders", "connection"}, order)
+}
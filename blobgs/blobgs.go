@@ -0,0 +1,229 @@
+// Package blobgs provides the "finish or abort" handling of the uploads in progress at
+// shutdown, e.g. S3, GCS or Azure multipart uploads: an upload is completed if the
+// remaining budget allows, and aborted cleanly otherwise, so that no orphaned parts are
+// left behind. The package depends on no client: an upload is described by the Upload
+// interface of this package.
+package blobgs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+// ErrBudgetExceeded is the error of the uploads aborted because their estimate exceeds
+// the budget left.
+var ErrBudgetExceeded = errors.New("blobgs: estimate exceeds the budget")
+
+// Upload is an interface that describes an upload in progress.
+type Upload interface {
+	// Complete finishes the upload, e.g. uploads the remaining parts and completes the
+	// multipart upload.
+	Complete(ctx context.Context) error
+
+	// Abort discards the upload and the parts uploaded so far.
+	Abort(ctx context.Context) error
+}
+
+// Action is a type that describes what was done with an upload at shutdown.
+type Action string
+
+const (
+	// ActionCompleted means that the upload was completed.
+	ActionCompleted Action = "completed"
+
+	// ActionAborted means that the upload was aborted, because the budget did not allow
+	// completing it or completing it failed.
+	ActionAborted Action = "aborted"
+
+	// ActionFailed means that aborting the upload failed too, so its parts may be orphaned.
+	ActionFailed Action = "failed"
+)
+
+// Decision is a struct that describes what was done with an upload at shutdown.
+type Decision struct {
+	// Name is the name of the upload.
+	Name string
+
+	// Action is what was done with the upload.
+	Action Action
+
+	// Err is the error of the completion of an aborted upload, or of the abort of a failed
+	// one, if any.
+	Err error
+
+	// Duration is the time spent finishing the upload.
+	Duration time.Duration
+}
+
+// Finisher is a struct that tracks the uploads in progress and finishes them at shutdown.
+//
+//	f := Register(gs, "uploads", 2*time.Second)
+//	done := f.Track(key, upload, func() time.Duration {
+//		return time.Duration(upload.PartsLeft()) * 500 * time.Millisecond
+//	})
+//	defer done()
+//
+// This example completes the upload at shutdown if its remaining parts fit into the
+// budget of the hook, keeping two seconds to abort it otherwise.
+type Finisher struct {
+	// reserve is the time kept before the deadline of the hook to abort the uploads.
+	reserve time.Duration
+
+	// mu guards the fields below.
+	mu        sync.Mutex
+	uploads   map[uint64]tracked
+	id        uint64
+	decisions []Decision
+}
+
+// tracked is a struct that holds an upload in progress.
+type tracked struct {
+	name     string
+	upload   Upload
+	estimate func() time.Duration
+}
+
+// Register is a function that creates a new Finisher. It registers the finishing of the
+// uploads in progress as a named hook configured with the provided hook options. The
+// provided reserve is the time kept before the deadline of the hook to abort the uploads
+// that are not completed in time. The hook fails if an upload cannot be completed nor
+// aborted.
+func Register(
+	gs gogs.HookRegistrar,
+	name string,
+	reserve time.Duration,
+	opts ...gogs.HookOption,
+) *Finisher {
+	f := &Finisher{reserve: reserve, uploads: make(map[uint64]tracked)}
+	gs.AddHook(name, f.finish, opts...)
+
+	return f
+}
+
+// Track is a method of the Finisher struct. It tracks the provided upload under the
+// provided name until the returned function is called, once the upload has completed or
+// failed on its own. The estimate returns the time needed to complete the upload, nil
+// meaning no time at all.
+func (f *Finisher) Track(name string, upload Upload, estimate func() time.Duration) (done func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.id++
+	id := f.id
+	f.uploads[id] = tracked{name: name, upload: upload, estimate: estimate}
+
+	return func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		delete(f.uploads, id)
+	}
+}
+
+// Decisions is a method of the Finisher struct. It returns what was done with the
+// uploads at shutdown, in order of tracking.
+func (f *Finisher) Decisions() []Decision {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Decision(nil), f.decisions...)
+}
+
+// finish is a method of the Finisher struct. It completes or aborts the uploads in
+// progress concurrently.
+func (f *Finisher) finish(ctx context.Context) error {
+	f.mu.Lock()
+	ids := make([]uint64, 0, len(f.uploads))
+	for id := range f.uploads {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	uploads := make([]tracked, 0, len(ids))
+	for _, id := range ids {
+		uploads = append(uploads, f.uploads[id])
+		delete(f.uploads, id)
+	}
+	f.mu.Unlock()
+
+	decisions := make([]Decision, len(uploads))
+	var wg sync.WaitGroup
+	for i, u := range uploads {
+		wg.Add(1)
+		go func(i int, u tracked) {
+			defer wg.Done()
+			decisions[i] = f.finishOne(ctx, u)
+		}(i, u)
+	}
+	wg.Wait()
+
+	f.mu.Lock()
+	f.decisions = append(f.decisions, decisions...)
+	f.mu.Unlock()
+
+	var failed []string
+	for _, d := range decisions {
+		if d.Action == ActionFailed {
+			failed = append(failed, fmt.Sprintf("%s (%v)", d.Name, d.Err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("blobgs: uploads neither completed nor aborted: %s", strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+// finishOne is a method of the Finisher struct. It completes the provided upload if its
+// estimate fits into the budget left before the reserve, and aborts it otherwise or if
+// the completion fails.
+func (f *Finisher) finishOne(ctx context.Context, u tracked) Decision {
+	start := time.Now()
+	d := Decision{Name: u.name, Action: ActionCompleted}
+
+	completeCtx, cancel := ctx, context.CancelFunc(func() {})
+	if deadline, ok := ctx.Deadline(); ok {
+		completeCtx, cancel = context.WithDeadline(ctx, deadline.Add(-f.reserve))
+	}
+	defer cancel()
+
+	if fits(completeCtx, u.estimate) {
+		if d.Err = u.upload.Complete(completeCtx); d.Err == nil {
+			d.Duration = time.Since(start)
+			return d
+		}
+	} else {
+		d.Err = ErrBudgetExceeded
+	}
+
+	d.Action = ActionAborted
+	if err := u.upload.Abort(ctx); err != nil {
+		d.Action = ActionFailed
+		d.Err = err
+	}
+	d.Duration = time.Since(start)
+
+	return d
+}
+
+// fits is a function that reports whether the provided estimate fits into the budget left
+// until the deadline of the context.
+func fits(ctx context.Context, estimate func() time.Duration) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok || estimate == nil {
+		return true
+	}
+
+	return time.Until(deadline) >= estimate()
+}
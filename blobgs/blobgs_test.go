@@ -0,0 +1,94 @@
+package blobgs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+type testUpload struct {
+	mu          sync.Mutex
+	completeErr error
+	abortErr    error
+	completed   bool
+	aborted     bool
+}
+
+func (u *testUpload) Complete(context.Context) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.completed = u.completeErr == nil
+	return u.completeErr
+}
+
+func (u *testUpload) Abort(context.Context) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.aborted = u.abortErr == nil
+	return u.abortErr
+}
+
+func Test_Finisher(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := gogs.New(context.Background())
+	f := Register(gs, "uploads", 10*time.Millisecond)
+
+	small, large, broken, orphan, finished := &testUpload{}, &testUpload{}, &testUpload{
+		completeErr: errors.New("part missing"),
+	}, &testUpload{
+		completeErr: errors.New("part missing"),
+		abortErr:    errors.New("access denied"),
+	}, &testUpload{}
+
+	f.Track("small", small, func() time.Duration { return time.Millisecond })
+	f.Track("large", large, func() time.Duration { return time.Hour })
+	f.Track("broken", broken, nil)
+	f.Track("orphan", orphan, nil)
+	done := f.Track("finished", finished, nil)
+	done()
+
+	cancel()
+	gs.WaitWithTimeout(time.Second)
+
+	assert.True(t, small.completed)
+	assert.True(t, large.aborted)
+	assert.True(t, broken.aborted)
+	assert.False(t, orphan.aborted)
+	assert.False(t, finished.completed || finished.aborted)
+
+	decisions := f.Decisions()
+	if assert.Len(t, decisions, 4) {
+		assert.Equal(t, "small", decisions[0].Name)
+		assert.Equal(t, ActionCompleted, decisions[0].Action)
+		assert.Equal(t, ActionAborted, decisions[1].Action)
+		assert.ErrorIs(t, decisions[1].Err, ErrBudgetExceeded)
+		assert.Equal(t, ActionAborted, decisions[2].Action)
+		assert.EqualError(t, decisions[2].Err, "part missing")
+		assert.Equal(t, ActionFailed, decisions[3].Action)
+	}
+
+	assert.EqualError(t, gs.Report().Err(),
+		"uploads: blobgs: uploads neither completed nor aborted: orphan (access denied)")
+}
+
+func Test_fits(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	assert.True(t, fits(context.Background(), func() time.Duration { return time.Hour }))
+	assert.True(t, fits(ctx, nil))
+	assert.True(t, fits(ctx, func() time.Duration { return time.Millisecond }))
+	assert.False(t, fits(ctx, func() time.Duration { return time.Minute }))
+
+	cancel()
+	assert.False(t, fits(ctx, nil))
+}
@@ -0,0 +1,108 @@
+package gogs
+
+import (
+	"context"
+	"time"
+)
+
+// Leak is a struct that describes a blocking call abandoned because the context was done
+// before it returned. Its goroutine keeps running until the call returns.
+type Leak struct {
+	// Name is the name of the blocking call.
+	Name string
+
+	// AbandonedAt is the time the call was abandoned at.
+	AbandonedAt time.Time
+}
+
+// Blocking is a method of the GracefulShutdown struct. It executes a blocking call that
+// does not support contexts, e.g. of an old client library, in a goroutine and waits for
+// it to complete or for the context to be done. In the latter case the call is abandoned,
+// the context error is returned, and the goroutine left running is listed in the leaks of
+// the report until the call returns.
+//
+//	err := gs.Blocking(ctx, "legacy-flush", client.Flush)
+//
+// This example gives up waiting for the flush at the deadline of the context.
+func (gs *GracefulShutdown) Blocking(ctx context.Context, name string, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	errCh := make(chan error, 1)
+
+	// leak and returned are guarded by mu.
+	var leak *Leak
+	var returned bool
+
+	go func() {
+		errCh <- fn()
+
+		gs.mu.Lock()
+		defer gs.mu.Unlock()
+
+		returned = true
+		if leak != nil {
+			gs.forgetLeakLocked(leak)
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if returned {
+		return <-errCh
+	}
+
+	leak = &Leak{Name: name, AbandonedAt: time.Now()}
+	gs.leaks = append(gs.leaks, leak)
+
+	return ctx.Err()
+}
+
+// BlockingValue is a function that executes a blocking call returning a value, like
+// Blocking. The zero value is returned if the call is abandoned.
+//
+//	conn, err := BlockingValue(ctx, gs, "dial", func() (net.Conn, error) {
+//		return legacy.Dial(addr)
+//	})
+//
+// This example dials with a library unaware of contexts, giving up at the deadline.
+func BlockingValue[T any](
+	ctx context.Context,
+	gs *GracefulShutdown,
+	name string,
+	fn func() (T, error),
+) (T, error) {
+	vCh := make(chan T, 1)
+	err := gs.Blocking(ctx, name, func() error {
+		v, err := fn()
+		vCh <- v
+		return err
+	})
+
+	select {
+	case v := <-vCh:
+		return v, err
+	default:
+		var zero T
+		return zero, err
+	}
+}
+
+// forgetLeakLocked is a method of the GracefulShutdown struct. It removes the provided
+// leak once its call has returned. The caller must hold mu.
+func (gs *GracefulShutdown) forgetLeakLocked(l *Leak) {
+	for i, leak := range gs.leaks {
+		if leak == l {
+			gs.leaks = append(gs.leaks[:i], gs.leaks[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package gogs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GracefulShutdown_Blocking(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background())
+	defer cancel()
+
+	errFailed := errors.New("failed")
+	err := gs.Blocking(context.Background(), "flush", func() error {
+		return errFailed
+	})
+	assert.ErrorIs(t, err, errFailed)
+
+	releaseCh := make(chan struct{})
+	ctx, cancelCall := context.WithTimeout(context.Background(), ShortDelay)
+	defer cancelCall()
+
+	err = gs.Blocking(ctx, "legacy", func() error {
+		<-releaseCh
+		return nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	leaks := gs.Report().Leaks
+	if assert.Len(t, leaks, 1) {
+		assert.Equal(t, "legacy", leaks[0].Name)
+	}
+
+	close(releaseCh)
+	assert.Eventually(t, func() bool {
+		return len(gs.Report().Leaks) == 0
+	}, LongDelay, ShortDelay/5)
+}
+
+func Test_BlockingValue(t *testing.T) {
+	t.Parallel()
+	gs := &GracefulShutdown{}
+
+	v, err := BlockingValue(context.Background(), gs, "dial", func() (int, error) {
+		return 42, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 42, v)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	v, err = BlockingValue(ctx, gs, "dial", func() (int, error) {
+		return 42, nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, v)
+}
@@ -0,0 +1,117 @@
+package gogs
+
+import (
+	"context"
+	"time"
+)
+
+// Checkpoint is a method of the GracefulShutdown struct. It reports whether the drain of
+// the shutdown has started or the provided context is done. It is meant to be called at
+// safe points of hot loops, e.g. of encoders or batch jobs, so that CPU-bound workers
+// yield without checking channels themselves. It costs an atomic load until the shutdown
+// starts.
+//
+//	for _, frame := range frames {
+//		if gs.Checkpoint(ctx) {
+//			return saveProgress(frame)
+//		}
+//		encode(frame)
+//	}
+//
+// This example saves the progress of the encoder and returns once the drain starts.
+func (gs *GracefulShutdown) Checkpoint(ctx context.Context) bool {
+	if gs.draining.Load() {
+		return true
+	}
+
+	select {
+	case <-ctx.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// Sleep is a method of the GracefulShutdown struct. It pauses for the provided duration,
+// like time.Sleep, but returns early once the shutdown starts, i.e. once the context
+// created by New is done or the drain starts, or once the provided context is done. It
+// reports whether the whole duration elapsed, so that the worker loops polling at an
+// interval do not delay the drain by up to that interval.
+//
+//	for gs.Sleep(ctx, 30*time.Second) {
+//		pollQueue(ctx)
+//	}
+//
+// This example polls the queue every thirty seconds until the shutdown starts.
+func (gs *GracefulShutdown) Sleep(ctx context.Context, d time.Duration) bool {
+	if gs.Checkpoint(ctx) {
+		return false
+	}
+
+	var shutdown <-chan struct{}
+	if gs.ctx != nil {
+		shutdown = gs.ctx.Done()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-shutdown:
+	case <-gs.drainChan():
+	case <-ctx.Done():
+	}
+
+	return false
+}
+
+// DrainStarted is a method of the GracefulShutdown struct. It returns a channel closed
+// once the drain of the shutdown starts, i.e. once the instance is removed from the
+// service registries and before the hooks tear the components down. It is the signal for
+// applying back-pressure to the upstream producers, e.g. pausing the consumption of a
+// topic or answering 429 on ingest endpoints, while the work in flight completes.
+//
+//	go func() {
+//		<-gs.DrainStarted()
+//		consumer.Pause(consumer.Assignment())
+//	}()
+//
+// This example pauses the consumption of the assigned partitions once the drain starts.
+func (gs *GracefulShutdown) DrainStarted() <-chan struct{} {
+	return gs.drainChan()
+}
+
+// drainChan is a method of the GracefulShutdown struct. It returns the channel closed once
+// the drain starts, creating it if needed.
+func (gs *GracefulShutdown) drainChan() chan struct{} {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if gs.drainCh == nil {
+		gs.drainCh = make(chan struct{})
+	}
+
+	return gs.drainCh
+}
+
+// startDrain is a method of the GracefulShutdown struct. It marks the drain as started,
+// for Checkpoint and DrainStarted, and starts the functions registered with OnDrain,
+// exactly once.
+func (gs *GracefulShutdown) startDrain() {
+	gs.draining.Store(true)
+	gs.drainOnce.Do(func() {
+		close(gs.drainChan())
+
+		gs.mu.Lock()
+		fns := gs.onDrain
+		gs.onDrain = nil
+		gs.drainFnsStarted = true
+		gs.mu.Unlock()
+
+		for _, f := range fns {
+			go f.run()
+		}
+	})
+}
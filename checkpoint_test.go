@@ -0,0 +1,85 @@
+package gogs
+
+import (
+	"context"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GracefulShutdown_Checkpoint(t *testing.T) {
+	t.Parallel()
+	gs, _, _ := New(context.Background(), WithSignals(syscall.SIGINT))
+
+	assert.False(t, gs.Checkpoint(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.True(t, gs.Checkpoint(ctx))
+
+	gs.Subscribe()
+	stopped := make(chan bool)
+	go func() {
+		defer gs.Unsubscribe()
+		for !gs.Checkpoint(context.Background()) {
+			runtime.Gosched()
+		}
+		stopped <- true
+	}()
+
+	go gs.Wait()
+	assert.True(t, <-stopped)
+	assert.True(t, gs.Checkpoint(context.Background()))
+}
+
+func Test_GracefulShutdown_Sleep(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background(), WithSignals(syscall.SIGINT))
+
+	assert.True(t, gs.Sleep(context.Background(), time.Millisecond))
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	cancelCtx()
+	assert.False(t, gs.Sleep(ctx, LongDelay))
+
+	time.AfterFunc(ShortDelay, cancel)
+	start := time.Now()
+	assert.False(t, gs.Sleep(context.Background(), time.Minute))
+	assert.Less(t, time.Since(start), LongDelay)
+	assert.False(t, gs.Sleep(context.Background(), time.Millisecond))
+}
+
+func Test_GracefulShutdown_DrainStarted(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background(), WithSignals(syscall.SIGINT))
+
+	drainStarted := gs.DrainStarted()
+	var deregistered bool
+	gs.AddRegistrar("consul", &testRegistrar{fn: func() {
+		select {
+		case <-drainStarted:
+			assert.Fail(t, "drain started before the deregistration")
+		default:
+		}
+		deregistered = true
+	}})
+
+	gs.Subscribe()
+	go func() {
+		defer gs.Unsubscribe()
+		<-drainStarted
+		assert.True(t, deregistered)
+	}()
+
+	cancel()
+	gs.Wait()
+
+	select {
+	case <-gs.DrainStarted():
+	default:
+		assert.Fail(t, "drain not started")
+	}
+}
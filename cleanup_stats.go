@@ -0,0 +1,151 @@
+package gogs
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultCleanupBuckets is the default list of upper bounds of the buckets of
+// CleanupStats.
+var defaultCleanupBuckets = []time.Duration{
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// CleanupStats is a struct that describes the outcomes of the cleanup functions executed
+// by UnsubscribeFnWithTimeout, to tune their timeouts based on the observed durations.
+type CleanupStats struct {
+	// Completed is the count of cleanup functions that completed before their timeout.
+	Completed uint64
+
+	// TimedOut is the count of cleanup functions whose timeout elapsed first.
+	TimedOut uint64
+
+	// Buckets is the histogram of the durations of the cleanup functions that returned,
+	// including the ones that returned after their timeout. The counts are cumulative, the
+	// last bucket having no upper bound.
+	Buckets []CleanupBucket
+
+	// Sum is the total duration of the cleanup functions that returned.
+	Sum time.Duration
+}
+
+// CleanupBucket is a struct that describes a bucket of the histogram of CleanupStats.
+type CleanupBucket struct {
+	// UpperBound is the inclusive upper bound of the durations counted by the bucket. Zero
+	// means no upper bound.
+	UpperBound time.Duration
+
+	// Count is the count of durations lower than or equal to the upper bound.
+	Count uint64
+}
+
+// cleanupStats is a struct that records the outcomes of the cleanup functions.
+type cleanupStats struct {
+	// mu guards the fields below.
+	mu sync.Mutex
+
+	// completed and timedOut count the outcomes.
+	completed, timedOut uint64
+
+	// counts is the count of durations per bucket, not cumulative, the last one counting
+	// the durations above all bounds.
+	counts []uint64
+
+	// sum is the total of the recorded durations.
+	sum time.Duration
+}
+
+// WithCleanupBuckets is an option that sets the upper bounds, in increasing order, of the
+// buckets of the histogram of the durations of the cleanup functions executed by
+// UnsubscribeFnWithTimeout, see CleanupStats. The default bounds range from 10
+// milliseconds to 10 seconds.
+func WithCleanupBuckets(bounds ...time.Duration) Option {
+	return func(cfg *config) {
+		cfg.cleanupBuckets = bounds
+	}
+}
+
+// CleanupStats is a method of the GracefulShutdown struct. It returns the outcomes of the
+// cleanup functions executed by UnsubscribeFnWithTimeout so far.
+//
+//	stats := gs.CleanupStats()
+//	log.Printf("%d of %d cleanups timed out", stats.TimedOut, stats.Completed+stats.TimedOut)
+//
+// This example logs the share of cleanup functions whose timeout is too short.
+func (gs *GracefulShutdown) CleanupStats() CleanupStats {
+	bounds := gs.cleanupBuckets()
+
+	gs.cleanups.mu.Lock()
+	defer gs.cleanups.mu.Unlock()
+
+	stats := CleanupStats{
+		Completed: gs.cleanups.completed,
+		TimedOut:  gs.cleanups.timedOut,
+		Buckets:   make([]CleanupBucket, len(bounds)+1),
+		Sum:       gs.cleanups.sum,
+	}
+
+	var cumulative uint64
+	for i := range stats.Buckets {
+		if i < len(gs.cleanups.counts) {
+			cumulative += gs.cleanups.counts[i]
+		}
+		if i < len(bounds) {
+			stats.Buckets[i].UpperBound = bounds[i]
+		}
+		stats.Buckets[i].Count = cumulative
+	}
+
+	return stats
+}
+
+// cleanupBuckets is a method of the GracefulShutdown struct. It returns the upper bounds
+// of the buckets of the histogram.
+func (gs *GracefulShutdown) cleanupBuckets() []time.Duration {
+	if gs.cfg.cleanupBuckets != nil {
+		return gs.cfg.cleanupBuckets
+	}
+
+	return defaultCleanupBuckets
+}
+
+// recordCleanup is a method of the GracefulShutdown struct. It records the outcome of a
+// cleanup function, and its duration once it has returned.
+func (gs *GracefulShutdown) recordCleanup(timedOut bool) {
+	gs.cleanups.mu.Lock()
+	defer gs.cleanups.mu.Unlock()
+
+	if timedOut {
+		gs.cleanups.timedOut++
+	} else {
+		gs.cleanups.completed++
+	}
+}
+
+// recordCleanupDuration is a method of the GracefulShutdown struct. It records the
+// duration of a cleanup function that has returned.
+func (gs *GracefulShutdown) recordCleanupDuration(d time.Duration) {
+	bounds := gs.cleanupBuckets()
+
+	gs.cleanups.mu.Lock()
+	defer gs.cleanups.mu.Unlock()
+
+	if gs.cleanups.counts == nil {
+		gs.cleanups.counts = make([]uint64, len(bounds)+1)
+	}
+
+	i := 0
+	for i < len(bounds) && d > bounds[i] {
+		i++
+	}
+	gs.cleanups.counts[i]++
+	gs.cleanups.sum += d
+}
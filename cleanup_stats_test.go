@@ -0,0 +1,48 @@
+package gogs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GracefulShutdown_CleanupStats(t *testing.T) {
+	t.Parallel()
+	gs := &GracefulShutdown{}
+	WithCleanupBuckets(ShortDelay, 4*ShortDelay)(&gs.cfg)
+
+	stats := gs.CleanupStats()
+	assert.Equal(t, []CleanupBucket{
+		{UpperBound: ShortDelay},
+		{UpperBound: 4 * ShortDelay},
+		{},
+	}, stats.Buckets)
+
+	gs.SubscribeN(3)
+	gs.UnsubscribeFnWithTimeout(func() {}, LongDelay)
+	gs.UnsubscribeFnWithTimeout(func() { time.Sleep(2 * ShortDelay) }, LongDelay)
+
+	release := make(chan struct{})
+	gs.UnsubscribeFnWithTimeout(func() { <-release }, ShortDelay)
+
+	stats = gs.CleanupStats()
+	assert.Equal(t, uint64(2), stats.Completed)
+	assert.Equal(t, uint64(1), stats.TimedOut)
+	assert.Equal(t, []uint64{1, 2, 2}, bucketCounts(stats))
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		return gs.CleanupStats().Buckets[2].Count == 3
+	}, LongDelay, time.Millisecond)
+	assert.GreaterOrEqual(t, gs.CleanupStats().Sum, 2*ShortDelay)
+}
+
+func bucketCounts(stats CleanupStats) []uint64 {
+	counts := make([]uint64, 0, len(stats.Buckets))
+	for _, b := range stats.Buckets {
+		counts = append(counts, b.Count)
+	}
+
+	return counts
+}
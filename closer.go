@@ -0,0 +1,56 @@
+package gogs
+
+import (
+	"context"
+	"io"
+)
+
+// CloseAll is a function that closes all provided resources in reverse order, like
+// deferred calls. It stops as soon as the context is done and returns an error combining
+// the errors of all failed closers and the context error, or nil if all of them were
+// closed successfully.
+//
+//	err := CloseAll(ctx, []*os.File{access, audit})
+//
+// This example closes the audit file first and the access file second.
+func CloseAll[T io.Closer](ctx context.Context, closers []T) error {
+	var errs multiError
+	for i := len(closers) - 1; i >= 0; i-- {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+
+		if err := closers[i].Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+// RegisterCloser is a function that registers a named hook closing the provided resource
+// with the provided function and hook options. It removes the need to write a
+// context-aware adapter for every resource type while keeping type safety.
+//
+//	RegisterCloser(gs, "postgres", pool, func(p *pgxpool.Pool, _ context.Context) error {
+//		p.Close()
+//		return nil
+//	})
+//
+// This example closes the connection pool once all active shutdown events have completed.
+func RegisterCloser[T any](
+	gs HookRegistrar,
+	name string,
+	v T,
+	closeFn func(T, context.Context) error,
+	opts ...HookOption,
+) {
+	gs.AddHook(name, func(ctx context.Context) error {
+		return closeFn(v, ctx)
+	}, opts...)
+}
@@ -0,0 +1,67 @@
+package gogs
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCloser struct {
+	name  string
+	err   error
+	order *[]string
+}
+
+func (c testCloser) Close() error {
+	*c.order = append(*c.order, c.name)
+	return c.err
+}
+
+func Test_CloseAll(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Order", func(t *testing.T) {
+		var order []string
+		errFailed := errors.New("failed")
+		closers := []testCloser{
+			{name: "first", order: &order},
+			{name: "second", err: errFailed, order: &order},
+		}
+
+		err := CloseAll(context.Background(), closers)
+		assert.ErrorIs(t, err, errFailed)
+		assert.Equal(t, []string{"second", "first"}, order)
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		var order []string
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := CloseAll(ctx, []testCloser{{name: "first", order: &order}})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Empty(t, order)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		assert.NoError(t, CloseAll[testCloser](context.Background(), nil))
+	})
+}
+
+func Test_RegisterCloser(t *testing.T) {
+	t.Parallel()
+	gs, _, _ := New(context.Background(), WithSignals(syscall.SIGINT))
+
+	var order []string
+	RegisterCloser(gs, "resource", &order, func(o *[]string, _ context.Context) error {
+		*o = append(*o, "closed")
+		return nil
+	})
+
+	gs.Wait()
+	assert.Equal(t, []string{"closed"}, order)
+	assert.NoError(t, gs.Report().Err())
+}
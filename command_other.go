@@ -0,0 +1,28 @@
+//go:build !unix
+
+package gogs
+
+import (
+	"os"
+	"os/exec"
+)
+
+// IsolateCommand is a function that starts the provided command in its own process
+// group. Process groups are not available on this platform, so it does nothing.
+func IsolateCommand(*exec.Cmd) {}
+
+// SignalCommand is a function that sends the provided signal to the started command.
+func SignalCommand(cmd *exec.Cmd, sig os.Signal) error {
+	if cmd.Process == nil {
+		return os.ErrProcessDone
+	}
+
+	return cmd.Process.Signal(sig)
+}
+
+// signaled is a function that reports whether the provided error of the wait of a command
+// means that the command was terminated by the provided signal. Termination signals are
+// not reported on this platform.
+func signaled(error, os.Signal) bool {
+	return false
+}
@@ -0,0 +1,40 @@
+//go:build unix && !aix && !illumos && !solaris
+
+package gogs
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_IsolateCommand(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("sleep", "10")
+	IsolateCommand(cmd)
+	assert.True(t, cmd.SysProcAttr.Setpgid)
+
+	assert.ErrorIs(t, SignalCommand(cmd, syscall.SIGTERM), os.ErrProcessDone)
+
+	if err := cmd.Start(); err != nil {
+		t.Skipf("sleep is not available: %v", err)
+	}
+
+	pgid, err := syscall.Getpgid(cmd.Process.Pid)
+	assert.NoError(t, err)
+	assert.Equal(t, cmd.Process.Pid, pgid)
+	assert.NotEqual(t, syscall.Getpgrp(), pgid)
+
+	assert.NoError(t, SignalCommand(cmd, syscall.SIGTERM))
+	err = cmd.Wait()
+
+	var exitErr *exec.ExitError
+	if assert.ErrorAs(t, err, &exitErr) {
+		status := exitErr.Sys().(syscall.WaitStatus)
+		assert.Equal(t, syscall.SIGTERM, status.Signal())
+	}
+}
@@ -0,0 +1,63 @@
+//go:build unix
+
+package gogs
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// IsolateCommand is a function that starts the provided command in its own process
+// group, so that the shutdown signals sent to the group of the parent, e.g. SIGINT on
+// Ctrl+C in a terminal, do not reach the child, and the parent orchestrates its
+// termination with SignalCommand instead. It must be called before the command is
+// started.
+//
+// Blocking the signals with a signal mask around the exec, as in C, is not possible in
+// Go, since the runtime owns the signal masks of its threads; a separate process group
+// achieves the same isolation.
+//
+//	cmd := exec.Command("ffmpeg", args...)
+//	IsolateCommand(cmd)
+//	err := cmd.Start()
+//	gs.AddHook("ffmpeg", func(ctx context.Context) error {
+//		return SignalCommand(cmd, syscall.SIGTERM)
+//	})
+//
+// This example stops the encoder only when the hook is executed, after the drain.
+func IsolateCommand(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+	cmd.SysProcAttr.Pgid = 0
+}
+
+// SignalCommand is a function that sends the provided signal to the started command and,
+// if the command was isolated with IsolateCommand, to the processes it started.
+func SignalCommand(cmd *exec.Cmd, sig os.Signal) error {
+	if cmd.Process == nil {
+		return os.ErrProcessDone
+	}
+
+	sysSig, ok := sig.(syscall.Signal)
+	if !ok || cmd.SysProcAttr == nil || !cmd.SysProcAttr.Setpgid {
+		return cmd.Process.Signal(sig)
+	}
+
+	return syscall.Kill(-cmd.Process.Pid, sysSig)
+}
+
+// signaled is a function that reports whether the provided error of the wait of a command
+// means that the command was terminated by the provided signal.
+func signaled(err error, sig os.Signal) bool {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return false
+	}
+
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	return ok && status.Signaled() && status.Signal() == sig
+}
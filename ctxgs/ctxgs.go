@@ -0,0 +1,274 @@
+// Package ctxgs provides the context-first API of the graceful shutdown. Every method that
+// may block or fail takes a context and returns an error instead of swallowing it. It is
+// layered on the current API, which stays available through V1, so both can be used side
+// by side while the code migrates.
+package ctxgs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	v1 "github.com/dsbasko/go-gs"
+)
+
+var (
+	// ErrNotSubscribed is returned by Unsubscribe when there is no active shutdown event,
+	// see v1.ErrNotSubscribed.
+	ErrNotSubscribed = v1.ErrNotSubscribed
+
+	// ErrDrainTimeout is matched by the WaitError returned by Wait when the drain gave up
+	// on active shutdown events.
+	ErrDrainTimeout = errors.New("gogs: drain timed out")
+
+	// ErrShuttingDown is returned when the shutdown has already started, see
+	// v1.ErrShuttingDown.
+	ErrShuttingDown = v1.ErrShuttingDown
+
+	// ErrTimedOut is matched by the WaitError returned by Wait when the drain gave up on
+	// active shutdown events or a hook did not complete before its deadline, see
+	// v1.ErrTimedOut.
+	ErrTimedOut = v1.ErrTimedOut
+)
+
+// ErrHookFailed is the error of a failed hook wrapped by WaitError, see v1.ErrHookFailed.
+type ErrHookFailed = v1.ErrHookFailed
+
+// ErrAbandonedSubscriptions is the error WaitError matches when the drain gave up on active
+// shutdown events, see v1.ErrAbandonedSubscriptions.
+type ErrAbandonedSubscriptions = v1.ErrAbandonedSubscriptions
+
+// Option is an option configuring the shutdown, see the options of the v1 package.
+type Option = v1.Option
+
+// HookOption is an option configuring a hook, see the hook options of the v1 package.
+type HookOption = v1.HookOption
+
+// GracefulShutdowner is an interface that describes the context-first graceful shutdown.
+type GracefulShutdowner interface {
+	// Subscribe increments the count of active shutdown events by one, unless the context
+	// is done or the shutdown has started.
+	Subscribe(ctx context.Context) error
+
+	// Unsubscribe decrements the count of active shutdown events by one. It returns
+	// ErrNotSubscribed if there is no active shutdown event.
+	Unsubscribe(ctx context.Context) error
+
+	// Go runs the function in a goroutine tracked as an active shutdown event, unless the
+	// context is done or the shutdown has started.
+	Go(ctx context.Context, fn func(ctx context.Context)) error
+
+	// Register registers a named cleanup function executed once all active shutdown
+	// events have completed, unless the context is done or the shutdown has started.
+	Register(ctx context.Context, name string, fn func(ctx context.Context) error, opts ...HookOption) error
+
+	// Wait waits for the shutdown to complete within the deadline of the context, and
+	// returns the errors of the shutdown.
+	Wait(ctx context.Context) error
+
+	// Count returns the count of active shutdown events.
+	Count() int32
+
+	// Report returns the report of the shutdown.
+	Report() v1.Report
+
+	// V1 returns the underlying GracefulShutdowner of the v1 package.
+	V1() *v1.GracefulShutdown
+}
+
+// GracefulShutdown is a struct that implements the GracefulShutdowner interface on top of
+// a GracefulShutdowner of the v1 package.
+type GracefulShutdown struct {
+	// gs is the underlying GracefulShutdowner of the v1 package.
+	gs *v1.GracefulShutdown
+}
+
+// New is a function that creates a new GracefulShutdowner, see v1.New. It returns the
+// context canceled once the shutdown is triggered, and the function triggering it.
+//
+//	gs, ctx, cancel := New(
+//		context.Background(),
+//		v1.WithSignals(syscall.SIGINT, syscall.SIGTERM),
+//	)
+//	defer cancel()
+//
+//	if err := gs.Register(ctx, "db", db.Close); err != nil {
+//		return err
+//	}
+//
+//	waitCtx, waitCancel := context.WithTimeout(context.Background(), 30*time.Second)
+//	defer waitCancel()
+//	if err := gs.Wait(waitCtx); err != nil {
+//		log.Println(err)
+//	}
+//
+// This example closes the database once an interrupt or termination signal is received,
+// and logs whatever went wrong.
+func New(parentCtx context.Context, opts ...Option) (*GracefulShutdown, context.Context, context.CancelFunc) {
+	gs, ctx, cancel := v1.New(parentCtx, opts...)
+	return Wrap(gs), ctx, cancel
+}
+
+// Wrap is a function that returns the context-first API of the provided GracefulShutdowner
+// of the v1 package. Both share the same state, so existing code can keep using the v1 API.
+func Wrap(gs *v1.GracefulShutdown) *GracefulShutdown {
+	return &GracefulShutdown{gs: gs}
+}
+
+// Subscribe is a method of the GracefulShutdown struct. It increments the count of active
+// shutdown events by one, see v1.GracefulShutdown.SubscribeCtx.
+func (s *GracefulShutdown) Subscribe(ctx context.Context) error {
+	return s.gs.SubscribeCtx(ctx)
+}
+
+// Unsubscribe is a method of the GracefulShutdown struct. It decrements the count of
+// active shutdown events by one. It returns ErrNotSubscribed if there is no active
+// shutdown event, which the v1 API silently ignores, see
+// v1.GracefulShutdown.TryUnsubscribe.
+func (s *GracefulShutdown) Unsubscribe(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.gs.TryUnsubscribe()
+}
+
+// Go is a method of the GracefulShutdown struct. It runs the provided function in a
+// goroutine tracked as an active shutdown event, unless the context is done or the
+// shutdown has started, in which case it returns the error and the function is not run.
+func (s *GracefulShutdown) Go(ctx context.Context, fn func(ctx context.Context)) error {
+	if err := s.gs.SubscribeCtx(ctx); err != nil {
+		return err
+	}
+
+	go func() {
+		defer s.gs.Unsubscribe()
+		fn(ctx)
+	}()
+
+	return nil
+}
+
+// Register is a method of the GracefulShutdown struct. It registers a named cleanup
+// function, see v1.GracefulShutdown.TryAddHook. It returns the context error if the
+// context is done, or ErrShuttingDown if the shutdown has started, since the hook might
+// not run.
+func (s *GracefulShutdown) Register(
+	ctx context.Context,
+	name string,
+	fn func(ctx context.Context) error,
+	opts ...HookOption,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if s.gs.Reason() != "" {
+		return ErrShuttingDown
+	}
+
+	return s.gs.TryAddHook(name, fn, opts...)
+}
+
+// Wait is a method of the GracefulShutdown struct. It waits for the shutdown to complete.
+// If the context has a deadline, the drain gives up on the active shutdown events at the
+// deadline, see v1.GracefulShutdown.WaitWithTimeout. Wait returns the context error once
+// the context is canceled, leaving the shutdown running. It returns a WaitError if the
+// drain gave up or the hooks failed, or nil.
+func (s *GracefulShutdown) Wait(ctx context.Context) error {
+	done := make(chan v1.DrainResult, 1)
+	go func() {
+		if deadline, ok := ctx.Deadline(); ok {
+			done <- s.gs.WaitWithTimeoutReport(time.Until(deadline))
+			return
+		}
+		s.gs.Wait()
+		done <- s.gs.Report().Drain
+	}()
+
+	var drain v1.DrainResult
+	select {
+	case drain = <-done:
+	case <-ctx.Done():
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return ctx.Err()
+		}
+		drain = <-done
+	}
+
+	err := s.gs.Report().Err()
+	if err == nil && drain.Remaining == 0 {
+		return nil
+	}
+
+	return &WaitError{Drain: drain, Err: err}
+}
+
+// Count is a method of the GracefulShutdown struct. It returns the count of active
+// shutdown events.
+func (s *GracefulShutdown) Count() int32 {
+	return s.gs.Count()
+}
+
+// Report is a method of the GracefulShutdown struct. It returns the report of the
+// shutdown, see v1.GracefulShutdown.Report.
+func (s *GracefulShutdown) Report() v1.Report {
+	return s.gs.Report()
+}
+
+// V1 is a method of the GracefulShutdown struct. It returns the underlying
+// GracefulShutdowner of the v1 package, for the code not migrated yet.
+func (s *GracefulShutdown) V1() *v1.GracefulShutdown {
+	return s.gs
+}
+
+// WaitError is a struct that describes what went wrong during the shutdown. It matches
+// ErrDrainTimeout, ErrTimedOut and ErrAbandonedSubscriptions if the drain gave up on
+// active shutdown events, and wraps the errors of the hooks, see ErrHookFailed.
+//
+//	var abandoned ErrAbandonedSubscriptions
+//	switch err := gs.Wait(ctx); {
+//	case errors.As(err, &abandoned):
+//		log.Printf("abandoned: %v", abandoned.Names)
+//	case errors.Is(err, ErrTimedOut):
+//		log.Println("a hook timed out")
+//	}
+//
+// This example branches on the abandoned events and the timed out hooks.
+type WaitError struct {
+	// Drain is the result of the drain.
+	Drain v1.DrainResult
+
+	// Err is the error of the hooks, see v1.Report.Err, or nil.
+	Err error
+}
+
+// Error is a method of the WaitError struct. It returns the description of the error.
+func (e *WaitError) Error() string {
+	if e.Drain.Remaining == 0 {
+		return e.Err.Error()
+	}
+
+	msg := fmt.Sprintf("%s: %d active shutdown events abandoned", ErrDrainTimeout, e.Drain.Remaining)
+	if e.Err != nil {
+		msg += "; " + e.Err.Error()
+	}
+
+	return msg
+}
+
+// Is is a method of the WaitError struct. It reports whether the drain gave up on active
+// shutdown events when the target is ErrDrainTimeout or ErrTimedOut.
+func (e *WaitError) Is(target error) bool {
+	return e.Drain.Remaining > 0 && (target == ErrDrainTimeout || errors.Is(e.Drain.Err(), target))
+}
+
+// As is a method of the WaitError struct. It finds ErrAbandonedSubscriptions if the drain
+// gave up on active shutdown events.
+func (e *WaitError) As(target any) bool {
+	return e.Drain.Remaining > 0 && errors.As(e.Drain.Err(), target)
+}
+
+// Unwrap is a method of the WaitError struct. It returns the error of the hooks.
+func (e *WaitError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,160 @@
+package ctxgs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	v1 "github.com/dsbasko/go-gs"
+)
+
+func Test_GracefulShutdown(t *testing.T) {
+	t.Parallel()
+	gs, ctx, cancel := New(context.Background())
+
+	assert.ErrorIs(t, gs.Unsubscribe(ctx), ErrNotSubscribed)
+	assert.NoError(t, gs.Subscribe(ctx))
+	assert.NoError(t, gs.Unsubscribe(ctx))
+
+	stopped := make(chan struct{})
+	assert.NoError(t, gs.Go(ctx, func(ctx context.Context) {
+		<-ctx.Done()
+		close(stopped)
+	}))
+	assert.Equal(t, int32(1), gs.Count())
+
+	errFailed := errors.New("failed")
+	assert.NoError(t, gs.Register(ctx, "db", func(context.Context) error { return errFailed }))
+
+	canceled, cancelRegister := context.WithCancel(ctx)
+	cancelRegister()
+	assert.ErrorIs(t, gs.Register(canceled, "cache", func(context.Context) error { return nil }), context.Canceled)
+
+	cancel()
+	err := gs.Wait(context.Background())
+	<-stopped
+
+	assert.ErrorIs(t, err, errFailed)
+	assert.NotErrorIs(t, err, ErrDrainTimeout)
+	assert.EqualError(t, err, "db: failed")
+	assert.ErrorIs(t, gs.Register(context.Background(), "late", func(context.Context) error { return nil }),
+		ErrShuttingDown)
+	assert.ErrorIs(t, gs.Subscribe(context.Background()), ErrShuttingDown)
+}
+
+func Test_GracefulShutdown_Wait(t *testing.T) {
+	t.Parallel()
+	gs, ctx, cancel := New(context.Background())
+
+	assert.NoError(t, gs.Subscribe(ctx))
+
+	cancel()
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer waitCancel()
+	err := gs.Wait(waitCtx)
+
+	var waitErr *WaitError
+	if assert.ErrorAs(t, err, &waitErr) {
+		assert.Equal(t, int32(1), waitErr.Drain.Remaining)
+	}
+	assert.ErrorIs(t, err, ErrDrainTimeout)
+	assert.ErrorIs(t, err, ErrTimedOut)
+	assert.EqualError(t, err, "gogs: drain timed out: 1 active shutdown events abandoned")
+
+	var abandoned ErrAbandonedSubscriptions
+	if assert.ErrorAs(t, err, &abandoned) {
+		assert.Equal(t, int32(1), abandoned.Count)
+	}
+}
+
+func Test_GracefulShutdown_Wait_HookTimedOut(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background())
+
+	assert.NoError(t, gs.Register(context.Background(), "db", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, v1.WithTimeout(10*time.Millisecond)))
+
+	cancel()
+	err := gs.Wait(context.Background())
+
+	assert.ErrorIs(t, err, ErrTimedOut)
+	assert.NotErrorIs(t, err, ErrDrainTimeout)
+	var failed ErrHookFailed
+	if assert.ErrorAs(t, err, &failed) {
+		assert.Equal(t, "db", failed.Name)
+	}
+	assert.False(t, errors.As(err, new(ErrAbandonedSubscriptions)))
+}
+
+func Test_Wrap(t *testing.T) {
+	t.Parallel()
+	legacy, _, cancel := v1.New(context.Background())
+
+	gs := Wrap(legacy)
+	assert.Same(t, legacy, gs.V1())
+
+	legacy.Subscribe()
+	assert.Equal(t, int32(1), gs.Count())
+	assert.NoError(t, gs.Unsubscribe(context.Background()))
+
+	cancel()
+	assert.NoError(t, gs.Wait(context.Background()))
+	assert.NoError(t, gs.Report().Err())
+}
+
+func Test_GracefulShutdown_Wait_Canceled(t *testing.T) {
+	t.Parallel()
+	gs, ctx, cancel := New(context.Background())
+
+	assert.NoError(t, gs.Subscribe(ctx))
+	cancel()
+
+	waitCtx, waitCancel := context.WithCancel(context.Background())
+	waitCancel()
+	assert.ErrorIs(t, gs.Wait(waitCtx), context.Canceled)
+
+	deadlineCtx, deadlineCancel := context.WithTimeout(context.Background(), time.Minute)
+	time.AfterFunc(10*time.Millisecond, deadlineCancel)
+	assert.ErrorIs(t, gs.Wait(deadlineCtx), context.Canceled)
+	assert.Equal(t, int32(1), gs.Count())
+
+	assert.NoError(t, gs.Unsubscribe(context.Background()))
+	assert.NoError(t, gs.Wait(context.Background()))
+}
+
+func Test_GracefulShutdown_Unsubscribe_Concurrent(t *testing.T) {
+	t.Parallel()
+	gs, ctx, cancel := New(context.Background())
+	defer cancel()
+
+	const subscribed, callers = 10, 100
+	for i := 0; i < subscribed; i++ {
+		assert.NoError(t, gs.Subscribe(ctx))
+	}
+
+	var wg sync.WaitGroup
+	var unsubscribed atomic.Int32
+	start := make(chan struct{})
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			if gs.Unsubscribe(ctx) == nil {
+				unsubscribed.Add(1)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, int32(subscribed), unsubscribed.Load())
+	assert.Equal(t, int32(0), gs.Count())
+}
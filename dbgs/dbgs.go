@@ -0,0 +1,82 @@
+// Package dbgs provides hooks closing database clients, e.g. pgxpool, go-redis and
+// mongo-driver, once their in-flight operations have completed, so that Close does not
+// race active queries. The package depends on no driver: the clients are described by
+// the functions reporting their usage and closing them.
+package dbgs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+// maxPollInterval is the maximum interval between two checks of the operations in flight.
+const maxPollInterval = 100 * time.Millisecond
+
+// Client is a struct that describes a database client closed by Close.
+type Client struct {
+	// InUse returns the count of connections or sessions checked out of the client.
+	InUse func() int
+
+	// Close closes the client.
+	Close func(ctx context.Context) error
+}
+
+// Close is a function that waits for the connections or sessions checked out of the
+// client to return, up to the deadline of the context, and closes the client. The client
+// is closed even if operations are still in flight at the deadline, in which case an
+// error reporting their count is returned unless Close fails. Close is then passed a
+// context without deadline, so that it is not aborted right away.
+//
+//	err := Close(ctx, Pgx(pool))
+//
+// This example closes the pool once its acquired connections are released.
+func Close(ctx context.Context, c Client) error {
+	waitErr := waitIdle(ctx, c.InUse)
+	if waitErr != nil {
+		ctx = context.Background()
+	}
+
+	if err := c.Close(ctx); err != nil {
+		return err
+	}
+
+	return waitErr
+}
+
+// Register is a function that registers a named hook closing the client with Close.
+//
+//	Register(gs, "postgres", Pgx(pool), gogs.WithTimeout(5*time.Second))
+//
+// This example waits up to five seconds for the active queries before closing the pool.
+func Register(gs gogs.HookRegistrar, name string, c Client, opts ...gogs.HookOption) {
+	gs.AddHook(name, func(ctx context.Context) error {
+		return Close(ctx, c)
+	}, opts...)
+}
+
+// waitIdle is a function that polls the provided count with backoff until it is zero or
+// the context is done.
+func waitIdle(ctx context.Context, inUse func() int) error {
+	interval := time.Millisecond
+	for {
+		n := inUse()
+		if n <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("dbgs: %d operations in flight: %w", n, ctx.Err())
+		case <-timer.C:
+		}
+
+		if interval *= 2; interval > maxPollInterval {
+			interval = maxPollInterval
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package dbgs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+func Test_Close(t *testing.T) {
+	t.Parallel()
+
+	var inUse atomic.Int32
+	inUse.Store(1)
+	var closedInUse int32 = -1
+	c := Client{
+		InUse: func() int { return int(inUse.Load()) },
+		Close: func(context.Context) error {
+			closedInUse = inUse.Load()
+			return nil
+		},
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		inUse.Store(0)
+	}()
+
+	assert.NoError(t, Close(context.Background(), c))
+	assert.Equal(t, int32(0), closedInUse)
+}
+
+func Test_Close_Deadline(t *testing.T) {
+	t.Parallel()
+
+	var closeErr error
+	c := Client{
+		InUse: func() int { return 2 },
+		Close: func(ctx context.Context) error {
+			closeErr = ctx.Err()
+			return nil
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := Close(ctx, c)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.EqualError(t, err, "dbgs: 2 operations in flight: context deadline exceeded")
+	assert.NoError(t, closeErr)
+
+	errFailed := errors.New("failed")
+	c.Close = func(context.Context) error { return errFailed }
+	assert.ErrorIs(t, Close(ctx, c), errFailed)
+}
+
+func Test_Register(t *testing.T) {
+	t.Parallel()
+	gs, _, _ := gogs.New(context.Background())
+
+	var closed bool
+	Register(gs, "postgres", Pgx(func() int32 { return 0 }, func() { closed = true }))
+
+	gs.Wait()
+
+	assert.True(t, closed)
+	report := gs.Report()
+	assert.Len(t, report.Hooks, 1)
+	assert.Equal(t, "postgres", report.Hooks[0].Name)
+	assert.NoError(t, report.Err())
+}
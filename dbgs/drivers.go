@@ -0,0 +1,97 @@
+package dbgs
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Pgx is a function that describes a pgxpool.Pool, whose acquired connections are
+// reported by its statistics. The functions are passed instead of the pool to keep this
+// package free of dependencies.
+//
+//	Pgx(func() int32 { return pool.Stat().AcquiredConns() }, pool.Close)
+//
+// This example describes a pool of the pgx driver.
+func Pgx(acquired func() int32, closeFn func()) Client {
+	return Client{
+		InUse: func() int {
+			return int(acquired())
+		},
+		Close: func(context.Context) error {
+			closeFn()
+			return nil
+		},
+	}
+}
+
+// Redis is a function that describes a go-redis client, whose connections in use are the
+// total connections of its pool that are not idle.
+//
+//	Redis(func() (uint32, uint32) {
+//		stats := rdb.PoolStats()
+//		return stats.TotalConns, stats.IdleConns
+//	}, rdb.Close)
+//
+// This example describes a client of the go-redis driver.
+func Redis(stats func() (total, idle uint32), closeFn func() error) Client {
+	return Client{
+		InUse: func() int {
+			total, idle := stats()
+			return int(total) - int(idle)
+		},
+		Close: func(context.Context) error {
+			return closeFn()
+		},
+	}
+}
+
+// Disconnecter is an interface that describes a mongo-driver client.
+type Disconnecter interface {
+	Disconnect(ctx context.Context) error
+}
+
+// Mongo is a function that describes a mongo-driver client, whose checked-out connections
+// are counted by the provided Tracker fed by the pool monitor of the client.
+func Mongo(client Disconnecter, tracker *Tracker) Client {
+	return Client{
+		InUse: tracker.InUse,
+		Close: client.Disconnect,
+	}
+}
+
+// Tracker is a struct that counts the connections or sessions checked out of a client
+// that does not expose its statistics, e.g. from the events of its pool monitor.
+//
+//	tracker := &Tracker{}
+//	monitor := &event.PoolMonitor{Event: func(e *event.PoolEvent) {
+//		switch e.Type {
+//		case event.GetSucceeded:
+//			tracker.Acquire()
+//		case event.ConnectionReturned:
+//			tracker.Release()
+//		}
+//	}}
+//	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri).SetPoolMonitor(monitor))
+//	Register(gs, "mongo", Mongo(client, tracker))
+//
+// This example counts the connections checked out of a mongo-driver client.
+type Tracker struct {
+	// inUse is the count of checked-out connections.
+	inUse atomic.Int64
+}
+
+// Acquire is a method of the Tracker struct. It counts a checked-out connection.
+func (t *Tracker) Acquire() {
+	t.inUse.Add(1)
+}
+
+// Release is a method of the Tracker struct. It counts a returned connection.
+func (t *Tracker) Release() {
+	t.inUse.Add(-1)
+}
+
+// InUse is a method of the Tracker struct. It returns the count of checked-out
+// connections.
+func (t *Tracker) InUse() int {
+	return int(t.inUse.Load())
+}
@@ -0,0 +1,52 @@
+package dbgs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testDisconnecter struct {
+	err error
+}
+
+func (d testDisconnecter) Disconnect(context.Context) error {
+	return d.err
+}
+
+func Test_Pgx(t *testing.T) {
+	t.Parallel()
+
+	var closed bool
+	c := Pgx(func() int32 { return 3 }, func() { closed = true })
+
+	assert.Equal(t, 3, c.InUse())
+	assert.NoError(t, c.Close(context.Background()))
+	assert.True(t, closed)
+}
+
+func Test_Redis(t *testing.T) {
+	t.Parallel()
+
+	errFailed := errors.New("failed")
+	c := Redis(func() (uint32, uint32) { return 5, 2 }, func() error { return errFailed })
+
+	assert.Equal(t, 3, c.InUse())
+	assert.ErrorIs(t, c.Close(context.Background()), errFailed)
+}
+
+func Test_Mongo(t *testing.T) {
+	t.Parallel()
+
+	errFailed := errors.New("failed")
+	tracker := &Tracker{}
+	c := Mongo(testDisconnecter{err: errFailed}, tracker)
+
+	tracker.Acquire()
+	tracker.Acquire()
+	tracker.Release()
+	assert.Equal(t, 1, c.InUse())
+	assert.ErrorIs(t, c.Close(context.Background()), errFailed)
+}
@@ -0,0 +1,107 @@
+package gogs
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultDeadlockThreshold is the default time the drain may make no progress before
+	// the deadlock warning is logged.
+	defaultDeadlockThreshold = time.Minute
+
+	// maxGoroutineDump is the maximum size of the goroutine dump of the deadlock warning.
+	maxGoroutineDump = 8 << 20
+)
+
+// WithDeadlockWarning is an option that sets the time the drain of a shutdown without
+// deadline may make no progress, i.e. no active shutdown event completes, before a
+// warning listing the pending events and the stacks of all goroutines is logged through
+// the logger set with WithLogger or the standard logger. The warning is logged again if
+// the drain stalls again after some progress. It points at the events that are never
+// unsubscribed, which hang the shutdown forever when Wait is called without timeout. The
+// default threshold is one minute, and a negative one disables the warning. The drains
+// limited by a deadline, e.g. with WaitWithTimeout or WithGracePeriod, are never warned
+// about.
+//
+//	gs, ctx, cancel := New(context.Background(), WithDeadlockWarning(10*time.Second))
+//
+// This example logs the warning once the drain has made no progress for ten seconds.
+func WithDeadlockWarning(threshold time.Duration) Option {
+	return func(cfg *config) {
+		cfg.deadlockThreshold = threshold
+	}
+}
+
+// watchDeadlock is a method of the GracefulShutdown struct. It logs the deadlock warning
+// whenever the drain makes no progress for the threshold, until the provided channel is
+// closed, unless the context of the drain has a deadline.
+func (gs *GracefulShutdown) watchDeadlock(ctx context.Context, doneCh <-chan struct{}) {
+	threshold := gs.cfg.deadlockThreshold
+	if threshold == 0 {
+		threshold = defaultDeadlockThreshold
+	}
+	if _, ok := ctx.Deadline(); ok || threshold < 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(threshold)
+		defer ticker.Stop()
+
+		last, warned := gs.unsubscribed.Load(), false
+		for {
+			select {
+			case <-ticker.C:
+			case <-doneCh:
+				return
+			case <-ctx.Done():
+				return
+			}
+
+			count := gs.unsubscribed.Load()
+			if count != last {
+				last, warned = count, false
+				continue
+			}
+			if !warned && gs.Count() > 0 {
+				warned = true
+				gs.warnDeadlock(threshold)
+			}
+		}
+	}()
+}
+
+// warnDeadlock is a method of the GracefulShutdown struct. It logs the deadlock warning
+// for a drain that has made no progress for the provided duration.
+func (gs *GracefulShutdown) warnDeadlock(stalled time.Duration) {
+	var pending []string
+	for _, sub := range gs.Subscriptions(0) {
+		name := sub.Name
+		if name == "" {
+			name = "unnamed"
+		}
+		pending = append(pending, fmt.Sprintf("%s (%d, %s)", name, sub.Count, time.Since(sub.Since).Round(time.Second)))
+	}
+
+	gs.logger().Printf(
+		"gogs: drain made no progress for %s without deadline, possible deadlock; pending: %s\n%s",
+		stalled, strings.Join(pending, ", "), goroutineDump(),
+	)
+}
+
+// goroutineDump is a function that returns the stacks of all goroutines, cut at
+// maxGoroutineDump.
+func goroutineDump() []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= maxGoroutineDump {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
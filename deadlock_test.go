@@ -0,0 +1,73 @@
+package gogs
+
+import (
+	"context"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WithDeadlockWarning(t *testing.T) {
+	t.Parallel()
+	out := &syncBuffer{}
+	gs, _, cancel := New(
+		context.Background(),
+		WithDeadlockWarning(ShortDelay),
+		WithLogger(log.New(out, "", 0)),
+	)
+
+	gs.Subscribe()
+	done := gs.SubscribeNamed("export")
+	cancel()
+	waitDone := make(chan struct{})
+	go func() {
+		gs.Wait()
+		close(waitDone)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return strings.Contains(out.String(), "possible deadlock")
+	}, LongDelay, ShortDelay/5)
+	warning := out.String()
+	assert.Contains(t, warning, "pending: unnamed (1, ")
+	assert.Contains(t, warning, "export (1, ")
+	assert.Contains(t, warning, "goroutine ")
+	assert.Equal(t, 1, strings.Count(out.String(), "possible deadlock"))
+
+	done()
+	assert.Eventually(t, func() bool {
+		return strings.Count(out.String(), "possible deadlock") == 2
+	}, LongDelay, ShortDelay/5)
+
+	gs.Unsubscribe()
+	<-waitDone
+}
+
+func Test_WithDeadlockWarning_deadline(t *testing.T) {
+	t.Parallel()
+	out := &syncBuffer{}
+	gs, _, cancel := New(
+		context.Background(),
+		WithDeadlockWarning(ShortDelay/5),
+		WithLogger(log.New(out, "", 0)),
+	)
+
+	gs.Subscribe()
+	cancel()
+	gs.WaitWithTimeout(ShortDelay)
+	assert.NotContains(t, out.String(), "possible deadlock")
+
+	gs2, _, cancel2 := New(
+		context.Background(),
+		WithDeadlockWarning(-1),
+		WithLogger(log.New(out, "", 0)),
+	)
+	gs2.Subscribe()
+	cancel2()
+	time.AfterFunc(ShortDelay, gs2.Unsubscribe)
+	gs2.Wait()
+	assert.NotContains(t, out.String(), "possible deadlock")
+}
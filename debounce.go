@@ -0,0 +1,72 @@
+package gogs
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// WithSignalDebounce is an option that keeps listening to the signals once one of them
+// has triggered the shutdown, until the shutdown has completed. Repeats of a signal
+// within the provided window after its first delivery are ignored, as supervisors
+// sometimes re-send SIGTERM. Later repeats and other signals triggering the shutdown are
+// passed to the escalation function, which exits the process with code 1 if nil.
+//
+//	gs, ctx, cancel := New(
+//		context.Background(),
+//		WithSignals(syscall.SIGINT, syscall.SIGTERM),
+//		WithSignalDebounce(5*time.Second, nil),
+//	)
+//
+// This example ignores the SIGTERM re-sent within five seconds, and exits immediately on
+// a SIGTERM received later or on a SIGINT, e.g. when Ctrl+C is pressed during the
+// shutdown.
+func WithSignalDebounce(window time.Duration, escalate func(sig os.Signal)) Option {
+	return func(cfg *config) {
+		cfg.debounceWindow = window
+		cfg.escalate = escalate
+	}
+}
+
+// debounceSignals is a method of the GracefulShutdown struct. It receives the signals
+// delivered after the provided first one has triggered the shutdown, ignoring the repeats
+// within the debounce window and escalating the others, until the shutdown has completed.
+func (gs *GracefulShutdown) debounceSignals(sigCh <-chan os.Signal, first os.Signal) {
+	firstSeen := map[os.Signal]time.Time{first: time.Now()}
+	done := gs.Progress()
+
+	for {
+		select {
+		case sig := <-sigCh:
+			if !gs.cfg.triggers(sig) {
+				continue
+			}
+
+			if seen, ok := firstSeen[sig]; ok && time.Since(seen) < gs.cfg.debounceWindow {
+				continue
+			}
+			if _, ok := firstSeen[sig]; !ok {
+				firstSeen[sig] = time.Now()
+			}
+
+			gs.escalate(sig)
+		case _, ok := <-done:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// escalate is a method of the GracefulShutdown struct. It passes the provided signal
+// received during the shutdown to the escalation function, or exits the process if none
+// is configured.
+func (gs *GracefulShutdown) escalate(sig os.Signal) {
+	if gs.cfg.escalate != nil {
+		gs.cfg.escalate(sig)
+		return
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "gogs: received %s during the shutdown, exiting\n", sig)
+	gs.exitProcess(1)
+}
@@ -0,0 +1,57 @@
+//go:build unix
+
+package gogs
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WithSignalDebounce(t *testing.T) {
+	escalated := make(chan os.Signal, 2)
+	gs, ctx, _ := New(
+		context.Background(),
+		WithSignals(syscall.SIGUSR1, syscall.SIGUSR2),
+		WithSignalDebounce(time.Minute, func(sig os.Signal) { escalated <- sig }),
+	)
+
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+	<-ctx.Done()
+
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR2))
+	assert.Equal(t, syscall.SIGUSR2, <-escalated)
+
+	gs.Wait()
+	assert.Empty(t, escalated)
+	assert.Equal(t, ReasonSignal, gs.Reason())
+}
+
+func Test_WithSignalDebounce_Window(t *testing.T) {
+	var code int
+	exited := make(chan struct{})
+	gs, ctx, _ := New(
+		context.Background(),
+		WithSignals(syscall.SIGUSR1),
+		WithSignalDebounce(ShortDelay, nil),
+	)
+	gs.cfg.exit = func(c int) {
+		code = c
+		close(exited)
+	}
+
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+	<-ctx.Done()
+
+	time.Sleep(2 * ShortDelay)
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+	<-exited
+	assert.Equal(t, 1, code)
+
+	gs.Wait()
+}
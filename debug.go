@@ -0,0 +1,133 @@
+package gogs
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+)
+
+// defaultDebugInterval is the default interval of the dumps of the pending subscriptions
+// while the debug logging is enabled.
+const defaultDebugInterval = 10 * time.Second
+
+// WithDebugSignal is an option that toggles the debug logging of the shutdown whenever the
+// provided signal is received, e.g. SIGUSR2, so that operators can look into a misbehaving
+// instance without restarting it. While enabled, every subscription and unsubscription is
+// logged with its caller, and so are the phases and the hooks, and the status of the
+// shutdown with the pending subscriptions is dumped at the provided interval, ten seconds
+// if zero or less, see OnStatusRequest. The messages are written through the logger set
+// with WithLogger or the standard logger. The signal never triggers the shutdown.
+//
+//	gs, ctx, cancel := New(
+//		context.Background(),
+//		WithSignals(syscall.SIGINT, syscall.SIGTERM),
+//		WithDebugSignal(syscall.SIGUSR2, 5*time.Second),
+//	)
+//
+// This example enables the debug logging on the first SIGUSR2, dumping the pending
+// subscriptions every five seconds, and disables it on the second.
+func WithDebugSignal(sig os.Signal, interval time.Duration) Option {
+	return func(cfg *config) {
+		if interval <= 0 {
+			interval = defaultDebugInterval
+		}
+		cfg.debugSignal = sig
+		cfg.debugInterval = interval
+		if cfg.ignored == nil {
+			cfg.ignored = make(map[os.Signal]bool)
+		}
+		cfg.ignored[sig] = true
+	}
+}
+
+// watchDebug is a method of the GracefulShutdown struct. It toggles the debug logging on
+// the debug signal until the shutdown has completed.
+func (gs *GracefulShutdown) watchDebug() {
+	if gs.cfg.debugSignal == nil {
+		return
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, gs.cfg.debugSignal)
+	done := gs.doneChan()
+
+	go func() {
+		defer signal.Stop(sigCh)
+
+		for {
+			select {
+			case <-sigCh:
+				gs.toggleDebug()
+			case <-done:
+				if gs.debug.Load() {
+					gs.toggleDebug()
+				}
+				return
+			}
+		}
+	}()
+}
+
+// toggleDebug is a method of the GracefulShutdown struct. It enables the debug logging
+// and starts the dumps of the pending subscriptions, or disables and stops them, and
+// reports whether the debug logging is enabled.
+func (gs *GracefulShutdown) toggleDebug() bool {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if gs.debugStop != nil {
+		gs.debug.Store(false)
+		close(gs.debugStop)
+		gs.debugStop = nil
+		gs.logger().Printf("gogs: debug logging disabled")
+		return false
+	}
+
+	gs.debugStop = make(chan struct{})
+	gs.debug.Store(true)
+	gs.logger().Printf("gogs: debug logging enabled")
+	go gs.dumpPending(gs.debugStop)
+
+	return true
+}
+
+// dumpPending is a method of the GracefulShutdown struct. It logs the status of the
+// shutdown with the pending subscriptions at the debug interval until the provided channel
+// is closed.
+func (gs *GracefulShutdown) dumpPending(stop chan struct{}) {
+	t := time.NewTicker(gs.cfg.debugInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			var b strings.Builder
+			writeStatus(&b, gs.Snapshot(), gs.Subscriptions(0))
+			gs.debugf("%s", strings.TrimPrefix(strings.TrimSuffix(b.String(), "\n"), "gogs: "))
+		case <-stop:
+			return
+		}
+	}
+}
+
+// debugf is a method of the GracefulShutdown struct. It logs the provided message if the
+// debug logging is enabled.
+func (gs *GracefulShutdown) debugf(format string, args ...any) {
+	if !gs.debug.Load() {
+		return
+	}
+
+	gs.logger().Printf("gogs: debug: "+format, args...)
+}
+
+// logger is a method of the GracefulShutdown struct. It returns the logger set with
+// WithLogger or the standard logger.
+func (gs *GracefulShutdown) logger() *log.Logger {
+	if gs.cfg.logger == nil {
+		return log.Default()
+	}
+
+	return gs.cfg.logger
+}
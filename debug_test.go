@@ -0,0 +1,47 @@
+package gogs
+
+import (
+	"context"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GracefulShutdown_toggleDebug(t *testing.T) {
+	t.Parallel()
+	var buf syncBuffer
+	gs, _, cancel := New(
+		context.Background(),
+		WithLogger(log.New(&buf, "", 0)),
+		WithDebugSignal(nil, ShortDelay),
+	)
+
+	gs.Subscribe()
+	assert.True(t, gs.toggleDebug())
+	done := gs.SubscribeNamed("export")
+	time.Sleep(3 * ShortDelay)
+
+	gs.AddHook("db", func(context.Context) error { return nil })
+	cancel()
+	go func() {
+		shortDelay()
+		gs.Unsubscribe()
+		done()
+	}()
+	gs.Wait()
+	assert.False(t, gs.toggleDebug())
+	gs.Subscribe()
+
+	out := buf.String()
+	assert.Contains(t, out, "gogs: debug logging enabled\n")
+	assert.Regexp(t, `gogs: debug: count \+1 to 2 at .*debug_test\.go:\d+\n`, out)
+	assert.Contains(t, out, "gogs: debug: running, 2 pending (unnamed x1 for ")
+	assert.Contains(t, out, "export x1 for ")
+	assert.Contains(t, out, "gogs: debug: phase drain\n")
+	assert.Contains(t, out, "gogs: debug: hook db started, 1 hooks left\n")
+	assert.Regexp(t, `gogs: debug: hook db completed in \S+, error: <nil>\n`, out)
+	assert.True(t, strings.HasSuffix(out, "gogs: debug logging disabled\n"))
+}
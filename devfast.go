@@ -0,0 +1,28 @@
+package gogs
+
+import "time"
+
+// devFastBudget is the budget of the whole shutdown, and the kill delay if any, in the
+// mode set with WithDevFastShutdown.
+const devFastBudget = 100 * time.Millisecond
+
+// WithDevFastShutdown is an option that makes the shutdown near-instant for the local
+// development: the drain does not wait for the active shutdown events, and the grace
+// period, overriding the policies, and the kill delay, if any, are shrunk to 100
+// milliseconds, within which the hooks are executed. It is meant to be enabled through
+// the environment, see ParseEnvConfig, so that the production keeps the full graceful
+// behavior.
+//
+//	gs, ctx, cancel := New(
+//		context.Background(),
+//		WithGracePeriod(30*time.Second),
+//		WithEnvConfig("GOGS"),
+//	)
+//
+// This example shuts down within thirty seconds, or at once on a developer machine
+// exporting GOGS_DEV_FAST_SHUTDOWN=true.
+func WithDevFastShutdown() Option {
+	return func(cfg *config) {
+		cfg.devFast = true
+	}
+}
@@ -0,0 +1,36 @@
+package gogs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WithDevFastShutdown(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(
+		context.Background(),
+		WithGracePeriod(time.Minute),
+		WithPolicy(ReasonCancel, Policy{GracePeriod: time.Hour}),
+		WithDevFastShutdown(),
+	)
+
+	var budget time.Duration
+	gs.AddHook("db", func(ctx context.Context) error {
+		deadline, _ := ctx.Deadline()
+		budget = time.Until(deadline)
+		return nil
+	})
+	gs.Subscribe()
+
+	cancel()
+	start := time.Now()
+	gs.Wait()
+
+	assert.Less(t, time.Since(start), LongDelay)
+	assert.Equal(t, int32(0), gs.Count())
+	assert.LessOrEqual(t, budget, devFastBudget)
+	assert.Equal(t, devFastBudget, gs.Plan(ReasonCancel).GracePeriod)
+}
@@ -0,0 +1,93 @@
+package gogs
+
+// Done is a method of the GracefulShutdown struct. It returns a channel closed once the
+// shutdown has completed, i.e. once all active shutdown events and hooks have completed,
+// so that a main loop selecting over several channels needs no goroutine calling Wait.
+// For an instance created by New, the first call starts waiting as soon as the context is
+// done; for the other instances, the channel is closed when a call of Wait returns.
+//
+//	done := gs.Done()
+//	for {
+//		select {
+//		case job := <-jobs:
+//			process(ctx, job)
+//		case <-ticker.C:
+//			flushMetrics()
+//		case <-done:
+//			return
+//		}
+//	}
+//
+// This example runs the main loop until the shutdown has completed.
+func (gs *GracefulShutdown) Done() <-chan struct{} {
+	gs.doneOnce.Do(func() {
+		if gs.ctx == nil {
+			return
+		}
+
+		go func() {
+			<-gs.ctx.Done()
+			gs.Wait()
+		}()
+	})
+
+	return gs.doneChan()
+}
+
+// doneChan is a method of the GracefulShutdown struct. It returns the channel closed once
+// the shutdown has completed, creating it if needed.
+func (gs *GracefulShutdown) doneChan() chan struct{} {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if gs.doneCh == nil {
+		gs.doneCh = make(chan struct{})
+	}
+
+	return gs.doneCh
+}
+
+// complete is a method of the GracefulShutdown struct. It closes the channel returned by
+// Done, exactly once, and stops the watchdog started by WithKillDelay unless the shutdown
+// abandoned anything.
+func (gs *GracefulShutdown) complete() {
+	gs.completeOnce.Do(func() {
+		if !gs.Report().abandoned() {
+			gs.stopWatchdog()
+		}
+		close(gs.doneChan())
+	})
+}
+
+// AfterShutdown is a method of the GracefulShutdown struct. It registers a function
+// invoked with the report once the shutdown has completed, before Wait returns, so that
+// the components reporting the outcome of the shutdown, e.g. the metric emitters, flush it
+// before the process exits. The functions are invoked once, synchronously and in order of
+// registration, and must not block for long.
+//
+//	gs.AfterShutdown(func(report Report) {
+//		log.Printf("shutdown triggered by %s: %v", report.Reason, report.Err())
+//	})
+//
+// This example logs the outcome of the shutdown.
+func (gs *GracefulShutdown) AfterShutdown(fn func(report Report)) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	gs.afterFns = append(gs.afterFns, fn)
+}
+
+// runAfterShutdown is a method of the GracefulShutdown struct. It invokes the functions
+// registered with AfterShutdown, once.
+func (gs *GracefulShutdown) runAfterShutdown() {
+	gs.afterOnce.Do(func() {
+		gs.mu.Lock()
+		fns := gs.afterFns
+		gs.mu.Unlock()
+
+		report := gs.Report()
+		for _, fn := range fns {
+			fn(report)
+		}
+	})
+}
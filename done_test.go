@@ -0,0 +1,76 @@
+package gogs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GracefulShutdown_Done(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background())
+
+	closed := false
+	gs.AddHook("db", func(context.Context) error {
+		closed = true
+		return nil
+	})
+	gs.Subscribe()
+
+	done := gs.Done()
+	assert.Equal(t, done, gs.Done())
+
+	cancel()
+	select {
+	case <-done:
+		assert.Fail(t, "done before the drain")
+	case <-time.After(ShortDelay):
+	}
+
+	gs.Unsubscribe()
+	select {
+	case <-done:
+		assert.True(t, closed)
+		assert.Equal(t, PhaseDone, gs.Snapshot().Phase)
+	case <-time.After(LongDelay):
+		assert.Fail(t, "not done after the drain")
+	}
+}
+
+func Test_GracefulShutdown_Done_Wait(t *testing.T) {
+	t.Parallel()
+	gs := &GracefulShutdown{}
+
+	done := gs.Done()
+	gs.Wait()
+
+	select {
+	case <-done:
+	default:
+		assert.Fail(t, "not done after Wait")
+	}
+}
+
+func Test_GracefulShutdown_AfterShutdown(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background())
+
+	var calls []string
+	gs.AddHook("db", func(context.Context) error { return nil })
+	gs.AfterShutdown(func(report Report) {
+		calls = append(calls, "metrics")
+		assert.Len(t, report.Hooks, 1)
+		assert.Equal(t, PhaseDone, gs.Snapshot().Phase)
+	})
+	gs.AfterShutdown(func(Report) {
+		calls = append(calls, "logs")
+	})
+
+	cancel()
+	gs.Wait()
+	gs.Wait()
+
+	assert.Equal(t, []string{"metrics", "logs"}, calls)
+}
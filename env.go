@@ -0,0 +1,167 @@
+package gogs
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// envGracePeriod is the name of the variable holding the grace period.
+	envGracePeriod = "GRACE_PERIOD"
+
+	// envKillDelay is the name of the variable holding the kill delay.
+	envKillDelay = "KILL_DELAY"
+
+	// envDevFastShutdown is the name of the variable enabling the mode set with
+	// WithDevFastShutdown.
+	envDevFastShutdown = "DEV_FAST_SHUTDOWN"
+
+	// envSkipHooks is the name of the variable listing the hooks to skip.
+	envSkipHooks = "SKIP_HOOKS"
+
+	// envTimeoutSuffix is the suffix of the names of the variables holding the timeouts
+	// of the phases.
+	envTimeoutSuffix = "_TIMEOUT"
+)
+
+// ParseEnvConfig is a function that reads the shutdown configuration from environment
+// variables whose names start with the provided prefix followed by an underscore, and
+// returns it as an option overriding the options preceding it. Unset variables leave the
+// configuration unchanged. The values use the time.ParseDuration format and must not be
+// negative:
+//
+//   - <PREFIX>_GRACE_PERIOD sets the grace period, see WithGracePeriod;
+//   - <PREFIX>_KILL_DELAY sets the kill delay, see WithKillDelay;
+//   - <PREFIX>_DEREGISTER_TIMEOUT, <PREFIX>_DRAIN_TIMEOUT and <PREFIX>_CLOSE_TIMEOUT set
+//     the phase timeouts, see WithPhaseTimeout;
+//   - <PREFIX>_DEV_FAST_SHUTDOWN, a boolean in the strconv.ParseBool format, enables the
+//     near-instant shutdown for the local development, see WithDevFastShutdown;
+//   - <PREFIX>_SKIP_HOOKS, a comma-separated list of names, skips the hooks, see
+//     WithSkippedHooks.
+//
+// It returns an error naming the variable if a value is invalid.
+func ParseEnvConfig(prefix string) (Option, error) {
+	var opts []Option
+
+	if d, ok, err := lookupEnvDuration(prefix, envGracePeriod); err != nil {
+		return nil, err
+	} else if ok {
+		opts = append(opts, WithGracePeriod(d))
+	}
+
+	if d, ok, err := lookupEnvDuration(prefix, envKillDelay); err != nil {
+		return nil, err
+	} else if ok {
+		opts = append(opts, WithKillDelay(d))
+	}
+
+	for _, phase := range timedPhases {
+		name := strings.ToUpper(string(phase)) + envTimeoutSuffix
+		if d, ok, err := lookupEnvDuration(prefix, name); err != nil {
+			return nil, err
+		} else if ok {
+			opts = append(opts, WithPhaseTimeout(phase, d))
+		}
+	}
+
+	if fast, ok, err := lookupEnvBool(prefix, envDevFastShutdown); err != nil {
+		return nil, err
+	} else if ok && fast {
+		opts = append(opts, WithDevFastShutdown())
+	}
+
+	if names := lookupEnvList(prefix, envSkipHooks); len(names) > 0 {
+		opts = append(opts, WithSkippedHooks(names...))
+	}
+
+	return func(cfg *config) {
+		for _, opt := range opts {
+			opt(cfg)
+		}
+	}, nil
+}
+
+// WithEnvConfig is an option that reads the shutdown configuration from environment
+// variables, so that operators can tune the shutdown per environment without recompiling.
+// See ParseEnvConfig for the list of variables. It panics if a value is invalid, so that
+// a misconfiguration is noticed at startup.
+//
+//	gs, ctx, cancel := New(
+//		context.Background(),
+//		WithGracePeriod(30*time.Second),
+//		WithEnvConfig("GOGS"),
+//	)
+//
+// This example uses a grace period of thirty seconds unless GOGS_GRACE_PERIOD is set.
+func WithEnvConfig(prefix string) Option {
+	return func(cfg *config) {
+		opt, err := ParseEnvConfig(prefix)
+		if err != nil {
+			panic(err)
+		}
+		opt(cfg)
+	}
+}
+
+// lookupEnvDuration is a function that reads the duration from the environment variable
+// with the provided prefix and name. It reports whether the variable is set.
+func lookupEnvDuration(prefix, name string) (time.Duration, bool, error) {
+	if prefix != "" {
+		name = prefix + "_" + name
+	}
+
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return 0, false, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, false, fmt.Errorf("gogs: invalid %s: %w", name, err)
+	}
+	if d < 0 {
+		return 0, false, fmt.Errorf("gogs: invalid %s: negative duration %s", name, d)
+	}
+
+	return d, true, nil
+}
+
+// lookupEnvBool is a function that reads the boolean from the environment variable with
+// the provided prefix and name. It reports whether the variable is set.
+func lookupEnvBool(prefix, name string) (bool, bool, error) {
+	if prefix != "" {
+		name = prefix + "_" + name
+	}
+
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return false, false, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, false, fmt.Errorf("gogs: invalid %s: %w", name, err)
+	}
+
+	return b, true, nil
+}
+
+// lookupEnvList is a function that reads the comma-separated list from the environment
+// variable with the provided prefix and name, ignoring the blank items.
+func lookupEnvList(prefix, name string) []string {
+	if prefix != "" {
+		name = prefix + "_" + name
+	}
+
+	var items []string
+	for _, item := range strings.Split(os.Getenv(name), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
@@ -0,0 +1,180 @@
+package gogs
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"runtime/metrics"
+	"strconv"
+	"strings"
+)
+
+// cgroupRoot is the mount point of the cgroup file system the container limits are read
+// from.
+const cgroupRoot = "/sys/fs/cgroup"
+
+// EnvSnapshot is a struct that describes the environment and the runtime of the process
+// when the shutdown started, see WithEnvSnapshot.
+type EnvSnapshot struct {
+	// GoVersion is the version of Go the binary was built with.
+	GoVersion string
+
+	// GOMAXPROCS is the count of CPUs executing Go code simultaneously, and NumCPU the
+	// count of CPUs usable by the process.
+	GOMAXPROCS int
+	NumCPU     int
+
+	// GOGC is the garbage collection target percentage, -1 if the collector is disabled.
+	GOGC int
+
+	// MemoryLimit is the soft memory limit of the runtime, GOMEMLIMIT, in bytes.
+	// math.MaxInt64 means none.
+	MemoryLimit int64
+
+	// CPULimit is the count of CPUs the container is limited to, and ContainerMemoryLimit
+	// its memory limit in bytes, read from the cgroups. Zero means none or unknown.
+	CPULimit             float64
+	ContainerMemoryLimit int64
+
+	// Goroutines is the count of goroutines when the shutdown started.
+	Goroutines int
+
+	// Vars maps the names of the environment variables selected with WithEnvSnapshot to
+	// their values, unset ones omitted.
+	Vars map[string]string
+}
+
+// WithEnvSnapshot is an option that captures the environment and the runtime of the
+// process when the shutdown starts in Report.Env: the Go version, GOMAXPROCS, GOGC,
+// GOMEMLIMIT, the CPU and memory limits of the container and the count of goroutines, as
+// well as the values of the provided environment variables. It gives the investigations
+// of slow drains their context without extra tooling, e.g. a drain slowed down by CPU
+// throttling or by a garbage collector under memory pressure.
+//
+//	gs, ctx, cancel := New(context.Background(), WithEnvSnapshot("POD_NAME", "GIT_SHA"))
+//
+// This example also records the pod and the revision the report comes from.
+func WithEnvSnapshot(vars ...string) Option {
+	return func(cfg *config) {
+		cfg.envSnapshot = true
+		cfg.envVars = append(cfg.envVars, vars...)
+	}
+}
+
+// snapshotEnv is a method of the GracefulShutdown struct. It records the snapshot of the
+// environment in the report, if configured, once.
+func (gs *GracefulShutdown) snapshotEnv() {
+	if !gs.cfg.envSnapshot {
+		return
+	}
+
+	gs.envOnce.Do(func() {
+		env := captureEnv(gs.cfg.envVars)
+
+		gs.mu.Lock()
+		defer gs.mu.Unlock()
+		gs.report.Env = &env
+	})
+}
+
+// captureEnv is a function that returns the snapshot of the environment with the values
+// of the provided variables.
+func captureEnv(vars []string) EnvSnapshot {
+	env := EnvSnapshot{
+		GoVersion:   runtime.Version(),
+		GOMAXPROCS:  runtime.GOMAXPROCS(0),
+		NumCPU:      runtime.NumCPU(),
+		GOGC:        gcPercent(),
+		MemoryLimit: debug.SetMemoryLimit(-1),
+		Goroutines:  runtime.NumGoroutine(),
+	}
+	env.CPULimit, env.ContainerMemoryLimit = containerLimits(cgroupRoot)
+
+	for _, name := range vars {
+		if value, ok := os.LookupEnv(name); ok {
+			if env.Vars == nil {
+				env.Vars = make(map[string]string)
+			}
+			env.Vars[name] = value
+		}
+	}
+
+	return env
+}
+
+// gcPercent is a function that returns the garbage collection target percentage, read
+// from the runtime metrics if available, or from GOGC otherwise.
+func gcPercent() int {
+	sample := []metrics.Sample{{Name: "/gc/gogc:percent"}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() == metrics.KindUint64 {
+		return int(sample[0].Value.Uint64())
+	}
+
+	switch v := os.Getenv("GOGC"); v {
+	case "":
+		return 100
+	case "off":
+		return -1
+	default:
+		if percent, err := strconv.Atoi(v); err == nil {
+			return percent
+		}
+		return 100
+	}
+}
+
+// containerLimits is a function that returns the CPU and memory limits of the container
+// read from the cgroups mounted at the provided root, version 2 first, zero if none.
+func containerLimits(root string) (cpus float64, memory int64) {
+	if quota, period, ok := readPair(filepath.Join(root, "cpu.max")); ok {
+		cpus = quota / period
+	} else if quota, ok := readNumber(filepath.Join(root, "cpu", "cpu.cfs_quota_us")); ok && quota > 0 {
+		if period, ok := readNumber(filepath.Join(root, "cpu", "cpu.cfs_period_us")); ok && period > 0 {
+			cpus = quota / period
+		}
+	}
+
+	if limit, ok := readNumber(filepath.Join(root, "memory.max")); ok {
+		memory = int64(limit)
+	} else if limit, ok := readNumber(filepath.Join(root, "memory", "memory.limit_in_bytes")); ok && limit < math.MaxInt64/2 {
+		memory = int64(limit)
+	}
+
+	return cpus, memory
+}
+
+// readNumber is a function that reads the number the provided file holds. It reports
+// false if the file cannot be read or holds no number, e.g. "max".
+func readNumber(path string) (float64, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+	return n, err == nil
+}
+
+// readPair is a function that reads the two positive numbers the provided file holds,
+// e.g. the quota and the period of cpu.max. It reports false otherwise.
+func readPair(path string) (float64, float64, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) != 2 {
+		return 0, 0, false
+	}
+	a, errA := strconv.ParseFloat(fields[0], 64)
+	b, errB := strconv.ParseFloat(fields[1], 64)
+	if errA != nil || errB != nil || b <= 0 {
+		return 0, 0, false
+	}
+
+	return a, b, true
+}
@@ -0,0 +1,62 @@
+package gogs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WithEnvSnapshot(t *testing.T) {
+	t.Setenv("GOGS_TEST_REVISION", "abc123")
+	gs, _, cancel := New(context.Background(), WithEnvSnapshot("GOGS_TEST_REVISION", "GOGS_TEST_UNSET"))
+
+	assert.Nil(t, gs.Report().Env)
+	cancel()
+	gs.Wait()
+
+	env := gs.Report().Env
+	if assert.NotNil(t, env) {
+		assert.Equal(t, runtime.Version(), env.GoVersion)
+		assert.Equal(t, runtime.GOMAXPROCS(0), env.GOMAXPROCS)
+		assert.NotZero(t, env.GOGC)
+		assert.Positive(t, env.MemoryLimit)
+		assert.Positive(t, env.Goroutines)
+		assert.Equal(t, map[string]string{"GOGS_TEST_REVISION": "abc123"}, env.Vars)
+	}
+
+	gs, _, cancel = New(context.Background())
+	cancel()
+	gs.Wait()
+	assert.Nil(t, gs.Report().Env)
+}
+
+func Test_containerLimits(t *testing.T) {
+	t.Parallel()
+
+	v2 := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(v2, "cpu.max"), []byte("150000 100000\n"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(v2, "memory.max"), []byte("536870912\n"), 0o644))
+	cpus, memory := containerLimits(v2)
+	assert.Equal(t, 1.5, cpus)
+	assert.Equal(t, int64(512<<20), memory)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(v2, "cpu.max"), []byte("max 100000\n"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(v2, "memory.max"), []byte("max\n"), 0o644))
+	cpus, memory = containerLimits(v2)
+	assert.Zero(t, cpus)
+	assert.Zero(t, memory)
+
+	v1 := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(v1, "cpu"), 0o755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(v1, "memory"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(v1, "cpu", "cpu.cfs_quota_us"), []byte("50000\n"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(v1, "cpu", "cpu.cfs_period_us"), []byte("100000\n"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(v1, "memory", "memory.limit_in_bytes"), []byte("9223372036854771712\n"), 0o644))
+	cpus, memory = containerLimits(v1)
+	assert.Equal(t, 0.5, cpus)
+	assert.Zero(t, memory)
+}
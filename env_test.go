@@ -0,0 +1,58 @@
+package gogs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseEnvConfig(t *testing.T) {
+	t.Setenv("TEST_GRACE_PERIOD", "30s")
+	t.Setenv("TEST_KILL_DELAY", "5s")
+	t.Setenv("TEST_DRAIN_TIMEOUT", "20s")
+	t.Setenv("TEST_DEV_FAST_SHUTDOWN", "true")
+	t.Setenv("TEST_SKIP_HOOKS", "replica-sync, ,cache-dump")
+
+	opt, err := ParseEnvConfig("TEST")
+	assert.NoError(t, err)
+
+	cfg := config{gracePeriod: time.Minute}
+	WithPhaseTimeout(PhaseClose, time.Second)(&cfg)
+	opt(&cfg)
+
+	assert.Equal(t, 30*time.Second, cfg.gracePeriod)
+	assert.Equal(t, 5*time.Second, cfg.killDelay)
+	assert.Equal(t, 20*time.Second, cfg.phaseTimeouts[PhaseDrain])
+	assert.Equal(t, time.Second, cfg.phaseTimeouts[PhaseClose])
+	assert.True(t, cfg.devFast)
+	assert.Equal(t, map[string]bool{"replica-sync": true, "cache-dump": true}, cfg.skippedHooks)
+}
+
+func Test_WithEnvConfig_Invalid(t *testing.T) {
+	t.Setenv("TEST_CLOSE_TIMEOUT", "soon")
+
+	_, err := ParseEnvConfig("TEST")
+	assert.EqualError(t, err, `gogs: invalid TEST_CLOSE_TIMEOUT: time: invalid duration "soon"`)
+
+	assert.Panics(t, func() {
+		WithEnvConfig("TEST")(&config{})
+	})
+
+	t.Setenv("TEST_CLOSE_TIMEOUT", "1s")
+	t.Setenv("TEST_DEV_FAST_SHUTDOWN", "maybe")
+
+	_, err = ParseEnvConfig("TEST")
+	assert.EqualError(t, err, `gogs: invalid TEST_DEV_FAST_SHUTDOWN: strconv.ParseBool: parsing "maybe": invalid syntax`)
+}
+
+func Test_ParseEnvConfig_Negative(t *testing.T) {
+	t.Setenv("TEST_KILL_DELAY", "-5s")
+
+	_, err := ParseEnvConfig("TEST")
+	assert.EqualError(t, err, "gogs: invalid TEST_KILL_DELAY: negative duration -5s")
+
+	assert.Panics(t, func() {
+		WithEnvConfig("TEST")(&config{})
+	})
+}
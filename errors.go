@@ -0,0 +1,144 @@
+package gogs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrShuttingDown is returned by SubscribeCtx, Limiter.Acquire, Quiescer.Enter and
+// HookTx.Commit when the shutdown has already started.
+var ErrShuttingDown = errors.New("gogs: shutting down")
+
+// ErrNotSubscribed is returned by TryUnsubscribe when there is no active shutdown event.
+var ErrNotSubscribed = errors.New("gogs: not subscribed")
+
+// ErrTimedOut is matched by the errors of the hooks that did not complete before their
+// deadline, see ErrHookFailed, and by ErrAbandonedSubscriptions.
+var ErrTimedOut = errors.New("gogs: timed out")
+
+// ErrQuotaExceeded is reported for the hooks not executed because the quota of their
+// category is exhausted, see WithCategoryQuota.
+var ErrQuotaExceeded = errors.New("gogs: category quota exceeded")
+
+// ErrLimitExceeded is returned by Limiter.TryAcquire when all permits are in flight.
+var ErrLimitExceeded = errors.New("gogs: limit exceeded")
+
+// ErrExtensionDenied is returned by HookContext.Extend when the extension is not granted.
+var ErrExtensionDenied = errors.New("gogs: extension denied")
+
+// ErrAlreadyBound is returned by Registry.Bind when the registry is already bound.
+var ErrAlreadyBound = errors.New("gogs: registry is already bound")
+
+// ErrNotPending is returned by Abort when no shutdown is held, see WithAbortWindow.
+var ErrNotPending = errors.New("gogs: no shutdown is pending")
+
+// ErrTxDone is returned by HookTx.Commit when the transaction is already committed or
+// rolled back.
+var ErrTxDone = errors.New("gogs: hook transaction already done")
+
+// ErrHookFailed is an error that wraps the error returned by a named hook, see
+// Report.Err. It matches ErrTimedOut if the hook did not complete before its deadline.
+//
+//	var failed gogs.ErrHookFailed
+//	if errors.As(gs.Report().Err(), &failed) {
+//		alert(failed.Name, failed.Err)
+//	}
+//
+// This example alerts on the first failed hook.
+type ErrHookFailed struct {
+	// Name is the name of the hook.
+	Name string
+
+	// Err is the error returned by the hook.
+	Err error
+}
+
+// Error is a method of the ErrHookFailed struct. It returns the error message prefixed
+// with the name of the hook.
+func (e ErrHookFailed) Error() string {
+	return e.Name + ": " + e.Err.Error()
+}
+
+// Unwrap is a method of the ErrHookFailed struct. It returns the wrapped error.
+func (e ErrHookFailed) Unwrap() error {
+	return e.Err
+}
+
+// Is is a method of the ErrHookFailed struct. It reports whether the target is
+// ErrTimedOut and the hook did not complete before its deadline.
+func (e ErrHookFailed) Is(target error) bool {
+	return target == ErrTimedOut && errors.Is(e.Err, context.DeadlineExceeded)
+}
+
+// ErrAbandonedSubscriptions is an error that describes the active shutdown events the
+// drain gave up on, see DrainResult.Err. It matches ErrTimedOut.
+type ErrAbandonedSubscriptions struct {
+	// Count is the count of active shutdown events given up on, named or not.
+	Count int32
+
+	// Names is the list of names of the named subscribers given up on, see
+	// SubscribeNamed.
+	Names []string
+}
+
+// Error is a method of the ErrAbandonedSubscriptions struct. It returns the count of
+// abandoned events followed by the names of the named ones.
+func (e ErrAbandonedSubscriptions) Error() string {
+	msg := fmt.Sprintf("gogs: %d active shutdown events abandoned", e.Count)
+	if len(e.Names) > 0 {
+		msg += ": " + strings.Join(e.Names, ", ")
+	}
+
+	return msg
+}
+
+// Is is a method of the ErrAbandonedSubscriptions struct. It reports whether the target
+// is ErrTimedOut.
+func (e ErrAbandonedSubscriptions) Is(target error) bool {
+	return target == ErrTimedOut
+}
+
+// multiError is an error that combines several errors.
+type multiError []error
+
+// Error is a method of the multiError type. It returns the messages of all combined
+// errors separated by a semicolon.
+func (e multiError) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap is a method of the multiError type. It returns the combined errors.
+func (e multiError) Unwrap() []error {
+	return e
+}
+
+// Is is a method of the multiError type. It reports whether any of the combined errors
+// matches the target, so that errors.Is works on Go versions without multi-error support.
+func (e multiError) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// As is a method of the multiError type. It finds the first combined error that matches
+// the target, so that errors.As works on Go versions without multi-error support.
+func (e multiError) As(target any) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,109 @@
+// Package expvargs publishes the state of the shutdown with expvar, for the teams relying
+// on /debug/vars instead of Prometheus. It is a separate package since importing expvar
+// registers the /debug/vars handler on http.DefaultServeMux.
+package expvargs
+
+import (
+	"expvar"
+	"time"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+// State is a struct that describes the state of the shutdown published by Publish.
+type State struct {
+	// Reason is what triggered the shutdown, empty until it has started.
+	Reason gogs.Reason `json:"reason"`
+
+	// Phase is the current step of the shutdown, empty until it has started.
+	Phase gogs.Phase `json:"phase"`
+
+	// Active is the count of active shutdown events.
+	Active int32 `json:"active"`
+
+	// OldestSubscription is the age of the oldest active shutdown event, see
+	// gogs.GracefulShutdown.OldestSubscription.
+	OldestSubscription string `json:"oldest_subscription"`
+
+	// Hook is the name of the hook being executed, if any.
+	Hook string `json:"hook,omitempty"`
+
+	// HooksLeft is the count of hooks not completed yet.
+	HooksLeft int `json:"hooks_left"`
+
+	// Elapsed is the time elapsed since the shutdown started.
+	Elapsed string `json:"elapsed"`
+
+	// Report is the outcome of the last shutdown, present once it has completed.
+	Report *Report `json:"report,omitempty"`
+}
+
+// Report is a struct that describes the outcome of the executed hooks.
+type Report struct {
+	// Hooks is the list of executed hooks, in order of execution.
+	Hooks []Hook `json:"hooks"`
+
+	// Err is the combined error of the report, if any.
+	Err string `json:"error,omitempty"`
+}
+
+// Hook is a struct that describes the outcome of an executed hook.
+type Hook struct {
+	// Name is the name of the hook.
+	Name string `json:"name"`
+
+	// Duration is the time spent waiting for the hook.
+	Duration string `json:"duration"`
+
+	// Skipped reports whether the hook was skipped.
+	Skipped bool `json:"skipped,omitempty"`
+
+	// Err is the error of the hook, if any.
+	Err string `json:"error,omitempty"`
+}
+
+// Publish is a function that publishes the state of the shutdown under the provided
+// expvar name. Like expvar.Publish, it panics if the name is already in use, so it must
+// be called once per GracefulShutdowner.
+//
+//	expvargs.Publish(gs, "shutdown")
+//
+// This example exposes the state under the "shutdown" key of /debug/vars.
+func Publish(gs *gogs.GracefulShutdown, name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return state(gs)
+	}))
+}
+
+// state is a function that returns the current state of the shutdown.
+func state(gs *gogs.GracefulShutdown) State {
+	snapshot := gs.Snapshot()
+	s := State{
+		Reason:             gs.Reason(),
+		Phase:              snapshot.Phase,
+		Active:             gs.Count(),
+		OldestSubscription: gs.OldestSubscription().Round(time.Millisecond).String(),
+		Hook:               snapshot.Hook,
+		HooksLeft:          snapshot.HooksLeft,
+		Elapsed:            snapshot.Elapsed.Round(time.Millisecond).String(),
+	}
+
+	if snapshot.Phase != gogs.PhaseDone {
+		return s
+	}
+
+	report := gs.Report()
+	s.Report = &Report{Hooks: make([]Hook, 0, len(report.Hooks))}
+	for _, res := range report.Hooks {
+		h := Hook{Name: res.Name, Duration: res.Duration.Round(time.Millisecond).String(), Skipped: res.Skipped}
+		if res.Err != nil {
+			h.Err = res.Err.Error()
+		}
+		s.Report.Hooks = append(s.Report.Hooks, h)
+	}
+	if err := report.Err(); err != nil {
+		s.Report.Err = err.Error()
+	}
+
+	return s
+}
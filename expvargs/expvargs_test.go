@@ -0,0 +1,49 @@
+package expvargs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+// publishRuns counts the runs of Test_Publish, so that each one publishes a new variable,
+// e.g. with -count.
+var publishRuns atomic.Int32
+
+func Test_Publish(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := gogs.New(context.Background())
+
+	name := fmt.Sprintf("Test_Publish_%d", publishRuns.Add(1))
+	Publish(gs, name)
+	assert.Panics(t, func() { Publish(gs, name) })
+
+	var s State
+	assert.NoError(t, json.Unmarshal([]byte(expvar.Get(name).String()), &s))
+	assert.Equal(t, State{OldestSubscription: "0s", Elapsed: "0s"}, s)
+
+	gs.AddHook("db", func(context.Context) error { return errors.New("failed") })
+	gs.Subscribe()
+	cancel()
+	gs.Unsubscribe()
+	gs.Wait()
+
+	s = State{}
+	assert.NoError(t, json.Unmarshal([]byte(expvar.Get(name).String()), &s))
+	assert.Equal(t, gogs.ReasonCancel, s.Reason)
+	assert.Equal(t, gogs.PhaseDone, s.Phase)
+	if assert.NotNil(t, s.Report) {
+		assert.Len(t, s.Report.Hooks, 1)
+		assert.Equal(t, "db", s.Report.Hooks[0].Name)
+		assert.Equal(t, "failed", s.Report.Hooks[0].Err)
+		assert.Equal(t, "db: failed", s.Report.Err)
+	}
+}
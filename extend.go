@@ -0,0 +1,265 @@
+package gogs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// extensionKey is the key of the extension in the context passed to the hooks.
+type extensionKey struct{}
+
+// extensionPolicy is a struct that describes the extensions granted to the hooks, see
+// WithExtensions.
+type extensionPolicy struct {
+	max       time.Duration
+	authorize func(hook string, d time.Duration) bool
+}
+
+// WithExtensions is an option that allows the hooks to request extra time beyond the
+// deadline of the shutdown with HookContext.Extend, for the rare cases like a large
+// multipart upload that is nearly complete. The provided function authorizes each
+// request, without holding any lock of the context, and the extensions granted to a
+// shutdown never exceed the provided maximum in total. Nil authorizes all requests within
+// the maximum. The kill delay watchdog is
+// postponed by the granted time, while the hooks executed after the extended one only
+// get the time left until the original deadline, if any.
+//
+//	gs, ctx, cancel := New(
+//		context.Background(),
+//		WithGracePeriod(30*time.Second),
+//		WithExtensions(time.Minute, func(hook string, d time.Duration) bool {
+//			return hook == "s3-upload"
+//		}),
+//	)
+//
+// This example lets the upload hook extend the shutdown by up to one minute.
+func WithExtensions(maxTotal time.Duration, authorize func(hook string, d time.Duration) bool) Option {
+	return func(cfg *config) {
+		cfg.extensions = &extensionPolicy{max: maxTotal, authorize: authorize}
+	}
+}
+
+// Extend is a method of the HookContext struct. It requests to postpone the deadline of
+// the hook by the provided duration. It returns an error wrapping ErrExtensionDenied if
+// the extensions are not enabled with WithExtensions, if the authorization function
+// rejects the request, if the maximum would be exceeded or if the hook is already done.
+// The timeout of the hook set with WithTimeout is not extended.
+//
+//	gs.AddContextHook("s3-upload", func(hc HookContext) error {
+//		if upload.Remaining() < 0.1 && hc.Remaining() < 5*time.Second {
+//			_ = hc.Extend(5 * time.Second)
+//		}
+//		return upload.Complete(hc)
+//	})
+//
+// This example asks for five more seconds when the upload is nearly complete.
+func (hc HookContext) Extend(d time.Duration) error {
+	ext, ok := hc.Value(extensionKey{}).(*extendableCtx)
+	if !ok {
+		return fmt.Errorf("%w: extensions are not enabled", ErrExtensionDenied)
+	}
+
+	return ext.extend(hc.Name, d)
+}
+
+// extendableCtx is a context carrying the values of its parent, whose deadline is the one
+// of the parent postponed by the granted extensions. It is canceled when its parent is
+// canceled for another reason than its deadline.
+type extendableCtx struct {
+	context.Context
+
+	// gs is the GracefulShutdown granting the extensions.
+	gs *GracefulShutdown
+
+	// mu guards the fields below.
+	mu       sync.Mutex
+	deadline time.Time
+	timer    *time.Timer
+	err      error
+
+	// doneCh is closed once the context is done.
+	doneCh chan struct{}
+}
+
+// extendable is a method of the GracefulShutdown struct. It returns the provided context
+// made extendable, if the extensions are enabled or the deadlines are paused while the
+// process is suspended, see WithSuspendAware, and the context is not done yet.
+func (gs *GracefulShutdown) extendable(ctx context.Context) (context.Context, context.CancelFunc) {
+	if (gs.cfg.extensions == nil && !gs.cfg.suspendAware) || ctx.Err() != nil {
+		return ctx, func() {}
+	}
+
+	ext := &extendableCtx{Context: ctx, gs: gs, doneCh: make(chan struct{})}
+	if deadline, ok := ctx.Deadline(); ok {
+		ext.deadline = deadline
+		ext.timer = time.AfterFunc(time.Until(deadline), func() {
+			ext.cancel(context.DeadlineExceeded)
+		})
+	}
+
+	untrack := gs.track(ext)
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				ext.cancel(ctx.Err())
+			}
+		case <-stop:
+		}
+	}()
+
+	return ext, func() {
+		untrack()
+		close(stop)
+		ext.cancel(context.Canceled)
+	}
+}
+
+// Deadline is a method of the extendableCtx struct. It returns the current deadline, if
+// any.
+func (c *extendableCtx) Deadline() (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.deadline, !c.deadline.IsZero()
+}
+
+// Done is a method of the extendableCtx struct. It returns the channel closed once the
+// context is done.
+func (c *extendableCtx) Done() <-chan struct{} {
+	return c.doneCh
+}
+
+// Err is a method of the extendableCtx struct. It returns the reason the context is done,
+// if it is.
+func (c *extendableCtx) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.err
+}
+
+// Value is a method of the extendableCtx struct. It returns the context itself for the
+// extension key and the values of the parent otherwise.
+func (c *extendableCtx) Value(key any) any {
+	if _, ok := key.(extensionKey); ok {
+		return c
+	}
+
+	return c.Context.Value(key)
+}
+
+// extend is a method of the extendableCtx struct. It postpones the deadline by the
+// provided duration if the policy grants it. Without a deadline, there is nothing to
+// postpone and it succeeds. The policy is checked before taking the lock, which only
+// guards the grant and the update of the deadline.
+func (c *extendableCtx) extend(hook string, d time.Duration) error {
+	c.mu.Lock()
+	err, deadline := c.err, c.deadline
+	c.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrExtensionDenied, err)
+	}
+	if deadline.IsZero() {
+		return nil
+	}
+
+	policy := c.gs.cfg.extensions
+	if policy == nil {
+		return fmt.Errorf("%w: extensions are not enabled", ErrExtensionDenied)
+	}
+	if d <= 0 {
+		return fmt.Errorf("%w: non-positive duration %s", ErrExtensionDenied, d)
+	}
+	if granted := time.Duration(c.gs.extended.Load()); granted+d > policy.max {
+		return fmt.Errorf("%w: %s granted of %s at most", ErrExtensionDenied, granted, policy.max)
+	}
+	if policy.authorize != nil && !policy.authorize(hook, d) {
+		return fmt.Errorf("%w: not authorized", ErrExtensionDenied)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return fmt.Errorf("%w: %v", ErrExtensionDenied, c.err)
+	}
+	if granted, ok := c.gs.grantExtension(d, policy.max); !ok {
+		return fmt.Errorf("%w: %s granted of %s at most", ErrExtensionDenied, granted, policy.max)
+	}
+
+	c.gs.postponedKill.Add(int64(d))
+	c.deadline = c.deadline.Add(d)
+	c.timer.Reset(time.Until(c.deadline))
+
+	return nil
+}
+
+// grantExtension is a method of the GracefulShutdown struct. It adds the provided duration
+// to the total of the extensions unless the total would exceed the provided maximum, and
+// returns the total granted before. The check and the addition are atomic, since the
+// contexts of concurrent hooks share the total.
+func (gs *GracefulShutdown) grantExtension(d, maxTotal time.Duration) (time.Duration, bool) {
+	for {
+		granted := gs.extended.Load()
+		if time.Duration(granted)+d > maxTotal {
+			return time.Duration(granted), false
+		}
+		if gs.extended.CompareAndSwap(granted, granted+int64(d)) {
+			return time.Duration(granted), true
+		}
+	}
+}
+
+// pause is a method of the extendableCtx struct. It stops the timer of the deadline until
+// the deadline is postponed, see WithSuspendAware.
+func (c *extendableCtx) pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+}
+
+// postpone is a method of the extendableCtx struct. It postpones the deadline by the
+// provided duration and restarts its timer, unless the context is done.
+func (c *extendableCtx) postpone(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil || c.timer == nil {
+		return
+	}
+
+	c.deadline = c.deadline.Add(d)
+	c.timer.Reset(time.Until(c.deadline))
+}
+
+// cancel is a method of the extendableCtx struct. It marks the context as done with the
+// provided error, exactly once.
+func (c *extendableCtx) cancel(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return
+	}
+
+	c.err = err
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	close(c.doneCh)
+}
+
+// postponed is a method of the GracefulShutdown struct. It returns the extensions granted
+// since its last call, by which the kill delay watchdog is postponed.
+func (gs *GracefulShutdown) postponed() time.Duration {
+	return time.Duration(gs.postponedKill.Swap(0))
+}
@@ -0,0 +1,150 @@
+package gogs
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HookContext_Extend(t *testing.T) {
+	t.Parallel()
+	gs := &GracefulShutdown{}
+	WithExtensions(3*ShortDelay, func(hook string, d time.Duration) bool {
+		return hook == "upload"
+	})(&gs.cfg)
+
+	var mu sync.Mutex
+	var errs []error
+	gs.AddHook("metrics", func(context.Context) error {
+		return nil
+	})
+	gs.AddContextHook("upload", func(hc HookContext) error {
+		mu.Lock()
+		errs = append(errs, hc.Extend(2*ShortDelay), hc.Extend(2*ShortDelay))
+		mu.Unlock()
+		<-hc.Done()
+		return nil
+	})
+	gs.AddContextHook("cache", func(hc HookContext) error {
+		mu.Lock()
+		errs = append(errs, hc.Extend(ShortDelay))
+		mu.Unlock()
+		return nil
+	})
+
+	start := time.Now()
+	gs.WaitWithTimeout(ShortDelay)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, errs, 3)
+	assert.ErrorIs(t, errs[0], ErrExtensionDenied)
+	assert.NoError(t, errs[1])
+	assert.ErrorIs(t, errs[2], ErrExtensionDenied)
+	assert.GreaterOrEqual(t, time.Since(start), 3*ShortDelay)
+
+	hooks := gs.Report().Hooks
+	assert.NoError(t, hooks[0].Err)
+	assert.ErrorIs(t, hooks[1].Err, context.DeadlineExceeded)
+	assert.ErrorIs(t, hooks[2].Err, context.DeadlineExceeded)
+}
+
+func Test_HookContext_Extend_Disabled(t *testing.T) {
+	t.Parallel()
+	gs := &GracefulShutdown{}
+
+	var err error
+	gs.AddContextHook("upload", func(hc HookContext) error {
+		err = hc.Extend(ShortDelay)
+		return nil
+	})
+
+	gs.WaitWithTimeout(ShortDelay)
+
+	assert.ErrorIs(t, err, ErrExtensionDenied)
+}
+
+func Test_extendableCtx_Canceled(t *testing.T) {
+	t.Parallel()
+	gs := &GracefulShutdown{}
+	WithExtensions(LongDelay, nil)(&gs.cfg)
+
+	parent, cancelParent := context.WithTimeout(context.Background(), LongDelay)
+	ctx, cancel := gs.extendable(parent)
+	defer cancel()
+
+	cancelParent()
+	<-ctx.Done()
+
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+	assert.ErrorIs(t, ctx.(*extendableCtx).extend("upload", ShortDelay), ErrExtensionDenied)
+}
+
+func Test_GracefulShutdown_kill_Postponed(t *testing.T) {
+	t.Parallel()
+
+	exitCh := make(chan int, 1)
+	gs := &GracefulShutdown{}
+	WithKillDelay(ShortDelay)(&gs.cfg)
+	WithExtensions(LongDelay, nil)(&gs.cfg)
+	gs.cfg.exit = func(code int) {
+		exitCh <- code
+	}
+
+	gs.AddContextHook("upload", func(hc HookContext) error {
+		_ = hc.Extend(4 * ShortDelay)
+		select {}
+	})
+
+	start := time.Now()
+	gs.WaitWithTimeout(ShortDelay)
+	assert.Equal(t, 1, <-exitCh)
+	assert.GreaterOrEqual(t, time.Since(start), 6*ShortDelay)
+}
+
+func Test_GracefulShutdown_grantExtension(t *testing.T) {
+	t.Parallel()
+	gs := &GracefulShutdown{}
+
+	var wg sync.WaitGroup
+	var granted atomic.Int32
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, ok := gs.grantExtension(time.Second, 10*time.Second); ok {
+				granted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(10), granted.Load())
+	assert.Equal(t, int64(10*time.Second), gs.extended.Load())
+}
+
+func Test_extendableCtx_extend_Authorize(t *testing.T) {
+	t.Parallel()
+	gs := &GracefulShutdown{}
+
+	var ctx context.Context
+	WithExtensions(LongDelay, func(string, time.Duration) bool {
+		_, ok := ctx.Deadline()
+		return ok
+	})(&gs.cfg)
+
+	parent, cancelParent := context.WithTimeout(context.Background(), LongDelay)
+	defer cancelParent()
+	ctx, cancel := gs.extendable(parent)
+	defer cancel()
+
+	before, _ := ctx.Deadline()
+	assert.NoError(t, ctx.(*extendableCtx).extend("upload", ShortDelay))
+
+	after, _ := ctx.Deadline()
+	assert.Equal(t, before.Add(ShortDelay), after)
+}
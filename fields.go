@@ -0,0 +1,174 @@
+package gogs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// fieldTag is the struct tag configuring the hooks registered by RegisterFields.
+const fieldTag = "gs"
+
+// shutdowner is an interface that describes the components stopped with a context, e.g.
+// *http.Server.
+type shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+// stopper is an interface that describes the components stopped without a result, e.g.
+// *time.Ticker.
+type stopper interface {
+	Stop()
+}
+
+// RegisterFields is a function that scans the exported fields of the provided struct, or
+// pointer to a struct, and registers a hook for each field implementing Shutdown(ctx)
+// error, io.Closer or Stop(), in this order of preference. Since hooks are executed in
+// reverse order of registration, the fields are stopped in reverse field order, so that
+// the components declared first, which the later ones depend on, are stopped last. Nil
+// fields are skipped.
+//
+// The hooks are named after the fields and configured with the gs struct tag, a comma
+// separated list of the following settings:
+//
+//	name=postgres   the name of the hook instead of the name of the field
+//	timeout=5s      see WithTimeout
+//	category=flush  see WithCategory
+//	besteffort=1s   see WithBestEffort
+//	phase=final     see WithFinal; phase=close is the default
+//	-               skips the field
+//
+// For example:
+//
+//	type App struct {
+//		DB      *sql.DB      `gs:"name=postgres,timeout=5s"`
+//		Server  *http.Server `gs:"timeout=10s"`
+//		Metrics *http.Server `gs:"phase=final"`
+//		Ticker  *time.Ticker
+//	}
+//
+//	err := RegisterFields(gs, &app)
+//
+// This example stops the ticker, shuts the server down, closes the database and shuts the
+// metrics server down last. It returns an error describing the first invalid tag, in
+// which case no hook is registered.
+func RegisterFields(gs HookRegistrar, app any) error {
+	v := reflect.ValueOf(app)
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("gogs: RegisterFields expects a struct, got %T", app)
+	}
+
+	type fieldHook struct {
+		name string
+		fn   func(ctx context.Context) error
+		opts []HookOption
+	}
+
+	var hooks []fieldHook
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		tag := field.Tag.Get(fieldTag)
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+
+		fn := fieldHookFn(v.Field(i))
+		if fn == nil {
+			continue
+		}
+
+		name, opts, err := parseFieldTag(field.Name, tag)
+		if err != nil {
+			return fmt.Errorf("gogs: field %s: %w", field.Name, err)
+		}
+		hooks = append(hooks, fieldHook{name: name, fn: fn, opts: opts})
+	}
+
+	for _, h := range hooks {
+		gs.AddHook(h.name, h.fn, h.opts...)
+	}
+
+	return nil
+}
+
+// fieldHookFn is a function that returns the function stopping the component held by the
+// provided field, or nil if the field is nil or holds no such component.
+func fieldHookFn(field reflect.Value) func(ctx context.Context) error {
+	switch field.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		if field.IsNil() {
+			return nil
+		}
+	default:
+	}
+
+	v := field.Interface()
+	if field.Kind() == reflect.Struct && field.CanAddr() {
+		v = field.Addr().Interface()
+	}
+
+	switch c := v.(type) {
+	case shutdowner:
+		return c.Shutdown
+	case io.Closer:
+		return func(context.Context) error {
+			return c.Close()
+		}
+	case stopper:
+		return func(context.Context) error {
+			c.Stop()
+			return nil
+		}
+	default:
+		return nil
+	}
+}
+
+// parseFieldTag is a function that returns the name and the hook options described by
+// the provided gs struct tag of the field with the provided name.
+func parseFieldTag(fieldName, tag string) (string, []HookOption, error) {
+	name := fieldName
+	var opts []HookOption
+
+	for _, setting := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(setting), "=")
+		switch key {
+		case "":
+		case "name":
+			if value == "" {
+				return "", nil, fmt.Errorf("empty name")
+			}
+			name = value
+		case "category":
+			opts = append(opts, WithCategory(value))
+		case "phase":
+			switch Phase(value) {
+			case PhaseClose:
+			case "final":
+				opts = append(opts, WithFinal())
+			default:
+				return "", nil, fmt.Errorf("unknown phase %q", value)
+			}
+		case "timeout", "besteffort":
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return "", nil, fmt.Errorf("invalid %s: %w", key, err)
+			}
+			if key == "timeout" {
+				opts = append(opts, WithTimeout(d))
+			} else {
+				opts = append(opts, WithBestEffort(d))
+			}
+		default:
+			return "", nil, fmt.Errorf("unknown setting %q", key)
+		}
+	}
+
+	return name, opts, nil
+}
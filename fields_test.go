@@ -0,0 +1,101 @@
+package gogs
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testShutdowner struct {
+	name  string
+	order *[]string
+}
+
+func (s testShutdowner) Shutdown(context.Context) error {
+	*s.order = append(*s.order, s.name)
+	return nil
+}
+
+type testStopper struct {
+	name  string
+	order *[]string
+}
+
+func (s *testStopper) Stop() {
+	*s.order = append(*s.order, s.name)
+}
+
+func Test_RegisterFields(t *testing.T) {
+	t.Parallel()
+	gs, _, _ := New(context.Background(), WithSignals(syscall.SIGINT))
+
+	var order []string
+	app := struct {
+		DB       *testCloser `gs:"name=postgres,timeout=5s"`
+		Server   testShutdowner
+		Metrics  *testCloser `gs:"phase=final"`
+		Ticker   testStopper
+		Skipped  *testCloser `gs:"-"`
+		Missing  *testCloser
+		Config   string
+		internal *testCloser
+	}{
+		DB:       &testCloser{name: "db", order: &order},
+		Server:   testShutdowner{name: "server", order: &order},
+		Metrics:  &testCloser{name: "metrics", order: &order},
+		Ticker:   testStopper{name: "ticker", order: &order},
+		Skipped:  &testCloser{name: "skipped", order: &order},
+		internal: &testCloser{name: "internal", order: &order},
+	}
+
+	assert.NoError(t, RegisterFields(gs, &app))
+
+	gs.Wait()
+
+	assert.Equal(t, []string{"ticker", "server", "db", "metrics"}, order)
+
+	var names []string
+	for _, res := range gs.Report().Hooks {
+		names = append(names, res.Name)
+	}
+	assert.Equal(t, []string{"Ticker", "Server", "postgres", "Metrics"}, names)
+}
+
+func Test_RegisterFields_Invalid(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background(), WithSignals(syscall.SIGINT))
+	defer cancel()
+
+	assert.EqualError(t, RegisterFields(gs, 42), "gogs: RegisterFields expects a struct, got int")
+
+	var order []string
+	tests := []struct {
+		tag string
+		err string
+	}{
+		{tag: "timeout=soon", err: `invalid timeout: time: invalid duration "soon"`},
+		{tag: "phase=flush", err: `unknown phase "flush"`},
+		{tag: "retries=3", err: `unknown setting "retries"`},
+		{tag: "name=", err: "empty name"},
+	}
+	for _, tt := range tests {
+		name, _, err := parseFieldTag("DB", tt.tag)
+		assert.Empty(t, name)
+		assert.EqualError(t, err, tt.err, tt.tag)
+	}
+
+	app := struct {
+		First *testCloser
+		DB    *testCloser `gs:"timeout=soon"`
+	}{
+		First: &testCloser{name: "first", order: &order},
+		DB:    &testCloser{name: "db", order: &order},
+	}
+	assert.EqualError(t, RegisterFields(gs, app), `gogs: field DB: invalid timeout: time: invalid duration "soon"`)
+
+	gs.WaitWithTimeout(time.Second)
+	assert.Empty(t, order)
+}
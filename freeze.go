@@ -0,0 +1,130 @@
+package gogs
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strconv"
+	"time"
+)
+
+// cgroupCPUStat is the path of the CPU statistics of the cgroup v2 of the process.
+const cgroupCPUStat = "/sys/fs/cgroup/cpu.stat"
+
+// WithFreezeAwareKill is an option that makes the watchdog started by WithKillDelay
+// account for the time the process did not run: while its container was frozen, e.g. by
+// the cgroup freezer or a checkpoint, or stopped, and while its cgroup was throttled. The
+// watchdog checks the clock at the provided interval and postpones the exit by the gaps
+// exceeding the interval and by the throttled time reported by the cgroup v2, if any, so
+// that it does not exit the process right after an unfreeze.
+//
+//	gs, ctx, cancel := New(
+//		context.Background(),
+//		WithGracePeriod(30*time.Second),
+//		WithKillDelay(5*time.Second),
+//		WithFreezeAwareKill(100*time.Millisecond),
+//	)
+//
+// This example exits the process thirty-five seconds of running time after the shutdown
+// started, however long the container was frozen in between.
+func WithFreezeAwareKill(interval time.Duration) Option {
+	return func(cfg *config) {
+		cfg.freezeInterval = interval
+	}
+}
+
+// freezeWatch is a struct that tracks the time at which the watchdog exits the process,
+// postponed by the time the process did not run.
+type freezeWatch struct {
+	// interval is the expected interval between two observations.
+	interval time.Duration
+
+	// killAt is the time at which the process exits.
+	killAt time.Time
+
+	// last and lastThrottled are the time and the throttled time of the last observation.
+	last          time.Time
+	lastThrottled time.Duration
+}
+
+// observe is a method of the freezeWatch struct. It accounts for the gap since the last
+// observation beyond the interval and for the increase of the throttled time, and reports
+// whether the process must exit.
+func (w *freezeWatch) observe(now time.Time, throttled time.Duration) bool {
+	if gap := now.Sub(w.last) - w.interval; gap > w.interval {
+		w.killAt = w.killAt.Add(gap)
+	}
+	if throttled > w.lastThrottled {
+		w.killAt = w.killAt.Add(throttled - w.lastThrottled)
+	}
+
+	w.last = now
+	w.lastThrottled = throttled
+
+	return !now.Before(w.killAt)
+}
+
+// watchFreeze is a method of the GracefulShutdown struct. It exits the process once the
+// provided time is reached, postponed by the time the process did not run, unless the
+// provided channel is closed before.
+func (gs *GracefulShutdown) watchFreeze(killAt time.Time, stop <-chan struct{}) {
+	w := freezeWatch{
+		interval:      gs.cfg.freezeInterval,
+		killAt:        killAt,
+		last:          time.Now(),
+		lastThrottled: cgroupThrottled(),
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			if !w.observe(now, cgroupThrottled()) {
+				continue
+			}
+			if d := gs.postponed(); d > 0 {
+				w.killAt = w.killAt.Add(d)
+				continue
+			}
+
+			gs.kill()
+			return
+		case <-stop:
+			return
+		}
+	}
+}
+
+// cgroupThrottled is a function that returns the time the cgroup v2 of the process was
+// throttled, or zero if it is not available.
+func cgroupThrottled() time.Duration {
+	data, err := os.ReadFile(cgroupCPUStat)
+	if err != nil {
+		return 0
+	}
+
+	return parseThrottled(data)
+}
+
+// parseThrottled is a function that returns the throttled time listed in the provided
+// content of a cpu.stat file, or zero if it is not listed.
+func parseThrottled(data []byte) time.Duration {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, ok := bytes.Cut(scanner.Bytes(), []byte(" "))
+		if !ok || string(key) != "throttled_usec" {
+			continue
+		}
+
+		usec, err := strconv.ParseInt(string(value), 10, 64)
+		if err != nil {
+			return 0
+		}
+
+		return time.Duration(usec) * time.Microsecond
+	}
+
+	return 0
+}
@@ -0,0 +1,82 @@
+package gogs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WithFreezeAwareKill(t *testing.T) {
+	t.Parallel()
+
+	exitCh := make(chan int, 1)
+	gs := &GracefulShutdown{}
+	WithKillDelay(ShortDelay)(&gs.cfg)
+	WithFreezeAwareKill(time.Millisecond)(&gs.cfg)
+	gs.cfg.exit = func(code int) {
+		exitCh <- code
+	}
+
+	gs.AddHook("stuck", func(context.Context) error {
+		select {}
+	})
+
+	gs.WaitWithTimeout(ShortDelay)
+	assert.Equal(t, 1, <-exitCh)
+}
+
+func Test_freezeWatch_observe(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	w := freezeWatch{interval: time.Second, killAt: start.Add(10 * time.Second), last: start}
+
+	assert.False(t, w.observe(start.Add(time.Second), 0))
+	assert.Equal(t, start.Add(10*time.Second), w.killAt)
+
+	assert.False(t, w.observe(start.Add(1500*time.Millisecond), 0))
+	assert.Equal(t, start.Add(10*time.Second), w.killAt)
+
+	assert.False(t, w.observe(start.Add(31500*time.Millisecond), 0), "frozen for thirty seconds")
+	assert.Equal(t, start.Add(39*time.Second), w.killAt)
+
+	assert.False(t, w.observe(start.Add(32500*time.Millisecond), 3*time.Second), "throttled for three seconds")
+	assert.Equal(t, start.Add(42*time.Second), w.killAt)
+
+	for now := start.Add(33500 * time.Millisecond); now.Before(w.killAt); now = now.Add(time.Second) {
+		assert.False(t, w.observe(now, 3*time.Second))
+	}
+	assert.True(t, w.observe(start.Add(42500*time.Millisecond), 3*time.Second))
+	assert.Equal(t, start.Add(42*time.Second), w.killAt)
+}
+
+func Test_parseThrottled(t *testing.T) {
+	t.Parallel()
+
+	stat := "usage_usec 8000\nnr_periods 10\nnr_throttled 2\nthrottled_usec 1500000\n"
+	assert.Equal(t, 1500*time.Millisecond, parseThrottled([]byte(stat)))
+	assert.Zero(t, parseThrottled([]byte("usage_usec 8000\n")))
+	assert.Zero(t, parseThrottled([]byte("throttled_usec many\n")))
+}
+
+func Test_WithFreezeAwareKill_Completed(t *testing.T) {
+	t.Parallel()
+
+	exited := make(chan int, 1)
+	gs := &GracefulShutdown{}
+	WithKillDelay(ShortDelay)(&gs.cfg)
+	WithFreezeAwareKill(time.Millisecond)(&gs.cfg)
+	gs.cfg.exit = func(code int) {
+		exited <- code
+	}
+
+	gs.WaitWithTimeout(ShortDelay)
+
+	select {
+	case <-exited:
+		assert.Fail(t, "exited after a clean shutdown")
+	case <-time.After(4 * ShortDelay):
+	}
+}
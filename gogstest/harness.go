@@ -0,0 +1,190 @@
+// Package gogstest provides utilities for end-to-end testing of the graceful shutdown of
+// applications built with the gogs package.
+package gogstest
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+const (
+	// defaultReadyTimeout is the default time Start waits for the application to be ready.
+	defaultReadyTimeout = 5 * time.Second
+
+	// defaultSettleTimeout is the default time Shutdown waits for the goroutines of the
+	// application to exit.
+	defaultSettleTimeout = time.Second
+)
+
+// Harness is a struct that boots an application in-process with gogs.Run, waits for its
+// readiness, simulates a termination signal and asserts that the shutdown completes
+// within a budget without leaking goroutines.
+//
+//	h := gogstest.New(t, app, gogs.WithSignals(syscall.SIGTERM))
+//	h.Start()
+//	h.Shutdown(5 * time.Second)
+//
+// This example tests that the application shuts down gracefully within five seconds.
+type Harness struct {
+	// ReadyTimeout limits the time Start waits for the application to be marked as ready.
+	ReadyTimeout time.Duration
+
+	// SettleTimeout limits the time Shutdown waits for the goroutines started by the
+	// application to exit.
+	SettleTimeout time.Duration
+
+	// LeakCheck enables the check of leaked goroutines. The check counts all goroutines of
+	// the process, so it must be disabled in parallel tests.
+	LeakCheck bool
+
+	// t is the test the harness reports to.
+	t testing.TB
+
+	// app and opts are passed to gogs.Run.
+	app  func(ctx context.Context, gs *gogs.GracefulShutdown) error
+	opts []gogs.Option
+
+	// gsCh receives the GracefulShutdowner created by gogs.Run.
+	gsCh chan *gogs.GracefulShutdown
+
+	// gs is the GracefulShutdowner of the running application.
+	gs *gogs.GracefulShutdown
+
+	// errCh receives the error returned by gogs.Run.
+	errCh chan error
+
+	// goroutines is the count of goroutines before the application was started.
+	goroutines int
+}
+
+// New is a function that creates a new Harness for the provided application and options.
+func New(
+	t testing.TB,
+	app func(ctx context.Context, gs *gogs.GracefulShutdown) error,
+	opts ...gogs.Option,
+) *Harness {
+	return &Harness{
+		ReadyTimeout:  defaultReadyTimeout,
+		SettleTimeout: defaultSettleTimeout,
+		LeakCheck:     true,
+		t:             t,
+		app:           app,
+		opts:          opts,
+		gsCh:          make(chan *gogs.GracefulShutdown, 1),
+		errCh:         make(chan error, 1),
+	}
+}
+
+// Start is a method of the Harness struct. It boots the application with gogs.Run and
+// waits for it to be marked as ready. The test fails immediately if the application does
+// not become ready in time or returns before that.
+func (h *Harness) Start() *gogs.GracefulShutdown {
+	h.t.Helper()
+	h.goroutines, _ = goroutines()
+
+	go func() {
+		h.errCh <- gogs.Run(context.Background(), func(ctx context.Context, gs *gogs.GracefulShutdown) error {
+			h.gsCh <- gs
+			return h.app(ctx, gs)
+		}, h.opts...)
+	}()
+
+	timer := time.NewTimer(h.ReadyTimeout)
+	defer timer.Stop()
+
+	select {
+	case h.gs = <-h.gsCh:
+	case err := <-h.errCh:
+		h.t.Fatalf("gogstest: application exited before start: %v", err)
+	}
+
+	select {
+	case <-h.gs.Ready():
+	case err := <-h.errCh:
+		h.t.Fatalf("gogstest: application exited before it was ready: %v", err)
+	case <-timer.C:
+		h.t.Fatalf("gogstest: application is not ready after %s", h.ReadyTimeout)
+	}
+
+	return h.gs
+}
+
+// Shutdown is a method of the Harness struct. It simulates a termination signal and
+// waits for the application to shut down. The test fails if the shutdown takes longer
+// than the budget, if the application returns an error or if goroutines leak.
+func (h *Harness) Shutdown(budget time.Duration) {
+	h.t.Helper()
+	if h.gs == nil {
+		h.t.Fatal("gogstest: Shutdown is called before Start")
+	}
+
+	start := time.Now()
+	h.gs.Trigger(gogs.ReasonSignal)
+
+	timer := time.NewTimer(budget)
+	defer timer.Stop()
+
+	select {
+	case err := <-h.errCh:
+		if err != nil {
+			h.t.Errorf("gogstest: application returned an error: %v", err)
+		}
+	case <-timer.C:
+		h.t.Fatalf("gogstest: shutdown is not completed after %s", budget)
+	}
+
+	if elapsed := time.Since(start); elapsed > budget {
+		h.t.Errorf("gogstest: shutdown took %s, budget is %s", elapsed, budget)
+	}
+
+	if h.LeakCheck {
+		h.checkLeaks()
+	}
+}
+
+// checkLeaks is a method of the Harness struct. It waits for the count of goroutines to
+// return to the count before the application was started and fails the test with a dump
+// of all goroutines otherwise.
+func (h *Harness) checkLeaks() {
+	h.t.Helper()
+
+	deadline := time.Now().Add(h.SettleTimeout)
+	for {
+		count, dump := goroutines()
+		if count <= h.goroutines {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			h.t.Errorf("gogstest: %d goroutines leaked:\n%s", count-h.goroutines, dump)
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// goroutines is a function that returns the count of goroutines and a dump of their
+// stacks, ignoring the goroutine that os/signal starts on the first call to Notify and
+// keeps for the lifetime of the process.
+func goroutines() (int, string) {
+	buf := make([]byte, 1<<20)
+	buf = buf[:runtime.Stack(buf, true)]
+
+	var count int
+	stacks := strings.Split(string(buf), "\n\n")
+	dump := make([]string, 0, len(stacks))
+	for _, stack := range stacks {
+		if strings.Contains(stack, "os/signal.loop") {
+			continue
+		}
+		count++
+		dump = append(dump, stack)
+	}
+
+	return count, strings.Join(dump, "\n\n")
+}
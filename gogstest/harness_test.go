@@ -0,0 +1,114 @@
+package gogstest
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+type fakeTB struct {
+	testing.TB
+	errors []string
+}
+
+func (tb *fakeTB) Helper() {}
+
+func (tb *fakeTB) Errorf(format string, args ...any) {
+	tb.errors = append(tb.errors, fmt.Sprintf(format, args...))
+}
+
+func (tb *fakeTB) Fatal(args ...any) {
+	tb.errors = append(tb.errors, fmt.Sprint(args...))
+	runtime.Goexit()
+}
+
+func (tb *fakeTB) Fatalf(format string, args ...any) {
+	tb.errors = append(tb.errors, fmt.Sprintf(format, args...))
+	runtime.Goexit()
+}
+
+func worker(ctx context.Context, gs *gogs.GracefulShutdown) error {
+	gs.Subscribe()
+	go func() {
+		defer gs.Unsubscribe()
+		<-ctx.Done()
+	}()
+
+	gs.MarkReady()
+	return nil
+}
+
+func Test_Harness(t *testing.T) {
+	h := New(t, worker, gogs.WithSignals(syscall.SIGTERM))
+	h.Start()
+	h.Shutdown(time.Second)
+}
+
+func Test_Harness_Failures(t *testing.T) {
+	run := func(fn func(tb *fakeTB)) []string {
+		tb := &fakeTB{TB: t}
+		doneCh := make(chan struct{})
+		go func() {
+			defer close(doneCh)
+			fn(tb)
+		}()
+		<-doneCh
+		return tb.errors
+	}
+
+	t.Run("NotReady", func(t *testing.T) {
+		errs := run(func(tb *fakeTB) {
+			h := New(tb, func(ctx context.Context, _ *gogs.GracefulShutdown) error {
+				<-ctx.Done()
+				return nil
+			}, gogs.WithSignals(syscall.SIGTERM))
+			h.ReadyTimeout = 10 * time.Millisecond
+			h.Start().Trigger(gogs.ReasonCancel)
+		})
+		assert.Len(t, errs, 1)
+		assert.Contains(t, errs[0], "is not ready")
+	})
+
+	t.Run("Budget", func(t *testing.T) {
+		errs := run(func(tb *fakeTB) {
+			h := New(tb, func(ctx context.Context, gs *gogs.GracefulShutdown) error {
+				gs.AddHook("slow", func(context.Context) error {
+					time.Sleep(100 * time.Millisecond)
+					return nil
+				})
+				gs.MarkReady()
+				return nil
+			}, gogs.WithSignals(syscall.SIGTERM))
+			h.LeakCheck = false
+			h.Start()
+			h.Shutdown(10 * time.Millisecond)
+		})
+		assert.Len(t, errs, 1)
+		assert.Contains(t, errs[0], "shutdown is not completed")
+	})
+
+	t.Run("Leak", func(t *testing.T) {
+		stopCh := make(chan struct{})
+		defer close(stopCh)
+
+		errs := run(func(tb *fakeTB) {
+			h := New(tb, func(_ context.Context, gs *gogs.GracefulShutdown) error {
+				go func() { <-stopCh }()
+				gs.MarkReady()
+				return nil
+			}, gogs.WithSignals(syscall.SIGTERM))
+			h.SettleTimeout = 10 * time.Millisecond
+			h.Start()
+			h.Shutdown(time.Second)
+		})
+		assert.Len(t, errs, 1)
+		assert.Contains(t, errs[0], "goroutines leaked")
+	})
+}
@@ -0,0 +1,80 @@
+package gogstest
+
+import (
+	"testing"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+// Order is a function that returns the names of the hooks executed during the shutdown,
+// in order of execution. Skipped best-effort hooks are not listed.
+func Order(gs gogs.Reporter) []string {
+	var order []string
+	for _, res := range gs.Report().Hooks {
+		if !res.Skipped {
+			order = append(order, res.Name)
+		}
+	}
+
+	return order
+}
+
+// AssertOrder is a function that asserts that the hooks with the provided names were
+// executed during the shutdown in the provided order. Hooks that are not listed are
+// ignored, so that the test only pins the ordering it cares about. It reports whether the
+// assertion succeeded.
+//
+//	h.Start()
+//	h.Shutdown(5 * time.Second)
+//	gogstest.AssertOrder(t, gs, "http", "workers", "db")
+//
+// This example guards the teardown ordering of the application against refactors.
+func AssertOrder(t testing.TB, gs gogs.Reporter, names ...string) bool {
+	t.Helper()
+
+	listed := make(map[string]bool, len(names))
+	for _, name := range names {
+		listed[name] = true
+	}
+
+	var actual []string
+	for _, name := range Order(gs) {
+		if listed[name] {
+			actual = append(actual, name)
+		}
+	}
+
+	if !equalOrder(actual, names) {
+		t.Errorf("gogstest: hooks are executed in order %q, expected %q", actual, names)
+		return false
+	}
+
+	return true
+}
+
+// AssertOrder is a method of the Harness struct. It asserts that the hooks with the
+// provided names were executed in the provided order, see AssertOrder.
+func (h *Harness) AssertOrder(names ...string) bool {
+	h.t.Helper()
+	if h.gs == nil {
+		h.t.Fatal("gogstest: AssertOrder is called before Start")
+	}
+
+	return AssertOrder(h.t, h.gs, names...)
+}
+
+// equalOrder is a function that reports whether both lists hold the same names in the same
+// order.
+func equalOrder(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
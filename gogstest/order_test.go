@@ -0,0 +1,48 @@
+package gogstest
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+func app(_ context.Context, gs *gogs.GracefulShutdown) error {
+	for _, name := range []string{"db", "cache", "workers", "http"} {
+		gs.AddHook(name, func(context.Context) error { return nil })
+	}
+	gs.AddHook("warm-state", func(context.Context) error { return nil }, gogs.WithBestEffort(time.Hour))
+
+	gs.MarkReady()
+	return nil
+}
+
+func Test_Harness_AssertOrder(t *testing.T) {
+	h := New(t, app, gogs.WithSignals(syscall.SIGTERM), gogs.WithGracePeriod(time.Second))
+	gs := h.Start()
+	h.Shutdown(time.Second)
+
+	assert.Equal(t, []string{"http", "workers", "cache", "db"}, Order(gs))
+	assert.True(t, h.AssertOrder("http", "workers", "db"))
+}
+
+func Test_AssertOrder_Failure(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := gogs.New(context.Background())
+	defer cancel()
+
+	_ = app(context.Background(), gs)
+	gs.Wait()
+
+	tb := &fakeTB{TB: t}
+	assert.False(t, AssertOrder(tb, gs, "db", "http"))
+	assert.False(t, AssertOrder(tb, gs, "http", "missing"))
+	assert.Equal(t, []string{
+		`gogstest: hooks are executed in order ["http" "db"], expected ["db" "http"]`,
+		`gogstest: hooks are executed in order ["http"], expected ["http" "missing"]`,
+	}, tb.errors)
+}
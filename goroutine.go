@@ -0,0 +1,130 @@
+package gogs
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// shutdownContextKey is the key marking the contexts derived from the context created by
+// New, see WithContextCheck.
+type shutdownContextKey struct{}
+
+// WithContextCheck is an option that makes Go warn, through the logger set with
+// WithLogger or the standard logger, when a goroutine is started with a context that is
+// not derived from the context created by New, e.g. context.Background(). Such goroutines
+// never observe the cancellation and extend the drain until its deadline. The check costs
+// a context lookup per call, so it is meant for development and staging.
+func WithContextCheck() Option {
+	return func(cfg *config) {
+		cfg.contextCheck = true
+	}
+}
+
+// Go is a method of the GracefulShutdown struct. It starts the provided function in a new
+// goroutine tracked as an active shutdown event, and passes it the provided context.
+//
+//	gs.Go(ctx, func(ctx context.Context) {
+//		consume(ctx, queue)
+//	})
+//
+// This example consumes the queue until the context is canceled, and the drain waits for
+// the consumer to return.
+func (gs *GracefulShutdown) Go(ctx context.Context, fn func(ctx context.Context)) {
+	if gs.cfg.contextCheck {
+		gs.checkContext(ctx)
+	}
+
+	gs.Subscribe()
+	go func() {
+		defer gs.Unsubscribe()
+		fn(ctx)
+	}()
+}
+
+// Track is a method of the GracefulShutdown struct. It runs the provided function as an
+// active shutdown event on the calling goroutine. The event completes when the function
+// returns or panics, in which case the panic is propagated once the event is accounted
+// for, so that a panicking handler never leaks the event and hangs the drain.
+//
+//	func middleware(gs *gogs.GracefulShutdown, next http.Handler) http.Handler {
+//		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//			gs.Track(func() {
+//				next.ServeHTTP(w, r)
+//			})
+//		})
+//	}
+//
+// This example tracks every request, including the ones whose handler panics and is
+// recovered by the server.
+func (gs *GracefulShutdown) Track(fn func()) {
+	gs.Subscribe()
+	defer gs.Unsubscribe()
+
+	fn()
+}
+
+// OnContextDone is a method of the GracefulShutdown struct. It tracks the provided
+// function as an active shutdown event and executes it once the provided context is done,
+// completing the event when it returns, like context.AfterFunc with the accounting of the
+// shutdown. The returned stop function unregisters the function unless it has started,
+// completing the event, and reports whether it did. The context should observe the
+// shutdown, e.g. derive from the context created by New, otherwise the drain waits for it
+// until its deadline.
+//
+//	stop := gs.OnContextDone(sessionCtx, func() {
+//		session.Flush()
+//	})
+//	defer stop()
+//
+// This example flushes the session when it ends or the shutdown cancels it, and the drain
+// waits for the flush.
+func (gs *GracefulShutdown) OnContextDone(ctx context.Context, fn func()) (stop func() bool) {
+	if gs.cfg.contextCheck {
+		gs.checkContext(ctx)
+	}
+
+	gs.Subscribe()
+
+	var claimed atomic.Bool
+	stopCh := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-stopCh:
+			return
+		}
+
+		if claimed.CompareAndSwap(false, true) {
+			defer gs.Unsubscribe()
+			fn()
+		}
+	}()
+
+	return func() bool {
+		if !claimed.CompareAndSwap(false, true) {
+			return false
+		}
+
+		close(stopCh)
+		gs.Unsubscribe()
+		return true
+	}
+}
+
+// checkContext is a method of the GracefulShutdown struct. It warns if the provided
+// context does not observe the shutdown: if it is not derived from the context created by
+// New or, without such a context, if it can never be canceled.
+func (gs *GracefulShutdown) checkContext(ctx context.Context) {
+	if gs.ctx != nil && ctx.Value(shutdownContextKey{}) == gs {
+		return
+	}
+	if gs.ctx == nil && ctx.Done() != nil {
+		return
+	}
+
+	frame := callerFrame()
+	gs.logger().Printf(
+		"gogs: goroutine started at %s:%d with a context not derived from the shutdown context, it will not observe the cancellation",
+		frame.File, frame.Line,
+	)
+}
@@ -0,0 +1,108 @@
+package gogs
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GracefulShutdown_Go(t *testing.T) {
+	t.Parallel()
+	gs, ctx, cancel := New(context.Background(), WithSignals(syscall.SIGINT))
+
+	stopped := make(chan struct{})
+	gs.Go(ctx, func(ctx context.Context) {
+		<-ctx.Done()
+		close(stopped)
+	})
+	assert.Equal(t, int32(1), gs.Count())
+
+	cancel()
+	gs.Wait()
+
+	<-stopped
+	assert.Equal(t, int32(0), gs.Count())
+}
+
+func Test_GracefulShutdown_OnContextDone(t *testing.T) {
+	t.Parallel()
+	gs, ctx, cancel := New(context.Background(), WithSignals(syscall.SIGINT))
+
+	sessionCtx, endSession := context.WithCancel(ctx)
+	flushed := make(chan struct{})
+	gs.OnContextDone(sessionCtx, func() {
+		close(flushed)
+	})
+
+	stop := gs.OnContextDone(ctx, func() {
+		assert.Fail(t, "stopped function executed")
+	})
+	assert.Equal(t, int32(2), gs.Count())
+	assert.True(t, stop())
+	assert.False(t, stop())
+	assert.Equal(t, int32(1), gs.Count())
+
+	endSession()
+	<-flushed
+	assert.Eventually(t, func() bool { return gs.Count() == 0 }, LongDelay, time.Millisecond)
+
+	stopLate := gs.OnContextDone(ctx, func() {})
+	cancel()
+	gs.Wait()
+
+	assert.False(t, stopLate())
+	assert.Equal(t, int32(0), gs.Count())
+}
+
+func Test_GracefulShutdown_Track(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background(), WithSignals(syscall.SIGINT))
+	defer cancel()
+
+	var count int32
+	gs.Track(func() {
+		count = gs.Count()
+	})
+	assert.Equal(t, int32(1), count)
+	assert.Equal(t, int32(0), gs.Count())
+
+	assert.PanicsWithValue(t, "handler", func() {
+		gs.Track(func() {
+			panic("handler")
+		})
+	})
+	assert.Equal(t, int32(0), gs.Count())
+}
+
+func Test_WithContextCheck(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	gs, ctx, cancel := New(
+		context.Background(),
+		WithContextCheck(),
+		WithLogger(log.New(&buf, "", 0)),
+	)
+	defer cancel()
+
+	derived, derivedCancel := context.WithCancel(ctx)
+	defer derivedCancel()
+	gs.Go(derived, func(context.Context) {})
+	assert.Empty(t, buf.String())
+
+	gs.Go(context.Background(), func(context.Context) {})
+	assert.Contains(t, buf.String(), "gogs: goroutine started at ")
+	assert.Contains(t, buf.String(), "goroutine_test.go:")
+	assert.Contains(t, buf.String(), "not derived from the shutdown context")
+
+	buf.Reset()
+	child := gs.Scope("workers", WithContextCheck(), WithLogger(log.New(&buf, "", 0)))
+	child.Go(ctx, func(context.Context) {})
+	assert.Empty(t, buf.String())
+	child.Go(context.TODO(), func(context.Context) {})
+	assert.NotEmpty(t, buf.String())
+}
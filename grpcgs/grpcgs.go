@@ -0,0 +1,204 @@
+// Package grpcgs provides adapters shutting down gRPC servers gracefully without depending
+// on the grpc package.
+package grpcgs
+
+import (
+	"context"
+	"sync"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+// Server is an interface that describes the methods of *grpc.Server used to shut it
+// down. It allows stopping gRPC servers without depending on the grpc package.
+type Server interface {
+	// GracefulStop stops the server from accepting new connections and RPCs and blocks
+	// until all the pending RPCs are finished.
+	GracefulStop()
+
+	// Stop stops the server immediately, canceling all active RPCs.
+	Stop()
+}
+
+// StreamNotifier is a struct that broadcasts a GOAWAY-like drain notification to the
+// registered stream handlers, so that long-running server-streaming RPCs can end their
+// streams gracefully before GracefulStop blocks on them.
+type StreamNotifier struct {
+	// mu guards the fields below.
+	mu sync.Mutex
+
+	// next is the key of the next registered stream.
+	next uint64
+
+	// streams holds the callbacks of the registered streams.
+	streams map[uint64]func()
+
+	// drainCh is closed when the drain notification is broadcast.
+	drainCh chan struct{}
+}
+
+// NewStreamNotifier is a function that creates a new StreamNotifier.
+func NewStreamNotifier() *StreamNotifier {
+	return &StreamNotifier{
+		streams: make(map[uint64]func()),
+		drainCh: make(chan struct{}),
+	}
+}
+
+// Register is a method of the StreamNotifier struct. It registers a callback of a stream
+// handler that is invoked once when the drain notification is broadcast. If the
+// notification was already broadcast, the callback is invoked immediately. It returns a
+// function that unregisters the callback and must be called when the stream ends.
+//
+//	func (s *service) Watch(req *pb.WatchRequest, stream pb.Service_WatchServer) error {
+//		ctx, cancel := context.WithCancel(stream.Context())
+//		defer notifier.Register(cancel)()
+//		return s.watch(ctx, req, stream)
+//	}
+//
+// This example ends the stream gracefully once the server starts draining.
+func (n *StreamNotifier) Register(notify func()) (unregister func()) {
+	n.mu.Lock()
+	select {
+	case <-n.drainCh:
+		n.mu.Unlock()
+		notify()
+		return func() {}
+	default:
+	}
+
+	key := n.next
+	n.next++
+	n.streams[key] = notify
+	n.mu.Unlock()
+
+	return func() {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		delete(n.streams, key)
+	}
+}
+
+// Active is a method of the StreamNotifier struct. It returns the count of registered
+// streams.
+func (n *StreamNotifier) Active() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return len(n.streams)
+}
+
+// Draining is a method of the StreamNotifier struct. It returns a channel that is closed
+// when the drain notification is broadcast.
+func (n *StreamNotifier) Draining() <-chan struct{} {
+	return n.drainCh
+}
+
+// Notify is a method of the StreamNotifier struct. It broadcasts the drain notification to
+// all registered streams, invoking each callback once. It returns the count of notified
+// streams. Subsequent calls do nothing.
+func (n *StreamNotifier) Notify() int {
+	n.mu.Lock()
+	select {
+	case <-n.drainCh:
+		n.mu.Unlock()
+		return 0
+	default:
+	}
+
+	close(n.drainCh)
+	streams := n.streams
+	n.streams = make(map[uint64]func())
+	n.mu.Unlock()
+
+	for _, notify := range streams {
+		notify()
+	}
+
+	return len(streams)
+}
+
+// Stop is a function that broadcasts the drain notification to the streams registered
+// with the notifier, if any, and gracefully stops the server. If the context is done
+// before the pending RPCs are finished, the server is stopped immediately and the context
+// error is returned.
+//
+//	err := Stop(ctx, srv, notifier)
+//
+// This example asks the streaming handlers to finish and waits for them within the
+// deadline of the context.
+func Stop(ctx context.Context, srv Server, notifier *StreamNotifier) error {
+	if notifier != nil {
+		notifier.Notify()
+	}
+
+	doneCh := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(doneCh)
+	}()
+
+	select {
+	case <-doneCh:
+		return nil
+	case <-ctx.Done():
+		srv.Stop()
+		<-doneCh
+		return ctx.Err()
+	}
+}
+
+// HealthServer is an interface that describes the method of *health.Server from
+// google.golang.org/grpc/health used to flip its status on shutdown. It allows updating
+// the health service without depending on the grpc package.
+type HealthServer interface {
+	// Shutdown sets the serving status of every service and of the server as a whole to
+	// NOT_SERVING, and ignores all future status changes.
+	Shutdown()
+}
+
+// AddHealth is a function that sets the serving status of the provided health server
+// to NOT_SERVING, per service and overall, as soon as the shutdown is triggered. It is
+// registered as intake, see gogs.GracefulShutdown.AddIntake, so that gRPC clients with health-based load
+// balancing stop sending RPCs to the draining instance before it stops serving them.
+//
+//	healthSrv := health.NewServer()
+//	grpc_health_v1.RegisterHealthServer(srv, healthSrv)
+//	AddHealth(gs, healthSrv)
+//
+// This example reports NOT_SERVING to health checks once the shutdown starts.
+func AddHealth(gs gogs.IntakeRegistrar, health HealthServer) {
+	gs.AddIntake("grpc-health", func() error {
+		health.Shutdown()
+		return nil
+	})
+}
+
+// DrainingTrailer is the trailer set to "true" on the RPCs completed once the drain of the
+// shutdown has started, see MarkDraining.
+const DrainingTrailer = "draining"
+
+// MarkDraining is a function that calls the provided function with the draining trailer
+// and "true" if the drain of the shutdown has started, and reports whether it did. Called
+// from an interceptor once the handler has returned, it lets smart clients and proxies
+// send their hedged RPCs and retries to other instances rather than queue them behind the
+// drain, without depending on the grpc package.
+//
+//	func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+//		resp, err := handler(ctx, req)
+//		MarkDraining(gs, func(key, value string) {
+//			_ = grpc.SetTrailer(ctx, metadata.Pairs(key, value))
+//		})
+//		return resp, err
+//	}
+//
+// This example is a unary interceptor marking the RPCs completed during the drain.
+func MarkDraining(gs *gogs.GracefulShutdown, set func(key, value string)) bool {
+	select {
+	case <-gs.DrainStarted():
+		set(DrainingTrailer, "true")
+		return true
+	default:
+		return false
+	}
+}
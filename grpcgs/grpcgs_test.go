@@ -0,0 +1,114 @@
+package grpcgs
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+type testServer struct {
+	once    sync.Once
+	stopCh  chan struct{}
+	stopped bool
+}
+
+func newTestServer() *testServer {
+	return &testServer{stopCh: make(chan struct{})}
+}
+
+func (s *testServer) GracefulStop() {
+	<-s.stopCh
+}
+
+func (s *testServer) Stop() {
+	s.once.Do(func() {
+		s.stopped = true
+		close(s.stopCh)
+	})
+}
+
+func Test_StreamNotifier(t *testing.T) {
+	t.Parallel()
+	notifier := NewStreamNotifier()
+
+	var notified []string
+	notifier.Register(func() { notified = append(notified, "first") })
+	unregister := notifier.Register(func() { notified = append(notified, "second") })
+	assert.Equal(t, 2, notifier.Active())
+
+	unregister()
+	assert.Equal(t, 1, notifier.Active())
+
+	assert.Equal(t, 1, notifier.Notify())
+	assert.Equal(t, 0, notifier.Notify())
+	assert.Equal(t, []string{"first"}, notified)
+
+	_, ok := <-notifier.Draining()
+	assert.False(t, ok)
+
+	notifier.Register(func() { notified = append(notified, "late") })
+	assert.Equal(t, []string{"first", "late"}, notified)
+	assert.Equal(t, 0, notifier.Active())
+}
+
+func Test_Stop(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Graceful", func(t *testing.T) {
+		srv := newTestServer()
+		notifier := NewStreamNotifier()
+		notifier.Register(func() { close(srv.stopCh) })
+
+		assert.NoError(t, Stop(context.Background(), srv, notifier))
+		assert.False(t, srv.stopped)
+	})
+
+	t.Run("Forced", func(t *testing.T) {
+		srv := newTestServer()
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		assert.ErrorIs(t, Stop(ctx, srv, nil), context.DeadlineExceeded)
+		assert.True(t, srv.stopped)
+	})
+}
+
+type testHealthServer struct {
+	notServing bool
+}
+
+func (s *testHealthServer) Shutdown() {
+	s.notServing = true
+}
+
+func Test_AddHealth(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := gogs.New(context.Background())
+
+	health := &testHealthServer{}
+	AddHealth(gs, health)
+	assert.False(t, health.notServing)
+
+	cancel()
+	assert.True(t, health.notServing)
+}
+
+func Test_MarkDraining(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := gogs.New(context.Background())
+
+	trailers := map[string]string{}
+	set := func(key, value string) { trailers[key] = value }
+	assert.False(t, MarkDraining(gs, set))
+	assert.Empty(t, trailers)
+
+	cancel()
+	gs.Wait()
+	assert.True(t, MarkDraining(gs, set))
+	assert.Equal(t, map[string]string{DrainingTrailer: "true"}, trailers)
+}
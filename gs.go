@@ -8,6 +8,11 @@
 // complete, and an atomic.Int32 to keep track of the count of active events. The package
 // also provides functions for creating a new context or channel that can be used to
 // signal shutdown events.
+//
+// The package has no dependencies. Integrations with servers, databases, brokers and
+// service registries live in subpackages, e.g. httpgs, dbgs, natsgs or registrar, built
+// on the extension points of this package: hooks, intake, the final hooks and the
+// Registrar interface.
 package gogs
 
 import (
@@ -56,22 +61,318 @@ type GracefulShutdowner interface {
 	WaitWithTimeout(duration time.Duration)
 }
 
+// HookRegistrar is an interface that provides the registration of hooks, implemented by
+// GracefulShutdown. The integrations registering hooks only accept it, so that they can
+// be tested with a fake.
+type HookRegistrar interface {
+	// AddHook registers a named cleanup function executed once all active shutdown events
+	// have completed.
+	AddHook(name string, hookFn func(ctx context.Context) error, opts ...HookOption)
+}
+
+// IntakeRegistrar is an interface that provides the registration of the functions
+// stopping the intake of new work, implemented by GracefulShutdown.
+type IntakeRegistrar interface {
+	// AddIntake registers a named function stopping the intake of new work, e.g. closing a
+	// listener, executed as soon as the shutdown is triggered.
+	AddIntake(name string, stopFn func() error)
+}
+
+// Reporter is an interface that provides the outcome and the progress of the shutdown,
+// implemented by GracefulShutdown.
+type Reporter interface {
+	// Reason returns what triggered the shutdown, or an empty reason if the shutdown has
+	// not started yet.
+	Reason() Reason
+
+	// Report returns the outcome of the executed hooks.
+	Report() Report
+
+	// Snapshot returns the current progress of the shutdown.
+	Snapshot() Snapshot
+}
+
 // GracefulShutdown is a struct that implements the GracefulShutdowner interface.
 // It provides a mechanism for managing graceful shutdowns in Go applications.
 // It uses a sync.WaitGroup to wait for all active shutdown events to complete,
 // and an atomic.Int32 to keep track of the count of active events.
 type GracefulShutdown struct {
-	// wg is a WaitGroup that is used to wait for all active shutdown events to complete.
+	// wg is a WaitGroup that is used to wait for all active shutdown events to complete,
+	// unless replaced with WithWaitGroup.
 	wg sync.WaitGroup
 
+	// subMu serializes the changes of the count of active shutdown events with the start
+	// of the drain, so that the WaitGroup is never added to from zero while it is waited
+	// for.
+	subMu sync.Mutex
+
+	// waiting reports whether the drain waits for the WaitGroup, meanwhile the
+	// subscriptions from zero wait for it to complete or are rejected, see admit. drained
+	// reports whether it has completed, and waitDone is closed then. They are guarded by
+	// subMu.
+	waiting  bool
+	drained  bool
+	waitDone chan struct{}
+
+	// reserved is the count of the pending critical events, see SubscribeCritical, which
+	// only their own completion releases. It is guarded by subMu.
+	reserved int32
+
+	// hooksStarted reports whether the hooks to execute were selected, after which the
+	// registered hooks are not executed, see TryAddHook. earlyStarted reports the same of
+	// the hooks registered with WithEarlyStart, see WithPipelining. They are guarded by mu.
+	hooksStarted bool
+	earlyStarted bool
+
 	// list is an atomic integer that keeps track of the count of active shutdown events.
 	list atomic.Int32
+
+	// unsubscribed is the count of active shutdown events completed so far, telling the
+	// progress of the drain, see WithDeadlockWarning.
+	unsubscribed atomic.Int64
+
+	// mu guards hooks and report.
+	mu sync.Mutex
+
+	// hooks is the list of registered cleanup functions.
+	hooks []hook
+
+	// hooksOnce guarantees that the registered hooks are executed only once.
+	hooksOnce sync.Once
+
+	// traceOnce starts the terminal trace only once, see WithTerminalTrace.
+	traceOnce sync.Once
+
+	// cleanups records the outcomes of the cleanup functions, see CleanupStats.
+	cleanups cleanupStats
+
+	// doneCh is closed once the shutdown has completed, see Done. It is created lazily and
+	// guarded by mu. doneOnce starts the wait of Done and completeOnce closes doneCh.
+	doneCh       chan struct{}
+	doneOnce     sync.Once
+	completeOnce sync.Once
+
+	// report is the outcome of the executed hooks.
+	report Report
+
+	// cfg is the configuration built from the options passed to the constructor.
+	cfg config
+
+	// parentCtx and ctx are the contexts passed to and created by New. They are used to
+	// tell a parent cancellation from a call to the cancel function.
+	parentCtx, ctx context.Context
+
+	// cancel stops the intake and cancels the context created by New.
+	cancel context.CancelFunc
+
+	// readyCh is closed once the application is ready. It is guarded by mu.
+	readyCh chan struct{}
+
+	// startupAbortCh is closed once the startup is abandoned, see AbortStartup. It is
+	// guarded by mu.
+	startupAbortCh chan struct{}
+
+	// reason is what triggered the shutdown. It is guarded by mu.
+	reason Reason
+
+	// phase is the current step of the shutdown, hook is the name of the hook being
+	// executed and hooksLeft is the count of hooks not completed yet. They are guarded by mu.
+	phase     Phase
+	hook      string
+	hooksLeft int
+
+	// subscribers maps the IDs of the named active shutdown events to their subscribers.
+	// They are guarded by mu.
+	subscribers  map[uint64]Subscriber
+	subscriberID uint64
+
+	// criticalCh is closed once a critical subscriber completes, see awaitCritical. It is
+	// created lazily and guarded by mu.
+	criticalCh chan struct{}
+
+	// ages tracks when the unnamed active shutdown events were subscribed.
+	ages subscriptionAges
+
+	// disabledModules is the set of modules whose hooks are skipped. It is guarded by mu.
+	disabledModules map[string]bool
+
+	// pool holds the goroutines executing the hooks, if configured with WithHookPool.
+	pool *hookPool
+
+	// draining is set once the drain phase starts, see Checkpoint.
+	draining atomic.Bool
+
+	// drainCh is closed once the drain phase starts, see DrainStarted. It is created
+	// lazily and guarded by mu, and drainOnce closes it.
+	drainCh   chan struct{}
+	drainOnce sync.Once
+
+	// onDrain holds the functions registered with OnDrain until the drain starts, after
+	// which drainFnsStarted is set and the functions are executed as they are registered.
+	// Both are guarded by mu.
+	onDrain         []drainFn
+	drainFnsStarted bool
+
+	// extended is the total of the extensions granted to the hooks, and postponedKill the
+	// part of it the kill delay watchdog has not been postponed by yet, see WithExtensions.
+	extended      atomic.Int64
+	postponedKill atomic.Int64
+
+	// afterFns is the list of functions invoked with the report once the shutdown has
+	// completed, guarded by mu, and afterOnce invokes them once, see AfterShutdown.
+	afterFns  []func(report Report)
+	afterOnce sync.Once
+
+	// earlyOnce starts the hooks registered with WithEarlyStart once, earlyCh is closed
+	// once they have completed and earlyResults is their outcome. The latter two are
+	// guarded by mu, see WithPipelining.
+	earlyOnce    sync.Once
+	earlyCh      chan struct{}
+	earlyResults []HookResult
+
+	// debug reports whether the debug logging is enabled, and debugStop, guarded by mu,
+	// stops the dumps of the pending subscriptions, see WithDebugSignal.
+	debug     atomic.Bool
+	debugStop chan struct{}
+
+	// envOnce records the snapshot of the environment once, see WithEnvSnapshot.
+	envOnce sync.Once
+
+	// archiveOnce writes the report to the archive once, see WithReportArchive.
+	archiveOnce sync.Once
+
+	// suspendedAt is the time the process was suspended at in Unix nanoseconds, zero while
+	// it runs, and pausable is the set of the contexts whose deadline is paused meanwhile,
+	// guarded by mu, see WithSuspendAware.
+	suspendedAt atomic.Int64
+	pausable    map[*extendableCtx]struct{}
+
+	// progress streams the snapshots of the shutdown progress.
+	progress progress
+
+	// registrars is the list of entries in service registries. It is guarded by mu.
+	registrars []registrar
+
+	// intake holds the functions stopping the intake of new work.
+	intake intake
+
+	// pending holds the shutdown held by Trigger, see WithAbortWindow.
+	pending pending
+
+	// hard tracks the hard deadline of the shutdown for the contexts returned by Shield.
+	hard hardStop
+
+	// watchdog holds the watchdog started by WithKillDelay.
+	watchdog watchdog
+
+	// leaks is the list of abandoned blocking calls that have not returned yet. It is
+	// guarded by mu.
+	leaks []*Leak
+}
+
+// New is a function that creates a new context and a GracefulShutdowner instance
+// configured with the provided options. The created context is canceled when one of the
+// configured signals is received, when the parent context is canceled or when the returned
+// cancel function is called. The GracefulShutdowner remembers which of them triggered the
+// shutdown and applies the matching Policy.
+//
+//	gs, ctx, cancel := New(
+//		context.Background(),
+//		WithSignals(syscall.SIGINT, syscall.SIGTERM),
+//		WithPolicy(ReasonParent, Policy{GracePeriod: time.Second}),
+//	)
+//
+// This example creates a new context that will be canceled when an interrupt or
+// termination signal is received, and limits the shutdown to one second when it is
+// triggered by the cancellation of the parent context instead.
+func New(parentCtx context.Context, opts ...Option) (*GracefulShutdown, context.Context, context.CancelFunc) {
+	gs := newGracefulShutdown(parentCtx, opts)
+
+	var cancel context.CancelFunc
+	gs.ctx, cancel = context.WithCancel(context.WithValue(parentCtx, shutdownContextKey{}, gs))
+	gs.cancel = func() {
+		gs.stop(cancel)
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, gs.cfg.notified()...)
+
+	go func() {
+		defer signal.Stop(sigCh)
+
+		for {
+			select {
+			case sig := <-sigCh:
+				if !gs.cfg.triggers(sig) {
+					continue
+				}
+				at := time.Now()
+				gs.start(ReasonSignal)
+				for _, callback := range gs.cfg.beforeCancel {
+					callback(sig, at)
+				}
+				gs.cancel()
+				if gs.cfg.debounceWindow > 0 {
+					gs.debounceSignals(sigCh, sig)
+				}
+			case <-gs.ctx.Done():
+				gs.resolveReason()
+			}
+			return
+		}
+	}()
+	gs.watch()
+
+	return gs, gs.ctx, gs.cancel
+}
+
+// newGracefulShutdown is a function that creates a GracefulShutdown instance configured
+// with the provided options for New, or for NewRequests if the parent context is nil, binds
+// the registries set with WithPendingHooks and starts the hook pool. It panics if a
+// registry is already bound or if an option is only supported by New, before starting any
+// goroutine.
+func newGracefulShutdown(parentCtx context.Context, opts []Option) *GracefulShutdown {
+	gs := &GracefulShutdown{parentCtx: parentCtx}
+	for _, opt := range opts {
+		opt(&gs.cfg)
+	}
+	if parentCtx == nil {
+		if len(gs.cfg.sources) > 0 {
+			panic("gogs: WithTrigger requires an instance created by New")
+		}
+		if gs.cfg.smoke != nil {
+			panic("gogs: ParseSimulateShutdown requires an instance created by New")
+		}
+	}
+	for _, r := range gs.cfg.registries {
+		if err := r.Bind(gs); err != nil {
+			panic(err)
+		}
+	}
+	gs.startPool()
+
+	return gs
+}
+
+// watch is a method of the GracefulShutdown struct. It starts the watchers of the signals
+// set with WithSuspendAware, OnStatusRequest and WithDebugSignal and, for an instance
+// created by New, those of the triggers set with WithTrigger and of the simulated shutdown
+// set with ParseSimulateShutdown.
+func (gs *GracefulShutdown) watch() {
+	gs.watchSuspend()
+	gs.watchStatus()
+	gs.watchDebug()
+	if gs.ctx != nil {
+		gs.watchTriggers()
+		gs.watchSmokeTest()
+	}
 }
 
 // NewContext is a function that creates a new context and a GracefulShutdowner instance.
 // It takes a parent context and a variadic parameter of os.Signal as arguments.
-// The function uses the signal.NotifyContext function to register the provided signals to
-// the created context.
+// The function registers the provided signals to the created context in the same way as
+// the signal.NotifyContext function, except that SIGURG is never registered, see
+// WithSignals.
 //
 //	gs, ctx, cancel := NewContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 //
@@ -79,8 +380,7 @@ type GracefulShutdown struct {
 // termination signal is received. It also returns a GracefulShutdowner instance that can
 // be used to manage graceful shutdowns in the application.
 func NewContext(parentCtx context.Context, signals ...os.Signal) (GracefulShutdowner, context.Context, context.CancelFunc) {
-	ctx, cancel := signal.NotifyContext(parentCtx, signals...)
-	return &GracefulShutdown{}, ctx, cancel
+	return New(parentCtx, WithSignals(signals...))
 }
 
 // NewChannel is a function that creates a new channel and a GracefulShutdowner instance.
@@ -93,51 +393,117 @@ func NewContext(parentCtx context.Context, signals ...os.Signal) (GracefulShutdo
 // signal. It also returns a GracefulShutdowner instance that can be used to manage
 // graceful shutdowns in the application.
 func NewChannel(signals ...os.Signal) (GracefulShutdowner, chan os.Signal) {
+	return NewChannelWithOptions(WithSignals(signals...))
+}
+
+// NewChannelWithOptions is a function that creates a new channel and a GracefulShutdowner
+// instance configured with the provided options. Only the signals triggering the shutdown
+// are delivered to the channel, so ignored and routed signals never reach it. It is kept
+// for compatibility, NewRequests delivers the signals with their time and sequence number.
+//
+//	gs, stopCh := NewChannelWithOptions(WithSignals(), WithIgnoredSignals(NoiseSignals...))
+//
+// This example creates a new channel that receives every signal but the noise ones, such
+// as SIGPIPE or SIGCHLD, which would otherwise cause an accidental shutdown.
+func NewChannelWithOptions(opts ...Option) (*GracefulShutdown, chan os.Signal) {
+	gs, requests := NewRequests(opts...)
+
 	stopCh := make(chan os.Signal, 2)
-	signal.Notify(stopCh, signals...)
-	return &GracefulShutdown{}, stopCh
+	go func() {
+		for req := range requests {
+			select {
+			case stopCh <- req.Signal:
+			default:
+			}
+		}
+	}()
+
+	return gs, stopCh
 }
 
 // Subscribe is a method of the GracefulShutdown struct. It increments the count of active
-// shutdown events by one.
+// shutdown events by one. It joins the drain in progress unless the count has dropped to
+// zero, in which case it waits for the drain to complete first, see TrySubscribe.
 func (gs *GracefulShutdown) Subscribe() {
-	gs.list.Add(1)
-	gs.wg.Add(1)
+	count, _ := gs.admit(1, false, false)
+	gs.ages.push(1)
+	gs.notify(gs.cfg.observer.OnSubscribe, 1, count)
 }
 
 // SubscribeN is a method of the GracefulShutdown struct. It increments the count of
-// active shutdown events by the specified count.
+// active shutdown events by the specified count, like Subscribe.
 func (gs *GracefulShutdown) SubscribeN(count int32) {
-	gs.list.Add(count)
-	gs.wg.Add(int(count))
+	list, _ := gs.admit(count, false, false)
+	gs.ages.push(count)
+	gs.notify(gs.cfg.observer.OnSubscribe, count, list)
+}
+
+// SubscribeCtx is a method of the GracefulShutdown struct. It increments the count of
+// active shutdown events by one unless the shutdown has started, in which case it returns
+// ErrShuttingDown, or the context is done, in which case it returns the context error.
+// Unlike Subscribe, it never races with the start of Wait, so components can use it to
+// stop spawning new tracked work that would extend the drain.
+//
+//	if err := gs.SubscribeCtx(ctx); err != nil {
+//		return err
+//	}
+//	go func() {
+//		defer gs.Unsubscribe()
+//		process(ctx, job)
+//	}()
+//
+// This example processes the job only if the shutdown has not started yet.
+func (gs *GracefulShutdown) SubscribeCtx(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	gs.mu.Lock()
+	if gs.reason != "" {
+		gs.mu.Unlock()
+		return ErrShuttingDown
+	}
+	count, ok := gs.admit(1, true, false)
+	gs.mu.Unlock()
+	if !ok {
+		return ErrShuttingDown
+	}
+	gs.ages.push(1)
+
+	gs.notify(gs.cfg.observer.OnSubscribe, 1, count)
+	return nil
 }
 
 // Unsubscribe is a method of the GracefulShutdown struct. It decrements the count of
 // active shutdown events by one.
 func (gs *GracefulShutdown) Unsubscribe() {
-	if gs.list.Load() == 0 {
-		return
+	if gs.unsubscribe() {
+		gs.ages.pop(1)
+	}
+}
+
+// unsubscribe is a method of the GracefulShutdown struct. It decrements the count of
+// active shutdown events by one without forgetting an age, see SubscribeNamed, and reports
+// whether there was an event to decrement.
+func (gs *GracefulShutdown) unsubscribe() bool {
+	count, released := gs.release(1)
+	if released == 0 {
+		return false
 	}
-	gs.list.Add(-1)
-	gs.wg.Done()
+	gs.notify(gs.cfg.observer.OnUnsubscribe, -1, count)
+
+	return true
 }
 
 // UnsubscribeN is a method of the GracefulShutdown struct. It decrements the count of
 // active shutdown events by the specified count.
 func (gs *GracefulShutdown) UnsubscribeN(count int32) {
-	list := gs.list.Load()
-	if list == 0 {
+	list, count := gs.release(count)
+	if count == 0 {
 		return
 	}
-
-	if list < count {
-		count = list
-	}
-
-	gs.list.Add(count * -1)
-	for i := int32(0); i < count; i++ {
-		gs.wg.Done()
-	}
+	gs.ages.pop(count)
+	gs.notify(gs.cfg.observer.OnUnsubscribe, count*-1, list)
 }
 
 // UnsubscribeFn is a method of the GracefulShutdown struct. It executes the provided
@@ -153,7 +519,8 @@ func (gs *GracefulShutdown) UnsubscribeFn(cleanFn func()) {
 
 // UnsubscribeFnWithTimeout is a method of the GracefulShutdown struct. It executes the
 // provided function and unsubscribes after the specified duration. If the function
-// execution completes before the timeout, it unsubscribes immediately.
+// execution completes before the timeout, it unsubscribes immediately. The outcomes are
+// recorded in CleanupStats.
 func (gs *GracefulShutdown) UnsubscribeFnWithTimeout(
 	cleanFn func(),
 	duration time.Duration,
@@ -166,17 +533,20 @@ func (gs *GracefulShutdown) UnsubscribeFnWithTimeout(
 	doneCh := make(chan struct{})
 
 	t := time.NewTimer(duration)
+	defer t.Stop()
 
+	start := time.Now()
 	go func() {
 		cleanFn()
+		gs.recordCleanupDuration(time.Since(start))
 		close(doneCh)
 	}()
 
 	select {
 	case <-t.C:
-		return
+		gs.recordCleanup(true)
 	case <-doneCh:
-		return
+		gs.recordCleanup(false)
 	}
 }
 
@@ -187,31 +557,20 @@ func (gs *GracefulShutdown) Count() int32 {
 }
 
 // Wait is a method of the GracefulShutdown struct. It blocks until all active shutdown
-// events have completed.
+// events have completed. If the Policy of the shutdown reason has a grace period, it
+// behaves like WaitWithTimeout with that period.
 func (gs *GracefulShutdown) Wait() {
-	gs.wg.Wait()
+	gs.waitContext(context.Background())
 }
 
 // WaitWithTimeout is a method of the GracefulShutdown struct. It blocks until all active
 // shutdown events have completed or the specified duration has elapsed. If the duration
 // elapses before all events have completed, it unsubscribes from all remaining events.
+// Registered hooks are executed with the remaining part of the duration. The duration is
+// further limited by the grace period of the Policy of the shutdown reason, if any.
 func (gs *GracefulShutdown) WaitWithTimeout(duration time.Duration) {
-	timer := time.NewTimer(duration)
-	doneCh := make(chan struct{})
-	defer func() {
-		<-doneCh
-	}()
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	defer cancel()
 
-	go func() {
-		gs.Wait()
-		close(doneCh)
-	}()
-
-	select {
-	case <-timer.C:
-		gs.UnsubscribeN(gs.Count())
-		return
-	case <-doneCh:
-		return
-	}
+	gs.waitContext(ctx)
 }
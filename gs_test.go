@@ -191,3 +191,27 @@ func shortDelay() {
 func longDelay() {
 	time.Sleep(LongDelay)
 }
+
+func Test_GracefulShutdown_SubscribeCtx(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background(), WithSignals(syscall.SIGINT))
+
+	assert.NoError(t, gs.SubscribeCtx(context.Background()))
+	assert.Equal(t, int32(1), gs.Count())
+
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	ctxCancel()
+	assert.ErrorIs(t, gs.SubscribeCtx(ctx), context.Canceled)
+	assert.Equal(t, int32(1), gs.Count())
+
+	cancel()
+	assert.Eventually(t, func() bool {
+		return gs.Reason() == ReasonCancel
+	}, LongDelay, time.Millisecond)
+	assert.ErrorIs(t, gs.SubscribeCtx(context.Background()), ErrShuttingDown)
+	assert.Equal(t, int32(1), gs.Count())
+
+	gs.Unsubscribe()
+	gs.Wait()
+	assert.Equal(t, int32(0), gs.Count())
+}
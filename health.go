@@ -0,0 +1,128 @@
+package gogs
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// defaultHealthInterval is the default interval between two rounds of health checks.
+	defaultHealthInterval = 10 * time.Second
+
+	// defaultHealthFailures is the default count of consecutive failed rounds firing the
+	// HealthTrigger.
+	defaultHealthFailures = 3
+)
+
+// HealthTrigger is a struct that implements the Trigger interface by running health checks
+// periodically, and fires with ReasonHealth once they have failed in a row the configured
+// count of times, so that a wedged instance restarts gracefully under an orchestrator
+// while a transient failure does not shut it down. A round of checks fails if any check
+// fails, and a successful round resets the count.
+//
+//	gs, ctx, cancel := New(
+//		context.Background(),
+//		WithSignals(syscall.SIGTERM),
+//		WithTrigger(HealthTrigger{
+//			Checks:   map[string]func(context.Context) error{"db": db.PingContext},
+//			Interval: 5 * time.Second,
+//			Failures: 6,
+//		}),
+//	)
+//
+// This example shuts down once the database has been unreachable for thirty seconds.
+type HealthTrigger struct {
+	// Checks maps the names of the health checks to the functions running them. A check
+	// fails if it returns an error.
+	Checks map[string]func(ctx context.Context) error
+
+	// Interval is the interval between two rounds of checks. Zero or less means ten
+	// seconds.
+	Interval time.Duration
+
+	// Timeout limits each round of checks, which fails if the checks do not complete in
+	// time. Zero or less means the interval.
+	Timeout time.Duration
+
+	// Failures is the count of consecutive failed rounds firing the trigger. Zero or less
+	// means three.
+	Failures int
+
+	// OnFailure is invoked with the name and the error of each failed check, e.g. to log
+	// it, if set. It must be safe for concurrent use.
+	OnFailure func(name string, err error)
+}
+
+// Wait is a method of the HealthTrigger struct. It runs the checks until they fail the
+// configured count of times in a row, and returns ReasonHealth, or until the context is
+// done.
+func (h HealthTrigger) Wait(ctx context.Context) (Reason, error) {
+	interval := h.Interval
+	if interval <= 0 {
+		interval = defaultHealthInterval
+	}
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = interval
+	}
+	failures := h.Failures
+	if failures <= 0 {
+		failures = defaultHealthFailures
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	failed := 0
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+
+		if h.healthy(ctx, timeout) {
+			failed = 0
+			continue
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+
+		failed++
+		if failed >= failures {
+			return ReasonHealth, nil
+		}
+	}
+}
+
+// healthy is a method of the HealthTrigger struct. It runs a round of checks concurrently
+// within the provided timeout, and reports whether all of them succeeded.
+func (h HealthTrigger) healthy(ctx context.Context, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	results := make(chan error, len(h.Checks))
+	for name, check := range h.Checks {
+		go func(name string, check func(ctx context.Context) error) {
+			err := check(ctx)
+			if err != nil && h.OnFailure != nil {
+				h.OnFailure(name, err)
+			}
+			results <- err
+		}(name, check)
+	}
+
+	for range h.Checks {
+		select {
+		case err := <-results:
+			if err != nil {
+				return false
+			}
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	return true
+}
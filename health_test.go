@@ -0,0 +1,72 @@
+package gogs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HealthTrigger(t *testing.T) {
+	t.Parallel()
+
+	errDown := errors.New("down")
+	var round atomic.Int32
+	var mu sync.Mutex
+	var failures []string
+	trigger := HealthTrigger{
+		Checks: map[string]func(context.Context) error{
+			"db": func(context.Context) error {
+				// Fails twice, recovers once, then fails for good.
+				if n := round.Add(1); n == 3 {
+					return nil
+				}
+				return errDown
+			},
+			"cache": func(context.Context) error { return nil },
+		},
+		Interval: time.Millisecond,
+		Failures: 3,
+		OnFailure: func(name string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			failures = append(failures, name+": "+err.Error())
+		},
+	}
+
+	reason, err := trigger.Wait(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, ReasonHealth, reason)
+	assert.Equal(t, int32(6), round.Load())
+	assert.Len(t, failures, 5)
+	assert.Equal(t, "db: down", failures[0])
+}
+
+func Test_HealthTrigger_Timeout(t *testing.T) {
+	t.Parallel()
+
+	trigger := HealthTrigger{
+		Checks: map[string]func(context.Context) error{
+			"stuck": func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		},
+		Interval: time.Millisecond,
+		Failures: 2,
+	}
+
+	reason, err := trigger.Wait(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, ReasonHealth, reason)
+
+	ctx, cancel := context.WithTimeout(context.Background(), ShortDelay)
+	defer cancel()
+	trigger.Checks = map[string]func(context.Context) error{"ok": func(context.Context) error { return nil }}
+	_, err = trigger.Wait(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,442 @@
+package gogs
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// hook is a named cleanup function registered with AddHook.
+type hook struct {
+	// name is the name of the hook used in the report.
+	name string
+
+	// fn is the cleanup function itself.
+	fn func(ctx context.Context) error
+
+	// reasons limits the shutdown reasons the hook is executed for. Empty means all.
+	reasons []Reason
+
+	// bestEffort reports whether the hook is optional and executed after the required
+	// hooks only if estimate fits into the remaining budget.
+	bestEffort bool
+	estimate   time.Duration
+
+	// timeout limits the execution of the hook. Zero means no limit beyond the deadline of
+	// the shutdown.
+	timeout time.Duration
+
+	// category is the category whose quota the hook is charged to, see WithCategory.
+	category string
+
+	// final reports whether the hook is executed after all other hooks.
+	final bool
+
+	// condition reports whether the hook is executed. Nil means always.
+	condition func() bool
+
+	// module is the module the hook belongs to, see WithModule.
+	module string
+
+	// procs is the GOMAXPROCS the hook is executed with, see WithParallelism. Zero means
+	// unchanged.
+	procs int
+
+	// early reports whether the hook starts along with the drain, see WithEarlyStart.
+	early bool
+
+	// scope is the child the hook shuts down, if created by Scope, see Topology.
+	scope *GracefulShutdown
+}
+
+// HookOption is a function that configures a hook registered with AddHook.
+type HookOption func(h *hook)
+
+// WithReasons is a hook option that limits the execution of the hook to the shutdowns
+// triggered for one of the provided reasons.
+//
+//	gs.AddHook("cache-dump", dumpCache, WithReasons(ReasonSignal))
+//
+// This example dumps the cache on termination signals only, skipping it when the parent
+// context is canceled.
+func WithReasons(reasons ...Reason) HookOption {
+	return func(h *hook) {
+		h.reasons = reasons
+	}
+}
+
+// WithBestEffort is a hook option that makes the hook optional. Best-effort hooks are
+// executed after all required hooks, and only if the remaining budget is at least the
+// provided estimate of their duration. Otherwise they are skipped and reported as such.
+//
+//	gs.AddHook("warm-state", saveWarmState, WithBestEffort(time.Second))
+//
+// This example saves the warm state of the cache only if at least one second is left
+// once the required hooks have completed.
+func WithBestEffort(estimate time.Duration) HookOption {
+	return func(h *hook) {
+		h.bestEffort = true
+		h.estimate = estimate
+	}
+}
+
+// WithFinal is a hook option that executes the hook after all other hooks, including the
+// best-effort ones, whatever the order of registration. It suits the components observing
+// the shutdown itself, e.g. the metrics or the logs.
+func WithFinal() HookOption {
+	return func(h *hook) {
+		h.final = true
+	}
+}
+
+// WithCondition is a hook option that evaluates the provided predicate when the hook is
+// about to be executed, and skips the hook if it returns false. Skipped hooks are reported
+// as such. It allows registering the teardown of optional subsystems, e.g. the ones behind
+// feature flags, unconditionally.
+//
+//	gs.AddHook("recommendations", closeRecommender, WithCondition(flags.RecommenderEnabled))
+//
+// This example closes the recommender only if it is enabled when the shutdown starts.
+func WithCondition(condition func() bool) HookOption {
+	return func(h *hook) {
+		h.condition = condition
+	}
+}
+
+// WithTimeout is a hook option that limits the execution of the hook, within the deadline
+// of the shutdown. A hook that does not complete in time is abandoned and the next one is
+// executed.
+//
+//	gs.AddHook("kafka", flushProducer, WithTimeout(5*time.Second))
+//
+// This example gives up flushing the producer after five seconds.
+func WithTimeout(timeout time.Duration) HookOption {
+	return func(h *hook) {
+		h.timeout = timeout
+	}
+}
+
+// fits is a method of the hook struct. It reports whether the estimate of the hook fits
+// into the budget left until the deadline of the context.
+func (h hook) fits(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	deadline, ok := ctx.Deadline()
+	return !ok || time.Until(deadline) >= h.estimate
+}
+
+// runsFor is a method of the hook struct. It reports whether the hook is executed for the
+// provided shutdown reason.
+func (h hook) runsFor(reason Reason) bool {
+	if len(h.reasons) == 0 {
+		return true
+	}
+
+	for _, r := range h.reasons {
+		if r == reason {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HookResult is a struct that describes the outcome of a single executed hook.
+type HookResult struct {
+	// Name is the name of the hook.
+	Name string
+
+	// Err is the error returned by the hook, or the context error if the hook did not
+	// complete in time.
+	Err error
+
+	// Duration is the time spent waiting for the hook.
+	Duration time.Duration
+
+	// Skipped reports whether the hook was skipped, because its condition was not met or,
+	// for a best-effort hook, for lack of budget.
+	Skipped bool
+
+	// Module is the module the hook belongs to, if any. The hooks of disabled modules are
+	// skipped, see SetModuleEnabled.
+	Module string
+
+	// Persisted is the file the hook persisted the data it could not flush to, see
+	// AddOutbox.
+	Persisted string
+
+	// Stats is the work the hook recorded, see HookContext.Record.
+	Stats HookStats
+
+	// Procs is the GOMAXPROCS the hook was executed with, and CPUTime the CPU time the
+	// process consumed meanwhile, if the hook was configured with WithParallelism.
+	Procs   int
+	CPUTime time.Duration
+}
+
+// Report is a struct that describes the outcome of the executed hooks and the schedule of
+// the phases. Hooks are listed in order of execution.
+type Report struct {
+	// Reason is what triggered the shutdown.
+	Reason Reason
+
+	// Hooks is the list of executed hooks.
+	Hooks []HookResult
+
+	// Schedule is the list of budgets of the phases limited by a timeout, in order of
+	// execution.
+	Schedule []PhaseBudget
+
+	// Drain lists the active shutdown events the drain gave up on at its deadline, if any.
+	Drain DrainResult
+
+	// Intake is the list of executed functions stopping the intake, see AddIntake.
+	Intake []HookResult
+
+	// Deregistered is the list of entries removed from service registries, see
+	// AddRegistrar.
+	Deregistered []HookResult
+
+	// Env is the snapshot of the environment when the shutdown started, if configured with
+	// WithEnvSnapshot.
+	Env *EnvSnapshot
+
+	// Leaks is the list of blocking calls abandoned at the deadline that have not returned
+	// yet, see Blocking.
+	Leaks []Leak
+}
+
+// Err is a method of the Report struct. It returns an error combining the errors of all
+// failed intake stops, deregistrations and hooks, or nil if all of them succeeded.
+func (r Report) Err() error {
+	var errs multiError
+	for _, results := range [][]HookResult{r.Intake, r.Deregistered, r.Hooks} {
+		for _, res := range results {
+			if res.Err != nil {
+				errs = append(errs, ErrHookFailed{Name: res.Name, Err: res.Err})
+			}
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+// abandoned is a method of the Report struct. It reports whether the shutdown abandoned
+// anything that may still keep the process alive, i.e. active shutdown events, intake
+// stops, deregistrations or hooks that did not complete in time, or blocking calls.
+func (r Report) abandoned() bool {
+	if r.Drain.Remaining > 0 || len(r.Leaks) > 0 {
+		return true
+	}
+	for _, results := range [][]HookResult{r.Intake, r.Deregistered, r.Hooks} {
+		for _, res := range results {
+			if errors.Is(res.Err, context.DeadlineExceeded) || errors.Is(res.Err, context.Canceled) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// AddHook is a method of the GracefulShutdown struct. It registers a named cleanup
+// function that is executed once all active shutdown events have completed. Hooks are
+// executed in reverse order of registration, like deferred calls. A hook registered once
+// the hooks are being executed is not executed, see TryAddHook, and one registered with
+// WithEarlyStart once the early hooks have started is executed with the others. Each hook
+// is executed at most once, however many ways the shutdown is triggered and however many
+// times Wait is called, concurrently or not, so the hooks need no sync.Once of their own.
+// The calls of Wait made while the hooks are executed return once they have completed.
+func (gs *GracefulShutdown) AddHook(
+	name string,
+	hookFn func(ctx context.Context) error,
+	opts ...HookOption,
+) {
+	h := hook{name: name, fn: hookFn}
+	for _, opt := range opts {
+		opt(&h)
+	}
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	gs.appendHook(h)
+}
+
+// appendHook is a method of the GracefulShutdown struct. It registers the provided hook.
+// A hook registered with WithEarlyStart once the early hooks have started is executed with
+// the other hooks instead, so that it is not left out. It must be called with mu held.
+func (gs *GracefulShutdown) appendHook(h hook) {
+	if h.early && gs.earlyStarted {
+		h.early = false
+	}
+	gs.hooks = append(gs.hooks, h)
+}
+
+// Report is a method of the GracefulShutdown struct. It returns the outcome of the
+// executed hooks and the schedule of the phases. The report is empty until the shutdown
+// has started.
+func (gs *GracefulShutdown) Report() Report {
+	intake := gs.intakeResults()
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	report := gs.report
+	report.Intake = intake
+	for _, l := range gs.leaks {
+		report.Leaks = append(report.Leaks, *l)
+	}
+
+	return report
+}
+
+// runHooks is a method of the GracefulShutdown struct. It executes the hooks registered
+// for the provided reason in reverse order of registration exactly once, the required
+// hooks first, the best-effort ones after them and the final ones last, and waits for the
+// early ones, see WithPipelining. A hook that does not complete before the context is
+// done is abandoned.
+func (gs *GracefulShutdown) runHooks(ctx context.Context, reason Reason) {
+	gs.hooksOnce.Do(func() {
+		gs.startEarlyHooks(ctx, reason)
+
+		gs.mu.Lock()
+		gs.hooksStarted = true
+		hooks := make([]hook, 0, len(gs.hooks))
+		for _, h := range gs.hooks {
+			if !h.early || !gs.cfg.pipelining {
+				hooks = append(hooks, h)
+			}
+		}
+		gs.mu.Unlock()
+
+		selected := selectHooks(hooks, reason)
+
+		q := newQuotas(ctx, gs.cfg.quotas)
+		results := make([]HookResult, 0, len(selected))
+		for i, h := range selected {
+			res := gs.execHook(ctx, q, h, len(selected)-i)
+			res.Module = h.module
+			results = append(results, res)
+		}
+		results = append(gs.earlyHooks(), results...)
+
+		gs.mu.Lock()
+		gs.hook = ""
+		gs.hooksLeft = 0
+		gs.report.Reason = reason
+		gs.report.Hooks = results
+		gs.mu.Unlock()
+	})
+}
+
+// execHook is a method of the GracefulShutdown struct. It executes the provided hook
+// unless it is skipped or its quota is exhausted, with the provided count of hooks left
+// including it.
+func (gs *GracefulShutdown) execHook(ctx context.Context, q quotas, h hook, hooksLeft int) HookResult {
+	if h.timeout <= 0 {
+		h.timeout = gs.cfg.hookTimeouts[h.name]
+	}
+
+	if gs.skipHook(h.name) {
+		return HookResult{Name: h.name, Skipped: true}
+	}
+	if gs.moduleDisabled(h.module) || (h.condition != nil && !h.condition()) || (h.bestEffort && !h.fits(ctx)) {
+		return HookResult{Name: h.name, Skipped: true}
+	}
+
+	var ok bool
+	if h, ok = q.limit(h); !ok {
+		return HookResult{Name: h.name, Err: ErrQuotaExceeded}
+	}
+
+	gs.mu.Lock()
+	gs.hook = h.name
+	gs.hooksLeft = hooksLeft
+	gs.mu.Unlock()
+	gs.debugf("hook %s started, %d hooks left", h.name, hooksLeft)
+	gs.publish()
+
+	ctx, cancel := gs.extendable(ctx)
+	defer cancel()
+
+	h.fn = gs.labeled(h.name, PhaseClose, h.fn)
+	hookCtx := gs.hookContext(ctx, h.name, PhaseClose)
+	res := runParallel(func() HookResult {
+		return runHook(hookCtx, h, gs.spawn)
+	}, h.procs)
+	res.Persisted = persisted(hookCtx)
+	res.Stats = recordedStats(hookCtx)
+	q.spend(h, res.Duration)
+	gs.debugf("hook %s completed in %s, error: %v", h.name, res.Duration, res.Err)
+
+	return res
+}
+
+// selectHooks is a function that returns the hooks registered for the provided reason in
+// order of execution: the required hooks, the best-effort ones and the final ones, each
+// group in reverse order of registration.
+func selectHooks(hooks []hook, reason Reason) []hook {
+	return orderHooks(hooks, func(h hook) bool { return h.runsFor(reason) })
+}
+
+// orderHooks is a function that returns the hooks the provided predicate selects in order
+// of execution, see selectHooks.
+func orderHooks(hooks []hook, selected func(h hook) bool) []hook {
+	var required, optional, final []hook
+	for i := len(hooks) - 1; i >= 0; i-- {
+		switch {
+		case !selected(hooks[i]):
+		case hooks[i].final:
+			final = append(final, hooks[i])
+		case hooks[i].bestEffort:
+			optional = append(optional, hooks[i])
+		default:
+			required = append(required, hooks[i])
+		}
+	}
+
+	ordered := make([]hook, 0, len(required)+len(optional)+len(final))
+	ordered = append(ordered, required...)
+	ordered = append(ordered, optional...)
+
+	return append(ordered, final...)
+}
+
+// runHook is a function that executes a single hook with the provided spawn function and
+// waits for it to complete or for the context to be done, or for the timeout of the hook
+// to elapse.
+func runHook(ctx context.Context, h hook, spawn func(fn func())) HookResult {
+	if err := ctx.Err(); err != nil {
+		return HookResult{Name: h.name, Err: err}
+	}
+
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+
+	spawn(func() {
+		errCh <- h.fn(ctx)
+	})
+
+	res := HookResult{Name: h.name}
+	select {
+	case res.Err = <-errCh:
+	case <-ctx.Done():
+		res.Err = ctx.Err()
+	}
+	res.Duration = time.Since(start)
+
+	return res
+}
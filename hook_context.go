@@ -0,0 +1,172 @@
+package gogs
+
+import (
+	"context"
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+// hookContextKey is the key of the hook metadata in the context passed to the hooks.
+type hookContextKey struct{}
+
+// hookMeta is a struct that holds the metadata of an executed hook.
+type hookMeta struct {
+	name    string
+	phase   Phase
+	attempt int
+	logger  *log.Logger
+
+	// output collects what the hook reports besides its error, see persisted.
+	output *hookOutput
+}
+
+// hookOutput is a struct that holds what a hook reports besides its error. It is guarded
+// by mu, since an abandoned hook may still write it.
+type hookOutput struct {
+	mu        sync.Mutex
+	persisted string
+	stats     HookStats
+}
+
+// HookContext is a struct that describes the execution of a hook. It is the context of
+// the hook, so it can be passed wherever a context.Context is expected.
+type HookContext struct {
+	context.Context
+
+	// Name is the name of the hook.
+	Name string
+
+	// Phase is the phase of the shutdown the hook is executed in.
+	Phase Phase
+
+	// Attempt is the number of the execution of the hook, starting at 1.
+	Attempt int
+
+	// Logger is the logger set with WithLogger, prefixed with the name of the hook. It
+	// discards the output if no logger is set.
+	Logger *log.Logger
+}
+
+// Remaining is a method of the HookContext struct. It returns the time left until the
+// deadline of the hook, or zero if the hook has no deadline or the deadline has passed.
+func (hc HookContext) Remaining() time.Duration {
+	deadline, ok := hc.Deadline()
+	if !ok {
+		return 0
+	}
+
+	if left := time.Until(deadline); left > 0 {
+		return left
+	}
+
+	return 0
+}
+
+// HookContextFrom is a function that returns the HookContext of the hook executing with
+// the provided context. It reports false if the context does not belong to a hook.
+//
+//	func flushCache(ctx context.Context) error {
+//		if hc, ok := HookContextFrom(ctx); ok && hc.Remaining() < time.Second {
+//			hc.Logger.Print("little time left, skipping the warm state")
+//			return cache.Close()
+//		}
+//		return cache.FlushAndClose()
+//	}
+//
+// This example skips the optional work of a hook registered with AddHook when little time
+// remains.
+func HookContextFrom(ctx context.Context) (HookContext, bool) {
+	meta, ok := ctx.Value(hookContextKey{}).(hookMeta)
+	if !ok {
+		return HookContext{}, false
+	}
+
+	return HookContext{
+		Context: ctx,
+		Name:    meta.name,
+		Phase:   meta.phase,
+		Attempt: meta.attempt,
+		Logger:  meta.logger,
+	}, true
+}
+
+// WithLogger is an option that sets the logger passed to the hooks in their HookContext.
+// Each hook receives a copy prefixed with its name.
+func WithLogger(logger *log.Logger) Option {
+	return func(cfg *config) {
+		cfg.logger = logger
+	}
+}
+
+// AddContextHook is a method of the GracefulShutdown struct. It registers a named cleanup
+// function like AddHook, passing it the HookContext describing its execution, so that the
+// hook can adapt its behavior, e.g. skip optional work when little time remains.
+//
+//	gs.AddContextHook("search-index", func(hc HookContext) error {
+//		if hc.Remaining() < 5*time.Second {
+//			return index.Close()
+//		}
+//		return index.CommitAndClose(hc)
+//	})
+//
+// This example commits the index only if at least five seconds remain.
+func (gs *GracefulShutdown) AddContextHook(
+	name string,
+	hookFn func(hc HookContext) error,
+	opts ...HookOption,
+) {
+	gs.AddHook(name, func(ctx context.Context) error {
+		hc, _ := HookContextFrom(ctx)
+		return hookFn(hc)
+	}, opts...)
+}
+
+// hookContext is a method of the GracefulShutdown struct. It returns the provided context
+// carrying the metadata of the provided hook executed in the provided phase.
+func (gs *GracefulShutdown) hookContext(ctx context.Context, name string, phase Phase) context.Context {
+	out := io.Discard
+	flags := log.LstdFlags
+	prefix := ""
+	if gs.cfg.logger != nil {
+		out = gs.cfg.logger.Writer()
+		flags = gs.cfg.logger.Flags()
+		prefix = gs.cfg.logger.Prefix()
+	}
+
+	return context.WithValue(ctx, hookContextKey{}, hookMeta{
+		name:    name,
+		phase:   phase,
+		attempt: 1,
+		logger:  log.New(out, prefix+name+": ", flags),
+		output:  &hookOutput{},
+	})
+}
+
+// setPersisted is a function that records the file the hook executing with the provided
+// context persisted its data to. It does nothing outside of a hook.
+func setPersisted(ctx context.Context, path string) {
+	meta, ok := ctx.Value(hookContextKey{}).(hookMeta)
+	if !ok {
+		return
+	}
+
+	meta.output.mu.Lock()
+	defer meta.output.mu.Unlock()
+	meta.output.persisted = path
+}
+
+// persisted is a function that returns the file the hook executing with the provided
+// context persisted its data to, if any.
+func persisted(ctx context.Context) string {
+	meta, ok := ctx.Value(hookContextKey{}).(hookMeta)
+	if !ok {
+		return ""
+	}
+
+	meta.output.mu.Lock()
+	defer meta.output.mu.Unlock()
+
+	return meta.output.persisted
+}
@@ -0,0 +1,58 @@
+package gogs
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GracefulShutdown_AddContextHook(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	gs, _, _ := New(
+		context.Background(),
+		WithSignals(syscall.SIGINT),
+		WithLogger(log.New(&buf, "app: ", 0)),
+	)
+
+	var got HookContext
+	gs.AddContextHook("postgres", func(hc HookContext) error {
+		got = hc
+		hc.Logger.Print("closing")
+		return nil
+	}, WithTimeout(time.Minute))
+
+	gs.Wait()
+
+	assert.Equal(t, "postgres", got.Name)
+	assert.Equal(t, PhaseClose, got.Phase)
+	assert.Equal(t, 1, got.Attempt)
+	assert.InDelta(t, float64(time.Minute), float64(got.Remaining()), float64(time.Second))
+	assert.Equal(t, "app: postgres: closing\n", buf.String())
+}
+
+func Test_HookContextFrom(t *testing.T) {
+	t.Parallel()
+	gs, _, _ := New(context.Background(), WithSignals(syscall.SIGINT))
+
+	_, ok := HookContextFrom(context.Background())
+	assert.False(t, ok)
+
+	var hc HookContext
+	gs.AddHook("cache", func(ctx context.Context) error {
+		hc, ok = HookContextFrom(ctx)
+		hc.Logger.Print("discarded")
+		return nil
+	})
+
+	gs.Wait()
+
+	assert.True(t, ok)
+	assert.Equal(t, "cache", hc.Name)
+	assert.Zero(t, hc.Remaining())
+}
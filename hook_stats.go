@@ -0,0 +1,72 @@
+package gogs
+
+import "context"
+
+// HookStats is a struct that quantifies the work of a hook, so that the report tells how
+// much data a partially successful hook lost rather than just whether it failed.
+type HookStats struct {
+	// Flushed is the count of items the hook flushed.
+	Flushed int64
+
+	// Dropped is the count of items the hook dropped.
+	Dropped int64
+
+	// Bytes is the count of bytes the hook wrote.
+	Bytes int64
+}
+
+// add is a method of the HookStats struct. It returns the sum of the stats.
+func (s HookStats) add(other HookStats) HookStats {
+	return HookStats{
+		Flushed: s.Flushed + other.Flushed,
+		Dropped: s.Dropped + other.Dropped,
+		Bytes:   s.Bytes + other.Bytes,
+	}
+}
+
+// Record is a method of the HookContext struct. It adds the provided stats to the ones of
+// the hook, reported in HookResult.Stats. It can be called several times, e.g. once per
+// batch, and only what is recorded before the hook completes or is abandoned is reported.
+//
+//	gs.AddContextHook("events", func(hc HookContext) error {
+//		flushed, err := buffer.Flush(hc)
+//		hc.Record(HookStats{Flushed: int64(flushed), Dropped: int64(buffer.Len())})
+//		return err
+//	})
+//
+// This example reports how many buffered events were flushed and how many were lost.
+func (hc HookContext) Record(stats HookStats) {
+	meta, ok := hc.Value(hookContextKey{}).(hookMeta)
+	if !ok {
+		return
+	}
+
+	meta.output.mu.Lock()
+	defer meta.output.mu.Unlock()
+	meta.output.stats = meta.output.stats.add(stats)
+}
+
+// recordedStats is a function that returns the stats recorded by the hook executing with
+// the provided context, if any.
+func recordedStats(ctx context.Context) HookStats {
+	meta, ok := ctx.Value(hookContextKey{}).(hookMeta)
+	if !ok {
+		return HookStats{}
+	}
+
+	meta.output.mu.Lock()
+	defer meta.output.mu.Unlock()
+
+	return meta.output.stats
+}
+
+// Stats is a method of the Report struct. It returns the sum of the stats recorded by the
+// executed hooks, see HookContext.Record.
+func (r Report) Stats() HookStats {
+	var stats HookStats
+	for _, res := range r.Hooks {
+		stats = stats.add(res.Stats)
+	}
+
+	return stats
+}
@@ -0,0 +1,39 @@
+package gogs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HookContext_Record(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background())
+
+	gs.AddContextHook("events", func(hc HookContext) error {
+		hc.Record(HookStats{Flushed: 10, Bytes: 512})
+		hc.Record(HookStats{Flushed: 5, Dropped: 2, Bytes: 256})
+		return errors.New("partially flushed")
+	})
+	gs.AddHook("metrics", func(ctx context.Context) error {
+		hc, _ := HookContextFrom(ctx)
+		hc.Record(HookStats{Flushed: 1})
+		return nil
+	})
+	gs.AddHook("cache", func(context.Context) error { return nil })
+
+	HookContext{Context: context.Background()}.Record(HookStats{Dropped: 1})
+
+	cancel()
+	gs.Wait()
+
+	report := gs.Report()
+	if assert.Len(t, report.Hooks, 3) {
+		assert.Equal(t, HookStats{}, report.Hooks[0].Stats)
+		assert.Equal(t, HookStats{Flushed: 1}, report.Hooks[1].Stats)
+		assert.Equal(t, HookStats{Flushed: 15, Dropped: 2, Bytes: 768}, report.Hooks[2].Stats)
+	}
+	assert.Equal(t, HookStats{Flushed: 16, Dropped: 2, Bytes: 768}, report.Stats())
+}
@@ -0,0 +1,184 @@
+package gogs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GracefulShutdown_AddHook(t *testing.T) {
+	t.Parallel()
+	gs, _, _ := New(context.Background(), WithSignals(syscall.SIGINT))
+
+	var order []string
+	errFailed := errors.New("failed")
+	gs.AddHook("first", func(context.Context) error {
+		order = append(order, "first")
+		return nil
+	})
+	gs.AddHook("second", func(context.Context) error {
+		order = append(order, "second")
+		return errFailed
+	})
+
+	gs.Subscribe()
+	go func() {
+		shortDelay()
+		gs.Unsubscribe()
+	}()
+	gs.Wait()
+
+	assert.Equal(t, []string{"second", "first"}, order)
+
+	report := gs.Report()
+	assert.Len(t, report.Hooks, 2)
+	assert.Equal(t, "second", report.Hooks[0].Name)
+	assert.ErrorIs(t, report.Hooks[0].Err, errFailed)
+	assert.NoError(t, report.Hooks[1].Err)
+	assert.EqualError(t, report.Err(), "second: failed")
+	assert.NotErrorIs(t, report.Err(), ErrTimedOut)
+
+	var failed ErrHookFailed
+	if assert.ErrorAs(t, report.Err(), &failed) {
+		assert.Equal(t, "second", failed.Name)
+		assert.Equal(t, errFailed, failed.Err)
+	}
+
+	gs.Wait()
+	assert.Equal(t, []string{"second", "first"}, order)
+}
+
+func Test_GracefulShutdown_AddHook_Once(t *testing.T) {
+	t.Parallel()
+	parentCtx, cancelParent := context.WithCancel(context.Background())
+	gs, _, cancel := New(parentCtx)
+
+	var calls atomic.Int32
+	gs.AddHook("db", func(context.Context) error {
+		calls.Add(1)
+		time.Sleep(ShortDelay)
+		return nil
+	})
+
+	cancel()
+	cancelParent()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			gs.Wait()
+			assert.Equal(t, int32(1), calls.Load())
+		}()
+	}
+	wg.Wait()
+
+	gs.WaitWithTimeout(ShortDelay)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Len(t, gs.Report().Hooks, 1)
+}
+
+func Test_GracefulShutdown_AddHook_Timeout(t *testing.T) {
+	t.Parallel()
+	gs, _, _ := New(context.Background(), WithSignals(syscall.SIGINT))
+
+	gs.AddHook("slow", func(context.Context) error {
+		longDelay()
+		return nil
+	})
+
+	gs.WaitWithTimeout(ShortDelay)
+
+	report := gs.Report()
+	assert.Len(t, report.Hooks, 1)
+	assert.ErrorIs(t, report.Hooks[0].Err, context.DeadlineExceeded)
+	assert.Less(t, report.Hooks[0].Duration, LongDelay)
+}
+
+func Test_GracefulShutdown_WithBestEffort(t *testing.T) {
+	t.Parallel()
+	gs, _, _ := New(context.Background(), WithSignals(syscall.SIGINT))
+
+	var order []string
+	gs.AddHook("optional", func(context.Context) error {
+		order = append(order, "optional")
+		return nil
+	}, WithBestEffort(ShortDelay))
+	gs.AddHook("expensive", func(context.Context) error {
+		order = append(order, "expensive")
+		return nil
+	}, WithBestEffort(time.Minute))
+	gs.AddHook("required", func(context.Context) error {
+		order = append(order, "required")
+		return nil
+	})
+
+	gs.WaitWithTimeout(LongDelay)
+	assert.Equal(t, []string{"required", "optional"}, order)
+
+	report := gs.Report()
+	assert.Len(t, report.Hooks, 3)
+	assert.Equal(t, "expensive", report.Hooks[1].Name)
+	assert.True(t, report.Hooks[1].Skipped)
+	assert.False(t, report.Hooks[2].Skipped)
+	assert.NoError(t, report.Err())
+}
+
+func Test_GracefulShutdown_WithTimeout(t *testing.T) {
+	t.Parallel()
+	gs, _, _ := New(context.Background(), WithHookTimeout("configured", ShortDelay))
+
+	var executed bool
+	gs.AddHook("last", func(context.Context) error {
+		executed = true
+		return nil
+	})
+	gs.AddHook("configured", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	gs.AddHook("own", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithTimeout(ShortDelay))
+
+	gs.WaitWithTimeout(LongDelay)
+
+	report := gs.Report()
+	assert.Len(t, report.Hooks, 3)
+	assert.ErrorIs(t, report.Hooks[0].Err, context.DeadlineExceeded)
+	assert.ErrorIs(t, report.Hooks[1].Err, context.DeadlineExceeded)
+	assert.NoError(t, report.Hooks[2].Err)
+	assert.True(t, executed)
+}
+
+func Test_GracefulShutdown_WithCondition(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background())
+	defer cancel()
+
+	var executed []string
+	for _, name := range []string{"enabled", "disabled"} {
+		name := name
+		gs.AddHook(name, func(context.Context) error {
+			executed = append(executed, name)
+			return nil
+		}, WithCondition(func() bool { return name == "enabled" }))
+	}
+
+	gs.Wait()
+	assert.Equal(t, []string{"enabled"}, executed)
+
+	report := gs.Report()
+	if assert.Len(t, report.Hooks, 2) {
+		assert.True(t, report.Hooks[0].Skipped)
+		assert.False(t, report.Hooks[1].Skipped)
+	}
+}
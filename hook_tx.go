@@ -0,0 +1,113 @@
+package gogs
+
+import (
+	"context"
+	"sync"
+)
+
+// HookTx is a struct that stages the hooks of a module to swap them atomically with the
+// registered ones, see BeginHooks.
+type HookTx struct {
+	// gs is the GracefulShutdown the hooks are registered with.
+	gs *GracefulShutdown
+
+	// module is the module whose hooks are swapped.
+	module string
+
+	// mu guards the fields below.
+	mu sync.Mutex
+
+	// hooks is the list of staged hooks, in order of registration.
+	hooks []hook
+
+	// done reports whether the transaction is committed or rolled back.
+	done bool
+}
+
+// BeginHooks is a method of the GracefulShutdown struct. It starts a transaction replacing
+// the hooks registered under the provided module, see WithModule, e.g. when a config
+// reload rebuilds the components of the module. The hooks staged with HookTx.AddHook are
+// swapped with the registered ones at once by HookTx.Commit, so that a shutdown arriving
+// mid-reload executes either the old hooks or the new ones, never a mix of both.
+//
+//	tx := gs.BeginHooks("storage")
+//	db, err := openDB(cfg)
+//	if err != nil {
+//		tx.Rollback()
+//		return err
+//	}
+//	tx.AddHook("db", func(context.Context) error { return db.Close() })
+//	return tx.Commit()
+//
+// This example swaps the hook closing the database for the one closing the reopened one.
+func (gs *GracefulShutdown) BeginHooks(module string) *HookTx {
+	return &HookTx{gs: gs, module: module}
+}
+
+// AddHook is a method of the HookTx struct. It stages a hook like
+// GracefulShutdown.AddHook, registered under the module of the transaction on commit.
+func (tx *HookTx) AddHook(name string, hookFn func(ctx context.Context) error, opts ...HookOption) {
+	h := hook{name: name, fn: hookFn}
+	for _, opt := range opts {
+		opt(&h)
+	}
+	h.module = tx.module
+
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	tx.hooks = append(tx.hooks, h)
+}
+
+// Commit is a method of the HookTx struct. It replaces the hooks registered under the
+// module with the staged ones, at the position of the first replaced hook in the order of
+// registration, or after all the others if there is none. It returns ErrShuttingDown if
+// the hooks of the shutdown are already selected, including the early ones of a pipelined
+// shutdown once the drain has started, in which case the registered hooks are kept, or
+// ErrTxDone if the transaction is already done.
+func (tx *HookTx) Commit() error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+
+	gs := tx.gs
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if gs.hooksPicked() || gs.phase == PhaseClose || gs.phase == PhaseDone {
+		return ErrShuttingDown
+	}
+
+	hooks := make([]hook, 0, len(gs.hooks)+len(tx.hooks))
+	inserted := false
+	for _, h := range gs.hooks {
+		if h.module != tx.module {
+			hooks = append(hooks, h)
+			continue
+		}
+		if !inserted {
+			hooks = append(hooks, tx.hooks...)
+			inserted = true
+		}
+	}
+	if !inserted {
+		hooks = append(hooks, tx.hooks...)
+	}
+	gs.hooks = hooks
+
+	return nil
+}
+
+// Rollback is a method of the HookTx struct. It discards the staged hooks, keeping the
+// registered ones. It does nothing if the transaction is already done.
+func (tx *HookTx) Rollback() {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	tx.done = true
+	tx.hooks = nil
+}
@@ -0,0 +1,113 @@
+package gogs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GracefulShutdown_BeginHooks(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background())
+
+	var executed []string
+	record := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			executed = append(executed, name)
+			return nil
+		}
+	}
+
+	gs.AddHook("logs", record("logs"))
+	gs.AddHook("db-v1", record("db-v1"), WithModule("storage"))
+	gs.AddHook("cache-v1", record("cache-v1"), WithModule("storage"))
+	gs.AddHook("http", record("http"))
+
+	tx := gs.BeginHooks("storage")
+	tx.AddHook("db-v2", record("db-v2"))
+	tx.AddHook("cache-v2", record("cache-v2"))
+	assert.Len(t, gs.Plan(ReasonCancel).Hooks, 4)
+	assert.NoError(t, tx.Commit())
+	assert.ErrorIs(t, tx.Commit(), ErrTxDone)
+
+	rolledBack := gs.BeginHooks("storage")
+	rolledBack.AddHook("db-v3", record("db-v3"))
+	rolledBack.Rollback()
+	assert.ErrorIs(t, rolledBack.Commit(), ErrTxDone)
+
+	metrics := gs.BeginHooks("metrics")
+	metrics.AddHook("statsd", record("statsd"))
+	assert.NoError(t, metrics.Commit())
+
+	cancel()
+	gs.Wait()
+
+	assert.Equal(t, []string{"statsd", "http", "cache-v2", "db-v2", "logs"}, executed)
+	report := gs.Report()
+	if assert.Len(t, report.Hooks, 5) {
+		assert.Equal(t, "storage", report.Hooks[2].Module)
+	}
+
+	late := gs.BeginHooks("storage")
+	late.AddHook("db-v4", record("db-v4"))
+	assert.ErrorIs(t, late.Commit(), ErrShuttingDown)
+}
+
+func Test_HookTx_CommitDuringDrain(t *testing.T) {
+	t.Parallel()
+
+	for name, pipelining := range map[string]bool{"pipelined": true, "sequential": false} {
+		pipelining := pipelining
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			opts := []Option{}
+			if pipelining {
+				opts = append(opts, WithPipelining())
+			}
+			gs, _, cancel := New(context.Background(), opts...)
+
+			executed := make(chan string, 4)
+			record := func(name string) func(context.Context) error {
+				return func(context.Context) error {
+					executed <- name
+					return nil
+				}
+			}
+			gs.AddHook("flush-v1", record("flush-v1"), WithModule("storage"), WithEarlyStart())
+			gs.AddHook("db-v1", record("db-v1"), WithModule("storage"))
+
+			gs.Subscribe()
+			cancel()
+			doneCh := make(chan struct{})
+			go func() {
+				gs.Wait()
+				close(doneCh)
+			}()
+			<-gs.DrainStarted()
+			var names []string
+			if pipelining {
+				names = append(names, <-executed)
+			}
+
+			tx := gs.BeginHooks("storage")
+			tx.AddHook("flush-v2", record("flush-v2"), WithEarlyStart())
+			tx.AddHook("db-v2", record("db-v2"))
+			err := tx.Commit()
+			gs.Unsubscribe()
+			<-doneCh
+			close(executed)
+
+			for name := range executed {
+				names = append(names, name)
+			}
+			if pipelining {
+				assert.ErrorIs(t, err, ErrShuttingDown)
+				assert.Equal(t, []string{"flush-v1", "db-v1"}, names)
+			} else {
+				assert.NoError(t, err)
+				assert.ElementsMatch(t, []string{"flush-v2", "db-v2"}, names)
+			}
+		})
+	}
+}
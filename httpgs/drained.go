@@ -0,0 +1,109 @@
+package httpgs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+const (
+	// defaultDrainedTimeout is the time DrainedHandler waits for when the request sets no
+	// timeout.
+	defaultDrainedTimeout = 30 * time.Second
+
+	// drainedPollInterval is the interval at which DrainedHandler checks the progress of
+	// the shutdown.
+	drainedPollInterval = 50 * time.Millisecond
+)
+
+// DrainedStatus is a struct that describes the response of DrainedHandler.
+type DrainedStatus struct {
+	// Drained reports whether the drain has completed.
+	Drained bool `json:"drained"`
+
+	// Phase is the current step of the shutdown, empty until it has started.
+	Phase gogs.Phase `json:"phase"`
+
+	// Pending is the count of active shutdown events.
+	Pending int32 `json:"pending"`
+
+	// Elapsed is the time elapsed since the shutdown started.
+	Elapsed string `json:"elapsed"`
+}
+
+// DrainedHandler is a function that returns a long-poll handler blocking until the drain
+// of the shutdown has completed, i.e. until the hooks are executed, so that deployment
+// tooling can wait for the instance to be drained after sending SIGTERM rather than sleep
+// for a fixed interval. It responds with 200 OK once drained, or with 504 Gateway Timeout
+// once the timeout set by the "timeout" query parameter, thirty seconds by default, has
+// elapsed first, along with a DrainedStatus in JSON. It must be served by a server that
+// outlives the drain, e.g. an admin server closed with gogs.WithFinal.
+//
+//	admin.Handle("/drained", DrainedHandler(gs))
+//
+//	kill -TERM $PID && curl -f "http://$HOST:9090/drained?timeout=2m"
+//
+// This example lets a deployment script wait up to two minutes for the instance to drain.
+func DrainedHandler(gs gogs.Reporter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout := defaultDrainedTimeout
+		if v := r.URL.Query().Get("timeout"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d < 0 {
+				http.Error(w, "invalid timeout", http.StatusBadRequest)
+				return
+			}
+			timeout = d
+		}
+
+		status, ok := waitDrained(r.Context(), gs, timeout)
+		if !ok {
+			return
+		}
+
+		code := http.StatusOK
+		if !status.Drained {
+			code = http.StatusGatewayTimeout
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(status)
+	})
+}
+
+// waitDrained is a function that waits for the drain of the shutdown to complete or the
+// timeout to elapse, and returns the last status. It reports false if the context is done
+// first.
+func waitDrained(ctx context.Context, gs gogs.Reporter, timeout time.Duration) (DrainedStatus, bool) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	ticker := time.NewTicker(drainedPollInterval)
+	defer ticker.Stop()
+
+	status := drainedStatus(gs.Snapshot())
+	for !status.Drained {
+		select {
+		case <-ticker.C:
+			status = drainedStatus(gs.Snapshot())
+		case <-timer.C:
+			return status, true
+		case <-ctx.Done():
+			return status, false
+		}
+	}
+
+	return status, true
+}
+
+// drainedStatus is a function that returns the DrainedStatus of the provided snapshot.
+func drainedStatus(s gogs.Snapshot) DrainedStatus {
+	return DrainedStatus{
+		Drained: s.Phase == gogs.PhaseClose || s.Phase == gogs.PhaseDone,
+		Phase:   s.Phase,
+		Pending: s.Pending,
+		Elapsed: s.Elapsed.Round(time.Millisecond).String(),
+	}
+}
@@ -0,0 +1,63 @@
+package httpgs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+func Test_DrainedHandler(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := gogs.New(context.Background())
+	srv := httptest.NewServer(DrainedHandler(gs))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "?timeout=10ms")
+	if assert.NoError(t, err) {
+		var status DrainedStatus
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusGatewayTimeout, resp.StatusCode)
+		assert.False(t, status.Drained)
+	}
+
+	resp, err = http.Get(srv.URL + "?timeout=soon")
+	if assert.NoError(t, err) {
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	}
+
+	gs.Subscribe()
+	respCh := make(chan *http.Response, 1)
+	go func() {
+		resp, err := http.Get(srv.URL)
+		assert.NoError(t, err)
+		respCh <- resp
+	}()
+	cancel()
+	go gs.Wait()
+
+	shortDelay()
+	select {
+	case <-respCh:
+		assert.Fail(t, "responded before the drain completed")
+	default:
+	}
+	gs.Unsubscribe()
+
+	resp = <-respCh
+	if resp != nil {
+		var status DrainedStatus
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.True(t, status.Drained)
+		assert.Contains(t, []gogs.Phase{gogs.PhaseClose, gogs.PhaseDone}, status.Phase)
+	}
+}
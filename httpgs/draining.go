@@ -0,0 +1,98 @@
+package httpgs
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+// DrainingHeader is the header set to "true" on the responses written once the drain of
+// the shutdown has started, see MarkDraining.
+const DrainingHeader = "Draining"
+
+// MarkDraining is a function that wraps the provided handler so that the responses
+// written once the drain of the shutdown has started carry the "Draining: true" header,
+// including the responses of the requests received before. Smart clients and proxies then
+// send their hedged requests and retries to other instances rather than queue them behind
+// the drain. The wrapped writer keeps supporting http.Flusher and http.Hijacker.
+//
+//	srv := &http.Server{Handler: MarkDraining(gs, router)}
+//
+// This example marks the responses of the router during the drain.
+func MarkDraining(gs *gogs.GracefulShutdown, next http.Handler) http.Handler {
+	drainStarted := gs.DrainStarted()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&drainingWriter{ResponseWriter: w, drainStarted: drainStarted}, r)
+	})
+}
+
+// drainingWriter is a struct that sets the draining header before the header of the
+// response is written, if the drain has started.
+type drainingWriter struct {
+	http.ResponseWriter
+
+	// drainStarted is closed once the drain starts.
+	drainStarted <-chan struct{}
+
+	// wroteHeader reports whether the header of the response has been written.
+	wroteHeader bool
+}
+
+// WriteHeader is a method of the drainingWriter struct. It marks the response and writes
+// its header.
+func (w *drainingWriter) WriteHeader(code int) {
+	w.mark()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write is a method of the drainingWriter struct. It marks the response and writes its
+// body.
+func (w *drainingWriter) Write(p []byte) (int, error) {
+	w.mark()
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush is a method of the drainingWriter struct. It marks the response and flushes it,
+// if the underlying writer supports it.
+func (w *drainingWriter) Flush() {
+	w.mark()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack is a method of the drainingWriter struct. It hijacks the connection, if the
+// underlying writer supports it.
+func (w *drainingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("httpgs: hijacking not supported")
+	}
+
+	return h.Hijack()
+}
+
+// Unwrap is a method of the drainingWriter struct. It returns the underlying writer, for
+// http.ResponseController.
+func (w *drainingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// mark is a method of the drainingWriter struct. It sets the draining header if the drain
+// has started and the header of the response has not been written yet.
+func (w *drainingWriter) mark() {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	select {
+	case <-w.drainStarted:
+		w.Header().Set(DrainingHeader, "true")
+	default:
+	}
+}
@@ -0,0 +1,55 @@
+package httpgs
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+func Test_MarkDraining(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := gogs.New(context.Background())
+
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	handler := MarkDraining(gs, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			started <- struct{}{}
+			<-release
+		}
+		_, _ = io.WriteString(w, "ok")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Empty(t, rec.Header().Get(DrainingHeader))
+
+	slow := httptest.NewRecorder()
+	slowDone := make(chan struct{})
+	go func() {
+		defer close(slowDone)
+		handler.ServeHTTP(slow, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	}()
+	<-started
+
+	gs.Subscribe()
+	cancel()
+	go gs.Wait()
+	<-gs.DrainStarted()
+
+	close(release)
+	<-slowDone
+	assert.Equal(t, "true", slow.Header().Get(DrainingHeader))
+	assert.Equal(t, "ok", slow.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "true", rec.Header().Get(DrainingHeader))
+
+	gs.Unsubscribe()
+}
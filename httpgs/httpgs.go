@@ -0,0 +1,283 @@
+// Package httpgs provides adapters draining HTTP/1, h2c and HTTP/3 servers gracefully.
+package httpgs
+
+import (
+	"context"
+	"crypto/tls"
+	"math"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxPollInterval is the maximum interval between two checks of the hijacked connections
+// in ShutdownH2C.
+const maxPollInterval = 500 * time.Millisecond
+
+// ConnReaper is a struct that tracks the connections of an http.Server and closes the
+// idle ones as soon as shutdown starts. Without it http.Server.Shutdown may be stuck
+// waiting on keep-alive connections that will never send another request.
+type ConnReaper struct {
+	// srv is the server whose connections are tracked.
+	srv *http.Server
+
+	// mu guards conns.
+	mu sync.Mutex
+
+	// conns holds the last known state of every open connection of the server, keyed by
+	// the connection accepted from the listener.
+	conns map[net.Conn]trackedConn
+}
+
+// trackedConn is a struct that holds a connection passed to the ConnState callback and
+// its last known state.
+type trackedConn struct {
+	conn  net.Conn
+	state http.ConnState
+}
+
+// NewConnReaper is a function that creates a new ConnReaper for the provided server. It
+// installs a ConnState callback on the server, keeping the previously configured one, so
+// it must be called before the server starts serving.
+//
+//	reaper := NewConnReaper(srv)
+//	go srv.ListenAndServe()
+//	<-ctx.Done()
+//	err := reaper.Shutdown(shutdownCtx)
+//
+// This example closes idle keep-alive connections at shutdown start and then drains the
+// server using http.Server.Shutdown.
+func NewConnReaper(srv *http.Server) *ConnReaper {
+	r := &ConnReaper{
+		srv:   srv,
+		conns: make(map[net.Conn]trackedConn),
+	}
+
+	prev := srv.ConnState
+	srv.ConnState = func(conn net.Conn, state http.ConnState) {
+		r.track(conn, state)
+		if prev != nil {
+			prev(conn, state)
+		}
+	}
+
+	return r
+}
+
+// Listener is a method of the ConnReaper struct. It wraps the provided listener so that
+// the reaper notices when hijacked connections are closed by their owners. Without it
+// hijacked connections are tracked until they are closed by CloseHijacked.
+//
+//	err := srv.Serve(reaper.Listener(ln))
+//
+// This example serves connections accepted from the wrapped listener.
+func (r *ConnReaper) Listener(ln net.Listener) net.Listener {
+	return &reaperListener{Listener: ln, reaper: r}
+}
+
+// Idle is a method of the ConnReaper struct. It returns the current count of idle
+// connections.
+func (r *ConnReaper) Idle() int {
+	return r.count(http.StateIdle)
+}
+
+// Hijacked is a method of the ConnReaper struct. It returns the count of connections
+// hijacked from the server, e.g. by websocket or h2c handlers. Such connections are no
+// longer managed by http.Server.Shutdown and have to be closed by their owners.
+func (r *ConnReaper) Hijacked() int {
+	return r.count(http.StateHijacked)
+}
+
+// Reap is a method of the ConnReaper struct. It disables keep-alives on the server and
+// closes all idle connections immediately. It returns the count of closed connections.
+func (r *ConnReaper) Reap() int {
+	r.srv.SetKeepAlivesEnabled(false)
+	return r.closeAll(http.StateIdle)
+}
+
+// CloseHijacked is a method of the ConnReaper struct. It closes all hijacked connections
+// immediately. It returns the count of closed connections.
+func (r *ConnReaper) CloseHijacked() int {
+	return r.closeAll(http.StateHijacked)
+}
+
+// Shutdown is a method of the ConnReaper struct. It reaps idle connections and then
+// gracefully shuts down the server using http.Server.Shutdown with the provided context.
+func (r *ConnReaper) Shutdown(ctx context.Context) error {
+	r.Reap()
+	return r.srv.Shutdown(ctx)
+}
+
+// count is a method of the ConnReaper struct. It returns the count of connections in the
+// provided state.
+func (r *ConnReaper) count(state http.ConnState) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var count int
+	for _, tc := range r.conns {
+		if tc.state == state {
+			count++
+		}
+	}
+
+	return count
+}
+
+// closeAll is a method of the ConnReaper struct. It closes all connections in the
+// provided state and returns their count.
+func (r *ConnReaper) closeAll(state http.ConnState) int {
+	r.mu.Lock()
+	var conns []net.Conn
+	for key, tc := range r.conns {
+		if tc.state == state {
+			conns = append(conns, tc.conn)
+			delete(r.conns, key)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, conn := range conns {
+		_ = conn.Close()
+	}
+
+	return len(conns)
+}
+
+// track is a method of the ConnReaper struct. It records the new state of the provided
+// connection.
+func (r *ConnReaper) track(conn net.Conn, state http.ConnState) {
+	key := conn
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		key = tlsConn.NetConn()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if state == http.StateClosed {
+		delete(r.conns, key)
+		return
+	}
+	r.conns[key] = trackedConn{conn: conn, state: state}
+}
+
+// forget is a method of the ConnReaper struct. It stops tracking the provided connection
+// accepted from the listener.
+func (r *ConnReaper) forget(conn net.Conn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.conns, conn)
+}
+
+// reaperListener is a listener that wraps the accepted connections so that the reaper
+// notices when they are closed.
+type reaperListener struct {
+	net.Listener
+	reaper *ConnReaper
+}
+
+// Accept is a method of the reaperListener struct. It waits for and returns the next
+// wrapped connection.
+func (l *reaperListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	return &reaperConn{Conn: conn, reaper: l.reaper}, nil
+}
+
+// reaperConn is a connection that notifies the reaper when it is closed.
+type reaperConn struct {
+	net.Conn
+	reaper *ConnReaper
+}
+
+// Close is a method of the reaperConn struct. It closes the connection and stops tracking
+// it.
+func (c *reaperConn) Close() error {
+	c.reaper.forget(c)
+	return c.Conn.Close()
+}
+
+// ShutdownH2C is a function that gracefully shuts down a server serving h2c, i.e.
+// cleartext HTTP/2. The h2c handler hijacks the connections, so http.Server.Shutdown
+// alone does not wait for them. The function shuts the server down with the reaper and
+// then waits for the hijacked connections to be closed. If the context is done before,
+// the remaining hijacked connections are closed and the context error is returned.
+//
+// The server must serve the connections from the listener wrapped by the reaper, and
+// should be configured with http2.ConfigureServer, so that h2c connections receive a
+// GOAWAY frame when the shutdown starts.
+//
+//	err := ShutdownH2C(ctx, reaper)
+//
+// This example drains both the HTTP/1 and the h2c connections of the server.
+func ShutdownH2C(ctx context.Context, reaper *ConnReaper) error {
+	if err := reaper.Shutdown(ctx); err != nil {
+		reaper.CloseHijacked()
+		return err
+	}
+
+	interval := time.Millisecond
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	for reaper.Hijacked() > 0 {
+		select {
+		case <-ctx.Done():
+			reaper.CloseHijacked()
+			return ctx.Err()
+		case <-timer.C:
+			if interval *= 2; interval > maxPollInterval {
+				interval = maxPollInterval
+			}
+			timer.Reset(interval)
+		}
+	}
+
+	return nil
+}
+
+// HTTP3Server is an interface that describes the methods of *http3.Server from quic-go
+// used to shut it down. It allows stopping HTTP/3 servers without depending on quic-go.
+type HTTP3Server interface {
+	// CloseGracefully sends a GOAWAY frame and waits for the running requests to complete
+	// or for the timeout to elapse.
+	CloseGracefully(timeout time.Duration) error
+
+	// Close closes the server immediately.
+	Close() error
+}
+
+// ShutdownHTTP3 is a function that gracefully shuts down an HTTP/3 server, which is not
+// covered by http.Server.Shutdown since it serves QUIC listeners. The graceful timeout is
+// the time left until the deadline of the context. If the context is done before the
+// running requests complete, the server is closed immediately and the context error is
+// returned.
+//
+//	err := ShutdownHTTP3(ctx, h3srv)
+//
+// This example drains the HTTP/3 server within the deadline of the context.
+func ShutdownHTTP3(ctx context.Context, srv HTTP3Server) error {
+	timeout := time.Duration(math.MaxInt64)
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.CloseGracefully(timeout)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		_ = srv.Close()
+		return ctx.Err()
+	}
+}
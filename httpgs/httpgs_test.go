@@ -0,0 +1,150 @@
+package httpgs
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	ShortDelay = 50 * time.Millisecond
+	LongDelay  = time.Second
+)
+
+func shortDelay() {
+	time.Sleep(ShortDelay)
+}
+
+type testHTTP3Server struct {
+	graceful chan struct{}
+	closed   bool
+}
+
+func (s *testHTTP3Server) CloseGracefully(time.Duration) error {
+	<-s.graceful
+	return nil
+}
+
+func (s *testHTTP3Server) Close() error {
+	s.closed = true
+	return nil
+}
+
+func Test_ConnReaper_Reap(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	reaper := NewConnReaper(ts.Config)
+	ts.Start()
+	defer ts.Close()
+
+	resp, err := ts.Client().Get(ts.URL)
+	assert.NoError(t, err)
+	_, _ = io.Copy(io.Discard, resp.Body)
+	assert.NoError(t, resp.Body.Close())
+
+	assert.Eventually(t, func() bool {
+		return reaper.Idle() == 1
+	}, LongDelay, time.Millisecond)
+
+	assert.Equal(t, 1, reaper.Reap())
+	assert.Equal(t, 0, reaper.Idle())
+
+	ctx, cancel := context.WithTimeout(context.Background(), LongDelay)
+	defer cancel()
+	assert.NoError(t, reaper.Shutdown(ctx))
+}
+
+func Test_ConnReaper_Hijacked(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			_ = conn.Close()
+		}
+	}))
+	reaper := NewConnReaper(ts.Config)
+	ts.Start()
+	defer ts.Close()
+
+	_, err := ts.Client().Get(ts.URL)
+	assert.Error(t, err)
+	assert.Equal(t, 1, reaper.Hijacked())
+	assert.Equal(t, 0, reaper.Idle())
+
+	assert.Equal(t, 1, reaper.CloseHijacked())
+	assert.Equal(t, 0, reaper.Hijacked())
+}
+
+func Test_ShutdownH2C(t *testing.T) {
+	t.Parallel()
+
+	releaseCh := make(chan struct{})
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		go func() {
+			<-releaseCh
+			_ = conn.Close()
+		}()
+	}))
+	reaper := NewConnReaper(ts.Config)
+	ts.Listener = reaper.Listener(ts.Listener)
+	ts.Start()
+	defer ts.Close()
+
+	hijack := func() {
+		conn, err := net.Dial("tcp", ts.Listener.Addr().String())
+		assert.NoError(t, err)
+		_, err = conn.Write([]byte("GET / HTTP/1.1\r\nHost: test\r\n\r\n"))
+		assert.NoError(t, err)
+		_, _ = bufio.NewReader(conn).Peek(1)
+	}
+
+	go hijack()
+	assert.Eventually(t, func() bool {
+		return reaper.Hijacked() == 1
+	}, LongDelay, time.Millisecond)
+
+	go func() {
+		shortDelay()
+		close(releaseCh)
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), LongDelay)
+	defer cancel()
+	assert.NoError(t, ShutdownH2C(ctx, reaper))
+	assert.Equal(t, 0, reaper.Hijacked())
+}
+
+func Test_ShutdownHTTP3(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Graceful", func(t *testing.T) {
+		srv := &testHTTP3Server{graceful: make(chan struct{})}
+		close(srv.graceful)
+
+		assert.NoError(t, ShutdownHTTP3(context.Background(), srv))
+		assert.False(t, srv.closed)
+	})
+
+	t.Run("Forced", func(t *testing.T) {
+		srv := &testHTTP3Server{graceful: make(chan struct{})}
+		ctx, cancel := context.WithTimeout(context.Background(), ShortDelay)
+		defer cancel()
+
+		assert.ErrorIs(t, ShutdownHTTP3(ctx, srv), context.DeadlineExceeded)
+		assert.True(t, srv.closed)
+	})
+}
@@ -0,0 +1,29 @@
+package httpgs
+
+import (
+	"encoding/json"
+	"net/http"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+// TopologyHandler is a function that returns a read-only handler responding with the
+// topology of the lifecycle in JSON, see gogs.GracefulShutdown.Topology, so that platform
+// teams can audit the signals, the budgets and the order of the shutdown across services.
+// It responds with 405 Method Not Allowed to any method but GET and HEAD.
+//
+//	admin.Handle("/admin/shutdown/topology", TopologyHandler(gs))
+//
+// This example serves the topology on the admin server.
+func TopologyHandler(gs *gogs.GracefulShutdown) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(gs.Topology())
+	})
+}
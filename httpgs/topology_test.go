@@ -0,0 +1,47 @@
+package httpgs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+func Test_TopologyHandler(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := gogs.New(context.Background(), gogs.WithGracePeriod(30*time.Second))
+	defer cancel()
+	gs.AddHook("db", func(context.Context) error { return nil }, gogs.WithTimeout(5*time.Second))
+
+	h := TopologyHandler(gs)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/shutdown/topology", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var body struct {
+		GracePeriod string `json:"grace_period"`
+		Hooks       []struct {
+			Name    string `json:"name"`
+			Timeout string `json:"timeout"`
+		} `json:"hooks"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "30s", body.GracePeriod)
+	if assert.Len(t, body.Hooks, 1) {
+		assert.Equal(t, "db", body.Hooks[0].Name)
+		assert.Equal(t, "5s", body.Hooks[0].Timeout)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/shutdown/topology", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "GET, HEAD", rec.Header().Get("Allow"))
+}
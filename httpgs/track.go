@@ -0,0 +1,47 @@
+package httpgs
+
+import (
+	"net/http"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+// RequestMetadata is a function that extracts the metadata describing a request, e.g. the
+// values stored in its context by other middlewares, see TrackRequests.
+type RequestMetadata func(r *http.Request) map[string]string
+
+// TrackRequests is a function that wraps the provided handler so that each request is an
+// active shutdown event named after its method and path, see
+// gogs.GracefulShutdown.SubscribeWithMetadata. If the drain gives up on the requests in
+// flight, the report lists them with the metadata returned by the provided extractor, nil
+// meaning the method and the path, and how long they had been running, so that it names
+// the stuck endpoints. The event completes even if the handler panics.
+//
+//	srv := &http.Server{Handler: TrackRequests(gs, router, func(r *http.Request) map[string]string {
+//		return map[string]string{
+//			"method":  r.Method,
+//			"path":    r.URL.Path,
+//			"request": middleware.GetReqID(r.Context()),
+//		}
+//	})}
+//
+// This example records the request ID set by an earlier middleware along with the
+// endpoint.
+func TrackRequests(gs *gogs.GracefulShutdown, next http.Handler, extract RequestMetadata) http.Handler {
+	if extract == nil {
+		extract = methodAndPath
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		done := gs.SubscribeWithMetadata(r.Method+" "+r.URL.Path, extract(r))
+		defer done()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// methodAndPath is a function that returns the method and the path of the request as its
+// metadata.
+func methodAndPath(r *http.Request) map[string]string {
+	return map[string]string{"method": r.Method, "path": r.URL.Path}
+}
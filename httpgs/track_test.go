@@ -0,0 +1,64 @@
+package httpgs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+type tenantKey struct{}
+
+func Test_TrackRequests(t *testing.T) {
+	t.Parallel()
+	gs, _, _ := gogs.New(context.Background())
+
+	started, release := make(chan struct{}, 2), make(chan struct{})
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	})
+	tenant := TrackRequests(gs, slow, func(r *http.Request) map[string]string {
+		return map[string]string{"tenant": r.Context().Value(tenantKey{}).(string)}
+	})
+	plain := TrackRequests(gs, slow, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	go tenant.ServeHTTP(httptest.NewRecorder(), req.WithContext(context.WithValue(req.Context(), tenantKey{}, "acme")))
+	<-started
+	go plain.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/export", nil))
+	<-started
+	assert.Equal(t, int32(2), gs.Count())
+
+	res := gs.WaitWithTimeoutReport(50 * time.Millisecond)
+	close(release)
+
+	assert.Equal(t, int32(2), res.Remaining)
+	if assert.Len(t, res.Subscribers, 2) {
+		assert.Equal(t, "POST /orders", res.Subscribers[0].Name)
+		assert.Equal(t, map[string]string{"tenant": "acme"}, res.Subscribers[0].Metadata)
+		assert.GreaterOrEqual(t, res.Subscribers[0].Duration, 50*time.Millisecond)
+		assert.Equal(t, "GET /export", res.Subscribers[1].Name)
+		assert.Equal(t, map[string]string{"method": "GET", "path": "/export"}, res.Subscribers[1].Metadata)
+	}
+}
+
+func Test_TrackRequests_Panic(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := gogs.New(context.Background())
+	defer cancel()
+
+	handler := TrackRequests(gs, http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}), nil)
+
+	assert.Panics(t, func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+	assert.Equal(t, int32(0), gs.Count())
+}
@@ -0,0 +1,141 @@
+package gogs
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// StopOrder is a type that describes whether the intake of new work is stopped before or
+// after the context created by New is canceled.
+type StopOrder int
+
+const (
+	// StopCancelFirst means that the context is canceled first and the intake is stopped
+	// right after it. It is the default order.
+	StopCancelFirst StopOrder = iota
+
+	// StopIntakeFirst means that the intake, e.g. the listeners, is stopped strictly
+	// before the context is canceled. In-flight requests using a context derived from it
+	// then do not fail spuriously while new requests are already refused. The order cannot
+	// be applied when the parent context is canceled, since the context is canceled with
+	// it.
+	StopIntakeFirst
+)
+
+// intake is a struct that holds the functions stopping the intake of new work.
+type intake struct {
+	// mu guards the fields below.
+	mu sync.Mutex
+
+	// stops is the list of registered functions stopping the intake.
+	stops []intakeStop
+
+	// stopped reports whether the intake is stopped.
+	stopped bool
+
+	// results is the outcome of the executed functions.
+	results []HookResult
+}
+
+// intakeStop is a named function stopping the intake registered with AddIntake.
+type intakeStop struct {
+	name string
+	fn   func() error
+}
+
+// WithStopOrder is an option that sets whether the intake registered with AddIntake is
+// stopped before or after the context created by New is canceled.
+//
+//	gs, ctx, cancel := New(context.Background(), WithStopOrder(StopIntakeFirst))
+//	gs.AddIntake("http", ln.Close)
+//
+// This example stops accepting connections before the handlers see the cancellation.
+func WithStopOrder(order StopOrder) Option {
+	return func(cfg *config) {
+		cfg.stopOrder = order
+	}
+}
+
+// AddIntake is a method of the GracefulShutdown struct. It registers a named function
+// stopping the intake of new work, e.g. closing a listener. The functions are executed in
+// reverse order of registration as soon as the shutdown is triggered, before or after the
+// cancellation of the context as set with WithStopOrder. If the intake is already stopped,
+// the function is executed immediately. The functions must not block.
+func (gs *GracefulShutdown) AddIntake(name string, stopFn func() error) {
+	gs.intake.mu.Lock()
+	defer gs.intake.mu.Unlock()
+
+	if gs.intake.stopped {
+		gs.intake.results = append(gs.intake.results, runIntakeStop(intakeStop{name: name, fn: stopFn}))
+		return
+	}
+
+	gs.intake.stops = append(gs.intake.stops, intakeStop{name: name, fn: stopFn})
+}
+
+// AddListener is a function that registers the provided listener, or any other closer,
+// as intake of the GracefulShutdowner, see AddIntake.
+//
+//	ln, err := net.Listen("tcp", ":8080")
+//	AddListener(gs, "http", ln)
+//
+// This example stops accepting connections once the shutdown is triggered.
+func AddListener(gs IntakeRegistrar, name string, ln io.Closer) {
+	gs.AddIntake(name, ln.Close)
+}
+
+// stop is a method of the GracefulShutdown struct. It cancels the provided context cancel
+// function and stops the intake in the configured order.
+func (gs *GracefulShutdown) stop(cancel context.CancelFunc) {
+	if gs.cfg.stopOrder == StopIntakeFirst {
+		gs.stopIntake()
+		cancel()
+		return
+	}
+
+	cancel()
+	gs.stopIntake()
+}
+
+// stopIntake is a method of the GracefulShutdown struct. It executes the functions
+// stopping the intake in reverse order of registration, exactly once.
+func (gs *GracefulShutdown) stopIntake() {
+	gs.intake.mu.Lock()
+	defer gs.intake.mu.Unlock()
+
+	if gs.intake.stopped {
+		return
+	}
+	gs.intake.stopped = true
+
+	for i := len(gs.intake.stops) - 1; i >= 0; i-- {
+		gs.intake.results = append(gs.intake.results, runIntakeStop(gs.intake.stops[i]))
+	}
+	gs.intake.stops = nil
+}
+
+// intakeResults is a method of the GracefulShutdown struct. It returns a copy of the
+// outcome of the executed functions stopping the intake.
+func (gs *GracefulShutdown) intakeResults() []HookResult {
+	gs.intake.mu.Lock()
+	defer gs.intake.mu.Unlock()
+
+	if len(gs.intake.results) == 0 {
+		return nil
+	}
+
+	results := make([]HookResult, len(gs.intake.results))
+	copy(results, gs.intake.results)
+
+	return results
+}
+
+// runIntakeStop is a function that executes a single function stopping the intake.
+func runIntakeStop(s intakeStop) HookResult {
+	start := time.Now()
+	err := s.fn()
+
+	return HookResult{Name: s.name, Err: err, Duration: time.Since(start)}
+}
@@ -0,0 +1,68 @@
+package gogs
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GracefulShutdown_AddIntake(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		order    StopOrder
+		canceled bool
+	}{
+		{order: StopCancelFirst, canceled: true},
+		{order: StopIntakeFirst, canceled: false},
+	}
+
+	for _, tt := range tests {
+		gs, ctx, cancel := New(context.Background(), WithStopOrder(tt.order))
+
+		var canceled []bool
+		errFailed := errors.New("failed")
+		gs.AddIntake("grpc", func() error {
+			canceled = append(canceled, ctx.Err() != nil)
+			return errFailed
+		})
+		gs.AddIntake("http", func() error {
+			canceled = append(canceled, ctx.Err() != nil)
+			return nil
+		})
+
+		cancel()
+		cancel()
+		assert.Equal(t, []bool{tt.canceled, tt.canceled}, canceled)
+
+		gs.AddIntake("late", func() error { return nil })
+		gs.Wait()
+
+		report := gs.Report()
+		if assert.Len(t, report.Intake, 3) {
+			assert.Equal(t, "http", report.Intake[0].Name)
+			assert.Equal(t, "grpc", report.Intake[1].Name)
+			assert.Equal(t, "late", report.Intake[2].Name)
+		}
+		assert.EqualError(t, report.Err(), "grpc: failed")
+	}
+}
+
+func Test_AddListener(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background())
+	defer cancel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	AddListener(gs, "http", ln)
+
+	gs.Wait()
+	_, err = ln.Accept()
+	assert.ErrorIs(t, err, net.ErrClosed)
+}
@@ -0,0 +1,126 @@
+// Package keepalivegs reports to the supervisor that the process is still alive while the
+// shutdown is in progress, so that a long but healthy drain is not mistaken for a hang,
+// e.g. by systemd's TimeoutStopSec or a Kubernetes liveness probe reading a file.
+package keepalivegs
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+// Notifier is an interface that describes a supervisor told that the process is alive.
+type Notifier interface {
+	// Notify tells the supervisor that the process is alive and needs at least the provided
+	// extension of its stop timeout, describing the progress of the shutdown.
+	Notify(extend time.Duration, s gogs.Snapshot) error
+}
+
+// Systemd is a struct that notifies systemd with sd_notify, extending the stop timeout of
+// the unit with EXTEND_TIMEOUT_USEC and describing the progress in STATUS.
+type Systemd struct {
+	// Socket is the notification socket. Empty means the NOTIFY_SOCKET variable, and no
+	// notification at all when it is unset, i.e. when the process is not run by systemd.
+	Socket string
+}
+
+// Notify is a method of the Systemd struct. It sends the extension and the status to the
+// notification socket.
+func (n Systemd) Notify(extend time.Duration, s gogs.Snapshot) error {
+	socket := n.Socket
+	if socket == "" {
+		socket = os.Getenv("NOTIFY_SOCKET")
+	}
+	if socket == "" {
+		return nil
+	}
+	if strings.HasPrefix(socket, "@") {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("keepalivegs: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	msg := fmt.Sprintf("EXTEND_TIMEOUT_USEC=%d\nSTATUS=%s\n", extend.Microseconds(), status(s))
+	if _, err = conn.Write([]byte(msg)); err != nil {
+		return fmt.Errorf("keepalivegs: %w", err)
+	}
+
+	return nil
+}
+
+// File is a struct that touches a file, for the supervisors checking that its
+// modification time is recent, e.g. a liveness probe running find -mmin.
+type File struct {
+	// Path is the file touched, created if missing.
+	Path string
+}
+
+// Notify is a method of the File struct. It sets the modification time of the file to
+// now and writes the status into it.
+func (n File) Notify(_ time.Duration, s gogs.Snapshot) error {
+	if err := os.WriteFile(n.Path, []byte(status(s)+"\n"), 0o644); err != nil {
+		return fmt.Errorf("keepalivegs: %w", err)
+	}
+
+	return nil
+}
+
+// Start is a function that notifies the provided supervisor as soon as the shutdown
+// starts and until it completes, first after the provided interval and then at intervals
+// doubling up to the provided maximum, since a drain that has lasted long tends to last
+// longer. Each notification asks for an extension of twice the interval until the next
+// one, so that a single lost notification does not get the process killed. Errors of the
+// notifier are ignored, since the shutdown goes on whatever the supervisor does.
+//
+//	keepalivegs.Start(gs, keepalivegs.Systemd{}, time.Second, 30*time.Second)
+//
+// This example extends the stop timeout of the systemd unit while the shutdown is in
+// progress, at one, two, four seconds and so on, and every thirty seconds eventually.
+func Start(gs *gogs.GracefulShutdown, n Notifier, interval, maxInterval time.Duration) {
+	progress := gs.Progress()
+
+	go func() {
+		s, ok := <-progress
+		if !ok {
+			return
+		}
+
+		timer := time.NewTimer(0)
+		defer timer.Stop()
+
+		next := interval
+		for {
+			select {
+			case s, ok = <-progress:
+				if !ok {
+					return
+				}
+			case <-timer.C:
+				_ = n.Notify(2*next, s)
+				timer.Reset(next)
+
+				if next *= 2; next > maxInterval {
+					next = maxInterval
+				}
+			}
+		}
+	}()
+}
+
+// status is a function that describes the provided snapshot in a single line.
+func status(s gogs.Snapshot) string {
+	msg := fmt.Sprintf("shutting down: %s, %d pending", s.Phase, s.Pending)
+	if s.Hook != "" {
+		msg += fmt.Sprintf(", closing %s (%d hooks left)", s.Hook, s.HooksLeft)
+	}
+
+	return msg + fmt.Sprintf(", %s elapsed", s.Elapsed.Round(time.Second))
+}
@@ -0,0 +1,91 @@
+package keepalivegs
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+type testNotifier struct {
+	mu      sync.Mutex
+	extends []time.Duration
+}
+
+func (n *testNotifier) Notify(extend time.Duration, _ gogs.Snapshot) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.extends = append(n.extends, extend)
+	return nil
+}
+
+func Test_Start(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := gogs.New(context.Background())
+
+	n := &testNotifier{}
+	Start(gs, n, 20*time.Millisecond, 40*time.Millisecond)
+
+	gs.Subscribe()
+	cancel()
+	time.AfterFunc(130*time.Millisecond, gs.Unsubscribe)
+	gs.Wait()
+	time.Sleep(50 * time.Millisecond)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	assert.GreaterOrEqual(t, len(n.extends), 3)
+	assert.LessOrEqual(t, len(n.extends), 5)
+	assert.Equal(t, []time.Duration{40 * time.Millisecond, 80 * time.Millisecond, 80 * time.Millisecond}, n.extends[:3])
+}
+
+func Test_Systemd_Notify(t *testing.T) {
+	t.Parallel()
+	dir, err := os.MkdirTemp("", "keepalivegs")
+	assert.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	socket := filepath.Join(dir, "notify")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	assert.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	err = Systemd{Socket: socket}.Notify(2*time.Second, gogs.Snapshot{
+		Phase:     gogs.PhaseClose,
+		Hook:      "postgres",
+		HooksLeft: 2,
+		Elapsed:   3 * time.Second,
+	})
+	assert.NoError(t, err)
+
+	buf := make([]byte, 256)
+	nr, err := conn.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t,
+		"EXTEND_TIMEOUT_USEC=2000000\nSTATUS=shutting down: close, 0 pending, closing postgres (2 hooks left), 3s elapsed\n",
+		string(buf[:nr]),
+	)
+
+	assert.NoError(t, Systemd{}.Notify(time.Second, gogs.Snapshot{}))
+	assert.Error(t, Systemd{Socket: filepath.Join(dir, "missing")}.Notify(time.Second, gogs.Snapshot{}))
+}
+
+func Test_File_Notify(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "alive")
+
+	assert.NoError(t, File{Path: path}.Notify(time.Second, gogs.Snapshot{Phase: gogs.PhaseDrain, Pending: 3}))
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "shutting down: drain, 3 pending, 0s elapsed\n", string(data))
+	assert.Error(t, File{Path: filepath.Join(path, "child")}.Notify(time.Second, gogs.Snapshot{}))
+}
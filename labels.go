@@ -0,0 +1,45 @@
+package gogs
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// WithPprofLabels is an option that labels the goroutines executing the hooks and the
+// deregistrations with runtime/pprof, so that the goroutine profiles captured during a
+// stuck shutdown show which hook each goroutine belongs to. The goroutines get the labels
+// gogs_hook, with the name of the hook, and gogs_phase, along with the provided
+// additional key-value pairs, e.g. the name of the service. The goroutines started by the
+// hooks inherit the labels.
+//
+//	gs, ctx, cancel := New(context.Background(), WithPprofLabels("service", "billing"))
+//
+// This example labels the hooks of the billing service.
+func WithPprofLabels(labels ...string) Option {
+	return func(cfg *config) {
+		cfg.pprofLabels = append([]string{}, labels...)
+	}
+}
+
+// labeled is a method of the GracefulShutdown struct. It returns the provided hook
+// function labeling its goroutine with the name of the hook and the provided phase, if
+// configured with WithPprofLabels.
+func (gs *GracefulShutdown) labeled(
+	name string,
+	phase Phase,
+	fn func(ctx context.Context) error,
+) func(ctx context.Context) error {
+	if gs.cfg.pprofLabels == nil {
+		return fn
+	}
+
+	labels := append([]string{"gogs_hook", name, "gogs_phase", string(phase)}, gs.cfg.pprofLabels...)
+	return func(ctx context.Context) error {
+		var err error
+		pprof.Do(ctx, pprof.Labels(labels...), func(ctx context.Context) {
+			err = fn(ctx)
+		})
+
+		return err
+	}
+}
@@ -0,0 +1,57 @@
+package gogs
+
+import (
+	"context"
+	"runtime/pprof"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type labelRegistrar func(ctx context.Context)
+
+func (r labelRegistrar) Deregister(ctx context.Context) error {
+	r(ctx)
+	return nil
+}
+
+func Test_WithPprofLabels(t *testing.T) {
+	t.Parallel()
+	gs, _, _ := New(context.Background(), WithSignals(syscall.SIGINT), WithPprofLabels("service", "billing"))
+
+	labels := make(map[string]string)
+	gs.AddRegistrar("consul", labelRegistrar(func(ctx context.Context) {
+		labels["registrar"], _ = pprof.Label(ctx, "gogs_phase")
+	}))
+	gs.AddHook("db", func(ctx context.Context) error {
+		labels["hook"], _ = pprof.Label(ctx, "gogs_hook")
+		labels["phase"], _ = pprof.Label(ctx, "gogs_phase")
+		labels["service"], _ = pprof.Label(ctx, "service")
+		return nil
+	})
+
+	gs.Wait()
+
+	assert.Equal(t, map[string]string{
+		"registrar": "deregister",
+		"hook":      "db",
+		"phase":     "close",
+		"service":   "billing",
+	}, labels)
+}
+
+func Test_GracefulShutdown_labeled(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background(), WithSignals(syscall.SIGINT))
+	defer cancel()
+
+	var labeled bool
+	fn := gs.labeled("db", PhaseClose, func(ctx context.Context) error {
+		_, labeled = pprof.Label(ctx, "gogs_hook")
+		return nil
+	})
+
+	assert.NoError(t, fn(context.Background()))
+	assert.False(t, labeled)
+}
@@ -0,0 +1,42 @@
+package gogs
+
+import "time"
+
+const (
+	// LaunchdExitTimeout is the default time launchd waits for a job to exit after sending
+	// it SIGTERM before sending it SIGKILL, see ExitTimeOut in launchd.plist(5).
+	LaunchdExitTimeout = 20 * time.Second
+
+	// launchdMargin is the time left between the deadline of the shutdown and SIGKILL, so
+	// that the report is written and the process exits on its own.
+	launchdMargin = time.Second
+)
+
+// WithLaunchd is an option that fits the shutdown into the stop conventions of launchd on
+// macOS: launchd sends SIGTERM to stop a job and SIGKILL once the provided exit timeout,
+// the ExitTimeOut key of the job, has elapsed, LaunchdExitTimeout if zero. The grace period
+// is set to the exit timeout less a second, and the kill delay to that second, so that the
+// process exits on its own before launchd kills it and marks the job as crashed. The
+// signals are not changed, SIGTERM must be among them. See UnderLaunchd.
+//
+//	opts := []Option{WithSignals(syscall.SIGINT, syscall.SIGTERM)}
+//	if UnderLaunchd() {
+//		opts = append(opts, WithLaunchd(0), OnStatusRequest(nil))
+//	}
+//	gs, ctx, cancel := New(context.Background(), opts...)
+//
+// This example shuts down within nineteen seconds when run by launchd.
+func WithLaunchd(exitTimeout time.Duration) Option {
+	return func(cfg *config) {
+		if exitTimeout <= 0 {
+			exitTimeout = LaunchdExitTimeout
+		}
+
+		margin := launchdMargin
+		if exitTimeout <= 2*margin {
+			margin = exitTimeout / 2
+		}
+		cfg.gracePeriod = exitTimeout - margin
+		cfg.killDelay = margin
+	}
+}
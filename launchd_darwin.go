@@ -0,0 +1,11 @@
+package gogs
+
+import "os"
+
+// UnderLaunchd is a function that reports whether the process runs as a launchd job, which
+// launchd tells by setting XPC_SERVICE_NAME to the label of the job, see WithLaunchd.
+// Processes started from a terminal have it set to "0".
+func UnderLaunchd() bool {
+	name := os.Getenv("XPC_SERVICE_NAME")
+	return name != "" && name != "0"
+}
@@ -0,0 +1,9 @@
+//go:build !darwin
+
+package gogs
+
+// UnderLaunchd is a function that reports whether the process runs as a launchd job, see
+// WithLaunchd. There is no launchd on this platform, so it returns false.
+func UnderLaunchd() bool {
+	return false
+}
@@ -0,0 +1,21 @@
+package gogs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WithLaunchd(t *testing.T) {
+	t.Parallel()
+
+	var cfg config
+	WithLaunchd(0)(&cfg)
+	assert.Equal(t, LaunchdExitTimeout-time.Second, cfg.gracePeriod)
+	assert.Equal(t, time.Second, cfg.killDelay)
+
+	WithLaunchd(time.Second)(&cfg)
+	assert.Equal(t, 500*time.Millisecond, cfg.gracePeriod)
+	assert.Equal(t, 500*time.Millisecond, cfg.killDelay)
+}
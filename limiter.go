@@ -0,0 +1,135 @@
+package gogs
+
+import (
+	"context"
+	"sync"
+)
+
+// Limiter is a struct that admits new work up to a maximum in flight, and is drained by
+// the shutdown: it stops issuing permits as soon as the shutdown is triggered, and the
+// outstanding permits are active shutdown events, so the drain waits for them. It unifies
+// load shedding and draining in one primitive.
+//
+//	limiter := NewLimiter(gs, "jobs", 100)
+//
+//	release, err := limiter.Acquire(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	go func() {
+//		defer release()
+//		process(ctx, job)
+//	}()
+//
+// This example processes at most one hundred jobs at a time, and none once the shutdown
+// has started.
+type Limiter struct {
+	// gs is the GracefulShutdowner the permits are tracked by.
+	gs *GracefulShutdown
+
+	// sem holds a token per outstanding permit.
+	sem chan struct{}
+
+	// mu guards stopped, so that no permit is issued once the limiter is stopped.
+	mu      sync.Mutex
+	stopped bool
+
+	// done is closed once the limiter is stopped, releasing the waiting callers.
+	done chan struct{}
+}
+
+// NewLimiter is a function that creates a new Limiter admitting up to the provided
+// maximum of permits in flight. The limiter is registered as intake with the provided
+// name, see AddIntake.
+func NewLimiter(gs *GracefulShutdown, name string, maxInFlight int) *Limiter {
+	l := &Limiter{
+		gs:   gs,
+		sem:  make(chan struct{}, maxInFlight),
+		done: make(chan struct{}),
+	}
+	gs.AddIntake(name, l.stop)
+
+	return l
+}
+
+// Acquire is a method of the Limiter struct. It waits for a permit and returns the
+// function releasing it. It returns ErrShuttingDown if the shutdown has started, or the
+// context error if the context is done first. The release function is idempotent.
+func (l *Limiter) Acquire(ctx context.Context) (func(), error) {
+	select {
+	case l.sem <- struct{}{}:
+	case <-l.done:
+		return nil, ErrShuttingDown
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	return l.admit(ctx)
+}
+
+// TryAcquire is a method of the Limiter struct. It returns the function releasing a
+// permit if one is available right away, ErrLimitExceeded if all permits are in flight,
+// or ErrShuttingDown if the shutdown has started.
+func (l *Limiter) TryAcquire() (func(), error) {
+	select {
+	case l.sem <- struct{}{}:
+	default:
+		if l.isStopped() {
+			return nil, ErrShuttingDown
+		}
+		return nil, ErrLimitExceeded
+	}
+
+	return l.admit(context.Background())
+}
+
+// InFlight is a method of the Limiter struct. It returns the count of outstanding
+// permits.
+func (l *Limiter) InFlight() int {
+	return len(l.sem)
+}
+
+// admit is a method of the Limiter struct. It turns the acquired token into a permit
+// tracked as an active shutdown event, unless the limiter is stopped.
+func (l *Limiter) admit(ctx context.Context) (func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	err := ErrShuttingDown
+	if !l.stopped {
+		err = l.gs.SubscribeCtx(ctx)
+	}
+	if err != nil {
+		<-l.sem
+		return nil, err
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			<-l.sem
+			l.gs.Unsubscribe()
+		})
+	}, nil
+}
+
+// stop is a method of the Limiter struct. It stops issuing permits.
+func (l *Limiter) stop() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.stopped {
+		l.stopped = true
+		close(l.done)
+	}
+
+	return nil
+}
+
+// isStopped is a method of the Limiter struct. It reports whether the limiter is stopped.
+func (l *Limiter) isStopped() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.stopped
+}
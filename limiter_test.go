@@ -0,0 +1,79 @@
+package gogs
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Limiter(t *testing.T) {
+	t.Parallel()
+	gs, _, _ := New(context.Background(), WithSignals(syscall.SIGINT))
+	limiter := NewLimiter(gs, "jobs", 2)
+
+	release1, err := limiter.Acquire(context.Background())
+	assert.NoError(t, err)
+	release2, err := limiter.TryAcquire()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, limiter.InFlight())
+	assert.Equal(t, int32(2), gs.Count())
+
+	_, err = limiter.TryAcquire()
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+
+	ctx, cancel := context.WithTimeout(context.Background(), ShortDelay)
+	defer cancel()
+	_, err = limiter.Acquire(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	release2()
+	release2()
+	assert.Equal(t, 1, limiter.InFlight())
+	assert.Equal(t, int32(1), gs.Count())
+
+	waitCh := make(chan struct{})
+	go func() {
+		gs.Wait()
+		close(waitCh)
+	}()
+
+	assert.Eventually(t, func() bool {
+		_, err = limiter.TryAcquire()
+		return err == ErrShuttingDown
+	}, time.Second, time.Millisecond)
+
+	select {
+	case <-waitCh:
+		t.Fatal("drain does not wait for the outstanding permit")
+	case <-time.After(ShortDelay):
+	}
+
+	release1()
+	<-waitCh
+	assert.Equal(t, 0, limiter.InFlight())
+}
+
+func Test_Limiter_Waiting(t *testing.T) {
+	t.Parallel()
+	gs, _, _ := New(context.Background(), WithSignals(syscall.SIGINT))
+	limiter := NewLimiter(gs, "jobs", 1)
+
+	release, err := limiter.Acquire(context.Background())
+	assert.NoError(t, err)
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := limiter.Acquire(context.Background())
+		errCh <- err
+	}()
+
+	gs.Trigger(ReasonSignal)
+	assert.ErrorIs(t, <-errCh, ErrShuttingDown)
+
+	release()
+	gs.Wait()
+	assert.Equal(t, int32(0), gs.Count())
+}
@@ -0,0 +1,54 @@
+package gogs
+
+// WithModule is a hook option that registers the hook under the provided module, so that
+// the hooks of a whole module can be disabled at runtime with SetModuleEnabled, e.g. when
+// the feature behind the module is turned off.
+//
+//	gs.AddHook("recommender-cache", flushCache, WithModule("recommendations"))
+//	gs.AddHook("recommender-model", saveModel, WithModule("recommendations"))
+//
+// This example groups the hooks of the recommendations feature.
+func WithModule(module string) HookOption {
+	return func(h *hook) {
+		h.module = module
+	}
+}
+
+// SetModuleEnabled is a method of the GracefulShutdown struct. It enables or disables the
+// hooks registered under the provided module with WithModule. The hooks of disabled
+// modules are skipped and reported as such, with their module. Modules are enabled by
+// default.
+//
+//	flags.OnChange("recommendations", func(on bool) {
+//		gs.SetModuleEnabled("recommendations", on)
+//	})
+//
+// This example keeps the participation of the module in the shutdown in sync with its
+// feature flag.
+func (gs *GracefulShutdown) SetModuleEnabled(module string, enabled bool) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if enabled {
+		delete(gs.disabledModules, module)
+		return
+	}
+
+	if gs.disabledModules == nil {
+		gs.disabledModules = make(map[string]bool)
+	}
+	gs.disabledModules[module] = true
+}
+
+// moduleDisabled is a method of the GracefulShutdown struct. It reports whether the
+// provided module is disabled.
+func (gs *GracefulShutdown) moduleDisabled(module string) bool {
+	if module == "" {
+		return false
+	}
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	return gs.disabledModules[module]
+}
@@ -0,0 +1,45 @@
+package gogs
+
+import (
+	"context"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GracefulShutdown_SetModuleEnabled(t *testing.T) {
+	t.Parallel()
+	gs, _, _ := New(context.Background(), WithSignals(syscall.SIGINT))
+
+	var executed []string
+	hookFn := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			executed = append(executed, name)
+			return nil
+		}
+	}
+	gs.AddHook("db", hookFn("db"))
+	gs.AddHook("model", hookFn("model"), WithModule("recommendations"))
+	gs.AddHook("cache", hookFn("cache"), WithModule("recommendations"))
+	gs.AddHook("search", hookFn("search"), WithModule("search"))
+
+	gs.SetModuleEnabled("recommendations", false)
+	gs.SetModuleEnabled("search", false)
+	gs.SetModuleEnabled("search", true)
+
+	plan := gs.Plan(ReasonWait)
+	assert.True(t, plan.Hooks[1].Disabled)
+	assert.Equal(t, "recommendations", plan.Hooks[1].Module)
+	assert.False(t, plan.Hooks[0].Disabled)
+
+	gs.Wait()
+
+	assert.Equal(t, []string{"search", "db"}, executed)
+	assert.Equal(t, []HookResult{
+		{Name: "search", Module: "search", Duration: gs.Report().Hooks[0].Duration},
+		{Name: "cache", Module: "recommendations", Skipped: true},
+		{Name: "model", Module: "recommendations", Skipped: true},
+		{Name: "db", Duration: gs.Report().Hooks[3].Duration},
+	}, gs.Report().Hooks)
+}
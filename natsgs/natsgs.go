@@ -0,0 +1,159 @@
+// Package natsgs provides the graceful draining of NATS subscriptions and connections.
+// The package depends on no client: *nats.Conn and *nats.Subscription are described by
+// the interfaces of this package.
+package natsgs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+const (
+	// maxPollInterval is the maximum interval between two checks of the closed connection.
+	maxPollInterval = 100 * time.Millisecond
+
+	// reportMargin is the time before the deadline of the hook at which the drain gives up,
+	// so that the error listing the undrained subscriptions reaches the report.
+	reportMargin = 5 * time.Millisecond
+)
+
+// Conn is an interface that describes a *nats.Conn.
+type Conn interface {
+	// Drain unsubscribes all subscriptions, processes the pending messages, flushes the
+	// published ones and closes the connection asynchronously.
+	Drain() error
+
+	// IsClosed reports whether the connection is closed.
+	IsClosed() bool
+}
+
+// Subscription is an interface that describes a *nats.Subscription.
+type Subscription interface {
+	// Drain unsubscribes the subscription and processes its pending messages
+	// asynchronously.
+	Drain() error
+
+	// IsValid reports whether the subscription is still active.
+	IsValid() bool
+
+	// Pending returns the count of messages and bytes not processed yet.
+	Pending() (int, int, error)
+}
+
+// Drainer is a struct that drains NATS subscriptions and a connection along the phases of
+// the shutdown: the subscriptions stop receiving messages as soon as the shutdown is
+// triggered, and the connection is drained and closed by a hook, within its deadline.
+//
+//	d := Register(gs, "nats", nc)
+//	d.AddSubscription("orders", ordersSub)
+//
+// This example stops the orders subscription once the shutdown starts, and drains the
+// connection once all active shutdown events have completed.
+type Drainer struct {
+	// gs is the GracefulShutdowner the subscriptions are registered with.
+	gs *gogs.GracefulShutdown
+
+	// conn is the drained connection.
+	conn Conn
+
+	// subs is the list of named subscriptions. It is guarded by mu.
+	mu   sync.Mutex
+	subs []namedSubscription
+}
+
+// namedSubscription is a struct that holds a subscription and its name.
+type namedSubscription struct {
+	name string
+	sub  Subscription
+}
+
+// Register is a function that creates a new Drainer for the provided connection. It
+// registers the connection drain as a named hook configured with the provided hook
+// options. The hook fails with an error listing the subscriptions that are not drained
+// if the connection is not closed before the deadline of the hook.
+func Register(
+	gs *gogs.GracefulShutdown,
+	name string,
+	conn Conn,
+	opts ...gogs.HookOption,
+) *Drainer {
+	d := &Drainer{gs: gs, conn: conn}
+	gs.AddHook(name, d.drain, opts...)
+
+	return d
+}
+
+// AddSubscription is a method of the Drainer struct. It registers the provided
+// subscription as intake of the GracefulShutdowner under the provided name, so that it
+// stops receiving messages once the shutdown is triggered, see gogs.AddIntake. The name
+// identifies the subscription if it is not drained in time.
+func (d *Drainer) AddSubscription(name string, sub Subscription) {
+	d.mu.Lock()
+	d.subs = append(d.subs, namedSubscription{name: name, sub: sub})
+	d.mu.Unlock()
+
+	d.gs.AddIntake(name, sub.Drain)
+}
+
+// drain is a method of the Drainer struct. It drains the connection and waits for it to
+// be closed, giving up shortly before the deadline of the context.
+func (d *Drainer) drain(ctx context.Context) error {
+	if err := d.conn.Drain(); err != nil && !d.conn.IsClosed() {
+		return err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline.Add(-reportMargin))
+		defer cancel()
+	}
+
+	interval := time.Millisecond
+	for !d.conn.IsClosed() {
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("natsgs: %w, undrained subscriptions: %s", ctx.Err(), d.undrained())
+		case <-timer.C:
+		}
+
+		if interval *= 2; interval > maxPollInterval {
+			interval = maxPollInterval
+		}
+	}
+
+	return nil
+}
+
+// undrained is a method of the Drainer struct. It describes the subscriptions that are
+// still active, with the count of their pending messages.
+func (d *Drainer) undrained() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var names []string
+	for _, s := range d.subs {
+		if !s.sub.IsValid() {
+			continue
+		}
+
+		msgs, _, err := s.sub.Pending()
+		if err != nil {
+			names = append(names, s.name)
+			continue
+		}
+		names = append(names, fmt.Sprintf("%s (%d pending)", s.name, msgs))
+	}
+
+	if len(names) == 0 {
+		return "none"
+	}
+
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,96 @@
+package natsgs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+type testConn struct {
+	drainErr error
+	closed   atomic.Bool
+	closeIn  time.Duration
+}
+
+func (c *testConn) Drain() error {
+	if c.closeIn >= 0 {
+		time.AfterFunc(c.closeIn, func() { c.closed.Store(true) })
+	}
+	return c.drainErr
+}
+
+func (c *testConn) IsClosed() bool {
+	return c.closed.Load()
+}
+
+type testSubscription struct {
+	drained atomic.Bool
+	pending int
+}
+
+func (s *testSubscription) Drain() error {
+	s.drained.Store(true)
+	return nil
+}
+
+func (s *testSubscription) IsValid() bool {
+	return s.pending > 0
+}
+
+func (s *testSubscription) Pending() (int, int, error) {
+	return s.pending, s.pending * 100, nil
+}
+
+func Test_Register(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := gogs.New(context.Background())
+
+	conn := &testConn{closeIn: 10 * time.Millisecond}
+	orders := &testSubscription{}
+	d := Register(gs, "nats", conn)
+	d.AddSubscription("orders", orders)
+
+	cancel()
+	assert.True(t, orders.drained.Load())
+
+	gs.Wait()
+
+	assert.True(t, conn.IsClosed())
+	report := gs.Report()
+	assert.NoError(t, report.Err())
+	assert.Equal(t, "orders", report.Intake[0].Name)
+	assert.Equal(t, "nats", report.Hooks[0].Name)
+}
+
+func Test_Register_Undrained(t *testing.T) {
+	t.Parallel()
+	gs, _, _ := gogs.New(context.Background())
+
+	d := Register(gs, "nats", &testConn{closeIn: -1}, gogs.WithTimeout(20*time.Millisecond))
+	d.AddSubscription("orders", &testSubscription{pending: 12})
+	d.AddSubscription("audit", &testSubscription{})
+
+	gs.Wait()
+
+	err := gs.Report().Hooks[0].Err
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.EqualError(t, err, "natsgs: context deadline exceeded, undrained subscriptions: orders (12 pending)")
+}
+
+func Test_Register_DrainError(t *testing.T) {
+	t.Parallel()
+	gs, _, _ := gogs.New(context.Background())
+
+	errFailed := errors.New("failed")
+	Register(gs, "nats", &testConn{drainErr: errFailed, closeIn: -1})
+
+	gs.Wait()
+
+	assert.ErrorIs(t, gs.Report().Hooks[0].Err, errFailed)
+}
@@ -0,0 +1,87 @@
+package gogs
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// methodPrefix is the common prefix of the names of the GracefulShutdown methods. It is
+// used to skip the frames of this package when looking for the caller.
+var methodPrefix = func() string {
+	name := runtime.FuncForPC(reflect.ValueOf((*GracefulShutdown).Count).Pointer()).Name()
+	return name[:strings.LastIndex(name, ".")+1]
+}()
+
+// Event is a struct that describes a change of the count of active shutdown events.
+type Event struct {
+	// Delta is the change of the count, positive for subscriptions and negative for
+	// unsubscriptions.
+	Delta int32
+
+	// Count is the count of active shutdown events after the change.
+	Count int32
+
+	// Caller is the frame of the code that subscribed or unsubscribed.
+	Caller runtime.Frame
+}
+
+// Observer is a struct that holds the callbacks invoked on every subscription and
+// unsubscription. The callbacks are invoked synchronously and must not block.
+type Observer struct {
+	// OnSubscribe is invoked after the count of active shutdown events is incremented.
+	OnSubscribe func(event Event)
+
+	// OnUnsubscribe is invoked after the count of active shutdown events is decremented.
+	OnUnsubscribe func(event Event)
+}
+
+// WithObserver is an option that sets the observer notified about every change of the
+// count of active shutdown events. It enables custom bookkeeping, leak detection and
+// debugging of unexpected count changes.
+//
+//	gs, ctx, cancel := New(context.Background(), WithObserver(Observer{
+//		OnSubscribe: func(e Event) {
+//			log.Printf("subscribed at %s:%d, count %d", e.Caller.File, e.Caller.Line, e.Count)
+//		},
+//	}))
+//
+// This example logs the location of every subscription.
+func WithObserver(observer Observer) Option {
+	return func(cfg *config) {
+		cfg.observer = observer
+	}
+}
+
+// notify is a method of the GracefulShutdown struct. It invokes the provided callback, if
+// any, with the change of the count and the frame of the caller.
+func (gs *GracefulShutdown) notify(callback func(event Event), delta, count int32) {
+	if gs.debug.Load() {
+		frame := callerFrame()
+		gs.debugf("count %+d to %d at %s:%d", delta, count, frame.File, frame.Line)
+	}
+	if callback == nil {
+		return
+	}
+
+	callback(Event{
+		Delta:  delta,
+		Count:  count,
+		Caller: callerFrame(),
+	})
+}
+
+// callerFrame is a function that returns the first frame of the call stack outside of the
+// GracefulShutdown methods.
+func callerFrame() runtime.Frame {
+	pcs := make([]uintptr, 16)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, methodPrefix) || !more {
+			return frame
+		}
+	}
+}
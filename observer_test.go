@@ -0,0 +1,44 @@
+package gogs
+
+import (
+	"context"
+	"runtime"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GracefulShutdown_Observer(t *testing.T) {
+	t.Parallel()
+
+	var events []Event
+	record := func(e Event) {
+		events = append(events, e)
+	}
+	gs, _, cancel := New(
+		context.Background(),
+		WithSignals(syscall.SIGINT),
+		WithObserver(Observer{OnSubscribe: record, OnUnsubscribe: record}),
+	)
+	defer cancel()
+
+	_, file, line, _ := runtime.Caller(0)
+	gs.SubscribeN(3)
+	gs.Unsubscribe()
+	gs.UnsubscribeFn(func() {})
+	gs.UnsubscribeN(5)
+	gs.Unsubscribe()
+
+	assert.Len(t, events, 4)
+	assert.Equal(t, []int32{3, -1, -1, -1}, []int32{
+		events[0].Delta, events[1].Delta, events[2].Delta, events[3].Delta,
+	})
+	assert.Equal(t, []int32{3, 2, 1, 0}, []int32{
+		events[0].Count, events[1].Count, events[2].Count, events[3].Count,
+	})
+	for i, e := range events {
+		assert.Equal(t, file, e.Caller.File)
+		assert.Equal(t, line+1+i, e.Caller.Line)
+	}
+}
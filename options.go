@@ -0,0 +1,352 @@
+package gogs
+
+import (
+	"io"
+	"log"
+	"os"
+	"syscall"
+	"time"
+)
+
+// defaultSignals is the list of signals that trigger the shutdown unless WithSignals is
+// provided.
+var defaultSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// Option is a function that configures a GracefulShutdowner created by New.
+type Option func(cfg *config)
+
+// config is a struct that holds the configuration built from the options.
+type config struct {
+	// signals is the list of signals that trigger the shutdown, and signalsSet reports
+	// whether it was set with WithSignals. Empty means all signals once set.
+	signals    []os.Signal
+	signalsSet bool
+
+	// ignored is the set of signals that never trigger the shutdown.
+	ignored map[os.Signal]bool
+
+	// handlers maps the signals routed to a handler instead of triggering the shutdown.
+	handlers map[os.Signal]func(os.Signal)
+
+	// beforeCancel is the list of callbacks invoked when a signal triggers the shutdown,
+	// before the context is canceled, see WithBeforeCancel.
+	beforeCancel []func(sig os.Signal, at time.Time)
+
+	// policies maps the shutdown reasons to their policies.
+	policies map[Reason]Policy
+
+	// observer is notified about every change of the count of active shutdown events.
+	observer Observer
+
+	// progressInterval is the interval between two snapshots sent by Progress.
+	progressInterval time.Duration
+
+	// gracePeriod limits the shutdown when the Policy of the reason has no grace period.
+	gracePeriod time.Duration
+
+	// killDelay is the delay after the deadline of the shutdown after which the process
+	// exits.
+	killDelay time.Duration
+
+	// terminalTrace enables the terminal trace, written to traceOut instead of the
+	// standard error if set, see WithTerminalTrace.
+	terminalTrace bool
+	traceOut      io.Writer
+
+	// devFast skips the drain and shrinks the budgets, see WithDevFastShutdown.
+	devFast bool
+
+	// sources is the list of triggers starting the shutdown, see WithTrigger.
+	sources []Trigger
+
+	// archive configures the files the reports are written to, see WithReportArchive.
+	// Nil means none.
+	archive *ReportArchive
+
+	// suspendAware pauses the deadline of the shutdown and the kill delay watchdog while
+	// the process is suspended, see WithSuspendAware. suspend stops the process, it is
+	// replaced in tests.
+	suspendAware bool
+	suspend      func()
+
+	// statusHandler is invoked on the status signals, see OnStatusRequest.
+	statusHandler func(s Snapshot, pending []Subscription)
+
+	// debugSignal toggles the debug logging, and debugInterval is the interval of the dumps
+	// of the pending subscriptions, see WithDebugSignal.
+	debugSignal   os.Signal
+	debugInterval time.Duration
+
+	// leakDetection attaches a finalizer to the tokens, see WithLeakDetection.
+	leakDetection bool
+
+	// freezeInterval is the interval at which the watchdog checks the clock to account for
+	// the time the process did not run. Zero means the watchdog relies on a timer.
+	freezeInterval time.Duration
+
+	// cleanupBuckets is the list of upper bounds of the buckets of CleanupStats. Nil means
+	// the default ones.
+	cleanupBuckets []time.Duration
+
+	// waitGroup counts the active shutdown events instead of the sync.WaitGroup of the
+	// instance. Nil means the latter.
+	waitGroup WaitGroup
+
+	// extensions is the policy granting extensions to the hooks. Nil means none.
+	extensions *extensionPolicy
+
+	// phaseTimeouts maps the phases to their timeouts.
+	phaseTimeouts map[Phase]time.Duration
+
+	// startupBarrier is the maximum time the shutdown waits for the end of the startup.
+	// Zero means no wait, see WithStartupBarrier.
+	startupBarrier time.Duration
+
+	// skippedHooks is the set of names of the hooks skipped, see WithSkippedHooks.
+	skippedHooks map[string]bool
+
+	// hookTimeouts maps the names of the hooks to their timeouts, unless set with
+	// WithTimeout.
+	hookTimeouts map[string]time.Duration
+
+	// registries is the list of registries bound to the GracefulShutdowner by New.
+	registries []*Registry
+
+	// quotas maps the categories of hooks to their shares of the budget of the hooks.
+	quotas map[string]float64
+
+	// logger is the logger the hooks receive in their HookContext.
+	logger *log.Logger
+
+	// contextCheck enables the warnings about goroutines started with a context not
+	// derived from the context created by New.
+	contextCheck bool
+
+	// hookPool is the count of goroutines executing the hooks spawned at creation.
+	hookPool int
+
+	// pprofLabels is the list of additional key-value pairs labeling the goroutines of the
+	// hooks. Nil means the goroutines are not labeled.
+	pprofLabels []string
+
+	// stopOrder is the order of the intake stop and the context cancellation.
+	stopOrder StopOrder
+
+	// abortWindow is the time Trigger holds the shutdown before starting it, see Abort.
+	// Zero means the shutdown starts at once.
+	abortWindow time.Duration
+
+	// debounceWindow is the window within which the repeats of the signal that triggered
+	// the shutdown are ignored, and escalate receives the other signals. Zero means the
+	// signals are no longer received once the shutdown is triggered.
+	debounceWindow time.Duration
+	escalate       func(sig os.Signal)
+
+	// deadlockThreshold is the time the drain without deadline may make no progress
+	// before the deadlock warning. Zero means the default one, less means none, see
+	// WithDeadlockWarning.
+	deadlockThreshold time.Duration
+
+	// pipelining starts the hooks registered with WithEarlyStart along with the drain,
+	// see WithPipelining.
+	pipelining bool
+
+	// smoke configures the shutdown simulated right after the startup, see
+	// ParseSimulateShutdown. Nil means none.
+	smoke *smokeTest
+
+	// envSnapshot records the snapshot of the environment in the report, with the values
+	// of envVars, see WithEnvSnapshot.
+	envSnapshot bool
+	envVars     []string
+
+	// exit terminates the process when the kill delay elapses. It is replaced in tests.
+	exit func(code int)
+}
+
+// Policy is a struct that describes how the shutdown is performed for a particular Reason.
+type Policy struct {
+	// GracePeriod limits the time Wait spends waiting for the active shutdown events and
+	// executing the hooks. Zero means no limit.
+	GracePeriod time.Duration
+}
+
+// WithSignals is an option that sets the signals triggering the shutdown, an interrupt or
+// termination signal by default. As with signal.Notify, all incoming signals trigger the
+// shutdown if none are provided, except SIGURG, which the Go runtime sends to preempt
+// goroutines and which is never listened to, see NoiseSignals for the other ones.
+func WithSignals(signals ...os.Signal) Option {
+	return func(cfg *config) {
+		cfg.signals = signals
+		cfg.signalsSet = true
+	}
+}
+
+// WithPolicy is an option that sets the Policy applied when the shutdown is triggered for
+// the provided reason. It allows, for example, draining faster when the parent context is
+// canceled than when a termination signal is received.
+func WithPolicy(reason Reason, policy Policy) Option {
+	return func(cfg *config) {
+		if cfg.policies == nil {
+			cfg.policies = make(map[Reason]Policy)
+		}
+		cfg.policies[reason] = policy
+	}
+}
+
+// WithGracePeriod is an option that limits the time Wait spends waiting for the active
+// shutdown events and executing the hooks. The grace period of the Policy of the
+// shutdown reason, if any, takes precedence.
+func WithGracePeriod(gracePeriod time.Duration) Option {
+	return func(cfg *config) {
+		cfg.gracePeriod = gracePeriod
+	}
+}
+
+// WithKillDelay is an option that starts a watchdog exiting the process with code 1 once
+// the provided delay has elapsed after the deadline of the shutdown, in case abandoned
+// hooks or the code running after Wait keep the process alive. The watchdog is only
+// started when the shutdown has a deadline, e.g. a grace period, and is stopped once the
+// shutdown has completed without abandoning any shutdown event, hook or blocking call, so
+// that it is no hard limit on the lifetime of the process after a clean shutdown.
+func WithKillDelay(killDelay time.Duration) Option {
+	return func(cfg *config) {
+		cfg.killDelay = killDelay
+	}
+}
+
+// WithPhaseTimeout is an option that limits the time spent in the provided phase of the
+// shutdown, within the overall deadline. When the sum of the timeouts of the phases
+// exceeds the time left until the deadline, the timeouts are scaled down proportionally
+// and the resulting schedule is listed in the Report.
+//
+//	gs, ctx, cancel := New(
+//		context.Background(),
+//		WithGracePeriod(30*time.Second),
+//		WithPhaseTimeout(PhaseDrain, 20*time.Second),
+//	)
+//
+// This example leaves at least ten seconds to the hooks.
+func WithPhaseTimeout(phase Phase, timeout time.Duration) Option {
+	return func(cfg *config) {
+		if cfg.phaseTimeouts == nil {
+			cfg.phaseTimeouts = make(map[Phase]time.Duration)
+		}
+		cfg.phaseTimeouts[phase] = timeout
+	}
+}
+
+// WithHookTimeout is an option that limits the execution of the hooks registered with the
+// provided name, unless they set their own timeout with WithTimeout. It allows tuning the
+// hooks from the outside, e.g. from a PolicyFile.
+func WithHookTimeout(name string, timeout time.Duration) Option {
+	return func(cfg *config) {
+		if cfg.hookTimeouts == nil {
+			cfg.hookTimeouts = make(map[string]time.Duration)
+		}
+		cfg.hookTimeouts[name] = timeout
+	}
+}
+
+// WithIgnoredSignals is an option that prevents the provided signals from triggering the
+// shutdown, even if they are listed in WithSignals or if all signals are listed. It
+// prevents accidental shutdowns on noise signals, see NoiseSignals.
+func WithIgnoredSignals(signals ...os.Signal) Option {
+	return func(cfg *config) {
+		if cfg.ignored == nil {
+			cfg.ignored = make(map[os.Signal]bool)
+		}
+		for _, sig := range signals {
+			cfg.ignored[sig] = true
+		}
+	}
+}
+
+// WithSignalHandler is an option that routes the provided signals to the handler instead
+// of triggering the shutdown. The signals must also be listed in WithSignals, if provided,
+// unless all signals are listed. The handler is invoked synchronously and must not block.
+//
+//	gs, ctx, cancel := New(
+//		context.Background(),
+//		WithSignals(syscall.SIGTERM, syscall.SIGHUP),
+//		WithSignalHandler(func(os.Signal) { reloadConfig() }, syscall.SIGHUP),
+//	)
+//
+// This example reloads the configuration on SIGHUP and shuts down on SIGTERM only.
+func WithSignalHandler(handler func(sig os.Signal), signals ...os.Signal) Option {
+	return func(cfg *config) {
+		if cfg.handlers == nil {
+			cfg.handlers = make(map[os.Signal]func(os.Signal))
+		}
+		for _, sig := range signals {
+			cfg.handlers[sig] = handler
+		}
+	}
+}
+
+// WithBeforeCancel is an option that invokes the provided callback synchronously when a
+// signal triggers the shutdown, with the signal and the time it was received at, before
+// the context created by New is canceled. Unlike the goroutines waiting for the context,
+// whose order is nondeterministic, the callback is guaranteed to run first, e.g. to flip
+// the readiness or to log the exact time of receipt. It must not block, and the option
+// can be repeated, in which case the callbacks are invoked in order.
+//
+//	gs, ctx, cancel := New(
+//		context.Background(),
+//		WithSignals(syscall.SIGINT, syscall.SIGTERM),
+//		WithBeforeCancel(func(sig os.Signal, at time.Time) {
+//			ready.Store(false)
+//			log.Printf("received %s at %s", sig, at.Format(time.RFC3339Nano))
+//		}),
+//	)
+//
+// This example fails the readiness probe before any component sees the shutdown.
+func WithBeforeCancel(callback func(sig os.Signal, at time.Time)) Option {
+	return func(cfg *config) {
+		cfg.beforeCancel = append(cfg.beforeCancel, callback)
+	}
+}
+
+// triggers is a method of the config struct. It reports whether the provided signal
+// triggers the shutdown, invoking its handler if the signal is routed.
+func (cfg *config) triggers(sig os.Signal) bool {
+	if cfg.ignored[sig] {
+		return false
+	}
+
+	if handler, ok := cfg.handlers[sig]; ok {
+		handler(sig)
+		return false
+	}
+
+	return true
+}
+
+// notified is a method of the config struct. It returns the signals to listen to: the ones
+// set with WithSignals, all of them if none were, or the default ones along with the routed
+// ones if WithSignals is not provided.
+func (cfg *config) notified() []os.Signal {
+	if !cfg.signalsSet {
+		signals := append([]os.Signal(nil), defaultSignals...)
+		for sig := range cfg.handlers {
+			signals = append(signals, sig)
+		}
+		return signals
+	}
+
+	if len(cfg.signals) == 0 {
+		return allSignals
+	}
+
+	return cfg.signals
+}
+
+// triggering is a method of the config struct. It returns the signals triggering the
+// shutdown, the default ones if WithSignals is not provided. Empty means all signals.
+func (cfg *config) triggering() []os.Signal {
+	if !cfg.signalsSet {
+		return defaultSignals
+	}
+
+	return cfg.signals
+}
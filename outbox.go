@@ -0,0 +1,100 @@
+package gogs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// defaultPersistReserve is the default time an outbox keeps before the deadline to
+// persist the messages it could not flush.
+const defaultPersistReserve = time.Second
+
+// Outbox is a struct that describes buffered messages flushed at shutdown, and persisted
+// to disk when they cannot be flushed in time, see AddOutbox.
+type Outbox struct {
+	// Flush sends the buffered messages. It must return once the context is done.
+	Flush func(ctx context.Context) error
+
+	// Persist writes the messages left after Flush to the provided writer.
+	Persist func(w io.Writer) error
+
+	// Dir is the directory of the file the messages are persisted to. Empty means the
+	// default directory for temporary files.
+	Dir string
+
+	// Reserve is the time kept before the deadline of the hook to persist the messages.
+	// Zero means one second.
+	Reserve time.Duration
+}
+
+// AddOutbox is a function that registers a named hook flushing the outbox. If the flush
+// fails or does not complete before the reserve preceding the deadline of the hook, the
+// remaining messages are persisted to a new file whose path is listed in the Report, so
+// that they are not silently lost. The error of the flush is reported either way.
+//
+//	AddOutbox(gs, "events", Outbox{
+//		Flush:   producer.Flush,
+//		Persist: producer.WritePending,
+//		Dir:     "/var/lib/app/outbox",
+//	}, WithTimeout(10*time.Second))
+//
+// This example sends the pending events for up to nine seconds and writes the unsent ones
+// to the outbox directory in the last second.
+func AddOutbox(gs HookRegistrar, name string, outbox Outbox, opts ...HookOption) {
+	gs.AddHook(name, func(ctx context.Context) error {
+		return outbox.run(ctx, name)
+	}, opts...)
+}
+
+// run is a method of the Outbox struct. It flushes the outbox within the deadline of the
+// provided context less the reserve, and persists the remaining messages on failure.
+func (o Outbox) run(ctx context.Context, name string) error {
+	reserve := o.Reserve
+	if reserve <= 0 {
+		reserve = defaultPersistReserve
+	}
+
+	flushCtx := ctx
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancel context.CancelFunc
+		flushCtx, cancel = context.WithDeadline(ctx, deadline.Add(-reserve))
+		defer cancel()
+	}
+
+	flushErr := o.Flush(flushCtx)
+	if flushErr == nil {
+		return nil
+	}
+
+	path, err := o.persist(name)
+	if err != nil {
+		return fmt.Errorf("flush: %w; persist: %v", flushErr, err)
+	}
+	setPersisted(ctx, path)
+
+	return fmt.Errorf("flush: %w; persisted to %s", flushErr, path)
+}
+
+// persist is a method of the Outbox struct. It writes the remaining messages to a new
+// file named after the hook and returns its path.
+func (o Outbox) persist(name string) (string, error) {
+	f, err := os.CreateTemp(o.Dir, name+"-*.outbox")
+	if err != nil {
+		return "", err
+	}
+
+	if err = o.Persist(f); err != nil {
+		_ = f.Close()
+		return "", err
+	}
+
+	if err = f.Sync(); err != nil {
+		_ = f.Close()
+		return "", err
+	}
+
+	return f.Name(), f.Close()
+}
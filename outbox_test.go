@@ -0,0 +1,96 @@
+package gogs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_AddOutbox(t *testing.T) {
+	t.Parallel()
+	gs, _, _ := New(context.Background(), WithSignals(syscall.SIGINT))
+
+	var persisted bool
+	AddOutbox(gs, "events", Outbox{
+		Flush: func(context.Context) error { return nil },
+		Persist: func(io.Writer) error {
+			persisted = true
+			return nil
+		},
+		Dir: t.TempDir(),
+	})
+
+	gs.Wait()
+
+	assert.False(t, persisted)
+	report := gs.Report()
+	assert.Len(t, report.Hooks, 1)
+	assert.NoError(t, report.Hooks[0].Err)
+	assert.Empty(t, report.Hooks[0].Persisted)
+}
+
+func Test_AddOutbox_Persist(t *testing.T) {
+	t.Parallel()
+	gs, _, _ := New(context.Background(), WithSignals(syscall.SIGINT))
+	dir := t.TempDir()
+
+	var flushLeft time.Duration
+	AddOutbox(gs, "events", Outbox{
+		Flush: func(ctx context.Context) error {
+			deadline, _ := ctx.Deadline()
+			flushLeft = time.Until(deadline)
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		Persist: func(w io.Writer) error {
+			_, err := io.WriteString(w, "pending\n")
+			return err
+		},
+		Dir:     dir,
+		Reserve: time.Second,
+	}, WithTimeout(time.Second+ShortDelay))
+
+	gs.Wait()
+
+	assert.LessOrEqual(t, flushLeft, ShortDelay)
+
+	report := gs.Report()
+	assert.Len(t, report.Hooks, 1)
+	res := report.Hooks[0]
+	assert.ErrorIs(t, res.Err, context.DeadlineExceeded)
+	assert.Equal(t, dir, filepath.Dir(res.Persisted))
+	assert.True(t, strings.HasPrefix(filepath.Base(res.Persisted), "events-"))
+	assert.Contains(t, res.Err.Error(), "persisted to "+res.Persisted)
+
+	data, err := os.ReadFile(res.Persisted)
+	assert.NoError(t, err)
+	assert.Equal(t, "pending\n", string(data))
+}
+
+func Test_AddOutbox_PersistError(t *testing.T) {
+	t.Parallel()
+	gs, _, _ := New(context.Background(), WithSignals(syscall.SIGINT))
+
+	errFlush := errors.New("broker down")
+	errPersist := errors.New("disk full")
+	AddOutbox(gs, "events", Outbox{
+		Flush:   func(context.Context) error { return errFlush },
+		Persist: func(io.Writer) error { return errPersist },
+		Dir:     t.TempDir(),
+	})
+
+	gs.Wait()
+
+	res := gs.Report().Hooks[0]
+	assert.ErrorIs(t, res.Err, errFlush)
+	assert.EqualError(t, res.Err, "flush: broker down; persist: disk full")
+	assert.Empty(t, res.Persisted)
+}
@@ -0,0 +1,93 @@
+package gogs
+
+import (
+	"runtime"
+	"sync"
+)
+
+// procsBoost is a struct that raises GOMAXPROCS while hooks need it, and restores the
+// previous value once none does, since GOMAXPROCS is global to the process.
+type procsBoost struct {
+	// mu guards the fields below.
+	mu sync.Mutex
+
+	// active is the count of hooks executing with a raised GOMAXPROCS.
+	active int
+
+	// prev is the value of GOMAXPROCS before the first of them.
+	prev int
+}
+
+// boost is the procsBoost of the process.
+var boost procsBoost
+
+// WithParallelism is a hook option that raises GOMAXPROCS to the provided value while the
+// hook is executed, and restores it afterwards, so that CPU-bound work, e.g. compressing a
+// snapshot, meets a tight grace period even when the process is usually limited to fewer
+// threads. Zero or less means the count of CPUs. GOMAXPROCS is never lowered, and the
+// report tells the value the hook ran with and the CPU time the process consumed
+// meanwhile, where the platform reports it.
+//
+//	gs.AddHook("snapshot", compressSnapshot, WithParallelism(0), WithFinal())
+//
+// This example compresses the snapshot with all the CPUs of the machine.
+func WithParallelism(procs int) HookOption {
+	return func(h *hook) {
+		if procs <= 0 {
+			procs = runtime.NumCPU()
+		}
+		h.procs = procs
+	}
+}
+
+// raise is a method of the procsBoost struct. It raises GOMAXPROCS to at least the
+// provided value, and returns the value in effect and the function restoring it.
+func (b *procsBoost) raise(procs int) (int, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	current := runtime.GOMAXPROCS(0)
+	if b.active == 0 {
+		b.prev = current
+	}
+	b.active++
+	if procs > current {
+		runtime.GOMAXPROCS(procs)
+		current = procs
+	}
+
+	var once sync.Once
+	return current, func() {
+		once.Do(b.release)
+	}
+}
+
+// release is a method of the procsBoost struct. It restores GOMAXPROCS once no hook needs
+// it raised anymore.
+func (b *procsBoost) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.active--
+	if b.active == 0 {
+		runtime.GOMAXPROCS(b.prev)
+	}
+}
+
+// runParallel is a function that executes the provided hook with runHook, raising
+// GOMAXPROCS as configured with WithParallelism, and records the measurements.
+func runParallel(run func() HookResult, procs int) HookResult {
+	if procs <= 0 {
+		return run()
+	}
+
+	effective, restore := boost.raise(procs)
+	defer restore()
+
+	cpu := processCPUTime()
+	res := run()
+	res.Procs = effective
+	res.CPUTime = processCPUTime() - cpu
+
+	return res
+}
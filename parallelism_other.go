@@ -0,0 +1,11 @@
+//go:build !unix
+
+package gogs
+
+import "time"
+
+// processCPUTime is a function that returns the CPU time consumed by the process so far.
+// It is not measured on this platform, so it returns zero.
+func processCPUTime() time.Duration {
+	return 0
+}
@@ -0,0 +1,56 @@
+package gogs
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WithParallelism(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(1))
+	gs, _, cancel := New(context.Background())
+
+	var procs int
+	gs.AddHook("snapshot", func(context.Context) error {
+		for deadline := time.Now().Add(ShortDelay / 10); time.Now().Before(deadline); {
+			procs = runtime.GOMAXPROCS(0)
+		}
+		return nil
+	}, WithParallelism(4))
+	gs.AddHook("cache", func(context.Context) error { return nil })
+
+	cancel()
+	gs.Wait()
+
+	assert.Equal(t, 4, procs)
+	assert.Equal(t, 1, runtime.GOMAXPROCS(0))
+
+	report := gs.Report()
+	if assert.Len(t, report.Hooks, 2) {
+		assert.Zero(t, report.Hooks[0].Procs)
+		assert.Equal(t, 4, report.Hooks[1].Procs)
+		if processCPUTime() > 0 {
+			assert.Positive(t, report.Hooks[1].CPUTime)
+		}
+	}
+}
+
+func Test_procsBoost_raise(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(8))
+	var b procsBoost
+
+	effective, restore := b.raise(2)
+	assert.Equal(t, 8, effective)
+
+	effective, restoreNested := b.raise(16)
+	assert.Equal(t, 16, effective)
+	restoreNested()
+	restoreNested()
+	assert.Equal(t, 16, runtime.GOMAXPROCS(0))
+
+	restore()
+	assert.Equal(t, 8, runtime.GOMAXPROCS(0))
+}
@@ -0,0 +1,19 @@
+//go:build unix
+
+package gogs
+
+import (
+	"syscall"
+	"time"
+)
+
+// processCPUTime is a function that returns the user and system CPU time consumed by the
+// process so far.
+func processCPUTime() time.Duration {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0
+	}
+
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano())
+}
@@ -0,0 +1,175 @@
+package gogs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Persistable is an interface that describes an in-memory component, e.g. a job queue,
+// whose state is saved at shutdown and loaded back at startup, see AddPersistable.
+type Persistable interface {
+	// Save writes the state to the provided writer. It must return once the context is
+	// done.
+	Save(ctx context.Context, w io.Writer) error
+
+	// Load restores the state from the provided reader.
+	Load(ctx context.Context, r io.Reader) error
+}
+
+// StateStore is an interface that describes where the states of the Persistable
+// components are kept between two runs, see FileStore.
+type StateStore interface {
+	// Write writes the state of the named component with the provided function. The
+	// previous state is replaced only if the function succeeds.
+	Write(name string, fn func(w io.Writer) error) error
+
+	// Read reads the state of the named component with the provided function. It returns
+	// an error wrapping fs.ErrNotExist if there is none.
+	Read(name string, fn func(r io.Reader) error) error
+
+	// Remove removes the state of the named component, if any.
+	Remove(name string) error
+}
+
+// FileStore is a struct that implements the StateStore interface with a file per
+// component, replaced atomically.
+type FileStore struct {
+	// Dir is the directory of the files. It must exist. Empty means the default directory
+	// for temporary files.
+	Dir string
+}
+
+// Write is a method of the FileStore struct. It writes the state to a temporary file,
+// syncs it and renames it over the file of the component.
+func (s FileStore) Write(name string, fn func(w io.Writer) error) error {
+	f, err := os.CreateTemp(s.dir(), name+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err = fn(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), s.path(name))
+}
+
+// Read is a method of the FileStore struct. It reads the file of the component.
+func (s FileStore) Read(name string, fn func(r io.Reader) error) error {
+	f, err := os.Open(s.path(name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return fn(f)
+}
+
+// Remove is a method of the FileStore struct. It removes the file of the component, if
+// any.
+func (s FileStore) Remove(name string) error {
+	if err := os.Remove(s.path(name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// dir is a method of the FileStore struct. It returns the directory of the files.
+func (s FileStore) dir() string {
+	if s.Dir == "" {
+		return os.TempDir()
+	}
+
+	return s.Dir
+}
+
+// path is a method of the FileStore struct. It returns the path of the file of the named
+// component.
+func (s FileStore) path(name string) string {
+	return filepath.Join(s.dir(), name+".state")
+}
+
+// AddPersistable is a function that loads the state of the named component from the
+// store, if any, and registers a named hook saving it back at shutdown, so that the
+// in-memory component, e.g. a job queue, survives the restarts. The state is removed from
+// the store once loaded, so that a crash before the next shutdown does not load it twice.
+// A nil store means a FileStore in the default directory for temporary files. It returns
+// the error of the load, in which case the state is kept in the store and the hook is not
+// registered, so that the state is not overwritten. The hook records the bytes it wrote
+// in the Report.
+//
+//	if err := AddPersistable(ctx, gs, "jobs", queue, FileStore{Dir: "/var/lib/app"}); err != nil {
+//		return err
+//	}
+//
+// This example loads the jobs left by the previous run and saves the pending ones at
+// shutdown.
+func AddPersistable(
+	ctx context.Context,
+	gs HookRegistrar,
+	name string,
+	p Persistable,
+	store StateStore,
+	opts ...HookOption,
+) error {
+	if store == nil {
+		store = FileStore{}
+	}
+
+	err := store.Read(name, func(r io.Reader) error {
+		return p.Load(ctx, r)
+	})
+	switch {
+	case err == nil:
+		if err = store.Remove(name); err != nil {
+			return fmt.Errorf("remove state of %s: %w", name, err)
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("load state of %s: %w", name, err)
+	}
+
+	gs.AddHook(name, func(ctx context.Context) error {
+		return store.Write(name, func(w io.Writer) error {
+			cw := &countingWriter{w: w}
+			err := p.Save(ctx, cw)
+			if hc, ok := HookContextFrom(ctx); ok {
+				hc.Record(HookStats{Bytes: cw.n})
+			}
+			return err
+		})
+	}, opts...)
+
+	return nil
+}
+
+// countingWriter is a struct that counts the bytes written to the underlying writer.
+type countingWriter struct {
+	// w is the underlying writer.
+	w io.Writer
+
+	// n is the count of bytes written.
+	n int64
+}
+
+// Write is a method of the countingWriter struct. It writes to the underlying writer.
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+
+	return n, err
+}
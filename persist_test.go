@@ -0,0 +1,112 @@
+package gogs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testQueue struct {
+	jobs []string
+	err  error
+}
+
+func (q *testQueue) Save(_ context.Context, w io.Writer) error {
+	if q.err != nil {
+		return q.err
+	}
+	_, err := io.WriteString(w, strings.Join(q.jobs, ","))
+	return err
+}
+
+func (q *testQueue) Load(_ context.Context, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return errors.New("empty state")
+	}
+	q.jobs = strings.Split(string(data), ",")
+	return nil
+}
+
+func Test_AddPersistable(t *testing.T) {
+	t.Parallel()
+	store := FileStore{Dir: t.TempDir()}
+
+	gs, _, _ := New(context.Background(), WithSignals(syscall.SIGINT))
+	queue := &testQueue{}
+	assert.NoError(t, AddPersistable(context.Background(), gs, "jobs", queue, store))
+	assert.Empty(t, queue.jobs)
+
+	queue.jobs = []string{"a", "b"}
+	gs.Wait()
+	report := gs.Report()
+	if assert.Len(t, report.Hooks, 1) {
+		assert.NoError(t, report.Hooks[0].Err)
+		assert.Equal(t, int64(3), report.Hooks[0].Stats.Bytes)
+	}
+
+	gs, _, _ = New(context.Background(), WithSignals(syscall.SIGINT))
+	queue = &testQueue{}
+	assert.NoError(t, AddPersistable(context.Background(), gs, "jobs", queue, store))
+	assert.Equal(t, []string{"a", "b"}, queue.jobs)
+	_, err := os.Stat(filepath.Join(store.Dir, "jobs.state"))
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	queue.err = errors.New("disk full")
+	gs.Wait()
+	assert.EqualError(t, gs.Report().Err(), "jobs: disk full")
+	entries, err := os.ReadDir(store.Dir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func Test_AddPersistable_LoadFailed(t *testing.T) {
+	t.Parallel()
+	store := FileStore{Dir: t.TempDir()}
+	assert.NoError(t, store.Write("jobs", func(io.Writer) error { return nil }))
+
+	gs, _, _ := New(context.Background(), WithSignals(syscall.SIGINT))
+	err := AddPersistable(context.Background(), gs, "jobs", &testQueue{}, store)
+	assert.EqualError(t, err, "load state of jobs: empty state")
+
+	gs.Wait()
+	assert.Empty(t, gs.Report().Hooks)
+	_, err = os.Stat(filepath.Join(store.Dir, "jobs.state"))
+	assert.NoError(t, err)
+}
+
+func Test_FileStore(t *testing.T) {
+	t.Parallel()
+	store := FileStore{Dir: t.TempDir()}
+
+	err := store.Read("jobs", func(io.Reader) error { return nil })
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.NoError(t, store.Remove("jobs"))
+
+	assert.NoError(t, store.Write("jobs", func(w io.Writer) error {
+		_, err := io.WriteString(w, "a")
+		return err
+	}))
+	assert.Error(t, store.Write("jobs", func(w io.Writer) error {
+		_, _ = io.WriteString(w, "b")
+		return errors.New("failed")
+	}))
+
+	var state []byte
+	assert.NoError(t, store.Read("jobs", func(r io.Reader) error {
+		state, err = io.ReadAll(r)
+		return err
+	}))
+	assert.Equal(t, "a", string(state))
+}
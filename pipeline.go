@@ -0,0 +1,99 @@
+package gogs
+
+import "context"
+
+// WithPipelining is an option that pipelines the drain and the execution of the hooks:
+// the hooks registered with WithEarlyStart start as soon as the drain starts, while the
+// active shutdown events, the critical ones included, are still being waited for, rather
+// than once they have completed. It squeezes more teardown into a short grace period. The
+// early hooks are executed one after another in the usual order, within the deadline of
+// the whole shutdown, and are listed first in the Report. The other hooks still start once
+// the drain has completed, and the shutdown completes once all hooks have.
+//
+//	gs, ctx, cancel := New(context.Background(), WithGracePeriod(10*time.Second), WithPipelining())
+//	gs.AddHook("metrics", pushMetrics, WithEarlyStart())
+//	gs.AddHook("db", db.Close)
+//
+// This example pushes the metrics while the requests in flight complete, and closes the
+// database once they have.
+func WithPipelining() Option {
+	return func(cfg *config) {
+		cfg.pipelining = true
+	}
+}
+
+// WithEarlyStart is a hook option that marks the hook as independent of the active
+// shutdown events, e.g. pushing metrics or releasing a resource the requests do not use,
+// so that it starts along with the drain when the shutdown is configured with
+// WithPipelining. Otherwise the option has no effect, as it has for a hook registered once
+// the early hooks have started, which is executed with the other hooks.
+func WithEarlyStart() HookOption {
+	return func(h *hook) {
+		h.early = true
+	}
+}
+
+// startEarlyHooks is a method of the GracefulShutdown struct. It starts executing the
+// hooks registered with WithEarlyStart for the provided reason, if the shutdown is
+// pipelined, exactly once. The results are stored once the hooks have completed.
+func (gs *GracefulShutdown) startEarlyHooks(ctx context.Context, reason Reason) {
+	if !gs.cfg.pipelining {
+		return
+	}
+
+	gs.earlyOnce.Do(func() {
+		gs.mu.Lock()
+		gs.earlyStarted = true
+		var hooks []hook
+		for _, h := range gs.hooks {
+			if h.early {
+				hooks = append(hooks, h)
+			}
+		}
+		doneCh := make(chan struct{})
+		gs.earlyCh = doneCh
+		gs.mu.Unlock()
+
+		go func() {
+			defer close(doneCh)
+
+			selected := selectHooks(hooks, reason)
+			q := newQuotas(ctx, gs.cfg.quotas)
+			results := make([]HookResult, 0, len(selected))
+			for i, h := range selected {
+				res := gs.execHook(ctx, q, h, len(selected)-i)
+				res.Module = h.module
+				results = append(results, res)
+			}
+
+			gs.mu.Lock()
+			gs.earlyResults = results
+			gs.mu.Unlock()
+		}()
+	})
+}
+
+// hooksPicked is a method of the GracefulShutdown struct. It reports whether the hooks to
+// execute, the early ones included, were selected, after which the registered hooks must
+// not change. It must be called with mu held.
+func (gs *GracefulShutdown) hooksPicked() bool {
+	return gs.hooksStarted || gs.earlyStarted
+}
+
+// earlyHooks is a method of the GracefulShutdown struct. It waits for the hooks started
+// by startEarlyHooks, if any, and returns their results.
+func (gs *GracefulShutdown) earlyHooks() []HookResult {
+	gs.mu.Lock()
+	doneCh := gs.earlyCh
+	gs.mu.Unlock()
+
+	if doneCh == nil {
+		return nil
+	}
+	<-doneCh
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	return gs.earlyResults
+}
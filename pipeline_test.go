@@ -0,0 +1,65 @@
+package gogs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WithPipelining(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background(), WithPipelining())
+
+	var pending int32
+	release := make(chan struct{})
+	gs.AddHook("metrics", func(context.Context) error {
+		pending = gs.Count()
+		close(release)
+		return nil
+	}, WithEarlyStart())
+	gs.AddHook("db", func(context.Context) error {
+		assert.Zero(t, gs.Count())
+		return nil
+	})
+
+	done := gs.SubscribeCritical("export")
+	go func() {
+		<-release
+		done()
+	}()
+
+	cancel()
+	gs.Wait()
+	assert.Equal(t, int32(1), pending)
+
+	var names []string
+	for _, res := range gs.Report().Hooks {
+		names = append(names, res.Name)
+	}
+	assert.Equal(t, []string{"metrics", "db"}, names)
+}
+
+func Test_WithEarlyStart_NotPipelined(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background())
+
+	pending := int32(-1)
+	gs.AddHook("metrics", func(context.Context) error {
+		pending = gs.Count()
+		return nil
+	}, WithEarlyStart())
+	gs.AddHook("db", func(context.Context) error { return nil })
+
+	gs.Subscribe()
+	cancel()
+	go gs.Unsubscribe()
+	gs.Wait()
+	assert.Zero(t, pending)
+
+	var names []string
+	for _, res := range gs.Report().Hooks {
+		names = append(names, res.Name)
+	}
+	assert.Equal(t, []string{"db", "metrics"}, names)
+}
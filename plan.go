@@ -0,0 +1,270 @@
+package gogs
+
+import (
+	"math"
+	"time"
+)
+
+// Plan is a struct that describes how the shutdown would be performed for a reason: its
+// budget, the timeouts of its phases and the hooks in order of execution. It is returned
+// by GracefulShutdown.Plan and can be adjusted before being passed to Simulate.
+type Plan struct {
+	// Reason is the reason the plan is made for.
+	Reason Reason
+
+	// GracePeriod limits the whole shutdown. Zero means no limit.
+	GracePeriod time.Duration
+
+	// PhaseTimeouts maps the phases to their timeouts, see WithPhaseTimeout.
+	PhaseTimeouts map[Phase]time.Duration
+
+	// Quotas maps the categories of hooks to their shares of the budget of the hooks, see
+	// WithCategoryQuota.
+	Quotas map[string]float64
+
+	// Registrars is the list of names of the entries removed in PhaseDeregister.
+	Registrars []string
+
+	// Hooks is the list of hooks executed for the reason, in order of execution.
+	Hooks []PlannedHook
+}
+
+// PlannedHook is a struct that describes a hook of a Plan.
+type PlannedHook struct {
+	// Name is the name of the hook.
+	Name string
+
+	// Timeout limits the execution of the hook, see WithTimeout and WithHookTimeout.
+	Timeout time.Duration
+
+	// BestEffort reports whether the hook is optional, and Estimate is the estimate of its
+	// duration, see WithBestEffort.
+	BestEffort bool
+	Estimate   time.Duration
+
+	// Category is the category of the hook, see WithCategory.
+	Category string
+
+	// Final reports whether the hook is executed after all other hooks, see WithFinal.
+	Final bool
+
+	// Conditional reports whether the hook is executed only if its condition is met, see
+	// WithCondition.
+	Conditional bool
+
+	// Module is the module of the hook, and Disabled reports whether the module is
+	// disabled, in which case the hook is skipped, see WithModule.
+	Module   string
+	Disabled bool
+
+	// Skipped reports whether the hook is skipped by the operator, see WithSkippedHooks.
+	Skipped bool
+}
+
+// Simulation is a struct that describes the outcome of a simulated shutdown.
+type Simulation struct {
+	// Elapsed is the simulated duration of the whole shutdown.
+	Elapsed time.Duration
+
+	// Hooks is the list of simulated hooks, in order of execution.
+	Hooks []SimulatedHook
+
+	// Abandoned is the list of names of the registrars and hooks that would be abandoned.
+	Abandoned []string
+}
+
+// SimulatedHook is a struct that describes the outcome of a simulated hook.
+type SimulatedHook struct {
+	// Name is the name of the hook.
+	Name string
+
+	// Start is the time the hook would start at, since the start of the shutdown.
+	Start time.Duration
+
+	// Duration is the time the hook would be waited for.
+	Duration time.Duration
+
+	// Skipped reports whether the hook would be skipped, because its module is disabled, it
+	// is skipped by the operator or, for a best-effort hook, for lack of budget.
+	Skipped bool
+
+	// Abandoned reports whether the hook would be abandoned, or not even started, because
+	// its timeout, its quota or the budget would be exhausted.
+	Abandoned bool
+}
+
+// Plan is a method of the GracefulShutdown struct. It returns how the shutdown would be
+// performed for the provided reason with the hooks registered so far.
+func (gs *GracefulShutdown) Plan(reason Reason) Plan {
+	grace := gs.gracePeriod(reason)
+
+	gs.mu.Lock()
+	hooks := selectHooks(gs.hooks, reason)
+	registrars := make([]string, 0, len(gs.registrars))
+	for _, r := range gs.registrars {
+		registrars = append(registrars, r.name)
+	}
+	gs.mu.Unlock()
+
+	planned := make([]PlannedHook, 0, len(hooks))
+	for _, h := range hooks {
+		timeout := h.timeout
+		if timeout <= 0 {
+			timeout = gs.cfg.hookTimeouts[h.name]
+		}
+
+		planned = append(planned, PlannedHook{
+			Name:        h.name,
+			Timeout:     timeout,
+			BestEffort:  h.bestEffort,
+			Estimate:    h.estimate,
+			Category:    h.category,
+			Final:       h.final,
+			Conditional: h.condition != nil,
+			Module:      h.module,
+			Disabled:    gs.moduleDisabled(h.module),
+			Skipped:     gs.cfg.skippedHooks[h.name],
+		})
+	}
+
+	return Plan{
+		Reason:        reason,
+		GracePeriod:   grace,
+		PhaseTimeouts: gs.cfg.phaseTimeouts,
+		Quotas:        gs.cfg.quotas,
+		Registrars:    registrars,
+		Hooks:         planned,
+	}
+}
+
+// Simulate is a function that replays the provided plan without executing anything, as
+// if the registrars and hooks took the provided durations, keyed by their names. The key
+// "drain" holds the time the active shutdown events would take to complete. Missing
+// durations are zero, and conditional hooks are assumed to be executed. It reports which
+// hooks would be abandoned, which makes it a capacity planning tool, e.g. for choosing
+// terminationGracePeriodSeconds.
+//
+//	plan := gs.Plan(ReasonSignal)
+//	plan.GracePeriod = 30 * time.Second
+//	sim := Simulate(plan, map[string]time.Duration{
+//		"drain":    10 * time.Second,
+//		"postgres": 5 * time.Second,
+//		"kafka":    20 * time.Second,
+//	})
+//
+// This example checks which hooks would be abandoned within a grace period of thirty
+// seconds given the observed durations.
+func Simulate(plan Plan, durations map[string]time.Duration) Simulation {
+	s := simulator{durations: durations}
+	if plan.GracePeriod > 0 {
+		s.deadline = plan.GracePeriod
+	}
+
+	schedule := scaleSchedule(plan.PhaseTimeouts, plan.GracePeriod)
+
+	s.deregister(plan.Registrars, s.phaseEnd(schedule, PhaseDeregister))
+	s.drain(s.phaseEnd(schedule, PhaseDrain))
+	s.close(plan, s.phaseEnd(schedule, PhaseClose))
+
+	return s.sim
+}
+
+// simulator is a struct that holds the state of a simulated shutdown.
+type simulator struct {
+	// durations maps the names of the registrars and hooks to their durations.
+	durations map[string]time.Duration
+
+	// now is the simulated time since the start of the shutdown.
+	now time.Duration
+
+	// deadline is the simulated deadline of the shutdown. Zero means no deadline.
+	deadline time.Duration
+
+	// sim is the outcome of the simulation.
+	sim Simulation
+}
+
+// phaseEnd is a method of the simulator struct. It returns the simulated time the phase
+// started now would end at, or zero if it is not limited.
+func (s *simulator) phaseEnd(schedule []PhaseBudget, phase Phase) time.Duration {
+	end := s.deadline
+	for _, pb := range schedule {
+		if pb.Phase == phase && (end == 0 || s.now+pb.Budget < end) {
+			end = s.now + pb.Budget
+		}
+	}
+
+	return end
+}
+
+// deregister is a method of the simulator struct. It simulates the concurrent removal of
+// the entries from the service registries.
+func (s *simulator) deregister(registrars []string, end time.Duration) {
+	var longest time.Duration
+	for _, name := range registrars {
+		d := s.durations[name]
+		if end > 0 && s.now+d > end {
+			d = end - s.now
+			s.sim.Abandoned = append(s.sim.Abandoned, name)
+		}
+		if d > longest {
+			longest = d
+		}
+	}
+
+	s.now += longest
+}
+
+// drain is a method of the simulator struct. It simulates the wait for the active
+// shutdown events.
+func (s *simulator) drain(end time.Duration) {
+	d := s.durations[string(PhaseDrain)]
+	if end > 0 && s.now+d > end {
+		d = end - s.now
+	}
+
+	s.now += d
+}
+
+// close is a method of the simulator struct. It simulates the execution of the hooks.
+func (s *simulator) close(plan Plan, end time.Duration) {
+	var q quotas
+	if end > 0 {
+		q = quotasFor(end-s.now, plan.Quotas)
+	}
+
+	for _, ph := range plan.Hooks {
+		res := SimulatedHook{Name: ph.Name, Start: s.now}
+		left := time.Duration(math.MaxInt64)
+		if end > 0 {
+			left = end - s.now
+		}
+
+		h, ok := q.limit(hook{name: ph.Name, category: ph.Category, timeout: ph.Timeout})
+		switch {
+		case ph.Disabled, ph.Skipped, ph.BestEffort && end > 0 && left < ph.Estimate:
+			res.Skipped = true
+		case left <= 0 || !ok:
+			res.Abandoned = true
+		default:
+			if h.timeout > 0 && h.timeout < left {
+				left = h.timeout
+			}
+
+			res.Duration = s.durations[ph.Name]
+			if res.Duration > left {
+				res.Duration = left
+				res.Abandoned = true
+			}
+			q.spend(h, res.Duration)
+		}
+
+		if res.Abandoned {
+			s.sim.Abandoned = append(s.sim.Abandoned, ph.Name)
+		}
+		s.now += res.Duration
+		s.sim.Hooks = append(s.sim.Hooks, res)
+	}
+
+	s.sim.Elapsed = s.now
+}
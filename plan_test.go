@@ -0,0 +1,85 @@
+package gogs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GracefulShutdown_Plan(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(
+		context.Background(),
+		WithGracePeriod(time.Minute),
+		WithPolicy(ReasonParent, Policy{GracePeriod: time.Second}),
+		WithHookTimeout("db", 5*time.Second),
+	)
+	defer cancel()
+
+	noop := func(context.Context) error { return nil }
+	gs.AddRegistrar("consul", &testRegistrar{})
+	gs.AddHook("metrics", noop, WithFinal())
+	gs.AddHook("db", noop)
+	gs.AddHook("cache", noop, WithBestEffort(time.Second), WithCategory("flushers"))
+	gs.AddHook("dump", noop, WithReasons(ReasonSignal), WithCondition(func() bool { return true }))
+
+	plan := gs.Plan(ReasonSignal)
+	assert.Equal(t, time.Minute, plan.GracePeriod)
+	assert.Equal(t, []string{"consul"}, plan.Registrars)
+	assert.Equal(t, []PlannedHook{
+		{Name: "dump", Conditional: true},
+		{Name: "db", Timeout: 5 * time.Second},
+		{Name: "cache", BestEffort: true, Estimate: time.Second, Category: "flushers"},
+		{Name: "metrics", Final: true},
+	}, plan.Hooks)
+
+	plan = gs.Plan(ReasonParent)
+	assert.Equal(t, time.Second, plan.GracePeriod)
+	assert.Len(t, plan.Hooks, 3)
+}
+
+func Test_Simulate(t *testing.T) {
+	t.Parallel()
+
+	plan := Plan{
+		GracePeriod:   30 * time.Second,
+		PhaseTimeouts: map[Phase]time.Duration{PhaseDrain: 20 * time.Second},
+		Quotas:        map[string]float64{"flushers": 0.5},
+		Registrars:    []string{"consul"},
+		Hooks: []PlannedHook{
+			{Name: "kafka", Category: "flushers"},
+			{Name: "postgres", Timeout: time.Second},
+			{Name: "nats", Category: "flushers"},
+			{Name: "warm-state", BestEffort: true, Estimate: 5 * time.Second},
+			{Name: "metrics", Final: true},
+			{Name: "logs", Final: true},
+		},
+	}
+
+	sim := Simulate(plan, map[string]time.Duration{
+		"consul":   2 * time.Second,
+		"drain":    25 * time.Second,
+		"kafka":    3 * time.Second,
+		"postgres": 2 * time.Second,
+		"nats":     2 * time.Second,
+		"metrics":  time.Second,
+		"logs":     time.Second,
+	})
+
+	assert.Equal(t, []SimulatedHook{
+		{Name: "kafka", Start: 22 * time.Second, Duration: 3 * time.Second},
+		{Name: "postgres", Start: 25 * time.Second, Duration: time.Second, Abandoned: true},
+		{Name: "nats", Start: 26 * time.Second, Duration: time.Second, Abandoned: true},
+		{Name: "warm-state", Start: 27 * time.Second, Skipped: true},
+		{Name: "metrics", Start: 27 * time.Second, Duration: time.Second},
+		{Name: "logs", Start: 28 * time.Second, Duration: time.Second},
+	}, sim.Hooks)
+	assert.Equal(t, []string{"postgres", "nats"}, sim.Abandoned)
+	assert.Equal(t, 29*time.Second, sim.Elapsed)
+
+	sim = Simulate(Plan{Hooks: []PlannedHook{{Name: "db"}}}, map[string]time.Duration{"db": time.Hour})
+	assert.Empty(t, sim.Abandoned)
+	assert.Equal(t, time.Hour, sim.Elapsed)
+}
@@ -0,0 +1,244 @@
+package gogs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SignalAction is a type that describes what a signal listed in a PolicyFile does.
+type SignalAction string
+
+const (
+	// SignalShutdown means that the signal triggers the shutdown.
+	SignalShutdown SignalAction = "shutdown"
+
+	// SignalIgnore means that the signal never triggers the shutdown.
+	SignalIgnore SignalAction = "ignore"
+)
+
+// Duration is a time.Duration that is decoded from strings in the time.ParseDuration
+// format, e.g. "30s", by encoding/json and by YAML decoders supporting
+// encoding.TextUnmarshaler.
+type Duration time.Duration
+
+// UnmarshalText is a method of the Duration type. It parses the duration from the text.
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+
+	return nil
+}
+
+// MarshalText is a method of the Duration type. It formats the duration as text.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// PolicyFile is a struct that describes the shutdown policy as configuration, so that
+// drain behavior can be managed across many services without recompiling them.
+//
+//	{
+//		"grace_period": "30s",
+//		"kill_delay": "5s",
+//		"phases": {"drain": "20s"},
+//		"hooks": {"postgres": "3s"},
+//		"reasons": {"parent": "1s"},
+//		"signals": {"SIGTERM": "shutdown", "SIGINT": "shutdown", "SIGHUP": "ignore"}
+//	}
+//
+// This example describes a policy draining for up to twenty seconds out of thirty, and
+// exiting the process five seconds after the deadline.
+type PolicyFile struct {
+	// GracePeriod limits the shutdown, see WithGracePeriod.
+	GracePeriod Duration `json:"grace_period" yaml:"grace_period"`
+
+	// KillDelay is the delay after the deadline after which the process exits, see
+	// WithKillDelay.
+	KillDelay Duration `json:"kill_delay" yaml:"kill_delay"`
+
+	// Phases maps the phases to their timeouts, see WithPhaseTimeout.
+	Phases map[Phase]Duration `json:"phases" yaml:"phases"`
+
+	// Hooks maps the names of the hooks to their timeouts, see WithHookTimeout.
+	Hooks map[string]Duration `json:"hooks" yaml:"hooks"`
+
+	// Reasons maps the shutdown reasons to their grace periods, see WithPolicy.
+	Reasons map[Reason]Duration `json:"reasons" yaml:"reasons"`
+
+	// Signals maps the names of the signals, e.g. "SIGTERM" or "TERM", to their actions.
+	// The signals triggering the shutdown replace the ones set with WithSignals.
+	Signals map[string]SignalAction `json:"signals" yaml:"signals"`
+}
+
+// Validate is a method of the PolicyFile struct. It returns an error describing the first
+// invalid setting of the policy, or nil if the policy is valid.
+func (p PolicyFile) Validate() error {
+	if p.GracePeriod < 0 {
+		return fmt.Errorf("gogs: invalid policy: negative grace_period %s", time.Duration(p.GracePeriod))
+	}
+	if p.KillDelay < 0 {
+		return fmt.Errorf("gogs: invalid policy: negative kill_delay %s", time.Duration(p.KillDelay))
+	}
+
+	for phase, timeout := range p.Phases {
+		if !isTimedPhase(phase) {
+			return fmt.Errorf("gogs: invalid policy: unknown phase %q", phase)
+		}
+		if timeout < 0 {
+			return fmt.Errorf("gogs: invalid policy: negative timeout of phase %q", phase)
+		}
+	}
+
+	for name, timeout := range p.Hooks {
+		if timeout < 0 {
+			return fmt.Errorf("gogs: invalid policy: negative timeout of hook %q", name)
+		}
+	}
+
+	for reason, grace := range p.Reasons {
+		if reason == "" {
+			return fmt.Errorf("gogs: invalid policy: empty reason")
+		}
+		if grace < 0 {
+			return fmt.Errorf("gogs: invalid policy: negative grace period of reason %q", reason)
+		}
+	}
+
+	for name, action := range p.Signals {
+		if _, ok := lookupSignal(name); !ok {
+			return fmt.Errorf("gogs: invalid policy: unknown signal %q", name)
+		}
+		if action != SignalShutdown && action != SignalIgnore {
+			return fmt.Errorf("gogs: invalid policy: unknown action %q of signal %q", action, name)
+		}
+	}
+
+	return nil
+}
+
+// Option is a method of the PolicyFile struct. It returns an option applying the policy,
+// overriding the options preceding it. Settings missing from the policy leave the
+// configuration unchanged. The policy must be valid, see Validate.
+func (p PolicyFile) Option() Option {
+	var opts []Option
+
+	if p.GracePeriod > 0 {
+		opts = append(opts, WithGracePeriod(time.Duration(p.GracePeriod)))
+	}
+	if p.KillDelay > 0 {
+		opts = append(opts, WithKillDelay(time.Duration(p.KillDelay)))
+	}
+	for phase, timeout := range p.Phases {
+		opts = append(opts, WithPhaseTimeout(phase, time.Duration(timeout)))
+	}
+	for name, timeout := range p.Hooks {
+		opts = append(opts, WithHookTimeout(name, time.Duration(timeout)))
+	}
+	for reason, grace := range p.Reasons {
+		opts = append(opts, WithPolicy(reason, Policy{GracePeriod: time.Duration(grace)}))
+	}
+
+	names := make([]string, 0, len(p.Signals))
+	for name := range p.Signals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var shutdown, ignored []os.Signal
+	for _, name := range names {
+		sig, _ := lookupSignal(name)
+		if p.Signals[name] == SignalIgnore {
+			ignored = append(ignored, sig)
+		} else {
+			shutdown = append(shutdown, sig)
+		}
+	}
+	if len(shutdown) > 0 {
+		opts = append(opts, WithSignals(shutdown...))
+	}
+	if len(ignored) > 0 {
+		opts = append(opts, WithIgnoredSignals(ignored...))
+	}
+
+	return func(cfg *config) {
+		for _, opt := range opts {
+			opt(cfg)
+		}
+	}
+}
+
+// ParsePolicy is a function that decodes the policy from the provided data with the
+// provided unmarshal function, validates it and returns the option applying it. Any
+// decoder with the signature of json.Unmarshal can be used, e.g. the one of a YAML
+// package, which keeps this package free of dependencies.
+//
+//	opt, err := ParsePolicy(data, yaml.Unmarshal)
+//
+// This example loads the policy from a YAML document.
+func ParsePolicy(data []byte, unmarshal func([]byte, any) error) (Option, error) {
+	var p PolicyFile
+	if err := unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("gogs: invalid policy: %w", err)
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	return p.Option(), nil
+}
+
+// LoadPolicyFile is a function that reads the policy from the JSON file at the provided
+// path, validates it and returns the option applying it. Unknown fields are rejected, so
+// that typos are noticed at startup.
+//
+//	opt, err := LoadPolicyFile("/etc/app/shutdown.json")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	gs, ctx, cancel := New(context.Background(), opt)
+//
+// This example fails the startup if the policy is missing or invalid.
+func LoadPolicyFile(path string) (Option, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("gogs: read policy: %w", err)
+	}
+
+	return ParsePolicy(data, unmarshalStrictJSON)
+}
+
+// unmarshalStrictJSON is a function that decodes the JSON data rejecting unknown fields.
+func unmarshalStrictJSON(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	return dec.Decode(v)
+}
+
+// isTimedPhase is a function that reports whether the provided phase can be limited by a
+// timeout.
+func isTimedPhase(phase Phase) bool {
+	for _, p := range timedPhases {
+		if p == phase {
+			return true
+		}
+	}
+
+	return false
+}
+
+// lookupSignal is a function that returns the signal with the provided name, with or
+// without the SIG prefix and in any case.
+func lookupSignal(name string) (os.Signal, bool) {
+	sig, ok := namedSignals[strings.TrimPrefix(strings.ToUpper(name), "SIG")]
+	return sig, ok
+}
@@ -0,0 +1,82 @@
+package gogs
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LoadPolicyFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "shutdown.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{
+		"grace_period": "30s",
+		"kill_delay": "5s",
+		"phases": {"drain": "20s"},
+		"hooks": {"postgres": "3s"},
+		"reasons": {"parent": "1s"},
+		"signals": {"SIGINT": "shutdown", "int": "shutdown", "SIGKILL": "ignore"}
+	}`), 0o600))
+
+	opt, err := LoadPolicyFile(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var cfg config
+	opt(&cfg)
+
+	assert.Equal(t, 30*time.Second, cfg.gracePeriod)
+	assert.Equal(t, 5*time.Second, cfg.killDelay)
+	assert.Equal(t, 20*time.Second, cfg.phaseTimeouts[PhaseDrain])
+	assert.Equal(t, 3*time.Second, cfg.hookTimeouts["postgres"])
+	assert.Equal(t, time.Second, cfg.policies[ReasonParent].GracePeriod)
+	assert.Equal(t, []os.Signal{os.Interrupt, os.Interrupt}, cfg.signals)
+	assert.True(t, cfg.ignored[os.Kill])
+}
+
+func Test_LoadPolicyFile_Invalid(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	tests := map[string]string{
+		`{"grace_period": "soon"}`:           `gogs: invalid policy: time: invalid duration "soon"`,
+		`{"grace_perod": "1s"}`:              `gogs: invalid policy: json: unknown field "grace_perod"`,
+		`{"kill_delay": "-1s"}`:              `gogs: invalid policy: negative kill_delay -1s`,
+		`{"phases": {"done": "1s"}}`:         `gogs: invalid policy: unknown phase "done"`,
+		`{"signals": {"SIGNOPE": "ignore"}}`: `gogs: invalid policy: unknown signal "SIGNOPE"`,
+		`{"signals": {"SIGINT": "reload"}}`:  `gogs: invalid policy: unknown action "reload" of signal "SIGINT"`,
+	}
+
+	i := 0
+	for data, expected := range tests {
+		i++
+		path := filepath.Join(dir, string(rune('a'+i))+".json")
+		assert.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+		_, err := LoadPolicyFile(path)
+		assert.EqualError(t, err, expected, data)
+	}
+
+	_, err := LoadPolicyFile(filepath.Join(dir, "missing.json"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func Test_ParsePolicy(t *testing.T) {
+	t.Parallel()
+
+	opt, err := ParsePolicy([]byte(`{"hooks": {"kafka": "2s"}}`), json.Unmarshal)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var cfg config
+	opt(&cfg)
+	assert.Equal(t, 2*time.Second, cfg.hookTimeouts["kafka"])
+	assert.Nil(t, cfg.signals)
+}
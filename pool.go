@@ -0,0 +1,95 @@
+package gogs
+
+import "sync"
+
+// hookPool is a struct that holds the goroutines executing the hooks, spawned when the
+// GracefulShutdowner is created, see WithHookPool.
+type hookPool struct {
+	// jobs passes the hooks to the idle workers.
+	jobs chan func()
+
+	// idle holds a token per idle worker.
+	idle chan struct{}
+
+	// done is closed once the shutdown has completed, stopping the idle workers.
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// WithHookPool is an option that spawns the provided count of goroutines executing the
+// hooks and the deregistrations when the GracefulShutdowner is created, so that the
+// shutdown does not depend on creating goroutines, e.g. under memory pressure close to
+// an OOM kill. Each abandoned hook keeps a worker busy; once all workers are busy, the
+// hooks are executed on new goroutines. The idle workers exit once the shutdown has
+// completed.
+//
+//	gs, ctx, cancel := New(context.Background(), WithHookPool(4))
+//
+// This example executes the hooks on four goroutines spawned at startup.
+func WithHookPool(size int) Option {
+	return func(cfg *config) {
+		cfg.hookPool = size
+	}
+}
+
+// startPool is a method of the GracefulShutdown struct. It spawns the workers of the hook
+// pool, if configured.
+func (gs *GracefulShutdown) startPool() {
+	if gs.cfg.hookPool <= 0 {
+		return
+	}
+
+	gs.pool = &hookPool{
+		jobs: make(chan func()),
+		idle: make(chan struct{}, gs.cfg.hookPool),
+		done: make(chan struct{}),
+	}
+	for i := 0; i < gs.cfg.hookPool; i++ {
+		gs.pool.idle <- struct{}{}
+		go gs.pool.work()
+	}
+}
+
+// stopPool is a method of the GracefulShutdown struct. It stops the idle workers of the
+// hook pool, if any.
+func (gs *GracefulShutdown) stopPool() {
+	if gs.pool == nil {
+		return
+	}
+
+	gs.pool.stopOnce.Do(func() {
+		close(gs.pool.done)
+	})
+}
+
+// spawn is a method of the GracefulShutdown struct. It executes the provided function on
+// an idle worker of the hook pool, or on a new goroutine if there is none.
+func (gs *GracefulShutdown) spawn(fn func()) {
+	if gs.pool != nil {
+		select {
+		case <-gs.pool.idle:
+			select {
+			case gs.pool.jobs <- fn:
+				return
+			case <-gs.pool.done:
+			}
+		default:
+		}
+	}
+
+	go fn()
+}
+
+// work is a method of the hookPool struct. It executes the received functions until the
+// pool is stopped.
+func (p *hookPool) work() {
+	for {
+		select {
+		case fn := <-p.jobs:
+			fn()
+			p.idle <- struct{}{}
+		case <-p.done:
+			return
+		}
+	}
+}
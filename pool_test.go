@@ -0,0 +1,62 @@
+package gogs
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// onPool is a function that reports whether it is called on a worker of a hook pool.
+func onPool() bool {
+	buf := make([]byte, 1<<16)
+	return strings.Contains(string(buf[:runtime.Stack(buf, false)]), "(*hookPool).work")
+}
+
+func Test_WithHookPool(t *testing.T) {
+	t.Parallel()
+	gs, _, _ := New(context.Background(), WithHookPool(1))
+
+	release := make(chan struct{})
+	defer close(release)
+
+	onPoolCh := make(chan bool, 2)
+	gs.AddHook("next", func(context.Context) error {
+		onPoolCh <- onPool()
+		return nil
+	})
+	gs.AddHook("stuck", func(context.Context) error {
+		onPoolCh <- onPool()
+		<-release
+		return nil
+	}, WithTimeout(ShortDelay))
+
+	gs.Wait()
+
+	assert.True(t, <-onPoolCh, "stuck")
+	assert.False(t, <-onPoolCh, "next")
+
+	report := gs.Report()
+	assert.ErrorIs(t, report.Hooks[0].Err, context.DeadlineExceeded)
+	assert.NoError(t, report.Hooks[1].Err)
+}
+
+func Test_WithHookPool_Stop(t *testing.T) {
+	t.Parallel()
+	gs, _, _ := New(context.Background(), WithHookPool(2))
+	pool := gs.pool
+
+	gs.Wait()
+
+	select {
+	case <-pool.done:
+	default:
+		t.Fatal("pool is not stopped")
+	}
+
+	ran := make(chan struct{})
+	gs.spawn(func() { close(ran) })
+	<-ran
+}
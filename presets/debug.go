@@ -0,0 +1,122 @@
+package presets
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"os"
+	"runtime/pprof"
+	"runtime/trace"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults of the duration of the CPU profile and of the execution trace, in seconds.
+const (
+	defaultProfileSeconds = 30
+	defaultTraceSeconds   = 1
+)
+
+// newDebugMux is a function that builds the mux serving pprof under /debug/pprof/ from
+// runtime/pprof and runtime/trace. Importing net/http/pprof is avoided since it registers
+// its handlers on http.DefaultServeMux, which would expose them on any server of the
+// application using it.
+func newDebugMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprofIndex)
+	mux.HandleFunc("/debug/pprof/cmdline", pprofCmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprofProfile)
+	mux.HandleFunc("/debug/pprof/trace", pprofTrace)
+	return mux
+}
+
+// pprofIndex is a function that serves the named profile, e.g. /debug/pprof/heap, with the
+// debug query parameter passed to pprof.Profile.WriteTo, and lists the profiles otherwise.
+func pprofIndex(w http.ResponseWriter, r *http.Request) {
+	if name := strings.TrimPrefix(r.URL.Path, "/debug/pprof/"); name != "" {
+		profile := pprof.Lookup(name)
+		if profile == nil {
+			http.Error(w, "unknown profile", http.StatusNotFound)
+			return
+		}
+
+		debug, _ := strconv.Atoi(r.FormValue("debug"))
+		if debug == 0 {
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+		} else {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		}
+		_ = profile.WriteTo(w, debug)
+		return
+	}
+
+	profiles := pprof.Profiles()
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name() < profiles[j].Name() })
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = fmt.Fprint(w, "<html><body>\n")
+	for _, profile := range profiles {
+		name := html.EscapeString(profile.Name())
+		_, _ = fmt.Fprintf(w, "<a href=\"%s?debug=1\">%s</a> (%d)<br>\n", name, name, profile.Count())
+	}
+	_, _ = fmt.Fprint(w, "<a href=\"profile\">profile</a><br>\n<a href=\"trace\">trace</a><br>\n</body></html>\n")
+}
+
+// pprofCmdline is a function that serves the command line of the process, with the
+// arguments separated by NUL bytes.
+func pprofCmdline(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = fmt.Fprint(w, strings.Join(os.Args, "\x00"))
+}
+
+// pprofProfile is a function that serves a CPU profile of the duration set by the seconds
+// query parameter, 30 seconds by default.
+func pprofProfile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", `attachment; filename="profile"`)
+	if err := pprof.StartCPUProfile(w); err != nil {
+		w.Header().Del("Content-Disposition")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sleep(r, seconds(r, defaultProfileSeconds))
+	pprof.StopCPUProfile()
+}
+
+// pprofTrace is a function that serves an execution trace of the duration set by the
+// seconds query parameter, one second by default.
+func pprofTrace(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", `attachment; filename="trace"`)
+	if err := trace.Start(w); err != nil {
+		w.Header().Del("Content-Disposition")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sleep(r, seconds(r, defaultTraceSeconds))
+	trace.Stop()
+}
+
+// seconds is a function that parses the seconds query parameter, returning the default
+// if it is missing or not positive.
+func seconds(r *http.Request, def int) time.Duration {
+	sec, err := strconv.ParseFloat(r.FormValue("seconds"), 64)
+	if err != nil || sec <= 0 {
+		sec = float64(def)
+	}
+	return time.Duration(sec * float64(time.Second))
+}
+
+// sleep is a function that waits for the provided duration, or until the request is
+// canceled, e.g. when the debug server shuts down.
+func sleep(r *http.Request, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-r.Context().Done():
+	}
+}
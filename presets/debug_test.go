@@ -0,0 +1,34 @@
+package presets
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_newDebugMux(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(newDebugMux())
+	defer ts.Close()
+
+	for path, want := range map[string]int{
+		"/debug/pprof/":                    http.StatusOK,
+		"/debug/pprof/heap?debug=1":        http.StatusOK,
+		"/debug/pprof/goroutine":           http.StatusOK,
+		"/debug/pprof/cmdline":             http.StatusOK,
+		"/debug/pprof/profile?seconds=0.1": http.StatusOK,
+		"/debug/pprof/trace?seconds=0.1":   http.StatusOK,
+		"/debug/pprof/unknown":             http.StatusNotFound,
+	} {
+		resp, err := http.Get(ts.URL + path)
+		if assert.NoError(t, err, path) {
+			assert.Equal(t, want, resp.StatusCode, path)
+			_ = resp.Body.Close()
+		}
+	}
+
+	_, pattern := http.DefaultServeMux.Handler(httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+	assert.Empty(t, pattern, "nothing is registered on http.DefaultServeMux")
+}
@@ -0,0 +1,124 @@
+// Package presets wires the components of the common application layouts to a
+// GracefulShutdowner in a single call, in the order they must be shut down.
+package presets
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	gogs "github.com/dsbasko/go-gs"
+	"github.com/dsbasko/go-gs/promgs"
+)
+
+// readHeaderTimeout limits the time the API and debug servers read request headers.
+const readHeaderTimeout = 5 * time.Second
+
+// Addrs is a struct that holds the addresses the listeners of Listeners listen on, e.g.
+// to resolve the ports picked by the system.
+type Addrs struct {
+	// API is the address of the public API.
+	API net.Addr
+
+	// Metrics is the address of the metrics server.
+	Metrics net.Addr
+
+	// Debug is the address of the pprof server.
+	Debug net.Addr
+}
+
+// Listeners is a function that serves the common trio of listeners of a service: the
+// public API with the provided handler, the metrics with the provided handler at
+// /metrics, which must not be nil, and pprof at /debug/pprof/. Nothing is registered on
+// http.DefaultServeMux, so that none of these is exposed by another server of the
+// application. The public API drains first:
+// it stops accepting connections once the drain starts and completes its requests in
+// flight as an active shutdown event, so that the hooks never tear down the components
+// serving them; the requests still in flight when the drain gives up are cut by the hook
+// named "api". The metrics and pprof servers close last, see promgs.Serve, so that the
+// shutdown itself can be observed and profiled. All addresses are listened on before any
+// server starts, so that nothing is served when one of them is not available.
+//
+//	addrs, err := Listeners(gs, ":8080", ":9090", "127.0.0.1:6060", router, promhttp.Handler())
+//
+// This example serves the router on port 8080, the Prometheus metrics on port 9090 and
+// pprof on the loopback interface only.
+func Listeners(
+	gs *gogs.GracefulShutdown,
+	apiAddr, metricsAddr, debugAddr string,
+	api, metrics http.Handler,
+) (Addrs, error) {
+	if metrics == nil {
+		return Addrs{}, errors.New("gogs: the metrics handler is nil")
+	}
+
+	apiLn, err := net.Listen("tcp", apiAddr)
+	if err != nil {
+		return Addrs{}, err
+	}
+	debugLn, err := net.Listen("tcp", debugAddr)
+	if err != nil {
+		_ = apiLn.Close()
+		return Addrs{}, err
+	}
+
+	metricsLn, err := promgs.Serve(gs, metricsAddr, metrics)
+	if err != nil {
+		_ = apiLn.Close()
+		_ = debugLn.Close()
+		return Addrs{}, err
+	}
+
+	serveDebug(gs, debugLn)
+	serveAPI(gs, apiLn, api)
+
+	return Addrs{API: apiLn.Addr(), Metrics: metricsLn, Debug: debugLn.Addr()}, nil
+}
+
+// serveAPI is a function that serves the public API on the provided listener and drains
+// it with drainServer under the name "api".
+func serveAPI(gs *gogs.GracefulShutdown, ln net.Listener, handler http.Handler) {
+	srv := &http.Server{Handler: handler, ReadHeaderTimeout: readHeaderTimeout}
+	go func() {
+		_ = srv.Serve(ln)
+	}()
+
+	drainServer(gs, "api", srv)
+}
+
+// drainServer is a function that shuts the provided server down as an active shutdown
+// event once the drain starts, and registers the named hook cutting the requests left.
+func drainServer(gs *gogs.GracefulShutdown, name string, srv *http.Server) {
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	gs.Subscribe()
+	go func() {
+		defer gs.Unsubscribe()
+		<-gs.DrainStarted()
+		errCh <- srv.Shutdown(ctx)
+	}()
+
+	gs.AddHook(name, func(context.Context) error {
+		cancel()
+		if err := <-errCh; err != nil {
+			_ = srv.Close()
+			return err
+		}
+		return nil
+	})
+}
+
+// serveDebug is a function that serves pprof on the provided listener, see newDebugMux,
+// and registers a final hook closing the server.
+func serveDebug(gs *gogs.GracefulShutdown, ln net.Listener) {
+	srv := &http.Server{Handler: newDebugMux(), ReadHeaderTimeout: readHeaderTimeout}
+	go func() {
+		_ = srv.Serve(ln)
+	}()
+
+	gs.AddHook("debug", func(ctx context.Context) error {
+		return srv.Shutdown(ctx)
+	}, gogs.WithFinal())
+}
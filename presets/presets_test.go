@@ -0,0 +1,98 @@
+package presets
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+func Test_Listeners(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := gogs.New(context.Background())
+	defer cancel()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	api := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		close(started)
+		<-release
+		_, _ = io.WriteString(w, "ok")
+	})
+
+	metrics := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "gogs_active 1\n")
+	})
+
+	addrs, err := Listeners(gs, "127.0.0.1:0", "127.0.0.1:0", "127.0.0.1:0", api, metrics)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var served bool
+	respCh := make(chan *http.Response, 1)
+	go func() {
+		resp, err := http.Get("http://" + addrs.API.String())
+		if assert.NoError(t, err) {
+			respCh <- resp
+		}
+	}()
+	<-started
+
+	var scraped, profiled bool
+	gs.AddHook("db", func(context.Context) error {
+		resp := <-respCh
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		served = string(body) == "ok"
+
+		if resp, err := http.Get("http://" + addrs.Metrics.String() + "/metrics"); err == nil {
+			scraped = resp.StatusCode == http.StatusOK
+			_ = resp.Body.Close()
+		}
+		if resp, err := http.Get("http://" + addrs.Debug.String() + "/debug/pprof/"); err == nil {
+			profiled = resp.StatusCode == http.StatusOK
+			_ = resp.Body.Close()
+		}
+		return nil
+	})
+
+	cancel()
+	go func() {
+		<-gs.DrainStarted()
+		close(release)
+	}()
+	gs.Wait()
+
+	assert.True(t, served)
+	assert.True(t, scraped)
+	assert.True(t, profiled)
+	_, err = http.Get("http://" + addrs.API.String())
+	assert.Error(t, err)
+
+	var names []string
+	for _, res := range gs.Report().Hooks {
+		assert.NoError(t, res.Err)
+		names = append(names, res.Name)
+	}
+	assert.Equal(t, []string{"db", "api", "debug", "metrics"}, names)
+}
+
+func Test_Listeners_Unavailable(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := gogs.New(context.Background())
+	defer cancel()
+
+	_, err := Listeners(gs, "127.0.0.1:0", "127.0.0.1:0", "256.0.0.1:0", nil, http.NotFoundHandler())
+	assert.Error(t, err)
+
+	_, err = Listeners(gs, "127.0.0.1:0", "127.0.0.1:0", "127.0.0.1:0", nil, nil)
+	assert.EqualError(t, err, "gogs: the metrics handler is nil")
+
+	cancel()
+	gs.Wait()
+	assert.Empty(t, gs.Report().Hooks)
+}
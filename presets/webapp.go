@@ -0,0 +1,106 @@
+package presets
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"sync/atomic"
+
+	gogs "github.com/dsbasko/go-gs"
+	"github.com/dsbasko/go-gs/dbgs"
+)
+
+// Closer is an interface that describes a component closed by WebApp, e.g. a cache
+// client.
+type Closer interface {
+	// Close releases the resources of the component.
+	Close() error
+}
+
+// Drainer is an interface that describes a queue flushed by WebApp, e.g. a producer
+// buffering the messages to publish.
+type Drainer interface {
+	// Drain publishes the buffered messages, or settles the received ones, and returns
+	// once done or once the context is done.
+	Drain(ctx context.Context) error
+}
+
+// WebApp is a function that wires the components of the classic web application stack to
+// the provided GracefulShutdowner in the canonical order, using its phases:
+//
+//  1. the returned readiness handler fails in PhaseDeregister, so that the load balancer
+//     stops sending requests before the drain starts;
+//  2. the HTTP server stops accepting connections once the drain starts and completes its
+//     requests in flight as an active shutdown event, while the workers, i.e. the other
+//     active shutdown events, stop along with it in PhaseDrain;
+//  3. in PhaseClose, the hook named "http" cuts the requests left, then the hooks named
+//     "queue", "cache" and "db" flush the queue, close the cache and close the database
+//     once its queries in flight have completed, see dbgs.Close.
+//
+// The logs are flushed last by a hook added with gogs.WithFinal. The hooks added after
+// WebApp execute before the hooks of WebApp, and the ones added before execute after, see
+// gogs.GracefulShutdown.AddHook. The nil components are skipped. The readiness handler
+// responds with 200 OK until the shutdown starts and 503 Service Unavailable afterwards.
+//
+//	srv := &http.Server{Addr: ":8080", Handler: router}
+//	ready := presets.WebApp(gs, srv, db, rdb, producer)
+//	gs.AddHook("logs", func(context.Context) error { return logger.Sync() }, gogs.WithFinal())
+//
+//	admin.Handle("/readyz", ready)
+//	go srv.ListenAndServe()
+//
+// This example shuts down the server, the Kafka producer, the Redis client and the
+// database in order, and flushes the logs once they are all closed.
+func WebApp(
+	gs *gogs.GracefulShutdown,
+	srv *http.Server,
+	db *sql.DB,
+	cache Closer,
+	queue Drainer,
+) http.Handler {
+	if db != nil {
+		dbgs.Register(gs, "db", dbgs.Client{
+			InUse: func() int { return db.Stats().InUse },
+			Close: func(context.Context) error { return db.Close() },
+		})
+	}
+	if cache != nil {
+		gs.AddHook("cache", func(context.Context) error {
+			return cache.Close()
+		})
+	}
+	if queue != nil {
+		gs.AddHook("queue", queue.Drain)
+	}
+	if srv != nil {
+		drainServer(gs, "http", srv)
+	}
+
+	r := &readiness{}
+	gs.AddRegistrar("readiness", r)
+
+	return r
+}
+
+// readiness is a struct that implements the readiness handler of WebApp, failing once it
+// is deregistered.
+type readiness struct {
+	// down reports whether the readiness is off.
+	down atomic.Bool
+}
+
+// Deregister is a method of the readiness struct. It turns the readiness off.
+func (r *readiness) Deregister(context.Context) error {
+	r.down.Store(true)
+	return nil
+}
+
+// ServeHTTP is a method of the readiness struct. It responds with 503 Service Unavailable
+// once the readiness is off, and 200 OK otherwise.
+func (r *readiness) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	if r.down.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,116 @@
+package presets
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+type testConnector struct{}
+
+func (testConnector) Connect(context.Context) (driver.Conn, error) {
+	return nil, errors.New("no database")
+}
+
+func (testConnector) Driver() driver.Driver {
+	return nil
+}
+
+type testComponent struct {
+	name   string
+	record func(name string)
+}
+
+func (c testComponent) Close() error {
+	c.record(c.name)
+	return nil
+}
+
+func (c testComponent) Drain(context.Context) error {
+	c.record(c.name)
+	return nil
+}
+
+func Test_WebApp(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := gogs.New(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, name)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	srv := &http.Server{Handler: http.NotFoundHandler(), ReadHeaderTimeout: readHeaderTimeout}
+	go func() {
+		_ = srv.Serve(ln)
+	}()
+
+	db := sql.OpenDB(testConnector{})
+	gs.AddHook("logs", func(context.Context) error {
+		record("logs")
+		return nil
+	}, gogs.WithFinal())
+	ready := WebApp(gs, srv, db, testComponent{"cache", record}, testComponent{"queue", record})
+
+	rec := httptest.NewRecorder()
+	ready.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	readyAtDrain := make(chan int, 1)
+	go func() {
+		<-gs.DrainStarted()
+		rec := httptest.NewRecorder()
+		ready.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		readyAtDrain <- rec.Code
+	}()
+
+	cancel()
+	gs.Wait()
+
+	assert.Equal(t, http.StatusServiceUnavailable, <-readyAtDrain)
+	assert.Equal(t, []string{"queue", "cache", "logs"}, order)
+	assert.Error(t, db.Ping())
+	_, err = http.Get("http://" + ln.Addr().String())
+	assert.Error(t, err)
+
+	var names []string
+	for _, res := range gs.Report().Hooks {
+		assert.NoError(t, res.Err)
+		names = append(names, res.Name)
+	}
+	assert.Equal(t, []string{"http", "queue", "cache", "db", "logs"}, names)
+}
+
+func Test_WebApp_Nil(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := gogs.New(context.Background())
+	defer cancel()
+
+	ready := WebApp(gs, nil, nil, nil, nil)
+
+	cancel()
+	gs.Wait()
+
+	rec := httptest.NewRecorder()
+	ready.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Empty(t, gs.Report().Hooks)
+}
@@ -0,0 +1,222 @@
+package gogs
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultProgressInterval is the default interval between two snapshots sent by Progress.
+const defaultProgressInterval = 100 * time.Millisecond
+
+// Phase is a type that describes the current step of the shutdown.
+type Phase string
+
+const (
+	// PhaseDeregister means that the shutdown removes the instance from the service
+	// registries, see AddRegistrar.
+	PhaseDeregister Phase = "deregister"
+
+	// PhaseDrain means that the shutdown waits for the active shutdown events to complete.
+	PhaseDrain Phase = "drain"
+
+	// PhaseClose means that the shutdown executes the registered hooks.
+	PhaseClose Phase = "close"
+
+	// PhaseDone means that the shutdown has completed.
+	PhaseDone Phase = "done"
+)
+
+// timedPhases is the list of phases that can be limited by a timeout, in order of
+// execution.
+var timedPhases = []Phase{PhaseDeregister, PhaseDrain, PhaseClose}
+
+// Snapshot is a struct that describes the progress of the shutdown at a point in time.
+type Snapshot struct {
+	// Phase is the current step of the shutdown.
+	Phase Phase
+
+	// Pending is the count of active shutdown events.
+	Pending int32
+
+	// Hook is the name of the hook being executed, if any.
+	Hook string
+
+	// HooksLeft is the count of hooks not completed yet.
+	HooksLeft int
+
+	// Elapsed is the time elapsed since the shutdown started.
+	Elapsed time.Duration
+}
+
+// progress is a struct that streams snapshots to the channels returned by Progress.
+type progress struct {
+	// mu guards the fields below.
+	mu sync.Mutex
+
+	// subs is the list of channels the snapshots are sent to.
+	subs []chan Snapshot
+
+	// startedAt is the time the shutdown started at.
+	startedAt time.Time
+
+	// startOnce and finishOnce guarantee that the progress is streamed only once.
+	startOnce, finishOnce sync.Once
+
+	// finished reports whether the shutdown has completed and the channels are closed.
+	finished bool
+
+	// last is the last snapshot sent.
+	last Snapshot
+
+	// stopCh stops the ticker goroutine.
+	stopCh chan struct{}
+}
+
+// WithProgressInterval is an option that sets the interval between two snapshots sent to
+// the channels returned by Progress. The default interval is 100 milliseconds.
+func WithProgressInterval(interval time.Duration) Option {
+	return func(cfg *config) {
+		cfg.progressInterval = interval
+	}
+}
+
+// Progress is a method of the GracefulShutdown struct. It returns a channel receiving
+// periodic snapshots of the shutdown progress, as well as a snapshot on every phase
+// change. The channel is closed once the shutdown has completed. Snapshots are dropped in
+// favor of newer ones when the receiver falls behind.
+//
+//	for s := range gs.Progress() {
+//		log.Printf("%s: %d pending, %d hooks left", s.Phase, s.Pending, s.HooksLeft)
+//	}
+//
+// This example logs the progress until the shutdown has completed.
+func (gs *GracefulShutdown) Progress() <-chan Snapshot {
+	ch := make(chan Snapshot, 1)
+
+	gs.progress.mu.Lock()
+	defer gs.progress.mu.Unlock()
+
+	if gs.progress.finished {
+		ch <- gs.progress.last
+		close(ch)
+		return ch
+	}
+
+	gs.progress.subs = append(gs.progress.subs, ch)
+	return ch
+}
+
+// startProgress is a method of the GracefulShutdown struct. It starts sending periodic
+// snapshots to the channels returned by Progress.
+func (gs *GracefulShutdown) startProgress() {
+	gs.progress.startOnce.Do(func() {
+		interval := gs.cfg.progressInterval
+		if interval <= 0 {
+			interval = defaultProgressInterval
+		}
+
+		gs.progress.mu.Lock()
+		gs.progress.startedAt = time.Now()
+		gs.progress.stopCh = make(chan struct{})
+		stopCh := gs.progress.stopCh
+		gs.progress.mu.Unlock()
+
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-stopCh:
+					return
+				case <-ticker.C:
+					gs.publish()
+				}
+			}
+		}()
+	})
+}
+
+// setPhase is a method of the GracefulShutdown struct. It changes the current phase of
+// the shutdown and sends a snapshot. Once the shutdown is done, the channels returned by
+// Progress are closed.
+func (gs *GracefulShutdown) setPhase(phase Phase) {
+	gs.mu.Lock()
+	gs.phase = phase
+	gs.mu.Unlock()
+
+	gs.debugf("phase %s", phase)
+	gs.publish()
+
+	if phase != PhaseDone {
+		return
+	}
+
+	gs.progress.finishOnce.Do(func() {
+		gs.progress.mu.Lock()
+		defer gs.progress.mu.Unlock()
+
+		close(gs.progress.stopCh)
+		for _, ch := range gs.progress.subs {
+			close(ch)
+		}
+		gs.progress.subs = nil
+		gs.progress.finished = true
+	})
+}
+
+// publish is a method of the GracefulShutdown struct. It sends a snapshot of the current
+// progress to the channels returned by Progress, replacing unread snapshots.
+func (gs *GracefulShutdown) publish() {
+	snapshot := gs.current()
+
+	gs.progress.mu.Lock()
+	defer gs.progress.mu.Unlock()
+
+	if gs.progress.finished {
+		return
+	}
+
+	snapshot.Elapsed = time.Since(gs.progress.startedAt)
+	gs.progress.last = snapshot
+	for _, ch := range gs.progress.subs {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- snapshot
+	}
+}
+
+// Snapshot is a method of the GracefulShutdown struct. It returns the current progress of
+// the shutdown, e.g. for status endpoints polling it. Elapsed is zero until the shutdown
+// has started, and stays at its final value once the shutdown has completed.
+func (gs *GracefulShutdown) Snapshot() Snapshot {
+	snapshot := gs.current()
+
+	gs.progress.mu.Lock()
+	defer gs.progress.mu.Unlock()
+
+	switch {
+	case gs.progress.finished:
+		snapshot.Elapsed = gs.progress.last.Elapsed
+	case !gs.progress.startedAt.IsZero():
+		snapshot.Elapsed = time.Since(gs.progress.startedAt)
+	}
+
+	return snapshot
+}
+
+// current is a method of the GracefulShutdown struct. It returns the current progress of
+// the shutdown without the elapsed time.
+func (gs *GracefulShutdown) current() Snapshot {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	return Snapshot{
+		Phase:     gs.phase,
+		Pending:   gs.Count(),
+		Hook:      gs.hook,
+		HooksLeft: gs.hooksLeft,
+	}
+}
@@ -0,0 +1,84 @@
+package gogs
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GracefulShutdown_Progress(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(
+		context.Background(),
+		WithSignals(syscall.SIGINT),
+		WithProgressInterval(time.Millisecond),
+	)
+	defer cancel()
+
+	gs.AddHook("slow", func(context.Context) error {
+		shortDelay()
+		return nil
+	})
+	progressCh := gs.Progress()
+
+	gs.Subscribe()
+	go func() {
+		shortDelay()
+		gs.Unsubscribe()
+	}()
+	go gs.Wait()
+
+	phases := make(map[Phase]bool)
+	var last Snapshot
+	for s := range progressCh {
+		phases[s.Phase] = true
+		if s.Phase == PhaseDrain {
+			assert.LessOrEqual(t, s.Pending, int32(1))
+		}
+		if s.Phase == PhaseClose && s.Hook != "" {
+			assert.Equal(t, "slow", s.Hook)
+			assert.Equal(t, 1, s.HooksLeft)
+		}
+		last = s
+	}
+
+	assert.True(t, phases[PhaseDrain])
+	assert.True(t, phases[PhaseClose])
+	assert.Equal(t, PhaseDone, last.Phase)
+	assert.GreaterOrEqual(t, last.Elapsed, 2*ShortDelay)
+
+	s, ok := <-gs.Progress()
+	assert.True(t, ok)
+	assert.Equal(t, PhaseDone, s.Phase)
+}
+
+func Test_GracefulShutdown_Snapshot(t *testing.T) {
+	t.Parallel()
+	gs, _, _ := New(context.Background(), WithSignals(syscall.SIGINT))
+
+	gs.Subscribe()
+	assert.Equal(t, Snapshot{Pending: 1}, gs.Snapshot())
+
+	var during Snapshot
+	gs.AddHook("db", func(context.Context) error {
+		during = gs.Snapshot()
+		return nil
+	})
+	go func() {
+		shortDelay()
+		gs.Unsubscribe()
+	}()
+	gs.Wait()
+
+	assert.Equal(t, PhaseClose, during.Phase)
+	assert.Equal(t, "db", during.Hook)
+	assert.Equal(t, 1, during.HooksLeft)
+	assert.Positive(t, during.Elapsed)
+
+	done := gs.Snapshot()
+	assert.Equal(t, PhaseDone, done.Phase)
+	assert.Equal(t, done, gs.Snapshot())
+}
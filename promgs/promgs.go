@@ -0,0 +1,50 @@
+// Package promgs provides a metrics server, e.g. for Prometheus, that stays alive until
+// the very end of the shutdown.
+package promgs
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+const (
+	// metricsPath is the path the metrics are served at by Serve.
+	metricsPath = "/metrics"
+
+	// metricsReadHeaderTimeout limits the time the metrics server reads request headers.
+	metricsReadHeaderTimeout = 5 * time.Second
+)
+
+// Serve is a function that serves the provided metrics handler, e.g.
+// promhttp.Handler(), at /metrics on the provided address, and registers a final hook
+// closing the server, see gogs.WithFinal. The server is neither registered as intake nor
+// drained with the other servers, so it is the last network component to close and the
+// shutdown itself can be observed. It returns the address the server listens on.
+//
+//	addr, err := Serve(gs, ":9090", promhttp.Handler())
+//
+// This example serves Prometheus metrics until all other hooks have completed.
+func Serve(gs gogs.HookRegistrar, addr string, handler http.Handler) (net.Addr, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, handler)
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: metricsReadHeaderTimeout}
+
+	go func() {
+		_ = srv.Serve(ln)
+	}()
+
+	gs.AddHook("metrics", func(ctx context.Context) error {
+		return srv.Shutdown(ctx)
+	}, gogs.WithFinal())
+
+	return ln.Addr(), nil
+}
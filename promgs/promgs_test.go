@@ -0,0 +1,50 @@
+package promgs
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+func Test_Serve(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := gogs.New(context.Background())
+	defer cancel()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "gogs_up 1\n")
+	})
+
+	addr, err := Serve(gs, "127.0.0.1:0", handler)
+	if !assert.NoError(t, err) {
+		return
+	}
+	url := "http://" + addr.String() + "/metrics"
+
+	var scraped bool
+	gs.AddHook("db", func(context.Context) error {
+		resp, err := http.Get(url)
+		if err == nil {
+			scraped = resp.StatusCode == http.StatusOK
+			_ = resp.Body.Close()
+		}
+		return err
+	})
+	gs.AddHook("cache", func(context.Context) error { return nil }, gogs.WithBestEffort(0))
+
+	gs.Wait()
+	assert.True(t, scraped)
+
+	report := gs.Report()
+	if assert.Len(t, report.Hooks, 3) {
+		assert.Equal(t, "metrics", report.Hooks[2].Name)
+	}
+
+	_, err = http.Get(url)
+	assert.Error(t, err)
+}
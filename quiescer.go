@@ -0,0 +1,123 @@
+package gogs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// quiesceMargin is the time before the deadline of the hook at which the Quiescer gives up
+// waiting for the operations, so that its error reaches the report.
+const quiesceMargin = 5 * time.Millisecond
+
+// Quiescer is a struct that guarantees that no operation is in flight at a critical
+// boundary of the shutdown, e.g. before closing a memory-mapped file. Operations enter it
+// with a cheap read lock, and its hook takes the write lock, executes the finalizer and
+// rejects all later operations. Unlike the drain, which counts the active shutdown
+// events, it excludes the operations started while the finalizer runs, so it suits the
+// data structures that must not be touched once released.
+//
+//	q := NewQuiescer(gs, "index", func(ctx context.Context) error {
+//		return syscall.Munmap(data)
+//	})
+//
+//	exit, err := q.Enter()
+//	if err != nil {
+//		return err
+//	}
+//	defer exit()
+//	return lookup(data, key)
+//
+// This example unmaps the index only once no lookup reads it, and fails the lookups
+// started afterwards.
+type Quiescer struct {
+	// mu is read-locked by the operations and write-locked by the hook.
+	mu sync.RWMutex
+
+	// closed reports whether the finalizer has run, rejecting the operations. It is
+	// guarded by mu.
+	closed bool
+
+	// finalize is executed under the write lock.
+	finalize func(ctx context.Context) error
+}
+
+// NewQuiescer is a function that creates a new Quiescer and registers it as a named hook
+// configured with the provided hook options. The hook waits for the operations in flight
+// to exit, then executes the provided finalizer, no operation entering meanwhile. If the
+// context of the hook is done first, the finalizer is not executed and the hook fails,
+// since operations are still in flight.
+func NewQuiescer(
+	gs HookRegistrar,
+	name string,
+	finalize func(ctx context.Context) error,
+	opts ...HookOption,
+) *Quiescer {
+	q := &Quiescer{finalize: finalize}
+	gs.AddHook(name, q.quiesce, opts...)
+
+	return q
+}
+
+// Enter is a method of the Quiescer struct. It starts an operation and returns the
+// function ending it, which must be called exactly once. It returns ErrShuttingDown once
+// the finalizer has run.
+func (q *Quiescer) Enter() (exit func(), err error) {
+	q.mu.RLock()
+	if q.closed {
+		q.mu.RUnlock()
+		return nil, ErrShuttingDown
+	}
+
+	return q.mu.RUnlock, nil
+}
+
+// quiesce is a method of the Quiescer struct. It takes the write lock, executes the
+// finalizer and rejects the later operations.
+func (q *Quiescer) quiesce(ctx context.Context) error {
+	// state is set to stateFinalizing or stateAbandoned by whichever comes first, the
+	// write lock or the end of the context.
+	const (
+		stateFinalizing int32 = iota + 1
+		stateAbandoned
+	)
+	var state atomic.Int32
+	var err error
+
+	waitCtx := ctx
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithDeadline(ctx, deadline.Add(-quiesceMargin))
+		defer cancel()
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+
+		q.mu.Lock()
+		defer q.mu.Unlock()
+
+		q.closed = true
+		if waitCtx.Err() == nil && state.CompareAndSwap(0, stateFinalizing) {
+			err = q.finalize(ctx)
+		}
+	}()
+
+	select {
+	case <-finished:
+	case <-waitCtx.Done():
+		state.CompareAndSwap(0, stateAbandoned)
+		if state.Load() == stateFinalizing {
+			<-finished
+		}
+	}
+
+	if state.Load() != stateFinalizing {
+		return fmt.Errorf("%w: operations still in flight", waitCtx.Err())
+	}
+
+	return err
+}
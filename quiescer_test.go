@@ -0,0 +1,64 @@
+package gogs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Quiescer(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background())
+
+	var inFlight bool
+	q := NewQuiescer(gs, "index", func(context.Context) error {
+		assert.False(t, inFlight)
+		return nil
+	})
+
+	exit, err := q.Enter()
+	assert.NoError(t, err)
+	inFlight = true
+	time.AfterFunc(ShortDelay, func() {
+		inFlight = false
+		exit()
+	})
+
+	cancel()
+	start := time.Now()
+	gs.Wait()
+
+	assert.GreaterOrEqual(t, time.Since(start), ShortDelay)
+	assert.NoError(t, gs.Report().Err())
+
+	_, err = q.Enter()
+	assert.ErrorIs(t, err, ErrShuttingDown)
+}
+
+func Test_Quiescer_InFlight(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background())
+
+	finalized := false
+	q := NewQuiescer(gs, "index", func(context.Context) error {
+		finalized = true
+		return nil
+	})
+
+	exit, err := q.Enter()
+	assert.NoError(t, err)
+
+	cancel()
+	gs.WaitWithTimeout(ShortDelay)
+
+	assert.EqualError(t, gs.Report().Err(), "index: context deadline exceeded: operations still in flight")
+
+	exit()
+	assert.Eventually(t, func() bool {
+		_, err := q.Enter()
+		return err != nil
+	}, LongDelay, time.Millisecond)
+	assert.False(t, finalized)
+}
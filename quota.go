@@ -0,0 +1,98 @@
+package gogs
+
+import (
+	"context"
+	"time"
+)
+
+// WithCategory is a hook option that assigns the hook to the provided category, so that
+// the hooks of the category share the quota set with WithCategoryQuota.
+//
+//	gs.AddHook("kafka", flushProducer, WithCategory("flushers"))
+//
+// This example counts the flush of the producer against the quota of the flushers.
+func WithCategory(category string) HookOption {
+	return func(h *hook) {
+		h.category = category
+	}
+}
+
+// WithCategoryQuota is an option that limits the total wall time spent executing the
+// hooks of the provided category to the provided share, within (0, 1], of the budget left
+// when the hooks start, so that one category cannot monopolize the grace period. A hook
+// is abandoned once the quota of its category is exhausted, and the hooks of the category
+// executed after that fail with ErrQuotaExceeded. Quotas only apply when the shutdown has
+// a deadline.
+//
+//	gs, ctx, cancel := New(
+//		context.Background(),
+//		WithGracePeriod(30*time.Second),
+//		WithCategoryQuota("flushers", 0.3),
+//	)
+//
+// This example gives the flushers at most thirty percent of the time left for the hooks.
+func WithCategoryQuota(category string, share float64) Option {
+	return func(cfg *config) {
+		if cfg.quotas == nil {
+			cfg.quotas = make(map[string]float64)
+		}
+		cfg.quotas[category] = share
+	}
+}
+
+// quotas is a type that holds the wall time left to each category of hooks with a quota.
+type quotas map[string]time.Duration
+
+// newQuotas is a function that computes the wall time of each category from the provided
+// shares of the budget left until the deadline of the context.
+func newQuotas(ctx context.Context, shares map[string]float64) quotas {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+
+	return quotasFor(time.Until(deadline), shares)
+}
+
+// quotasFor is a function that computes the wall time of each category from the provided
+// shares of the budget.
+func quotasFor(budget time.Duration, shares map[string]float64) quotas {
+	if len(shares) == 0 {
+		return nil
+	}
+
+	q := make(quotas, len(shares))
+	for category, share := range shares {
+		if share > 0 && share <= 1 {
+			q[category] = time.Duration(float64(budget) * share)
+		}
+	}
+
+	return q
+}
+
+// limit is a method of the quotas type. It limits the timeout of the hook to the wall time
+// left to its category, and reports whether any time is left.
+func (q quotas) limit(h hook) (hook, bool) {
+	left, ok := q[h.category]
+	if !ok {
+		return h, true
+	}
+	if left <= 0 {
+		return h, false
+	}
+
+	if h.timeout <= 0 || h.timeout > left {
+		h.timeout = left
+	}
+
+	return h, true
+}
+
+// spend is a method of the quotas type. It charges the wall time spent executing the hook
+// to its category.
+func (q quotas) spend(h hook, d time.Duration) {
+	if _, ok := q[h.category]; ok {
+		q[h.category] -= d
+	}
+}
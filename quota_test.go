@@ -0,0 +1,68 @@
+package gogs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GracefulShutdown_WithCategoryQuota(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(
+		context.Background(),
+		WithGracePeriod(10*ShortDelay),
+		WithCategoryQuota("flushers", 0.2),
+	)
+	defer cancel()
+
+	var executed bool
+	gs.AddHook("db", func(context.Context) error {
+		executed = true
+		return nil
+	})
+	gs.AddHook("metrics", func(context.Context) error {
+		return nil
+	}, WithCategory("flushers"))
+	gs.AddHook("kafka", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithCategory("flushers"))
+
+	start := time.Now()
+	gs.Wait()
+	assert.Less(t, time.Since(start), 5*ShortDelay)
+
+	report := gs.Report()
+	if assert.Len(t, report.Hooks, 3) {
+		assert.ErrorIs(t, report.Hooks[0].Err, context.DeadlineExceeded)
+		assert.ErrorIs(t, report.Hooks[1].Err, ErrQuotaExceeded)
+		assert.NoError(t, report.Hooks[2].Err)
+	}
+	assert.True(t, executed)
+}
+
+func Test_newQuotas(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, newQuotas(context.Background(), map[string]float64{"flushers": 0.5}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	q := newQuotas(ctx, map[string]float64{"flushers": 0.5, "invalid": 2})
+	assert.Len(t, q, 1)
+	assert.InDelta(t, 30*time.Minute, q["flushers"], float64(time.Second))
+
+	h, ok := q.limit(hook{name: "kafka", category: "flushers", timeout: time.Minute})
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, h.timeout)
+
+	q.spend(h, time.Hour)
+	_, ok = q.limit(h)
+	assert.False(t, ok)
+
+	_, ok = q.limit(hook{name: "db"})
+	assert.True(t, ok)
+}
@@ -0,0 +1,107 @@
+package gogs
+
+import (
+	"context"
+	"time"
+)
+
+// MarkReady is a method of the GracefulShutdown struct. It marks the application as
+// ready, i.e. fully started, closing the channel returned by Ready. Subsequent calls do
+// nothing.
+func (gs *GracefulShutdown) MarkReady() {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	readyCh := gs.readyChanLocked()
+	select {
+	case <-readyCh:
+	default:
+		close(readyCh)
+	}
+}
+
+// Ready is a method of the GracefulShutdown struct. It returns a channel that is closed
+// once the application is marked as ready with MarkReady.
+func (gs *GracefulShutdown) Ready() <-chan struct{} {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	return gs.readyChanLocked()
+}
+
+// readyChanLocked is a method of the GracefulShutdown struct. It returns the readiness
+// channel, creating it on first use. It must be called with mu held.
+func (gs *GracefulShutdown) readyChanLocked() chan struct{} {
+	if gs.readyCh == nil {
+		gs.readyCh = make(chan struct{})
+	}
+
+	return gs.readyCh
+}
+
+// WithStartupBarrier is an option that makes the shutdown wait for the end of the
+// startup, marked with MarkReady or AbortStartup, for up to the provided duration before
+// draining, so that a signal received while the application is still starting does not
+// tear down half-built components. The wait counts against the grace period. The startup
+// code should observe the context created by New and call AbortStartup when it gives up.
+//
+//	gs, ctx, cancel := New(context.Background(), WithStartupBarrier(10*time.Second))
+//	db, err := connect(ctx)
+//	if err != nil {
+//		gs.AbortStartup()
+//		return err
+//	}
+//	gs.AddHook("db", db.Close)
+//	gs.MarkReady()
+//
+// This example waits for the connection to be established or abandoned before the hooks
+// are executed.
+func WithStartupBarrier(maxWait time.Duration) Option {
+	return func(cfg *config) {
+		cfg.startupBarrier = maxWait
+	}
+}
+
+// AbortStartup is a method of the GracefulShutdown struct. It marks the startup as
+// abandoned without marking the application as ready, releasing the barrier set with
+// WithStartupBarrier. Subsequent calls do nothing.
+func (gs *GracefulShutdown) AbortStartup() {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if gs.startupAbortCh == nil {
+		gs.startupAbortCh = make(chan struct{})
+	}
+
+	select {
+	case <-gs.startupAbortCh:
+	default:
+		close(gs.startupAbortCh)
+	}
+}
+
+// awaitStartup is a method of the GracefulShutdown struct. It waits for the end of the
+// startup, for up to the duration set with WithStartupBarrier or until the context is done.
+func (gs *GracefulShutdown) awaitStartup(ctx context.Context) {
+	if gs.cfg.startupBarrier <= 0 {
+		return
+	}
+
+	gs.mu.Lock()
+	readyCh := gs.readyChanLocked()
+	if gs.startupAbortCh == nil {
+		gs.startupAbortCh = make(chan struct{})
+	}
+	abortCh := gs.startupAbortCh
+	gs.mu.Unlock()
+
+	timer := time.NewTimer(gs.cfg.startupBarrier)
+	defer timer.Stop()
+
+	select {
+	case <-readyCh:
+	case <-abortCh:
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,78 @@
+package gogs
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GracefulShutdown_Ready(t *testing.T) {
+	t.Parallel()
+	gs := &GracefulShutdown{}
+
+	readyCh := gs.Ready()
+	select {
+	case <-readyCh:
+		assert.Fail(t, "application is ready before MarkReady")
+	default:
+	}
+
+	gs.MarkReady()
+	gs.MarkReady()
+	_, ok := <-readyCh
+	assert.False(t, ok)
+	_, ok = <-gs.Ready()
+	assert.False(t, ok)
+}
+
+func Test_WithStartupBarrier(t *testing.T) {
+	t.Parallel()
+
+	for name, release := range map[string]func(gs *GracefulShutdown){
+		"ready": (*GracefulShutdown).MarkReady,
+		"abort": (*GracefulShutdown).AbortStartup,
+	} {
+		release := release
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			gs, _, cancel := New(context.Background(), WithStartupBarrier(LongDelay))
+
+			var started atomic.Bool
+			gs.AddHook("db", func(context.Context) error {
+				assert.True(t, started.Load())
+				return nil
+			})
+
+			cancel()
+			time.AfterFunc(ShortDelay, func() {
+				started.Store(true)
+				release(gs)
+			})
+
+			start := time.Now()
+			gs.Wait()
+			assert.GreaterOrEqual(t, time.Since(start), ShortDelay)
+		})
+	}
+}
+
+func Test_WithStartupBarrier_Timeout(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background(), WithStartupBarrier(ShortDelay))
+	cancel()
+
+	start := time.Now()
+	gs.Wait()
+	assert.GreaterOrEqual(t, time.Since(start), ShortDelay)
+
+	gs.AbortStartup()
+	gs.AbortStartup()
+	select {
+	case <-gs.Ready():
+		assert.Fail(t, "application is ready after AbortStartup")
+	default:
+	}
+}
@@ -0,0 +1,80 @@
+package gogs
+
+// Reason is a type that describes what triggered the shutdown.
+type Reason string
+
+const (
+	// ReasonSignal means that the shutdown was triggered by one of the configured signals.
+	ReasonSignal Reason = "signal"
+
+	// ReasonParent means that the shutdown was triggered by the cancellation of the parent
+	// context passed to New.
+	ReasonParent Reason = "parent"
+
+	// ReasonCancel means that the shutdown was triggered by the cancel function returned by
+	// New.
+	ReasonCancel Reason = "cancel"
+
+	// ReasonHealth means that the shutdown was triggered by failing health checks, see
+	// HealthTrigger.
+	ReasonHealth Reason = "health"
+
+	// ReasonSimulation means that the shutdown was triggered right after the startup to
+	// simulate it, see ParseSimulateShutdown.
+	ReasonSimulation Reason = "simulation"
+
+	// ReasonWait means that Wait was called before anything else triggered the shutdown.
+	ReasonWait Reason = "wait"
+)
+
+// Reason is a method of the GracefulShutdown struct. It returns what triggered the
+// shutdown, or an empty reason if the shutdown has not started yet.
+func (gs *GracefulShutdown) Reason() Reason {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	return gs.reason
+}
+
+// Trigger is a method of the GracefulShutdown struct. It starts the shutdown for the
+// provided reason, stops the intake and cancels the context created by New, as if the
+// shutdown had been triggered by that reason. It allows, for example, simulating a signal
+// in tests or shutting down from an admin endpoint. With WithAbortWindow, the shutdown is
+// held for the window first and can be canceled with Abort.
+func (gs *GracefulShutdown) Trigger(reason Reason) {
+	if gs.hold(reason) {
+		return
+	}
+	gs.start(reason)
+	if gs.cancel != nil {
+		gs.cancel()
+	}
+}
+
+// start is a method of the GracefulShutdown struct. It records the reason of the shutdown
+// unless another one was recorded before, and returns the recorded reason.
+func (gs *GracefulShutdown) start(reason Reason) Reason {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if gs.reason == "" {
+		gs.reason = reason
+	}
+
+	return gs.reason
+}
+
+// resolveReason is a method of the GracefulShutdown struct. It returns the recorded reason
+// of the shutdown. If none was recorded yet, it inspects the contexts created by New to
+// tell a parent cancellation from a call to the cancel function, and falls back to
+// ReasonWait.
+func (gs *GracefulShutdown) resolveReason() Reason {
+	switch {
+	case gs.parentCtx != nil && gs.parentCtx.Err() != nil:
+		return gs.start(ReasonParent)
+	case gs.ctx != nil && gs.ctx.Err() != nil:
+		return gs.start(ReasonCancel)
+	default:
+		return gs.start(ReasonWait)
+	}
+}
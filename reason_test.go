@@ -0,0 +1,87 @@
+package gogs
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GracefulShutdown_Reason(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Parent", func(t *testing.T) {
+		parentCtx, parentCancel := context.WithCancel(context.Background())
+		gs, ctx, cancel := New(parentCtx, WithSignals(syscall.SIGINT))
+		defer cancel()
+
+		assert.Equal(t, Reason(""), gs.Reason())
+		parentCancel()
+		<-ctx.Done()
+		gs.Wait()
+		assert.Equal(t, ReasonParent, gs.Reason())
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		gs, ctx, cancel := New(context.Background(), WithSignals(syscall.SIGINT))
+		cancel()
+		<-ctx.Done()
+		gs.Wait()
+		assert.Equal(t, ReasonCancel, gs.Reason())
+	})
+
+	t.Run("Wait", func(t *testing.T) {
+		gs, _, cancel := New(context.Background(), WithSignals(syscall.SIGINT))
+		defer cancel()
+
+		gs.Wait()
+		assert.Equal(t, ReasonWait, gs.Reason())
+
+		cancel()
+		assert.Equal(t, ReasonWait, gs.Reason())
+	})
+}
+
+func Test_GracefulShutdown_Policy(t *testing.T) {
+	t.Parallel()
+
+	parentCtx, parentCancel := context.WithCancel(context.Background())
+	gs, _, cancel := New(
+		parentCtx,
+		WithSignals(syscall.SIGINT),
+		WithPolicy(ReasonParent, Policy{GracePeriod: ShortDelay}),
+	)
+	defer cancel()
+
+	gs.SubscribeN(2)
+	parentCancel()
+
+	start := time.Now()
+	gs.Wait()
+	assert.Less(t, time.Since(start), LongDelay)
+	assert.Equal(t, int32(0), gs.Count())
+	assert.Equal(t, ReasonParent, gs.Report().Reason)
+}
+
+func Test_GracefulShutdown_WithReasons(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background(), WithSignals(syscall.SIGINT))
+
+	var executed []string
+	gs.AddHook("signal", func(context.Context) error {
+		executed = append(executed, "signal")
+		return nil
+	}, WithReasons(ReasonSignal))
+	gs.AddHook("cancel", func(context.Context) error {
+		executed = append(executed, "cancel")
+		return nil
+	}, WithReasons(ReasonParent, ReasonCancel))
+
+	cancel()
+	gs.Wait()
+
+	assert.Equal(t, []string{"cancel"}, executed)
+	assert.Len(t, gs.Report().Hooks, 1)
+}
@@ -0,0 +1,70 @@
+package gogs
+
+import (
+	"context"
+	"sync"
+)
+
+// Registrar is an interface that describes an entry of the instance in a service
+// registry or DNS. The registrar package implements it for Consul, etcd, Eureka and
+// Kubernetes Endpoints.
+type Registrar interface {
+	// Deregister removes the entry from the registry and returns once the entry is no
+	// longer visible to the clients, or the context is done.
+	Deregister(ctx context.Context) error
+}
+
+// registrar is a named Registrar added with AddRegistrar.
+type registrar struct {
+	name string
+	r    Registrar
+}
+
+// AddRegistrar is a method of the GracefulShutdown struct. It registers a named entry of
+// the instance in a service registry. All entries are removed concurrently in the very
+// first phase of the shutdown, PhaseDeregister, so that clients stop sending requests
+// before the drain starts. The outcome is listed in the Deregistered field of the report.
+//
+//	gs.AddRegistrar("consul", &registrar.Consul{
+//		Address:   "http://127.0.0.1:8500",
+//		ServiceID: "api-1",
+//	})
+//
+// This example removes the service from the local Consul agent once the shutdown starts.
+func (gs *GracefulShutdown) AddRegistrar(name string, r Registrar) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	gs.registrars = append(gs.registrars, registrar{name: name, r: r})
+}
+
+// deregister is a method of the GracefulShutdown struct. It removes all registered entries
+// concurrently and waits for them to complete or for the context to be done, exactly once.
+func (gs *GracefulShutdown) deregister(ctx context.Context) {
+	gs.mu.Lock()
+	registrars := gs.registrars
+	gs.registrars = nil
+	gs.mu.Unlock()
+
+	if len(registrars) == 0 {
+		return
+	}
+
+	results := make([]HookResult, len(registrars))
+	var wg sync.WaitGroup
+	for i, reg := range registrars {
+		wg.Add(1)
+		go func(i int, reg registrar) {
+			defer wg.Done()
+			results[i] = runHook(ctx, hook{
+				name: reg.name,
+				fn:   gs.labeled(reg.name, PhaseDeregister, reg.r.Deregister),
+			}, gs.spawn)
+		}(i, reg)
+	}
+	wg.Wait()
+
+	gs.mu.Lock()
+	gs.report.Deregistered = results
+	gs.mu.Unlock()
+}
@@ -0,0 +1,60 @@
+package registrar
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Consul is a gogs.Registrar that removes a service from a Consul agent using its HTTP
+// API.
+type Consul struct {
+	// Address is the base URL of the Consul agent, e.g. "http://127.0.0.1:8500".
+	Address string
+
+	// ServiceID is the ID of the registered service.
+	ServiceID string
+
+	// Token is the ACL token sent in the X-Consul-Token header, if any.
+	Token string
+
+	// Client is the HTTP client used for the requests. Nil means http.DefaultClient.
+	Client *http.Client
+}
+
+// Deregister is a method of the Consul struct. It deregisters the service from
+// the agent and waits until the agent no longer knows the service.
+func (c *Consul) Deregister(ctx context.Context) error {
+	base := strings.TrimSuffix(c.Address, "/")
+	id := url.PathEscape(c.ServiceID)
+
+	header := http.Header{}
+	if c.Token != "" {
+		header.Set("X-Consul-Token", c.Token)
+	}
+
+	deregisterURL := base + "/v1/agent/service/deregister/" + id
+	status, body, err := doRequest(ctx, c.Client, http.MethodPut, deregisterURL, nil, header)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return statusError(http.MethodPut, deregisterURL, status, body)
+	}
+
+	serviceURL := base + "/v1/agent/service/" + id
+	return waitGone(ctx, func(ctx context.Context) (bool, error) {
+		status, body, err := doRequest(ctx, c.Client, http.MethodGet, serviceURL, nil, header)
+		switch {
+		case err != nil:
+			return false, err
+		case status == http.StatusNotFound:
+			return true, nil
+		case status == http.StatusOK:
+			return false, nil
+		default:
+			return false, statusError(http.MethodGet, serviceURL, status, body)
+		}
+	})
+}
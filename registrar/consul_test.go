@@ -0,0 +1,38 @@
+package registrar
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Consul(t *testing.T) {
+	t.Parallel()
+
+	var lookups atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("X-Consul-Token"))
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/agent/service/deregister/api-1":
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/agent/service/api-1":
+			if lookups.Add(1) < 3 {
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer ts.Close()
+
+	r := &Consul{Address: ts.URL, ServiceID: "api-1", Token: "secret"}
+	assert.NoError(t, r.Deregister(context.Background()))
+	assert.Equal(t, int32(3), lookups.Load())
+
+	r.ServiceID = "api-2"
+	assert.ErrorContains(t, r.Deregister(context.Background()), "unexpected status 400")
+}
@@ -0,0 +1,73 @@
+package registrar
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Etcd is a gogs.Registrar that removes a key from etcd using the JSON gateway of its
+// v3 API.
+type Etcd struct {
+	// Address is the base URL of the etcd gateway, e.g. "http://127.0.0.1:2379".
+	Address string
+
+	// Key is the key of the registered entry.
+	Key string
+
+	// Client is the HTTP client used for the requests. Nil means http.DefaultClient.
+	Client *http.Client
+}
+
+// etcdKeyRequest is the body of the deleterange and range requests. Keys are encoded in
+// base64 by encoding/json.
+type etcdKeyRequest struct {
+	Key []byte `json:"key"`
+}
+
+// etcdRangeResponse is the body of the response to the range request.
+type etcdRangeResponse struct {
+	Kvs []json.RawMessage `json:"kvs"`
+}
+
+// Deregister is a method of the Etcd struct. It deletes the key and waits until
+// the key can no longer be read.
+func (e *Etcd) Deregister(ctx context.Context) error {
+	base := strings.TrimSuffix(e.Address, "/")
+	body, err := json.Marshal(etcdKeyRequest{Key: []byte(e.Key)})
+	if err != nil {
+		return err
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+
+	deleteURL := base + "/v3/kv/deleterange"
+	status, resp, err := doRequest(ctx, e.Client, http.MethodPost, deleteURL, bytes.NewReader(body), header)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return statusError(http.MethodPost, deleteURL, status, resp)
+	}
+
+	rangeURL := base + "/v3/kv/range"
+	return waitGone(ctx, func(ctx context.Context) (bool, error) {
+		status, resp, err := doRequest(ctx, e.Client, http.MethodPost, rangeURL, bytes.NewReader(body), header)
+		if err != nil {
+			return false, err
+		}
+		if status != http.StatusOK {
+			return false, statusError(http.MethodPost, rangeURL, status, resp)
+		}
+
+		var r etcdRangeResponse
+		if err := json.Unmarshal(resp, &r); err != nil {
+			return false, err
+		}
+
+		return len(r.Kvs) == 0, nil
+	})
+}
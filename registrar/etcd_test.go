@@ -0,0 +1,48 @@
+package registrar
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Etcd(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	keys := map[string]bool{"/services/api/1": true}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req etcdKeyRequest
+		if !assert.NoError(t, json.NewDecoder(r.Body).Decode(&req)) {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.URL.Path {
+		case "/v3/kv/deleterange":
+			delete(keys, string(req.Key))
+			_, _ = w.Write([]byte(`{"deleted": "1"}`))
+		case "/v3/kv/range":
+			if keys[string(req.Key)] {
+				_, _ = w.Write([]byte(`{"kvs": [{"key": "a2V5"}], "count": "1"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"count": "0"}`))
+		}
+	}))
+	defer ts.Close()
+
+	r := &Etcd{Address: ts.URL, Key: "/services/api/1"}
+	assert.NoError(t, r.Deregister(context.Background()))
+
+	mu.Lock()
+	assert.Empty(t, keys)
+	mu.Unlock()
+}
@@ -0,0 +1,58 @@
+package registrar
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Eureka is a gogs.Registrar that removes an instance from a Eureka server using its
+// REST API.
+type Eureka struct {
+	// Address is the base URL of the REST API of the Eureka server, e.g.
+	// "http://eureka:8761/eureka".
+	Address string
+
+	// App is the name of the registered application.
+	App string
+
+	// InstanceID is the ID of the registered instance.
+	InstanceID string
+
+	// Client is the HTTP client used for the requests. Nil means http.DefaultClient.
+	Client *http.Client
+}
+
+// Deregister is a method of the Eureka struct. It cancels the lease of the
+// instance and waits until the server no longer knows the instance.
+func (e *Eureka) Deregister(ctx context.Context) error {
+	instanceURL := strings.TrimSuffix(e.Address, "/") +
+		"/apps/" + url.PathEscape(e.App) +
+		"/" + url.PathEscape(e.InstanceID)
+
+	status, body, err := doRequest(ctx, e.Client, http.MethodDelete, instanceURL, nil, nil)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK && status != http.StatusNotFound {
+		return statusError(http.MethodDelete, instanceURL, status, body)
+	}
+
+	header := http.Header{}
+	header.Set("Accept", "application/json")
+
+	return waitGone(ctx, func(ctx context.Context) (bool, error) {
+		status, body, err := doRequest(ctx, e.Client, http.MethodGet, instanceURL, nil, header)
+		switch {
+		case err != nil:
+			return false, err
+		case status == http.StatusNotFound:
+			return true, nil
+		case status == http.StatusOK:
+			return false, nil
+		default:
+			return false, statusError(http.MethodGet, instanceURL, status, body)
+		}
+	})
+}
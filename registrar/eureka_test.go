@@ -0,0 +1,40 @@
+package registrar
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Eureka(t *testing.T) {
+	t.Parallel()
+
+	var deleted atomic.Bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/eureka/apps/API/api-1" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodDelete:
+			deleted.Store(true)
+		case http.MethodGet:
+			if deleted.Load() {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}
+	}))
+	defer ts.Close()
+
+	r := &Eureka{Address: ts.URL + "/eureka/", App: "API", InstanceID: "api-1"}
+	assert.NoError(t, r.Deregister(context.Background()))
+	assert.True(t, deleted.Load())
+
+	r.App = "WEB"
+	assert.ErrorContains(t, r.Deregister(context.Background()), "unexpected status 500")
+}
@@ -0,0 +1,331 @@
+package registrar
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Paths and variables of the in-cluster configuration of Kubernetes.
+const (
+	kubernetesTokenPath     = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	kubernetesCAPath        = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+	kubernetesNamespacePath = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+)
+
+// Kubernetes is a gogs.Registrar that removes the pod from the Endpoints of a
+// Kubernetes Service using the API server. It removes the label the Service selects the
+// pod by, and waits until the IP of the pod disappears from the Endpoints.
+//
+// The label must be dedicated to the selector of the Service, e.g. "example.com/in-service",
+// and must not be selected by the controller of the pod: a ReplicaSet losing its pod
+// creates a replacement and leaves the orphan running. Deregister refuses to remove a label
+// the controller selects the pod by.
+type Kubernetes struct {
+	// APIServer is the base URL of the API server, e.g. "https://10.0.0.1:443".
+	APIServer string
+
+	// Token is the bearer token of the service account.
+	Token string
+
+	// Namespace is the namespace of the pod and the Service.
+	Namespace string
+
+	// Pod is the name of the pod.
+	Pod string
+
+	// PodIP is the IP of the pod listed in the Endpoints.
+	PodIP string
+
+	// Service is the name of the Service.
+	Service string
+
+	// Label is the key of the label the Service selects the pod by, which the controller of
+	// the pod must not select it by.
+	Label string
+
+	// Client is the HTTP client used for the requests. Nil means http.DefaultClient.
+	Client *http.Client
+}
+
+// kubernetesEndpoints is the part of the Endpoints object listing the ready addresses.
+type kubernetesEndpoints struct {
+	Subsets []struct {
+		Addresses []struct {
+			IP string `json:"ip"`
+		} `json:"addresses"`
+	} `json:"subsets"`
+}
+
+// kubernetesPodOwners is the part of the pod object listing its controller.
+type kubernetesPodOwners struct {
+	Metadata struct {
+		OwnerReferences []struct {
+			APIVersion string `json:"apiVersion"`
+			Kind       string `json:"kind"`
+			Name       string `json:"name"`
+			Controller bool   `json:"controller"`
+		} `json:"ownerReferences"`
+	} `json:"metadata"`
+}
+
+// kubernetesController is the part of a controller object holding its pod selector.
+type kubernetesController struct {
+	Spec struct {
+		Selector json.RawMessage `json:"selector"`
+	} `json:"spec"`
+}
+
+// NewKubernetes is a function that creates a new Kubernetes registrar from the in-cluster
+// configuration: the service account mounted into the pod, and the POD_NAME and POD_IP
+// variables set with the downward API, which must not be empty. The service account must
+// be allowed to get and patch the pod, to get its controller, e.g. its ReplicaSet, and to
+// get the Endpoints.
+//
+//	r, err := NewKubernetes("api", "example.com/in-service")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	gs.AddRegistrar("kubernetes", r)
+//
+// This example removes the pod from the Endpoints of the api Service, which selects pods by
+// the example.com/in-service label while the Deployment selects them by other labels.
+func NewKubernetes(service, label string) (*Kubernetes, error) {
+	cfg, err := loadInCluster()
+	if err != nil {
+		return nil, err
+	}
+	pod, podIP, err := podFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Kubernetes{
+		APIServer: cfg.apiServer,
+		Token:     cfg.token,
+		Namespace: cfg.namespace,
+		Pod:       pod,
+		PodIP:     podIP,
+		Service:   service,
+		Label:     label,
+		Client:    cfg.client,
+	}, nil
+}
+
+// inCluster is a struct that holds the in-cluster configuration of Kubernetes.
+type inCluster struct {
+	// apiServer is the base URL of the API server.
+	apiServer string
+
+	// token is the bearer token of the service account.
+	token string
+
+	// namespace is the namespace of the pod.
+	namespace string
+
+	// client is the HTTP client trusting the CA of the cluster.
+	client *http.Client
+}
+
+// loadInCluster is a function that loads the in-cluster configuration from the service
+// account mounted into the pod.
+func loadInCluster() (inCluster, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return inCluster{}, errors.New("gogs: not running in a Kubernetes cluster")
+	}
+
+	token, err := os.ReadFile(kubernetesTokenPath)
+	if err != nil {
+		return inCluster{}, err
+	}
+	namespace, err := os.ReadFile(kubernetesNamespacePath)
+	if err != nil {
+		return inCluster{}, err
+	}
+	ca, err := os.ReadFile(kubernetesCAPath)
+	if err != nil {
+		return inCluster{}, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return inCluster{}, errors.New("gogs: invalid Kubernetes CA certificate")
+	}
+
+	return inCluster{
+		apiServer: "https://" + net.JoinHostPort(host, port),
+		token:     strings.TrimSpace(string(token)),
+		namespace: strings.TrimSpace(string(namespace)),
+		client: &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+		}},
+	}, nil
+}
+
+// podFromEnv is a function that reads the name and the IP of the pod from the POD_NAME and
+// POD_IP variables. An empty IP would make the wait for the Endpoints succeed at once.
+func podFromEnv() (name, ip string, err error) {
+	name, ip = os.Getenv("POD_NAME"), os.Getenv("POD_IP")
+	if name == "" || ip == "" {
+		return "", "", errors.New("gogs: POD_NAME and POD_IP must be set with the downward API")
+	}
+	return name, ip, nil
+}
+
+// Deregister is a method of the Kubernetes struct. It removes the label from the
+// pod and waits until the IP of the pod is no longer a ready address of the Endpoints. It
+// returns an error without removing the label if the controller of the pod selects it by
+// the label.
+func (k *Kubernetes) Deregister(ctx context.Context) error {
+	base := strings.TrimSuffix(k.APIServer, "/") + "/api/v1/namespaces/" + url.PathEscape(k.Namespace)
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+k.Token)
+
+	if err := k.checkController(ctx, base, header); err != nil {
+		return err
+	}
+
+	patch, err := json.Marshal([]map[string]string{{
+		"op":   "remove",
+		"path": "/metadata/labels/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(k.Label),
+	}})
+	if err != nil {
+		return err
+	}
+
+	podURL := base + "/pods/" + url.PathEscape(k.Pod)
+	patchHeader := header.Clone()
+	patchHeader.Set("Content-Type", "application/json-patch+json")
+
+	status, body, err := doRequest(ctx, k.Client, http.MethodPatch, podURL, bytes.NewReader(patch), patchHeader)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return statusError(http.MethodPatch, podURL, status, body)
+	}
+
+	endpointsURL := base + "/endpoints/" + url.PathEscape(k.Service)
+	return waitGone(ctx, func(ctx context.Context) (bool, error) {
+		status, body, err := doRequest(ctx, k.Client, http.MethodGet, endpointsURL, nil, header)
+		if err != nil {
+			return false, err
+		}
+		if status != http.StatusOK {
+			return false, statusError(http.MethodGet, endpointsURL, status, body)
+		}
+
+		var endpoints kubernetesEndpoints
+		if err := json.Unmarshal(body, &endpoints); err != nil {
+			return false, err
+		}
+
+		for _, subset := range endpoints.Subsets {
+			for _, addr := range subset.Addresses {
+				if addr.IP == k.PodIP {
+					return false, nil
+				}
+			}
+		}
+
+		return true, nil
+	})
+}
+
+// checkController is a method of the Kubernetes struct. It returns an error if the
+// controller of the pod selects it by the label, or if the controller cannot be read.
+func (k *Kubernetes) checkController(ctx context.Context, base string, header http.Header) error {
+	podURL := base + "/pods/" + url.PathEscape(k.Pod)
+	status, body, err := doRequest(ctx, k.Client, http.MethodGet, podURL, nil, header)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return statusError(http.MethodGet, podURL, status, body)
+	}
+
+	var pod kubernetesPodOwners
+	if err := json.Unmarshal(body, &pod); err != nil {
+		return err
+	}
+
+	for _, owner := range pod.Metadata.OwnerReferences {
+		if !owner.Controller {
+			continue
+		}
+
+		prefix := "/apis/"
+		if !strings.Contains(owner.APIVersion, "/") {
+			prefix = "/api/"
+		}
+		ownerURL := strings.TrimSuffix(k.APIServer, "/") + prefix + owner.APIVersion +
+			"/namespaces/" + url.PathEscape(k.Namespace) + "/" +
+			strings.ToLower(owner.Kind) + "s/" + url.PathEscape(owner.Name)
+
+		status, body, err := doRequest(ctx, k.Client, http.MethodGet, ownerURL, nil, header)
+		if err != nil {
+			return err
+		}
+		if status != http.StatusOK {
+			return statusError(http.MethodGet, ownerURL, status, body)
+		}
+
+		var controller kubernetesController
+		if err := json.Unmarshal(body, &controller); err != nil {
+			return err
+		}
+		if selectsLabel(controller.Spec.Selector, k.Label) {
+			return fmt.Errorf(
+				"gogs: the %s %s selects the pod by the label %q, removing it would orphan the pod",
+				owner.Kind, owner.Name, k.Label,
+			)
+		}
+	}
+
+	return nil
+}
+
+// selectsLabel is a function that reports whether the selector of a controller uses the
+// label, either as a label selector or as the plain map of a ReplicationController.
+func selectsLabel(selector json.RawMessage, label string) bool {
+	if len(selector) == 0 {
+		return false
+	}
+
+	var labels map[string]string
+	if err := json.Unmarshal(selector, &labels); err == nil {
+		_, ok := labels[label]
+		return ok
+	}
+
+	var labelSelector struct {
+		MatchLabels      map[string]string `json:"matchLabels"`
+		MatchExpressions []struct {
+			Key string `json:"key"`
+		} `json:"matchExpressions"`
+	}
+	if err := json.Unmarshal(selector, &labelSelector); err != nil {
+		return false
+	}
+	if _, ok := labelSelector.MatchLabels[label]; ok {
+		return true
+	}
+	for _, expr := range labelSelector.MatchExpressions {
+		if expr.Key == label {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,305 @@
+package registrar
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// KubernetesEviction is a gogs.Registrar that evicts the pod from the load balancing of
+// its Services through the API server as soon as the shutdown starts, for the clusters
+// where the propagation of the failing readiness probe is too slow even with a delay. It
+// sets the condition of a readiness gate of the pod to False, so that the pod is no longer
+// ready, and removes the endpoints of the pod from the EndpointSlices of a Service right
+// away rather than waiting for the controller to do so. Either step is optional.
+//
+// The readiness gate must be declared in the spec of the pod:
+//
+//	spec:
+//	  readinessGates:
+//	    - conditionType: example.com/serving
+//
+// The condition must be set to True once the application is ready, e.g. with the same
+// patch, for the pod to become ready in the first place. The service account must be
+// bound to a Role granting:
+//
+//	rules:
+//	  - apiGroups: [""]
+//	    resources: ["pods"]
+//	    verbs: ["get"]
+//	  - apiGroups: [""]
+//	    resources: ["pods/status"]
+//	    verbs: ["patch"]
+//	  - apiGroups: ["discovery.k8s.io"]
+//	    resources: ["endpointslices"]
+//	    verbs: ["list", "patch"]
+type KubernetesEviction struct {
+	// APIServer is the base URL of the API server, e.g. "https://10.0.0.1:443".
+	APIServer string
+
+	// Token is the bearer token of the service account.
+	Token string
+
+	// Namespace is the namespace of the pod and the Service.
+	Namespace string
+
+	// Pod is the name of the pod.
+	Pod string
+
+	// PodIP is the IP of the pod listed in the EndpointSlices.
+	PodIP string
+
+	// ReadinessGate is the condition type of the readiness gate set to False. Empty means
+	// the readiness of the pod is left as is.
+	ReadinessGate string
+
+	// Service is the name of the Service whose EndpointSlices the endpoints of the pod are
+	// removed from. Empty means none.
+	Service string
+
+	// Client is the HTTP client used for the requests. Nil means http.DefaultClient.
+	Client *http.Client
+}
+
+// kubernetesPod is the part of the Pod object listing its conditions.
+type kubernetesPod struct {
+	Status struct {
+		Conditions []kubernetesCondition `json:"conditions"`
+	} `json:"status"`
+}
+
+// kubernetesCondition is a condition of a Pod object.
+type kubernetesCondition struct {
+	Type               string `json:"type"`
+	Status             string `json:"status"`
+	Reason             string `json:"reason,omitempty"`
+	LastTransitionTime string `json:"lastTransitionTime,omitempty"`
+}
+
+// kubernetesSlices is the part of the EndpointSliceList object listing the endpoints.
+type kubernetesSlices struct {
+	Items []struct {
+		Metadata struct {
+			Name            string `json:"name"`
+			ResourceVersion string `json:"resourceVersion"`
+		} `json:"metadata"`
+		Endpoints []kubernetesEndpoint `json:"endpoints"`
+	} `json:"items"`
+}
+
+// kubernetesEndpoint is an endpoint of an EndpointSlice object.
+type kubernetesEndpoint struct {
+	Addresses  []string `json:"addresses"`
+	Conditions struct {
+		Ready   *bool `json:"ready"`
+		Serving *bool `json:"serving"`
+	} `json:"conditions"`
+	TargetRef struct {
+		Kind string `json:"kind"`
+		Name string `json:"name"`
+	} `json:"targetRef"`
+}
+
+// routable is a method of the kubernetesEndpoint struct. It reports whether the endpoint
+// still receives traffic: whether it is ready or serving, an unknown condition meaning
+// ready, and serving defaulting to ready as the API specifies.
+func (ep kubernetesEndpoint) routable() bool {
+	ready := ep.Conditions.Ready == nil || *ep.Conditions.Ready
+	serving := ready
+	if ep.Conditions.Serving != nil {
+		serving = *ep.Conditions.Serving
+	}
+
+	return ready || serving
+}
+
+// NewKubernetesEviction is a function that creates a new KubernetesEviction registrar
+// from the in-cluster configuration: the service account mounted into the pod, and the
+// POD_NAME and POD_IP variables set with the downward API, which must not be empty.
+//
+//	r, err := NewKubernetesEviction("example.com/serving", "api")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	gs.AddRegistrar("kubernetes", r)
+//
+// This example makes the pod unready and removes it from the EndpointSlices of the api
+// Service once the shutdown starts.
+func NewKubernetesEviction(readinessGate, service string) (*KubernetesEviction, error) {
+	cfg, err := loadInCluster()
+	if err != nil {
+		return nil, err
+	}
+	pod, podIP, err := podFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	return &KubernetesEviction{
+		APIServer:     cfg.apiServer,
+		Token:         cfg.token,
+		Namespace:     cfg.namespace,
+		Pod:           pod,
+		PodIP:         podIP,
+		ReadinessGate: readinessGate,
+		Service:       service,
+		Client:        cfg.client,
+	}, nil
+}
+
+// Deregister is a method of the KubernetesEviction struct. It sets the condition of the
+// readiness gate to False and waits until the pod is no longer ready, then removes the
+// endpoints of the pod from the EndpointSlices of the Service until none of them is ready
+// or serving. The EndpointSlice controller keeps listing a terminating pod as neither, so
+// that the endpoints of the pod need not disappear.
+func (k *KubernetesEviction) Deregister(ctx context.Context) error {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+k.Token)
+
+	if k.ReadinessGate != "" {
+		if err := k.unready(ctx, header); err != nil {
+			return err
+		}
+	}
+	if k.Service != "" {
+		return waitGone(ctx, func(ctx context.Context) (bool, error) {
+			return k.removeEndpoints(ctx, header)
+		})
+	}
+
+	return nil
+}
+
+// unready is a method of the KubernetesEviction struct. It sets the condition of the
+// readiness gate to False and waits until the Ready condition of the pod is False.
+func (k *KubernetesEviction) unready(ctx context.Context, header http.Header) error {
+	podURL := strings.TrimSuffix(k.APIServer, "/") + "/api/v1/namespaces/" +
+		url.PathEscape(k.Namespace) + "/pods/" + url.PathEscape(k.Pod)
+
+	var patch kubernetesPod
+	patch.Status.Conditions = []kubernetesCondition{{
+		Type:               k.ReadinessGate,
+		Status:             "False",
+		Reason:             "ShuttingDown",
+		LastTransitionTime: time.Now().UTC().Format(time.RFC3339),
+	}}
+	body, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+
+	patchHeader := header.Clone()
+	patchHeader.Set("Content-Type", "application/strategic-merge-patch+json")
+	statusURL := podURL + "/status"
+	status, resp, err := doRequest(ctx, k.Client, http.MethodPatch, statusURL, bytes.NewReader(body), patchHeader)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return statusError(http.MethodPatch, statusURL, status, resp)
+	}
+
+	return waitGone(ctx, func(ctx context.Context) (bool, error) {
+		status, body, err := doRequest(ctx, k.Client, http.MethodGet, podURL, nil, header)
+		if err != nil {
+			return false, err
+		}
+		if status != http.StatusOK {
+			return false, statusError(http.MethodGet, podURL, status, body)
+		}
+
+		var pod kubernetesPod
+		if err := json.Unmarshal(body, &pod); err != nil {
+			return false, err
+		}
+
+		for _, c := range pod.Status.Conditions {
+			if c.Type == "Ready" {
+				return c.Status != "True", nil
+			}
+		}
+
+		return true, nil
+	})
+}
+
+// removeEndpoints is a method of the KubernetesEviction struct. It removes the ready or
+// serving endpoints of the pod from the EndpointSlices of the Service and reports whether
+// there were none left. A slice modified concurrently is left for the next attempt.
+func (k *KubernetesEviction) removeEndpoints(ctx context.Context, header http.Header) (bool, error) {
+	base := strings.TrimSuffix(k.APIServer, "/") + "/apis/discovery.k8s.io/v1/namespaces/" +
+		url.PathEscape(k.Namespace) + "/endpointslices"
+	listURL := base + "?labelSelector=" + url.QueryEscape("kubernetes.io/service-name="+k.Service)
+
+	status, body, err := doRequest(ctx, k.Client, http.MethodGet, listURL, nil, header)
+	if err != nil {
+		return false, err
+	}
+	if status != http.StatusOK {
+		return false, statusError(http.MethodGet, listURL, status, body)
+	}
+
+	var slices kubernetesSlices
+	if err = json.Unmarshal(body, &slices); err != nil {
+		return false, err
+	}
+
+	patchHeader := header.Clone()
+	patchHeader.Set("Content-Type", "application/json-patch+json")
+
+	gone := true
+	for _, slice := range slices.Items {
+		ops := []map[string]string{{
+			"op":    "test",
+			"path":  "/metadata/resourceVersion",
+			"value": slice.Metadata.ResourceVersion,
+		}}
+		for i := len(slice.Endpoints) - 1; i >= 0; i-- {
+			ep := slice.Endpoints[i]
+			if !ep.routable() {
+				continue
+			}
+			if (ep.TargetRef.Kind == "Pod" && ep.TargetRef.Name == k.Pod) || contains(ep.Addresses, k.PodIP) {
+				ops = append(ops, map[string]string{"op": "remove", "path": fmt.Sprintf("/endpoints/%d", i)})
+			}
+		}
+		if len(ops) == 1 {
+			continue
+		}
+		gone = false
+
+		patch, err := json.Marshal(ops)
+		if err != nil {
+			return false, err
+		}
+
+		sliceURL := base + "/" + url.PathEscape(slice.Metadata.Name)
+		status, body, err := doRequest(ctx, k.Client, http.MethodPatch, sliceURL, bytes.NewReader(patch), patchHeader)
+		switch {
+		case err != nil:
+			return false, err
+		case status == http.StatusOK, status == http.StatusConflict, status == http.StatusUnprocessableEntity:
+		default:
+			return false, statusError(http.MethodPatch, sliceURL, status, body)
+		}
+	}
+
+	return gone, nil
+}
+
+// contains is a function that reports whether the provided addresses contain the
+// provided one.
+func contains(addrs []string, addr string) bool {
+	for _, a := range addrs {
+		if a == addr {
+			return true
+		}
+	}
+
+	return false
+}
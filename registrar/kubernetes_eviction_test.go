@@ -0,0 +1,105 @@
+package registrar
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_KubernetesEviction(t *testing.T) {
+	t.Parallel()
+
+	var unready, conflicted, removed atomic.Bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		switch {
+		case r.Method == http.MethodPatch && r.URL.Path == "/api/v1/namespaces/prod/pods/api-0/status":
+			body, _ := io.ReadAll(r.Body)
+			assert.Equal(t, "application/strategic-merge-patch+json", r.Header.Get("Content-Type"))
+			assert.Contains(t, string(body), `{"type":"example.com/serving","status":"False","reason":"ShuttingDown"`)
+			unready.Store(true)
+			_, _ = w.Write([]byte(`{}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/namespaces/prod/pods/api-0":
+			status := "True"
+			if unready.Load() {
+				status = "False"
+			}
+			_, _ = w.Write([]byte(`{"status": {"conditions": [{"type": "Ready", "status": "` + status + `"}]}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/apis/discovery.k8s.io/v1/namespaces/prod/endpointslices":
+			assert.Equal(t, "kubernetes.io/service-name=api", r.URL.Query().Get("labelSelector"))
+			if removed.Load() {
+				_, _ = w.Write([]byte(`{"items": [{"metadata": {"name": "api-x", "resourceVersion": "3"}, "endpoints": [
+					{"addresses": ["10.0.0.1"], "targetRef": {"kind": "Pod", "name": "api-0"},
+						"conditions": {"ready": false, "serving": false, "terminating": true}},
+					{"addresses": ["10.0.0.2"], "targetRef": {"kind": "Pod", "name": "api-1"}}]}]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"items": [{"metadata": {"name": "api-x", "resourceVersion": "2"}, "endpoints": [
+				{"addresses": ["10.0.0.1"], "targetRef": {"kind": "Pod", "name": "api-0"}},
+				{"addresses": ["10.0.0.2"], "targetRef": {"kind": "Pod", "name": "api-1"}}]}]}`))
+		case r.Method == http.MethodPatch && r.URL.Path == "/apis/discovery.k8s.io/v1/namespaces/prod/endpointslices/api-x":
+			body, _ := io.ReadAll(r.Body)
+			assert.Equal(t, "application/json-patch+json", r.Header.Get("Content-Type"))
+			assert.JSONEq(t, `[
+				{"op": "test", "path": "/metadata/resourceVersion", "value": "2"},
+				{"op": "remove", "path": "/endpoints/0"}
+			]`, string(body))
+			if !conflicted.Swap(true) {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				return
+			}
+			removed.Store(true)
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer ts.Close()
+
+	r := &KubernetesEviction{
+		APIServer:     ts.URL,
+		Token:         "token",
+		Namespace:     "prod",
+		Pod:           "api-0",
+		PodIP:         "10.0.0.1",
+		ReadinessGate: "example.com/serving",
+		Service:       "api",
+	}
+	assert.NoError(t, r.Deregister(context.Background()))
+	assert.True(t, unready.Load())
+	assert.True(t, removed.Load())
+
+	r.Pod = "api-1"
+	assert.ErrorContains(t, r.Deregister(context.Background()), "unexpected status 403")
+}
+
+func Test_NewKubernetesEviction(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+
+	_, err := NewKubernetesEviction("example.com/serving", "api")
+	assert.EqualError(t, err, "gogs: not running in a Kubernetes cluster")
+}
+
+func Test_kubernetesEndpoint_routable(t *testing.T) {
+	t.Parallel()
+
+	for conditions, routable := range map[string]bool{
+		`{}`:                                      true,
+		`{"ready": true}`:                         true,
+		`{"ready": false}`:                        false,
+		`{"ready": false, "serving": true}`:       true,
+		`{"ready": false, "serving": false}`:      false,
+		`{"serving": false, "terminating": true}`: true,
+	} {
+		var ep kubernetesEndpoint
+		assert.NoError(t, json.Unmarshal([]byte(`{"conditions": `+conditions+`}`), &ep))
+		assert.Equal(t, routable, ep.routable(), conditions)
+	}
+}
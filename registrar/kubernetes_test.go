@@ -0,0 +1,103 @@
+package registrar
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Kubernetes(t *testing.T) {
+	t.Parallel()
+
+	var patched atomic.Bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/namespaces/prod/pods/api-0":
+			_, _ = w.Write([]byte(`{"metadata": {"ownerReferences": [
+				{"apiVersion": "apps/v1", "kind": "ReplicaSet", "name": "api-5d9", "controller": true}
+			]}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/apis/apps/v1/namespaces/prod/replicasets/api-5d9":
+			_, _ = w.Write([]byte(`{"spec": {"selector": {
+				"matchLabels": {"app.kubernetes.io/name": "api"},
+				"matchExpressions": [{"key": "tier", "operator": "In", "values": ["web"]}]
+			}}}`))
+		case r.Method == http.MethodPatch && r.URL.Path == "/api/v1/namespaces/prod/pods/api-0":
+			body, _ := io.ReadAll(r.Body)
+			assert.Equal(t, "application/json-patch+json", r.Header.Get("Content-Type"))
+			assert.JSONEq(t, `[{"op": "remove", "path": "/metadata/labels/example.com~1in-service"}]`, string(body))
+			patched.Store(true)
+			_, _ = w.Write([]byte(`{}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/namespaces/prod/endpoints/api":
+			if patched.Load() {
+				_, _ = w.Write([]byte(`{"subsets": [{"addresses": [{"ip": "10.0.0.2"}]}]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"subsets": [{"addresses": [{"ip": "10.0.0.1"}, {"ip": "10.0.0.2"}]}]}`))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer ts.Close()
+
+	r := &Kubernetes{
+		APIServer: ts.URL,
+		Token:     "token",
+		Namespace: "prod",
+		Pod:       "api-0",
+		PodIP:     "10.0.0.1",
+		Service:   "api",
+		Label:     "example.com/in-service",
+	}
+	assert.NoError(t, r.Deregister(context.Background()))
+	assert.True(t, patched.Load())
+
+	patched.Store(false)
+	for _, label := range []string{"app.kubernetes.io/name", "tier"} {
+		r.Label = label
+		assert.ErrorContains(t, r.Deregister(context.Background()), "the ReplicaSet api-5d9 selects the pod")
+	}
+	assert.False(t, patched.Load())
+
+	r.Pod = "api-1"
+	assert.ErrorContains(t, r.Deregister(context.Background()), "unexpected status 403")
+}
+
+func Test_NewKubernetes(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+
+	_, err := NewKubernetes("api", "app")
+	assert.EqualError(t, err, "gogs: not running in a Kubernetes cluster")
+}
+
+func Test_podFromEnv(t *testing.T) {
+	t.Setenv("POD_NAME", "api-0")
+	t.Setenv("POD_IP", "")
+
+	_, _, err := podFromEnv()
+	assert.EqualError(t, err, "gogs: POD_NAME and POD_IP must be set with the downward API")
+
+	t.Setenv("POD_IP", "10.0.0.1")
+	name, ip, err := podFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, "api-0", name)
+	assert.Equal(t, "10.0.0.1", ip)
+}
+
+func Test_selectsLabel(t *testing.T) {
+	t.Parallel()
+
+	assert.False(t, selectsLabel(nil, "app"))
+	assert.True(t, selectsLabel(json.RawMessage(`{"app": "api"}`), "app"))
+	assert.False(t, selectsLabel(json.RawMessage(`{"app": "api"}`), "example.com/in-service"))
+	assert.True(t, selectsLabel(json.RawMessage(`{"matchLabels": {"app": "api"}}`), "app"))
+	assert.True(t, selectsLabel(json.RawMessage(`{"matchExpressions": [{"key": "app"}]}`), "app"))
+	assert.False(t, selectsLabel(json.RawMessage(`{"matchLabels": {"app": "api"}}`), "tier"))
+}
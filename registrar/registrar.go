@@ -0,0 +1,94 @@
+// Package registrar provides implementations of gogs.Registrar removing the instance from
+// service registries over their HTTP APIs: Consul, etcd, Eureka, and Kubernetes Endpoints,
+// EndpointSlices and readiness gates.
+package registrar
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+// The registrars implement the extension interface of the core package.
+var (
+	_ gogs.Registrar = (*Consul)(nil)
+	_ gogs.Registrar = (*Etcd)(nil)
+	_ gogs.Registrar = (*Eureka)(nil)
+	_ gogs.Registrar = (*Kubernetes)(nil)
+	_ gogs.Registrar = (*KubernetesEviction)(nil)
+)
+
+// maxPollInterval is the maximum interval between two checks of the removed entry.
+const maxPollInterval = 500 * time.Millisecond
+
+// waitGone is a function that polls the provided check with backoff until it reports that
+// the entry is gone, it fails or the context is done.
+func waitGone(ctx context.Context, gone func(ctx context.Context) (bool, error)) error {
+	interval := time.Millisecond
+	for {
+		ok, err := gone(ctx)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		if interval *= 2; interval > maxPollInterval {
+			interval = maxPollInterval
+		}
+	}
+}
+
+// doRequest is a function that sends an HTTP request with the provided client, or
+// http.DefaultClient if nil, and returns the status code and the body of the response.
+func doRequest(
+	ctx context.Context,
+	client *http.Client,
+	method, url string,
+	body io.Reader,
+	header http.Header,
+) (int, []byte, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return 0, nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return resp.StatusCode, data, nil
+}
+
+// statusError is a function that returns an error describing an unexpected status code
+// of the response to the request.
+func statusError(method, url string, status int, body []byte) error {
+	return fmt.Errorf("gogs: %s %s: unexpected status %d: %s", method, url, status, body)
+}
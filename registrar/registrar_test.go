@@ -0,0 +1,35 @@
+package registrar
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_waitGone(t *testing.T) {
+	t.Parallel()
+
+	var calls int
+	err := waitGone(context.Background(), func(context.Context) (bool, error) {
+		calls++
+		return calls == 3, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	errFailed := errors.New("failed")
+	err = waitGone(context.Background(), func(context.Context) (bool, error) {
+		return false, errFailed
+	})
+	assert.ErrorIs(t, err, errFailed)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = waitGone(ctx, func(context.Context) (bool, error) {
+		return false, nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
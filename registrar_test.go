@@ -0,0 +1,47 @@
+package gogs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testRegistrar struct {
+	err error
+	fn  func()
+}
+
+func (r *testRegistrar) Deregister(context.Context) error {
+	if r.fn != nil {
+		r.fn()
+	}
+	return r.err
+}
+
+func Test_GracefulShutdown_AddRegistrar(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background())
+	defer cancel()
+
+	errFailed := errors.New("failed")
+	var pending int32
+	gs.AddRegistrar("consul", &testRegistrar{fn: func() {
+		pending = gs.Count()
+		gs.Unsubscribe()
+	}})
+	gs.AddRegistrar("eureka", &testRegistrar{err: errFailed})
+
+	gs.Subscribe()
+	gs.Wait()
+
+	report := gs.Report()
+	if assert.Len(t, report.Deregistered, 2) {
+		assert.Equal(t, "consul", report.Deregistered[0].Name)
+		assert.NoError(t, report.Deregistered[0].Err)
+		assert.Equal(t, "eureka", report.Deregistered[1].Name)
+	}
+	assert.EqualError(t, report.Err(), "eureka: failed")
+	assert.Equal(t, int32(1), pending)
+}
@@ -0,0 +1,110 @@
+package gogs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Registry is a struct that holds hooks registered before a GracefulShutdowner exists,
+// e.g. from init functions or constructors of libraries, and binds them to one once it is
+// created.
+type Registry struct {
+	// mu guards the fields below.
+	mu sync.Mutex
+
+	// hooks is the list of hooks waiting for a GracefulShutdowner.
+	hooks []pendingHook
+
+	// names is the set of names of all registered hooks.
+	names map[string]bool
+
+	// bound is the GracefulShutdowner the registry is bound to, if any.
+	bound HookRegistrar
+}
+
+// pendingHook is a struct that holds the arguments of a hook waiting for AddHook.
+type pendingHook struct {
+	name string
+	fn   func(ctx context.Context) error
+	opts []HookOption
+}
+
+// DefaultRegistry is the registry used by RegisterPending, BindPending and
+// WithPendingHooks.
+var DefaultRegistry = &Registry{}
+
+// Register is a method of the Registry struct. It registers a named hook that is added to
+// the GracefulShutdowner the registry is bound to, or at the time it is bound. Hooks are
+// added in order of registration. Like database/sql.Register, it panics if a hook with the
+// same name is already registered, so that a package initialized twice is noticed.
+func (r *Registry) Register(name string, hookFn func(ctx context.Context) error, opts ...HookOption) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.names[name] {
+		panic(fmt.Sprintf("gogs: hook %q is already registered", name))
+	}
+	if r.names == nil {
+		r.names = make(map[string]bool)
+	}
+	r.names[name] = true
+
+	if r.bound != nil {
+		r.bound.AddHook(name, hookFn, opts...)
+		return
+	}
+	r.hooks = append(r.hooks, pendingHook{name: name, fn: hookFn, opts: opts})
+}
+
+// Bind is a method of the Registry struct. It adds the pending hooks to the provided
+// GracefulShutdowner, as well as the hooks registered later on. A registry can be bound
+// only once, otherwise ErrAlreadyBound is returned.
+func (r *Registry) Bind(gs HookRegistrar) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.bound != nil {
+		return ErrAlreadyBound
+	}
+	r.bound = gs
+
+	for _, h := range r.hooks {
+		gs.AddHook(h.name, h.fn, h.opts...)
+	}
+	r.hooks = nil
+
+	return nil
+}
+
+// RegisterPending is a function that registers a named hook in the DefaultRegistry. It
+// allows libraries to register their cleanup before the application creates its
+// GracefulShutdowner.
+//
+//	func init() {
+//		gogs.RegisterPending("metrics-flush", flushMetrics)
+//	}
+//
+// This example flushes the metrics of the library at shutdown once the application binds
+// the DefaultRegistry.
+func RegisterPending(name string, hookFn func(ctx context.Context) error, opts ...HookOption) {
+	DefaultRegistry.Register(name, hookFn, opts...)
+}
+
+// BindPending is a function that binds the DefaultRegistry to the provided
+// GracefulShutdowner, e.g. the one passed to the application by Run.
+func BindPending(gs HookRegistrar) error {
+	return DefaultRegistry.Bind(gs)
+}
+
+// WithPendingHooks is an option that binds the provided registry to the
+// GracefulShutdowner created by New or NewRequests, or the DefaultRegistry if none is
+// provided. They panic if the registry is already bound, before starting any goroutine.
+func WithPendingHooks(registries ...*Registry) Option {
+	return func(cfg *config) {
+		if len(registries) == 0 {
+			registries = []*Registry{DefaultRegistry}
+		}
+		cfg.registries = append(cfg.registries, registries...)
+	}
+}
@@ -0,0 +1,57 @@
+package gogs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Registry(t *testing.T) {
+	t.Parallel()
+
+	var order []string
+	hookFn := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			order = append(order, name)
+			return nil
+		}
+	}
+
+	r := &Registry{}
+	r.Register("first", hookFn("first"))
+	r.Register("second", hookFn("second"))
+	assert.Panics(t, func() {
+		r.Register("first", hookFn("first"))
+	})
+
+	gs, _, cancel := New(context.Background(), WithPendingHooks(r))
+	defer cancel()
+
+	r.Register("late", hookFn("late"))
+	assert.ErrorIs(t, r.Bind(gs), ErrAlreadyBound)
+	assert.Panics(t, func() {
+		New(context.Background(), WithPendingHooks(r))
+	})
+
+	gs.Wait()
+	assert.Equal(t, []string{"late", "second", "first"}, order)
+}
+
+func Test_Registry_Bind(t *testing.T) {
+	t.Parallel()
+
+	var executed bool
+	r := &Registry{}
+	r.Register("pending", func(context.Context) error {
+		executed = true
+		return nil
+	})
+
+	gs, _, cancel := New(context.Background())
+	defer cancel()
+
+	assert.NoError(t, r.Bind(gs))
+	gs.Wait()
+	assert.True(t, executed)
+}
@@ -0,0 +1,211 @@
+package gogs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// archivePrefix is the prefix of the names of the archived reports.
+	archivePrefix = "gogs-report-"
+
+	// archiveTimeFormat is the layout of the time in the names of the archived reports, so
+	// that they sort chronologically.
+	archiveTimeFormat = "20060102T150405.000000000Z"
+
+	// defaultArchiveKeep is the default count of archived reports kept.
+	defaultArchiveKeep = 5
+
+	// defaultArchiveMaxSize is the default maximum size of an archived report.
+	defaultArchiveMaxSize = 1 << 20
+)
+
+// ReportArchive is a struct that configures the files the reports of the shutdowns are
+// written to, see WithReportArchive.
+type ReportArchive struct {
+	// Dir is the directory the reports are written to. It must exist.
+	Dir string
+
+	// Keep is the count of reports kept in the directory, the new one included, the older
+	// ones are removed. Zero or less means five.
+	Keep int
+
+	// MaxSize is the maximum size of a report in bytes before compression. The hooks that
+	// do not fit are left out and counted, and the errors are cut. Zero or less means one
+	// mebibyte.
+	MaxSize int
+
+	// Compress makes the reports gzip-compressed.
+	Compress bool
+}
+
+// archivedReport is a struct that describes a report as written to the archive.
+type archivedReport struct {
+	Time      time.Time      `json:"time"`
+	Reason    Reason         `json:"reason"`
+	Err       string         `json:"error,omitempty"`
+	Abandoned int32          `json:"abandoned,omitempty"`
+	Critical  int32          `json:"abandoned_critical,omitempty"`
+	Stats     HookStats      `json:"stats"`
+	Env       *EnvSnapshot   `json:"env,omitempty"`
+	Hooks     []archivedHook `json:"hooks"`
+	Omitted   int            `json:"omitted_hooks,omitempty"`
+}
+
+// archivedHook is a struct that describes the outcome of a hook as written to the archive.
+type archivedHook struct {
+	Name      string    `json:"name"`
+	Duration  string    `json:"duration"`
+	Skipped   bool      `json:"skipped,omitempty"`
+	Err       string    `json:"error,omitempty"`
+	Persisted string    `json:"persisted,omitempty"`
+	Stats     HookStats `json:"stats"`
+}
+
+// WithReportArchive is an option that writes the report of the shutdown as JSON to a new
+// file in the provided directory once the shutdown has completed. The size of the reports
+// is bounded, they are optionally compressed, and the older ones are rotated out, so that
+// repeated crashes do not fill the disk. A failure to write the report is logged through
+// the logger set with WithLogger or the standard logger.
+//
+//	gs, ctx, cancel := New(
+//		context.Background(),
+//		WithReportArchive(ReportArchive{Dir: "/var/lib/app/shutdowns", Keep: 10, Compress: true}),
+//	)
+//
+// This example keeps the compressed reports of the last ten shutdowns.
+func WithReportArchive(archive ReportArchive) Option {
+	return func(cfg *config) {
+		if archive.Keep <= 0 {
+			archive.Keep = defaultArchiveKeep
+		}
+		if archive.MaxSize <= 0 {
+			archive.MaxSize = defaultArchiveMaxSize
+		}
+		cfg.archive = &archive
+	}
+}
+
+// archiveReport is a method of the GracefulShutdown struct. It writes the report to the
+// archive, if configured, once.
+func (gs *GracefulShutdown) archiveReport() {
+	if gs.cfg.archive == nil {
+		return
+	}
+
+	gs.archiveOnce.Do(func() {
+		if err := writeArchive(*gs.cfg.archive, gs.Report(), time.Now()); err != nil {
+			gs.logger().Printf("gogs: report not archived: %v", err)
+		}
+	})
+}
+
+// writeArchive is a function that writes the provided report to a new file of the archive
+// named after the provided time, and removes the oldest files beyond the count kept.
+func writeArchive(archive ReportArchive, report Report, now time.Time) error {
+	data, err := encodeReport(report, now, archive.MaxSize)
+	if err != nil {
+		return err
+	}
+
+	name := archivePrefix + now.UTC().Format(archiveTimeFormat) + ".json"
+	if archive.Compress {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err = zw.Write(data); err != nil {
+			return err
+		}
+		if err = zw.Close(); err != nil {
+			return err
+		}
+		data = buf.Bytes()
+		name += ".gz"
+	}
+
+	if err = os.WriteFile(filepath.Join(archive.Dir, name), data, 0o644); err != nil {
+		return err
+	}
+
+	return rotateArchive(archive.Dir, archive.Keep)
+}
+
+// encodeReport is a function that encodes the provided report in at most the provided
+// size, leaving out the last hooks and then cutting the errors if needed.
+func encodeReport(report Report, now time.Time, maxSize int) ([]byte, error) {
+	ar := archivedReport{
+		Time:      now,
+		Reason:    report.Reason,
+		Abandoned: report.Drain.Remaining,
+		Critical:  report.Drain.Critical,
+		Stats:     report.Stats(),
+		Env:       report.Env,
+		Hooks:     make([]archivedHook, 0, len(report.Hooks)),
+	}
+	if err := report.Err(); err != nil {
+		ar.Err = err.Error()
+	}
+	for _, res := range report.Hooks {
+		h := archivedHook{
+			Name:      res.Name,
+			Duration:  res.Duration.String(),
+			Skipped:   res.Skipped,
+			Persisted: res.Persisted,
+			Stats:     res.Stats,
+		}
+		if res.Err != nil {
+			h.Err = res.Err.Error()
+		}
+		ar.Hooks = append(ar.Hooks, h)
+	}
+
+	for {
+		data, err := json.Marshal(ar)
+		if err != nil || len(data) <= maxSize {
+			return data, err
+		}
+
+		switch {
+		case len(ar.Hooks) > 0:
+			ar.Hooks = ar.Hooks[:len(ar.Hooks)-1]
+			ar.Omitted++
+		case len(ar.Err) > 0:
+			ar.Err = ar.Err[:len(ar.Err)/2]
+		default:
+			return data[:0], nil
+		}
+	}
+}
+
+// rotateArchive is a function that removes the oldest archived reports in the provided
+// directory beyond the provided count.
+func rotateArchive(dir string, keep int) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(e.Name(), archivePrefix) {
+			names = append(names, e.Name())
+		}
+	}
+	if len(names) <= keep {
+		return nil
+	}
+
+	sort.Strings(names)
+	for _, name := range names[:len(names)-keep] {
+		if err = os.Remove(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package gogs
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WithReportArchive(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+
+	gs, _, cancel := New(context.Background(), WithReportArchive(ReportArchive{Dir: dir, Compress: true}))
+	gs.AddContextHook("events", func(hc HookContext) error {
+		hc.Record(HookStats{Flushed: 3, Dropped: 1})
+		return errors.New("partially flushed")
+	})
+
+	cancel()
+	gs.Wait()
+	gs.Wait()
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	if !assert.Len(t, entries, 1) {
+		return
+	}
+	assert.True(t, strings.HasSuffix(entries[0].Name(), ".json.gz"))
+
+	f, err := os.Open(filepath.Join(dir, entries[0].Name()))
+	assert.NoError(t, err)
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	assert.NoError(t, err)
+
+	var ar archivedReport
+	assert.NoError(t, json.NewDecoder(zr).Decode(&ar))
+	assert.Equal(t, ReasonCancel, ar.Reason)
+	assert.Equal(t, "events: partially flushed", ar.Err)
+	assert.Equal(t, HookStats{Flushed: 3, Dropped: 1}, ar.Stats)
+	if assert.Len(t, ar.Hooks, 1) {
+		assert.Equal(t, "events", ar.Hooks[0].Name)
+	}
+}
+
+func Test_writeArchive(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "other.json"), nil, 0o644))
+
+	report := Report{Reason: ReasonSignal}
+	for i := 0; i < 100; i++ {
+		report.Hooks = append(report.Hooks, HookResult{Name: "hook", Err: errors.New("failed")})
+	}
+
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	archive := ReportArchive{Dir: dir, Keep: 2, MaxSize: 1024}
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, writeArchive(archive, report, start.Add(time.Duration(i)*time.Second)))
+	}
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	if !assert.Len(t, entries, 3) {
+		return
+	}
+	assert.Equal(t, "gogs-report-20240102T030406.000000000Z.json", entries[0].Name())
+	assert.Equal(t, "gogs-report-20240102T030407.000000000Z.json", entries[1].Name())
+	assert.Equal(t, "other.json", entries[2].Name())
+
+	data, err := os.ReadFile(filepath.Join(dir, entries[1].Name()))
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, len(data), 1024)
+
+	var ar archivedReport
+	assert.NoError(t, json.Unmarshal(data, &ar))
+	assert.NotZero(t, ar.Omitted)
+	assert.Equal(t, 100, len(ar.Hooks)+ar.Omitted)
+}
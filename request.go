@@ -0,0 +1,60 @@
+package gogs
+
+import (
+	"os"
+	"os/signal"
+	"time"
+)
+
+// ShutdownRequest is a struct that describes a signal requesting the shutdown, delivered
+// by the channel returned by NewRequests.
+type ShutdownRequest struct {
+	// Signal is the received signal.
+	Signal os.Signal
+
+	// Time is the time the signal was received at.
+	Time time.Time
+
+	// Seq is the sequence number of the request, starting at one. Requests dropped because
+	// the receiver fell behind leave a gap in the sequence.
+	Seq uint64
+}
+
+// NewRequests is a function that creates a new channel and a GracefulShutdowner instance
+// configured with the provided options. The channel receives a ShutdownRequest for every
+// signal triggering the shutdown, and is receive-only, unlike the channels returned by
+// NewChannel and NewChannelWithOptions which are kept for compatibility. Only the signals
+// triggering the shutdown are delivered, and requests are dropped rather than blocking
+// when the receiver falls behind. It panics if configured with WithTrigger or
+// ParseSimulateShutdown, which start the shutdown of an instance created by New.
+//
+//	gs, requests := NewRequests(WithSignals(syscall.SIGINT, syscall.SIGTERM))
+//	req := <-requests
+//	log.Printf("shutdown requested by %s at %s", req.Signal, req.Time)
+//
+// This example waits for an interrupt or termination signal and logs when it arrived.
+func NewRequests(opts ...Option) (*GracefulShutdown, <-chan ShutdownRequest) {
+	gs := newGracefulShutdown(nil, opts)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, gs.cfg.notified()...)
+
+	requests := make(chan ShutdownRequest, 2)
+	go func() {
+		var seq uint64
+		for sig := range sigCh {
+			if !gs.cfg.triggers(sig) {
+				continue
+			}
+
+			seq++
+			select {
+			case requests <- ShutdownRequest{Signal: sig, Time: time.Now(), Seq: seq}:
+			default:
+			}
+		}
+	}()
+	gs.watch()
+
+	return gs, requests
+}
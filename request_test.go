@@ -0,0 +1,60 @@
+//go:build unix
+
+package gogs
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewRequests(t *testing.T) {
+	_, requests := NewRequests(
+		WithSignals(syscall.SIGUSR2, syscall.SIGWINCH),
+		WithIgnoredSignals(syscall.SIGWINCH),
+	)
+
+	before := time.Now()
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGWINCH))
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR2))
+
+	select {
+	case req := <-requests:
+		assert.Equal(t, syscall.SIGUSR2, req.Signal)
+		assert.Equal(t, uint64(1), req.Seq)
+		assert.False(t, req.Time.Before(before))
+	case <-time.After(LongDelay):
+		assert.Fail(t, "request is not delivered")
+	}
+	assert.Empty(t, requests)
+}
+
+func Test_NewRequests_Options(t *testing.T) {
+	t.Parallel()
+
+	var executed bool
+	r := &Registry{}
+	r.Register("pending", func(context.Context) error {
+		executed = true
+		return nil
+	})
+
+	gs, _ := NewRequests(WithSignals(syscall.SIGUSR2), WithPendingHooks(r))
+	gs.Wait()
+	assert.True(t, executed)
+
+	assert.PanicsWithValue(t, "gogs: WithTrigger requires an instance created by New", func() {
+		NewRequests(WithSignals(syscall.SIGUSR2), WithTrigger(TriggerFunc(func(ctx context.Context) (Reason, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		})))
+	})
+	assert.PanicsWithValue(t, "gogs: ParseSimulateShutdown requires an instance created by New", func() {
+		NewChannelWithOptions(WithSignals(syscall.SIGUSR2), func(cfg *config) {
+			cfg.smoke = &smokeTest{}
+		})
+	})
+}
@@ -0,0 +1,57 @@
+package gogs
+
+import "context"
+
+// Run is a function that runs the application with a new GracefulShutdowner configured
+// with the provided options. The application function receives the context created by
+// New and may either block until the context is done or start its components and return.
+// Once the shutdown is triggered, Run waits for all active shutdown events and hooks and
+// returns the error of the application, or the error of the executed hooks. If the
+// application returns an error before that, the shutdown is triggered immediately.
+//
+//	err := Run(context.Background(), func(ctx context.Context, gs *GracefulShutdown) error {
+//		gs.Subscribe()
+//		go worker(ctx, gs)
+//		gs.MarkReady()
+//		return nil
+//	}, WithSignals(syscall.SIGINT, syscall.SIGTERM))
+//
+// This example runs a worker until an interrupt or termination signal is received.
+func Run(
+	ctx context.Context,
+	app func(ctx context.Context, gs *GracefulShutdown) error,
+	opts ...Option,
+) error {
+	gs, appCtx, cancel := New(ctx, opts...)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- app(appCtx, gs)
+	}()
+
+	var appErr error
+	select {
+	case appErr = <-errCh:
+		if appErr != nil {
+			cancel()
+		}
+	case <-appCtx.Done():
+	}
+
+	<-appCtx.Done()
+	gs.Wait()
+
+	if appErr == nil {
+		select {
+		case appErr = <-errCh:
+		default:
+		}
+	}
+
+	if appErr != nil {
+		return appErr
+	}
+
+	return gs.Report().Err()
+}
@@ -0,0 +1,61 @@
+package gogs
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Run(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Trigger", func(t *testing.T) {
+		var closed bool
+		err := Run(context.Background(), func(ctx context.Context, gs *GracefulShutdown) error {
+			gs.AddHook("db", func(context.Context) error {
+				closed = true
+				return nil
+			})
+
+			gs.Subscribe()
+			go func() {
+				defer gs.Unsubscribe()
+				<-ctx.Done()
+				shortDelay()
+			}()
+
+			gs.Trigger(ReasonSignal)
+			return nil
+		}, WithSignals(syscall.SIGINT))
+
+		assert.NoError(t, err)
+		assert.True(t, closed)
+	})
+
+	t.Run("AppError", func(t *testing.T) {
+		errFailed := errors.New("failed")
+		err := Run(context.Background(), func(context.Context, *GracefulShutdown) error {
+			return errFailed
+		}, WithSignals(syscall.SIGINT))
+
+		assert.ErrorIs(t, err, errFailed)
+	})
+
+	t.Run("HookError", func(t *testing.T) {
+		errFailed := errors.New("failed")
+		ctx, cancel := context.WithCancel(context.Background())
+		err := Run(ctx, func(ctx context.Context, gs *GracefulShutdown) error {
+			gs.AddHook("db", func(context.Context) error {
+				return errFailed
+			})
+			cancel()
+			<-ctx.Done()
+			return nil
+		}, WithSignals(syscall.SIGINT))
+
+		assert.ErrorIs(t, err, errFailed)
+	})
+}
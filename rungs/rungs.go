@@ -0,0 +1,93 @@
+// Package rungs converts between the actors of oklog/run groups and the components of a
+// GracefulShutdowner, without depending on the run package, so that the applications
+// wired with run.Group adopt gogs one actor at a time.
+package rungs
+
+import (
+	"errors"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+// ReasonInterrupted means that the shutdown was triggered by the interruption of the run
+// group, or by an actor added with Add returning first.
+const ReasonInterrupted gogs.Reason = "interrupted"
+
+// ErrInterrupted is the error the interrupt functions of the actors added with Add receive
+// when the shutdown starts.
+var ErrInterrupted = errors.New("rungs: shutting down")
+
+// Group is an interface that describes the method of *run.Group adding an actor. It allows
+// adding the GracefulShutdowner to a group without depending on the run package.
+type Group interface {
+	// Add adds an actor: execute runs until it returns or interrupt is called.
+	Add(execute func() error, interrupt func(error))
+}
+
+// Actor is a function that returns the GracefulShutdowner as an actor of a run group. The
+// execute function blocks until the shutdown has completed and returns the error of the
+// report, and the interrupt function triggers the shutdown with ReasonInterrupted, so that
+// the shutdown starts when another actor returns, and the other actors are interrupted
+// once the shutdown has completed, e.g. on a signal. The GracefulShutdowner must be
+// created by New, see gogs.GracefulShutdown.Done.
+//
+//	var g run.Group
+//	g.Add(rungs.Actor(gs))
+//	g.Add(legacyConsumer.Run, legacyConsumer.Stop)
+//	err := g.Run()
+//
+// This example runs a legacy consumer along with the components managed by gogs.
+func Actor(gs *gogs.GracefulShutdown) (execute func() error, interrupt func(error)) {
+	execute = func() error {
+		<-gs.Done()
+		return gs.Report().Err()
+	}
+	interrupt = func(error) {
+		gs.Trigger(ReasonInterrupted)
+	}
+
+	return execute, interrupt
+}
+
+// AddActor is a function that adds the GracefulShutdowner to the provided group, see
+// Actor.
+func AddActor(g Group, gs *gogs.GracefulShutdown) {
+	g.Add(Actor(gs))
+}
+
+// Add is a function that runs an actor of a run group as a component of the
+// GracefulShutdowner. The execute function runs in a new goroutine tracked as an active
+// shutdown event, and the interrupt function is called with ErrInterrupted as intake,
+// see gogs.GracefulShutdown.AddIntake, so it must not block. As in a run group, an actor
+// returning before the shutdown triggers it with ReasonInterrupted. Its error is returned
+// by the returned function once the actor has returned.
+//
+//	wait := rungs.Add(gs, "consumer", consumer.Run, consumer.Stop)
+//	gs.Wait()
+//	err := wait()
+//
+// This example stops the consumer as soon as the shutdown starts and drains until it
+// returns.
+func Add(gs *gogs.GracefulShutdown, name string, execute func() error, interrupt func(error)) (wait func() error) {
+	errCh := make(chan error, 1)
+
+	gs.Subscribe()
+	go func() {
+		defer gs.Unsubscribe()
+
+		err := execute()
+		errCh <- err
+		gs.Trigger(ReasonInterrupted)
+	}()
+
+	gs.AddIntake(name, func() error {
+		interrupt(ErrInterrupted)
+		return nil
+	})
+
+	return func() error {
+		err := <-errCh
+		errCh <- err
+		return err
+	}
+}
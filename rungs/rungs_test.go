@@ -0,0 +1,124 @@
+package rungs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+// testGroup is a minimal run.Group: it runs the actors until the first one returns,
+// interrupts all of them and waits for them to return.
+type testGroup struct {
+	execute   []func() error
+	interrupt []func(error)
+}
+
+func (g *testGroup) Add(execute func() error, interrupt func(error)) {
+	g.execute = append(g.execute, execute)
+	g.interrupt = append(g.interrupt, interrupt)
+}
+
+func (g *testGroup) Run() error {
+	errCh := make(chan error, len(g.execute))
+	for _, execute := range g.execute {
+		go func(execute func() error) {
+			errCh <- execute()
+		}(execute)
+	}
+
+	err := <-errCh
+	for _, interrupt := range g.interrupt {
+		interrupt(err)
+	}
+	for i := 1; i < len(g.execute); i++ {
+		<-errCh
+	}
+
+	return err
+}
+
+func Test_Actor(t *testing.T) {
+	t.Parallel()
+
+	t.Run("interrupted", func(t *testing.T) {
+		t.Parallel()
+		gs, _, cancel := gogs.New(context.Background())
+		defer cancel()
+
+		errHook := errors.New("hook")
+		gs.AddHook("db", func(context.Context) error { return errHook })
+
+		var g testGroup
+		AddActor(&g, gs)
+		g.Add(func() error { return errors.New("actor") }, func(error) {})
+
+		err := g.Run()
+		assert.EqualError(t, err, "actor")
+		assert.Equal(t, ReasonInterrupted, gs.Report().Reason)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		t.Parallel()
+		gs, _, cancel := gogs.New(context.Background())
+
+		stopCh := make(chan struct{})
+		var once sync.Once
+		var g testGroup
+		AddActor(&g, gs)
+		g.Add(func() error {
+			<-stopCh
+			return nil
+		}, func(error) {
+			once.Do(func() { close(stopCh) })
+		})
+
+		time.AfterFunc(50*time.Millisecond, cancel)
+		assert.NoError(t, g.Run())
+		assert.Equal(t, gogs.ReasonCancel, gs.Report().Reason)
+	})
+}
+
+func Test_Add(t *testing.T) {
+	t.Parallel()
+
+	t.Run("interrupted", func(t *testing.T) {
+		t.Parallel()
+		gs, _, cancel := gogs.New(context.Background())
+		defer cancel()
+
+		stopCh := make(chan struct{})
+		var got error
+		wait := Add(gs, "consumer", func() error {
+			<-stopCh
+			return errors.New("stopped")
+		}, func(err error) {
+			got = err
+			close(stopCh)
+		})
+
+		gs.Trigger("test")
+		gs.Wait()
+		assert.Equal(t, int32(0), gs.Count())
+		assert.ErrorIs(t, got, ErrInterrupted)
+		assert.EqualError(t, wait(), "stopped")
+		assert.EqualError(t, wait(), "stopped")
+	})
+
+	t.Run("returned", func(t *testing.T) {
+		t.Parallel()
+		gs, _, cancel := gogs.New(context.Background())
+		defer cancel()
+
+		wait := Add(gs, "consumer", func() error { return nil }, func(error) {})
+
+		<-gs.Done()
+		assert.NoError(t, wait())
+		assert.Equal(t, ReasonInterrupted, gs.Report().Reason)
+	})
+}
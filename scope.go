@@ -0,0 +1,32 @@
+package gogs
+
+import "context"
+
+// Scope is a method of the GracefulShutdown struct. It creates a child GracefulShutdowner
+// configured with the provided options and registers its shutdown as a named hook of this
+// one. When the parent executes the hook, the child waits for its own active shutdown
+// events and executes its own hooks within the remaining budget of the parent, further
+// limited by its own grace period. The child shares the shutdown reason of the parent.
+//
+//	workers := gs.Scope("workers", WithPolicy(ReasonSignal, Policy{GracePeriod: 5 * time.Second}))
+//	workers.SubscribeN(4)
+//
+// This example gives the workers at most five seconds, or less if the parent has less
+// time left when it reaches the "workers" hook.
+func (gs *GracefulShutdown) Scope(name string, opts ...Option) *GracefulShutdown {
+	child := &GracefulShutdown{}
+	for _, opt := range opts {
+		opt(&child.cfg)
+	}
+	child.startPool()
+
+	gs.AddHook(name, func(ctx context.Context) error {
+		child.start(gs.Reason())
+		child.waitContext(ctx)
+		return child.Report().Err()
+	}, func(h *hook) {
+		h.scope = child
+	})
+
+	return child
+}
@@ -0,0 +1,54 @@
+package gogs
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GracefulShutdown_Scope(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ParentBudget", func(t *testing.T) {
+		gs, _, cancel := New(context.Background(), WithSignals(syscall.SIGINT))
+		defer cancel()
+
+		child := gs.Scope("child", WithPolicy(ReasonCancel, Policy{GracePeriod: time.Minute}))
+		child.Subscribe()
+		cancel()
+
+		start := time.Now()
+		gs.WaitWithTimeout(ShortDelay)
+		assert.Less(t, time.Since(start), LongDelay)
+		assert.Eventually(t, func() bool {
+			return child.Count() == 0
+		}, LongDelay, time.Millisecond)
+		assert.Equal(t, ReasonCancel, child.Reason())
+	})
+
+	t.Run("ChildBudget", func(t *testing.T) {
+		gs, _, cancel := New(context.Background(), WithSignals(syscall.SIGINT))
+		defer cancel()
+
+		var closed bool
+		gs.AddHook("db", func(ctx context.Context) error {
+			closed = ctx.Err() == nil
+			return nil
+		})
+		child := gs.Scope("child", WithPolicy(ReasonCancel, Policy{GracePeriod: ShortDelay}))
+		child.Subscribe()
+		cancel()
+
+		gs.WaitWithTimeout(LongDelay)
+		assert.Equal(t, int32(0), child.Count())
+		assert.True(t, closed)
+
+		report := gs.Report()
+		assert.Len(t, report.Hooks, 2)
+		assert.Equal(t, "child", report.Hooks[0].Name)
+		assert.NoError(t, report.Hooks[0].Err)
+	})
+}
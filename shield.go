@@ -0,0 +1,116 @@
+package gogs
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// hardStop is a struct that tracks the hard deadline of the shutdown.
+type hardStop struct {
+	// mu guards the fields below.
+	mu sync.Mutex
+
+	// doneCh is closed once the hard deadline has passed or the shutdown has completed.
+	doneCh chan struct{}
+
+	// closed reports whether doneCh is closed.
+	closed bool
+
+	// deadline is the hard deadline of the shutdown, if known.
+	deadline time.Time
+}
+
+// Shield is a method of the GracefulShutdown struct. It returns a context carrying the
+// values of the provided one but detached from its cancellation, so that in-flight work
+// can finish its downstream calls during the drain instead of failing with a context
+// canceled error as soon as the shutdown is triggered. The returned context is only done
+// once the hard deadline of the shutdown has passed or the shutdown has completed, and
+// reports that deadline once the shutdown has started.
+//
+//	func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+//		ctx := h.gs.Shield(r.Context())
+//		err := h.db.Save(ctx, order)
+//	}
+//
+// This example lets the order be saved even though the request context is canceled when
+// the shutdown starts.
+func (gs *GracefulShutdown) Shield(ctx context.Context) context.Context {
+	return shieldCtx{parent: ctx, hard: &gs.hard}
+}
+
+// begin is a method of the hardStop struct. It records the deadline of the provided
+// context and closes the done channel once the context is done.
+func (h *hardStop) begin(ctx context.Context) {
+	h.mu.Lock()
+	h.deadline, _ = ctx.Deadline()
+	h.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		h.close()
+	}()
+}
+
+// close is a method of the hardStop struct. It closes the done channel, exactly once.
+func (h *hardStop) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.doneCh == nil {
+		h.doneCh = make(chan struct{})
+	}
+	if !h.closed {
+		h.closed = true
+		close(h.doneCh)
+	}
+}
+
+// done is a method of the hardStop struct. It returns the done channel.
+func (h *hardStop) done() <-chan struct{} {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.doneCh == nil {
+		h.doneCh = make(chan struct{})
+	}
+
+	return h.doneCh
+}
+
+// shieldCtx is a context returned by Shield.
+type shieldCtx struct {
+	parent context.Context
+	hard   *hardStop
+}
+
+// Deadline is a method of the shieldCtx struct. It returns the hard deadline of the
+// shutdown, if known.
+func (c shieldCtx) Deadline() (time.Time, bool) {
+	c.hard.mu.Lock()
+	defer c.hard.mu.Unlock()
+
+	return c.hard.deadline, !c.hard.deadline.IsZero()
+}
+
+// Done is a method of the shieldCtx struct. It returns a channel closed once the hard
+// deadline has passed or the shutdown has completed.
+func (c shieldCtx) Done() <-chan struct{} {
+	return c.hard.done()
+}
+
+// Err is a method of the shieldCtx struct. It returns context.DeadlineExceeded once the
+// context is done, and nil otherwise.
+func (c shieldCtx) Err() error {
+	select {
+	case <-c.hard.done():
+		return context.DeadlineExceeded
+	default:
+		return nil
+	}
+}
+
+// Value is a method of the shieldCtx struct. It returns the value of the parent context.
+func (c shieldCtx) Value(key any) any {
+	return c.parent.Value(key)
+}
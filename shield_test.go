@@ -0,0 +1,36 @@
+package gogs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GracefulShutdown_Shield(t *testing.T) {
+	t.Parallel()
+	gs, ctx, cancel := New(context.Background(), WithGracePeriod(2*ShortDelay))
+	defer cancel()
+
+	type key struct{}
+	shielded := gs.Shield(context.WithValue(ctx, key{}, "value"))
+	assert.Equal(t, "value", shielded.Value(key{}))
+
+	gs.Subscribe()
+	cancel()
+	assert.Error(t, ctx.Err())
+	assert.NoError(t, shielded.Err())
+
+	start := time.Now()
+	go gs.Wait()
+
+	assert.Eventually(t, func() bool {
+		_, ok := shielded.Deadline()
+		return ok
+	}, LongDelay, time.Millisecond)
+
+	<-shielded.Done()
+	assert.ErrorIs(t, shielded.Err(), context.DeadlineExceeded)
+	assert.GreaterOrEqual(t, time.Since(start), 2*ShortDelay-ShortDelay/5)
+}
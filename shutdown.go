@@ -0,0 +1,301 @@
+package gogs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// watchdog is a struct that holds the watchdog started by WithKillDelay.
+type watchdog struct {
+	// mu guards the fields below.
+	mu sync.Mutex
+
+	// timer invokes kill once the kill delay has elapsed, unless the watchdog is the
+	// goroutine started by WithFreezeAwareKill.
+	timer *time.Timer
+
+	// stopCh is closed once the watchdog is stopped, so that the goroutine started by
+	// WithFreezeAwareKill returns.
+	stopCh chan struct{}
+
+	// stopped reports whether the watchdog is stopped, see stopWatchdog.
+	stopped bool
+}
+
+// waitContext is a method of the GracefulShutdown struct. It performs the shutdown within
+// the deadline of the provided context, further limited by the grace period of the Policy
+// of the shutdown reason, so that nested deadlines never exceed the outer ones. If a kill
+// delay is configured, the process exits once the delay has elapsed after the deadline,
+// unless it has exited before or the shutdown has completed without abandoning anything,
+// since abandoned hooks and the code running after Wait may still hold it.
+func (gs *GracefulShutdown) waitContext(ctx context.Context) {
+	reason := gs.resolveReason()
+
+	grace := gs.gracePeriod(reason)
+	if grace > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, grace)
+		defer cancel()
+	}
+	if gs.cfg.suspendAware {
+		var cancel context.CancelFunc
+		ctx, cancel = gs.extendable(ctx)
+		defer cancel()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	gs.hard.begin(ctx)
+	gs.awaitStartup(ctx)
+
+	if deadline, ok := ctx.Deadline(); ok && gs.killDelay() > 0 {
+		if gs.cfg.freezeInterval > 0 {
+			go gs.watchFreeze(deadline.Add(gs.killDelay()), gs.watchdogStop())
+		} else {
+			gs.armKill(time.Until(deadline) + gs.killDelay())
+		}
+	}
+
+	gs.shutdown(ctx, reason)
+	gs.archiveReport()
+	gs.runAfterShutdown()
+	gs.complete()
+}
+
+// shutdown is a method of the GracefulShutdown struct. It removes the instance from the
+// service registries, waits for all active shutdown events to complete and then executes
+// the hooks registered for the provided reason. Each
+// phase is limited by its budget in the schedule, if any. If the context is done before
+// all events have completed, it unsubscribes from all remaining events.
+func (gs *GracefulShutdown) shutdown(ctx context.Context, reason Reason) {
+	gs.snapshotEnv()
+	gs.stopIntake()
+	gs.startTrace(reason)
+	gs.startProgress()
+	defer gs.setPhase(PhaseDone)
+	defer gs.stopPool()
+
+	schedule := gs.schedule(ctx)
+	gs.mu.Lock()
+	gs.report.Schedule = schedule
+	gs.mu.Unlock()
+
+	gs.setPhase(PhaseDeregister)
+	deregisterCtx, cancel := phaseContext(ctx, schedule, PhaseDeregister)
+	defer cancel()
+
+	gs.deregister(deregisterCtx)
+
+	gs.startDrain()
+	gs.setPhase(PhaseDrain)
+	gs.startEarlyHooks(ctx, reason)
+	drainCtx, cancel := phaseContext(ctx, schedule, PhaseDrain)
+	defer cancel()
+	if gs.cfg.devFast {
+		cancel()
+	}
+
+	doneCh := make(chan struct{})
+	go func() {
+		gs.waitDrain()
+		close(doneCh)
+	}()
+	gs.watchDeadlock(drainCtx, doneCh)
+
+	select {
+	case <-drainCtx.Done():
+		gs.abandonSubscribers(false)
+		if !gs.awaitCritical(ctx) {
+			gs.abandonSubscribers(true)
+		}
+		<-doneCh
+	case <-doneCh:
+	}
+
+	gs.setPhase(PhaseClose)
+	closeCtx, cancel := phaseContext(ctx, schedule, PhaseClose)
+	defer cancel()
+
+	gs.runHooks(closeCtx, reason)
+}
+
+// PhaseBudget is a struct that describes the time allotted to a phase of the shutdown.
+type PhaseBudget struct {
+	// Phase is the phase of the shutdown.
+	Phase Phase
+
+	// Timeout is the timeout of the phase set with WithPhaseTimeout.
+	Timeout time.Duration
+
+	// Budget is the time actually allotted to the phase. It is less than the timeout when
+	// the phases did not fit into the deadline of the shutdown.
+	Budget time.Duration
+}
+
+// schedule is a method of the GracefulShutdown struct. It returns the budgets of the
+// phases limited by a timeout within the deadline of the context, see scaleSchedule.
+func (gs *GracefulShutdown) schedule(ctx context.Context) []PhaseBudget {
+	var left time.Duration
+	if deadline, ok := ctx.Deadline(); ok {
+		left = time.Until(deadline)
+	}
+
+	return scaleSchedule(gs.cfg.phaseTimeouts, left)
+}
+
+// scaleSchedule is a function that returns the budgets of the phases limited by a
+// timeout. When the sum of the timeouts exceeds the time left until the deadline, the
+// budgets are scaled down proportionally, so that early phases do not starve the later
+// ones. Zero or less left means no deadline.
+func scaleSchedule(timeouts map[Phase]time.Duration, left time.Duration) []PhaseBudget {
+	var total time.Duration
+	var schedule []PhaseBudget
+	for _, phase := range timedPhases {
+		if timeout := timeouts[phase]; timeout > 0 {
+			total += timeout
+			schedule = append(schedule, PhaseBudget{Phase: phase, Timeout: timeout, Budget: timeout})
+		}
+	}
+
+	if left <= 0 || total <= left {
+		return schedule
+	}
+
+	for i := range schedule {
+		schedule[i].Budget = time.Duration(float64(left) * float64(schedule[i].Timeout) / float64(total))
+	}
+
+	return schedule
+}
+
+// phaseContext is a function that returns a context limited by the budget of the provided
+// phase in the schedule, if any.
+func phaseContext(
+	ctx context.Context,
+	schedule []PhaseBudget,
+	phase Phase,
+) (context.Context, context.CancelFunc) {
+	for _, pb := range schedule {
+		if pb.Phase == phase {
+			return context.WithTimeout(ctx, pb.Budget)
+		}
+	}
+
+	return context.WithCancel(ctx)
+}
+
+// gracePeriod is a method of the GracefulShutdown struct. It returns the grace period of
+// the Policy of the provided reason, or the default one. Zero means no limit.
+func (gs *GracefulShutdown) gracePeriod(reason Reason) time.Duration {
+	if gs.cfg.devFast {
+		return devFastBudget
+	}
+
+	grace := gs.cfg.policies[reason].GracePeriod
+	if grace <= 0 {
+		grace = gs.cfg.gracePeriod
+	}
+
+	return grace
+}
+
+// killDelay is a method of the GracefulShutdown struct. It returns the kill delay, if any.
+func (gs *GracefulShutdown) killDelay() time.Duration {
+	if gs.cfg.devFast && gs.cfg.killDelay > 0 {
+		return devFastBudget
+	}
+
+	return gs.cfg.killDelay
+}
+
+// kill is a method of the GracefulShutdown struct. It is invoked by the watchdog when the
+// process is still running after the kill delay has elapsed after the deadline of the
+// shutdown, and exits the process, unless the watchdog was stopped, the deadline was
+// extended in the meantime, see WithExtensions, or the process was suspended, see
+// WithSuspendAware.
+func (gs *GracefulShutdown) kill() {
+	if gs.watchdogStopped() {
+		return
+	}
+	if gs.suspended() {
+		gs.armKill(suspendPoll)
+		return
+	}
+	if d := gs.postponed(); d > 0 {
+		gs.armKill(d)
+		return
+	}
+
+	_, _ = fmt.Fprintln(os.Stderr, "gogs: process is still running after the kill delay, exiting")
+	gs.exitProcess(1)
+}
+
+// armKill is a method of the GracefulShutdown struct. It invokes kill once the provided
+// delay has elapsed, unless the watchdog is stopped before.
+func (gs *GracefulShutdown) armKill(d time.Duration) {
+	gs.watchdog.mu.Lock()
+	defer gs.watchdog.mu.Unlock()
+
+	if gs.watchdog.stopped {
+		return
+	}
+	gs.watchdog.timer = time.AfterFunc(d, gs.kill)
+}
+
+// watchdogStop is a method of the GracefulShutdown struct. It returns the channel closed
+// once the watchdog is stopped, see stopWatchdog.
+func (gs *GracefulShutdown) watchdogStop() <-chan struct{} {
+	gs.watchdog.mu.Lock()
+	defer gs.watchdog.mu.Unlock()
+
+	if gs.watchdog.stopCh == nil {
+		gs.watchdog.stopCh = make(chan struct{})
+		if gs.watchdog.stopped {
+			close(gs.watchdog.stopCh)
+		}
+	}
+
+	return gs.watchdog.stopCh
+}
+
+// watchdogStopped is a method of the GracefulShutdown struct. It reports whether the
+// watchdog is stopped.
+func (gs *GracefulShutdown) watchdogStopped() bool {
+	gs.watchdog.mu.Lock()
+	defer gs.watchdog.mu.Unlock()
+
+	return gs.watchdog.stopped
+}
+
+// stopWatchdog is a method of the GracefulShutdown struct. It stops the watchdog started
+// by WithKillDelay, so that a shutdown completed without abandoning anything never exits
+// the process.
+func (gs *GracefulShutdown) stopWatchdog() {
+	gs.watchdog.mu.Lock()
+	defer gs.watchdog.mu.Unlock()
+
+	if gs.watchdog.stopped {
+		return
+	}
+	gs.watchdog.stopped = true
+	if gs.watchdog.timer != nil {
+		gs.watchdog.timer.Stop()
+		gs.watchdog.timer = nil
+	}
+	if gs.watchdog.stopCh != nil {
+		close(gs.watchdog.stopCh)
+	}
+}
+
+// exitProcess is a method of the GracefulShutdown struct. It exits the process with the
+// provided code.
+func (gs *GracefulShutdown) exitProcess(code int) {
+	exit := gs.cfg.exit
+	if exit == nil {
+		exit = os.Exit
+	}
+	exit(code)
+}
@@ -0,0 +1,127 @@
+package gogs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GracefulShutdown_WithGracePeriod(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background(), WithGracePeriod(ShortDelay))
+	defer cancel()
+
+	gs.Subscribe()
+
+	start := time.Now()
+	gs.Wait()
+	assert.Less(t, time.Since(start), LongDelay)
+	assert.Equal(t, int32(0), gs.Count())
+}
+
+func Test_GracefulShutdown_WithPhaseTimeout(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background(), WithPhaseTimeout(PhaseDrain, ShortDelay))
+	defer cancel()
+
+	var closed bool
+	gs.AddHook("db", func(ctx context.Context) error {
+		_, closed = ctx.Deadline()
+		return nil
+	})
+	gs.Subscribe()
+
+	gs.WaitWithTimeout(LongDelay)
+	assert.Equal(t, int32(0), gs.Count())
+	assert.True(t, closed)
+	assert.NoError(t, gs.Report().Err())
+}
+
+func Test_GracefulShutdown_WithKillDelay(t *testing.T) {
+	t.Parallel()
+
+	exitCh := make(chan int, 1)
+	gs := &GracefulShutdown{}
+	WithKillDelay(ShortDelay)(&gs.cfg)
+	gs.cfg.exit = func(code int) {
+		exitCh <- code
+	}
+
+	gs.AddHook("stuck", func(context.Context) error {
+		select {}
+	})
+
+	gs.WaitWithTimeout(ShortDelay)
+	assert.Equal(t, 1, <-exitCh)
+}
+
+func Test_GracefulShutdown_Schedule(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(
+		context.Background(),
+		WithGracePeriod(4*ShortDelay),
+		WithPhaseTimeout(PhaseDrain, 6*ShortDelay),
+		WithPhaseTimeout(PhaseClose, 2*ShortDelay),
+	)
+	defer cancel()
+
+	var budget time.Duration
+	gs.AddHook("db", func(ctx context.Context) error {
+		deadline, _ := ctx.Deadline()
+		budget = time.Until(deadline)
+		return nil
+	})
+	gs.Subscribe()
+
+	gs.Wait()
+
+	schedule := gs.Report().Schedule
+	if !assert.Len(t, schedule, 2) {
+		return
+	}
+	assert.Equal(t, PhaseDrain, schedule[0].Phase)
+	assert.Equal(t, 6*ShortDelay, schedule[0].Timeout)
+	assert.InDelta(t, 3*ShortDelay, schedule[0].Budget, float64(ShortDelay/5))
+	assert.Equal(t, PhaseClose, schedule[1].Phase)
+	assert.InDelta(t, ShortDelay, schedule[1].Budget, float64(ShortDelay/5))
+	assert.Greater(t, budget, ShortDelay/2)
+}
+
+func Test_GracefulShutdown_Schedule_Fits(t *testing.T) {
+	t.Parallel()
+	gs := &GracefulShutdown{}
+	WithPhaseTimeout(PhaseClose, ShortDelay)(&gs.cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), LongDelay)
+	defer cancel()
+
+	assert.Equal(t, []PhaseBudget{
+		{Phase: PhaseClose, Timeout: ShortDelay, Budget: ShortDelay},
+	}, gs.schedule(ctx))
+}
+
+func Test_GracefulShutdown_WithKillDelay_Completed(t *testing.T) {
+	t.Parallel()
+
+	exited := make(chan int, 1)
+	gs := &GracefulShutdown{}
+	WithKillDelay(ShortDelay)(&gs.cfg)
+	gs.cfg.exit = func(code int) {
+		exited <- code
+	}
+
+	gs.AddHook("db", func(context.Context) error {
+		return nil
+	})
+
+	gs.WaitWithTimeout(ShortDelay)
+	assert.NoError(t, gs.Report().Err())
+
+	select {
+	case <-exited:
+		assert.Fail(t, "exited after a clean shutdown")
+	case <-time.After(4 * ShortDelay):
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !unix
+
+package gogs
+
+import "os"
+
+// NoiseSignals is the list of signals that are routinely delivered to healthy processes
+// and should not trigger the shutdown when all signals are listed. There are no such
+// signals on this platform.
+var NoiseSignals []os.Signal
+
+// allSignals is the list of signals listened to when all signals trigger the shutdown. Nil
+// means all of them, as with signal.Notify.
+var allSignals []os.Signal
+
+// namedSignals maps the names of the signals accepted in a PolicyFile to the signals.
+var namedSignals = map[string]os.Signal{
+	"INT":  os.Interrupt,
+	"KILL": os.Kill,
+}
@@ -0,0 +1,130 @@
+//go:build unix
+
+package gogs
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GracefulShutdown_NoiseSignals(t *testing.T) {
+	routedCh := make(chan os.Signal, 1)
+	gs, ctx, cancel := New(
+		context.Background(),
+		WithSignals(syscall.SIGUSR1, syscall.SIGUSR2, syscall.SIGWINCH),
+		WithIgnoredSignals(NoiseSignals...),
+		WithSignalHandler(func(sig os.Signal) { routedCh <- sig }, syscall.SIGUSR1),
+	)
+	defer cancel()
+
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGWINCH))
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+	assert.Equal(t, syscall.SIGUSR1, <-routedCh)
+	assert.NoError(t, ctx.Err())
+
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR2))
+	<-ctx.Done()
+	assert.Equal(t, ReasonSignal, gs.Reason())
+}
+
+func Test_WithBeforeCancel(t *testing.T) {
+	ctxCh := make(chan context.Context, 1)
+	var order []string
+	sent := time.Now()
+	gs, ctx, cancel := New(
+		context.Background(),
+		WithSignals(syscall.SIGUSR2),
+		WithBeforeCancel(func(sig os.Signal, at time.Time) {
+			assert.Equal(t, syscall.SIGUSR2, sig)
+			assert.False(t, at.Before(sent))
+			assert.NoError(t, (<-ctxCh).Err())
+			order = append(order, "first")
+		}),
+		WithBeforeCancel(func(os.Signal, time.Time) {
+			order = append(order, "second")
+		}),
+	)
+	defer cancel()
+	ctxCh <- ctx
+
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR2))
+	<-ctx.Done()
+	assert.Equal(t, []string{"first", "second"}, order)
+	assert.Equal(t, ReasonSignal, gs.Reason())
+}
+
+func Test_WithDebugSignal(t *testing.T) {
+	gs, ctx, cancel := New(
+		context.Background(),
+		WithSignals(syscall.SIGTERM, syscall.SIGUSR1),
+		WithDebugSignal(syscall.SIGUSR1, time.Hour),
+	)
+	defer cancel()
+
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+	assert.Eventually(t, gs.debug.Load, LongDelay, time.Millisecond)
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+	assert.Eventually(t, func() bool { return !gs.debug.Load() }, LongDelay, time.Millisecond)
+	assert.NoError(t, ctx.Err())
+}
+
+func Test_NewChannelWithOptions(t *testing.T) {
+	_, stopCh := NewChannelWithOptions(
+		WithSignals(syscall.SIGUSR1, syscall.SIGWINCH),
+		WithIgnoredSignals(syscall.SIGWINCH),
+	)
+
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGWINCH))
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+
+	select {
+	case sig := <-stopCh:
+		assert.Equal(t, syscall.SIGUSR1, sig)
+	case <-time.After(LongDelay):
+		assert.Fail(t, "signal is not delivered")
+	}
+	assert.Empty(t, stopCh)
+}
+
+func Test_New_DefaultSignals(t *testing.T) {
+	triggered := make(chan Reason, 1)
+	trigger := SignalTrigger()
+	go func() {
+		reason, _ := trigger.Wait(context.Background())
+		triggered <- reason
+	}()
+
+	gs, ctx, cancel := New(context.Background())
+	defer cancel()
+
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGURG))
+	time.Sleep(ShortDelay)
+	assert.NoError(t, ctx.Err())
+	assert.Empty(t, triggered)
+
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGINT))
+	<-ctx.Done()
+	assert.Equal(t, ReasonSignal, gs.Reason())
+	assert.Equal(t, ReasonSignal, <-triggered)
+}
+
+func Test_config_notified(t *testing.T) {
+	var cfg config
+	WithSignalHandler(func(os.Signal) {}, syscall.SIGHUP)(&cfg)
+	assert.ElementsMatch(t, []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGHUP}, cfg.notified())
+	assert.Equal(t, defaultSignals, cfg.triggering())
+
+	WithSignals()(&cfg)
+	assert.Contains(t, cfg.notified(), syscall.SIGTERM)
+	assert.Contains(t, cfg.notified(), syscall.SIGCHLD)
+	assert.NotContains(t, cfg.notified(), syscall.SIGURG)
+	assert.Empty(t, cfg.triggering())
+
+	WithSignals(syscall.SIGUSR1)(&cfg)
+	assert.Equal(t, []os.Signal{syscall.SIGUSR1}, cfg.notified())
+}
@@ -0,0 +1,50 @@
+//go:build unix
+
+package gogs
+
+import (
+	"os"
+	"syscall"
+)
+
+// NoiseSignals is the list of signals that are routinely delivered to healthy processes
+// and should not trigger the shutdown when all signals are listed: broken pipes, child
+// status changes, urgent socket data, which the Go runtime also uses for goroutine
+// preemption, terminal resizes and, where available, status requests.
+var NoiseSignals = append([]os.Signal{
+	syscall.SIGPIPE,
+	syscall.SIGCHLD,
+	syscall.SIGURG,
+	syscall.SIGWINCH,
+}, statusSignals...)
+
+// allSignals is the list of signals listened to when all signals trigger the shutdown:
+// the ones below the limit of the os/signal package, but SIGURG, which the Go runtime
+// sends to preempt goroutines, and the ones that cannot be caught.
+var allSignals = func() []os.Signal {
+	var signals []os.Signal
+	for sig := syscall.Signal(1); sig < 65; sig++ {
+		switch sig {
+		case syscall.SIGURG, syscall.SIGKILL, syscall.SIGSTOP:
+			continue
+		}
+		signals = append(signals, sig)
+	}
+
+	return signals
+}()
+
+// namedSignals maps the names of the signals accepted in a PolicyFile to the signals.
+var namedSignals = map[string]os.Signal{
+	"HUP":   syscall.SIGHUP,
+	"INT":   syscall.SIGINT,
+	"KILL":  syscall.SIGKILL,
+	"QUIT":  syscall.SIGQUIT,
+	"TERM":  syscall.SIGTERM,
+	"USR1":  syscall.SIGUSR1,
+	"USR2":  syscall.SIGUSR2,
+	"PIPE":  syscall.SIGPIPE,
+	"CHLD":  syscall.SIGCHLD,
+	"URG":   syscall.SIGURG,
+	"WINCH": syscall.SIGWINCH,
+}
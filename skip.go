@@ -0,0 +1,33 @@
+package gogs
+
+// WithSkippedHooks is an option that skips the hooks with the provided names, e.g. to
+// bypass a known-broken teardown step during an incident without a redeploy. Each skip is
+// logged as a warning through the logger set with WithLogger or the standard logger, and
+// reported as such. It is usually set through the environment, see ParseEnvConfig.
+//
+//	GOGS_SKIP_HOOKS=replica-sync,cache-dump ./app
+//
+// This example skips the replica sync and the cache dump of an application configured
+// with WithEnvConfig("GOGS").
+func WithSkippedHooks(names ...string) Option {
+	return func(cfg *config) {
+		if cfg.skippedHooks == nil {
+			cfg.skippedHooks = make(map[string]bool, len(names))
+		}
+		for _, name := range names {
+			cfg.skippedHooks[name] = true
+		}
+	}
+}
+
+// skipHook is a method of the GracefulShutdown struct. It reports whether the hook with
+// the provided name is skipped with WithSkippedHooks, and warns about it if it is.
+func (gs *GracefulShutdown) skipHook(name string) bool {
+	if !gs.cfg.skippedHooks[name] {
+		return false
+	}
+
+	gs.logger().Printf("gogs: WARNING: hook %s skipped by the operator configuration, its teardown is bypassed", name)
+
+	return true
+}
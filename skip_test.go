@@ -0,0 +1,45 @@
+package gogs
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WithSkippedHooks(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	gs, _, cancel := New(
+		context.Background(),
+		WithSkippedHooks("replica-sync"),
+		WithLogger(log.New(&buf, "", 0)),
+	)
+
+	var executed []string
+	gs.AddHook("replica-sync", func(context.Context) error {
+		executed = append(executed, "replica-sync")
+		return nil
+	})
+	gs.AddHook("postgres", func(context.Context) error {
+		executed = append(executed, "postgres")
+		return nil
+	})
+
+	plan := gs.Plan(ReasonCancel)
+	assert.False(t, plan.Hooks[0].Skipped)
+	assert.True(t, plan.Hooks[1].Skipped)
+	assert.True(t, Simulate(plan, nil).Hooks[1].Skipped)
+
+	cancel()
+	gs.Wait()
+
+	assert.Equal(t, []string{"postgres"}, executed)
+	assert.True(t, gs.Report().Hooks[1].Skipped)
+	assert.Equal(t,
+		"gogs: WARNING: hook replica-sync skipped by the operator configuration, its teardown is bypassed\n",
+		buf.String(),
+	)
+}
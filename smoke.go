@@ -0,0 +1,140 @@
+package gogs
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// SimulateShutdownArg is the hidden command-line argument making the application shut
+	// down as soon as it has started, see ParseSimulateShutdown.
+	SimulateShutdownArg = "--simulate-shutdown"
+
+	// smokeBootTimeout is the time the simulated shutdown waits for the application to be
+	// marked ready before it is triggered anyway.
+	smokeBootTimeout = 10 * time.Second
+)
+
+// smokeTest is a struct that configures the simulated shutdown, see ParseSimulateShutdown.
+type smokeTest struct {
+	// out is the writer the report is written to.
+	out io.Writer
+
+	// bootTimeout is the time the shutdown waits for the application to be marked ready.
+	bootTimeout time.Duration
+}
+
+// ParseSimulateShutdown is a function that removes the hidden SimulateShutdownArg from
+// os.Args, if present, and returns the option simulating a shutdown, or an option doing
+// nothing otherwise. It must be called before the flags are parsed, with the flag package
+// or cobra, which then never see the argument. The simulated shutdown is triggered with
+// ReasonSimulation once the application is marked ready with MarkReady, or after ten
+// seconds otherwise, and its report is written to the standard output once it has
+// completed. The process exits with code 1 if an intake stop, a deregistration or a hook
+// failed, or if the drain gave up on active shutdown events. It is a smoke test of the
+// shutdown of the real binary for the CI pipelines. NewRequests, NewChannel and
+// NewChannelWithOptions panic if configured with the simulation, since they create no
+// context to cancel.
+//
+//	simulate := ParseSimulateShutdown()
+//	flag.Parse()
+//	gs, ctx, cancel := New(context.Background(), WithSignals(syscall.SIGTERM), simulate)
+//
+//	./app --config=ci.yaml --simulate-shutdown
+//
+// This example boots the application, shuts it down right away and prints the report
+// when started with the argument, and runs it as usual otherwise.
+func ParseSimulateShutdown() Option {
+	var opt Option
+	os.Args, opt = parseSimulateShutdown(os.Args)
+
+	return opt
+}
+
+// parseSimulateShutdown is a function that removes SimulateShutdownArg from the provided
+// arguments, and returns them along with the option simulating a shutdown if it was
+// present, or an option doing nothing otherwise.
+func parseSimulateShutdown(args []string) ([]string, Option) {
+	kept := make([]string, 0, len(args))
+	simulate := false
+	for i, arg := range args {
+		if i > 0 && arg == "--" {
+			kept = append(kept, args[i:]...)
+			break
+		}
+		if i > 0 && (arg == SimulateShutdownArg || arg == SimulateShutdownArg[1:]) {
+			simulate = true
+			continue
+		}
+		kept = append(kept, arg)
+	}
+
+	if !simulate {
+		return kept, func(*config) {}
+	}
+
+	return kept, func(cfg *config) {
+		cfg.smoke = &smokeTest{out: os.Stdout, bootTimeout: smokeBootTimeout}
+	}
+}
+
+// watchSmokeTest is a method of the GracefulShutdown struct. It triggers the simulated
+// shutdown once the application is ready, and writes the report once it has completed,
+// if configured with ParseSimulateShutdown.
+func (gs *GracefulShutdown) watchSmokeTest() {
+	smoke := gs.cfg.smoke
+	if smoke == nil {
+		return
+	}
+
+	gs.AfterShutdown(func(report Report) {
+		writeSmokeReport(smoke.out, report, gs.Snapshot().Elapsed)
+		if report.Err() != nil || report.Drain.Remaining > 0 {
+			gs.exitProcess(1)
+		}
+	})
+
+	ready := gs.Ready()
+	go func() {
+		timer := time.NewTimer(smoke.bootTimeout)
+		defer timer.Stop()
+
+		select {
+		case <-ready:
+		case <-timer.C:
+		case <-gs.ctx.Done():
+			return
+		}
+		gs.Trigger(ReasonSimulation)
+	}()
+}
+
+// writeSmokeReport is a function that writes the provided report of the simulated
+// shutdown, one line per step.
+func writeSmokeReport(w io.Writer, report Report, elapsed time.Duration) {
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, "gogs: simulated shutdown completed in %s\n", elapsed)
+	for _, step := range []struct {
+		kind    string
+		results []HookResult
+	}{{"intake", report.Intake}, {"registrar", report.Deregistered}, {"hook", report.Hooks}} {
+		for _, res := range step.results {
+			switch {
+			case res.Skipped:
+				_, _ = fmt.Fprintf(&b, "%s %s: skipped\n", step.kind, res.Name)
+			case res.Err != nil:
+				_, _ = fmt.Fprintf(&b, "%s %s: failed after %s: %v\n", step.kind, res.Name, res.Duration, res.Err)
+			default:
+				_, _ = fmt.Fprintf(&b, "%s %s: ok in %s\n", step.kind, res.Name, res.Duration)
+			}
+		}
+	}
+	if report.Drain.Remaining > 0 {
+		_, _ = fmt.Fprintf(&b, "drain: %d active events abandoned\n", report.Drain.Remaining)
+	}
+
+	_, _ = io.WriteString(w, b.String())
+}
@@ -0,0 +1,70 @@
+package gogs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseSimulateShutdown(t *testing.T) {
+	t.Parallel()
+
+	args, opt := parseSimulateShutdown([]string{"app", "-v", "--simulate-shutdown", "--", "--simulate-shutdown"})
+	assert.Equal(t, []string{"app", "-v", "--", "--simulate-shutdown"}, args)
+	var cfg config
+	opt(&cfg)
+	assert.NotNil(t, cfg.smoke)
+
+	args, opt = parseSimulateShutdown([]string{"app", "-v"})
+	assert.Equal(t, []string{"app", "-v"}, args)
+	cfg = config{}
+	opt(&cfg)
+	assert.Nil(t, cfg.smoke)
+}
+
+func Test_ParseSimulateShutdown(t *testing.T) {
+	t.Parallel()
+	_, opt := parseSimulateShutdown([]string{"app", "-simulate-shutdown"})
+	out := &syncBuffer{}
+
+	gs, ctx, cancel := New(context.Background(), func(cfg *config) {
+		opt(cfg)
+		cfg.smoke.out = out
+	})
+	defer cancel()
+	code := 0
+	gs.cfg.exit = func(c int) { code = c }
+
+	gs.AddHook("db", func(context.Context) error { return nil })
+	gs.AddHook("cache", func(context.Context) error { return errors.New("refused") })
+	gs.MarkReady()
+
+	<-ctx.Done()
+	gs.Wait()
+	assert.Equal(t, ReasonSimulation, gs.Reason())
+	assert.Equal(t, 1, code)
+	assert.Contains(t, out.String(), "gogs: simulated shutdown completed in ")
+	assert.Contains(t, out.String(), "hook cache: failed after ")
+	assert.Contains(t, out.String(), ": refused\nhook db: ok in ")
+}
+
+func Test_ParseSimulateShutdown_BootTimeout(t *testing.T) {
+	t.Parallel()
+	_, opt := parseSimulateShutdown([]string{"app", "--simulate-shutdown"})
+	out := &syncBuffer{}
+
+	gs, ctx, cancel := New(context.Background(), func(cfg *config) {
+		opt(cfg)
+		cfg.smoke.out = out
+		cfg.smoke.bootTimeout = ShortDelay
+	})
+	defer cancel()
+	gs.cfg.exit = func(int) { assert.Fail(t, "exited") }
+
+	<-ctx.Done()
+	gs.Wait()
+	assert.Equal(t, ReasonSimulation, gs.Reason())
+	assert.Contains(t, out.String(), "gogs: simulated shutdown completed in ")
+}
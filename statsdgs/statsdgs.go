@@ -0,0 +1,72 @@
+// Package statsdgs emits the metrics of the shutdown to a statsd or DogStatsD server over
+// UDP, for the teams relying on neither Prometheus nor OpenTelemetry. The metrics are
+// flushed once the shutdown has completed, before Wait returns and the process exits.
+package statsdgs
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+// Register is a function that emits the metrics of the shutdown to the statsd server at
+// the provided address once the shutdown has completed, see
+// gogs.GracefulShutdown.AfterShutdown. The names of the metrics start with the provided
+// prefix, if any:
+//
+//   - shutdown.duration, a timer of the time elapsed since the shutdown started;
+//   - shutdown.hook_failures, a counter of the failed intake stops, deregistrations and
+//     hooks;
+//   - shutdown.abandoned, a gauge of the active shutdown events the drain gave up on.
+//
+// The provided tags, e.g. "service:api", are appended in the DogStatsD format, so a plain
+// statsd server must be given none. Sending is best-effort, as usual with statsd.
+//
+//	err := Register(gs, "127.0.0.1:8125", "api", "env:prod")
+//
+// This example emits api.shutdown.duration and the other metrics tagged with the
+// environment.
+func Register(gs *gogs.GracefulShutdown, addr, prefix string, tags ...string) error {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return err
+	}
+
+	gs.AfterShutdown(func(report gogs.Report) {
+		defer conn.Close()
+		_, _ = conn.Write(metrics(report, gs.Snapshot().Elapsed, prefix, tags))
+	})
+
+	return nil
+}
+
+// metrics is a function that returns the packet of the metrics of the provided report,
+// one metric per line.
+func metrics(report gogs.Report, elapsed time.Duration, prefix string, tags []string) []byte {
+	if prefix != "" {
+		prefix += "."
+	}
+	suffix := ""
+	if len(tags) > 0 {
+		suffix = "|#" + strings.Join(tags, ",")
+	}
+
+	failures := 0
+	for _, results := range [][]gogs.HookResult{report.Intake, report.Deregistered, report.Hooks} {
+		for _, res := range results {
+			if res.Err != nil {
+				failures++
+			}
+		}
+	}
+
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, "%sshutdown.duration:%d|ms%s\n", prefix, elapsed.Milliseconds(), suffix)
+	_, _ = fmt.Fprintf(&b, "%sshutdown.hook_failures:%d|c%s\n", prefix, failures, suffix)
+	_, _ = fmt.Fprintf(&b, "%sshutdown.abandoned:%d|g%s", prefix, report.Drain.Remaining, suffix)
+
+	return []byte(b.String())
+}
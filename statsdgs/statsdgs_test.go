@@ -0,0 +1,53 @@
+package statsdgs
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+func Test_Register(t *testing.T) {
+	t.Parallel()
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer server.Close()
+
+	gs, _, cancel := gogs.New(context.Background())
+	assert.NoError(t, Register(gs, server.LocalAddr().String(), "api", "env:test"))
+	gs.AddHook("db", func(context.Context) error { return errors.New("failed") })
+
+	cancel()
+	gs.Wait()
+
+	buf := make([]byte, 1024)
+	assert.NoError(t, server.SetReadDeadline(time.Now().Add(time.Second)))
+	n, _, err := server.ReadFrom(buf)
+	assert.NoError(t, err)
+	assert.Regexp(t, `^api\.shutdown\.duration:\d+\|ms\|#env:test\n`, string(buf[:n]))
+	assert.Contains(t, string(buf[:n]), "api.shutdown.hook_failures:1|c|#env:test\n")
+	assert.Contains(t, string(buf[:n]), "api.shutdown.abandoned:0|g|#env:test")
+}
+
+func Test_metrics(t *testing.T) {
+	t.Parallel()
+
+	report := gogs.Report{
+		Intake: []gogs.HookResult{{Name: "http", Err: errors.New("failed")}},
+		Hooks:  []gogs.HookResult{{Name: "db"}, {Name: "cache", Err: errors.New("failed")}},
+		Drain:  gogs.DrainResult{Remaining: 3},
+	}
+
+	assert.Equal(
+		t,
+		"shutdown.duration:1500|ms\nshutdown.hook_failures:2|c\nshutdown.abandoned:3|g",
+		string(metrics(report, 1500*time.Millisecond, "", nil)),
+	)
+}
@@ -0,0 +1,103 @@
+package gogs
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+)
+
+// OnStatusRequest is an option that invokes the provided handler with the current
+// progress of the shutdown and the active shutdown events, oldest first, whenever the
+// status of the process is requested, i.e. on SIGINFO, sent by Ctrl-T in a terminal on
+// macOS and the BSDs, until the shutdown has completed. A nil handler prints the status to
+// the standard error. The status signal never triggers the shutdown, even if all signals
+// are listed. Status signals are not available on every platform, where the option does
+// nothing.
+//
+//	gs, ctx, cancel := New(
+//		context.Background(),
+//		WithSignals(syscall.SIGINT, syscall.SIGTERM),
+//		OnStatusRequest(nil),
+//	)
+//
+// This example prints what the drain waits for when Ctrl-T is pressed during a slow
+// shutdown.
+func OnStatusRequest(handler func(s Snapshot, pending []Subscription)) Option {
+	return func(cfg *config) {
+		if handler == nil {
+			handler = func(s Snapshot, pending []Subscription) {
+				writeStatus(os.Stderr, s, pending)
+			}
+		}
+		cfg.statusHandler = handler
+		if cfg.ignored == nil {
+			cfg.ignored = make(map[os.Signal]bool)
+		}
+		for _, sig := range statusSignals {
+			cfg.ignored[sig] = true
+		}
+	}
+}
+
+// watchStatus is a method of the GracefulShutdown struct. It invokes the status handler
+// on the status signals until the shutdown has completed.
+func (gs *GracefulShutdown) watchStatus() {
+	if gs.cfg.statusHandler == nil || len(statusSignals) == 0 {
+		return
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, statusSignals...)
+	done := gs.doneChan()
+
+	go func() {
+		defer signal.Stop(sigCh)
+
+		for {
+			select {
+			case <-sigCh:
+				gs.cfg.statusHandler(gs.Snapshot(), gs.Subscriptions(0))
+			case <-done:
+				return
+			}
+		}
+	}()
+}
+
+// writeStatus is a function that writes the provided progress and active shutdown events
+// on a single line.
+func writeStatus(w io.Writer, s Snapshot, pending []Subscription) {
+	var b strings.Builder
+	if s.Phase == "" {
+		b.WriteString("gogs: running")
+	} else {
+		fmt.Fprintf(&b, "gogs: phase %s for %s", s.Phase, s.Elapsed.Round(time.Millisecond))
+	}
+	fmt.Fprintf(&b, ", %d pending", s.Pending)
+
+	now := time.Now()
+	for i, sub := range pending {
+		sep := ", "
+		if i == 0 {
+			sep = " ("
+		}
+		name := sub.Name
+		if name == "" {
+			name = "unnamed"
+		}
+		fmt.Fprintf(&b, "%s%s x%d for %s", sep, name, sub.Count, now.Sub(sub.Since).Round(time.Millisecond))
+	}
+	if len(pending) > 0 {
+		b.WriteString(")")
+	}
+
+	if s.Hook != "" {
+		fmt.Fprintf(&b, ", hook %s, %d hooks left", s.Hook, s.HooksLeft)
+	}
+	b.WriteString("\n")
+
+	_, _ = io.WriteString(w, b.String())
+}
@@ -0,0 +1,12 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package gogs
+
+import (
+	"os"
+	"syscall"
+)
+
+// statusSignals is the list of the signals requesting the status of the process, see
+// OnStatusRequest: SIGINFO, sent by Ctrl-T in a terminal.
+var statusSignals = []os.Signal{syscall.SIGINFO}
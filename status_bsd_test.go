@@ -0,0 +1,31 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package gogs
+
+import (
+	"context"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_OnStatusRequest_Signal(t *testing.T) {
+	statusCh := make(chan Snapshot, 1)
+	gs, ctx, cancel := New(
+		context.Background(),
+		OnStatusRequest(func(s Snapshot, pending []Subscription) {
+			if assert.Len(t, pending, 1) {
+				assert.Equal(t, "export", pending[0].Name)
+			}
+			statusCh <- s
+		}),
+	)
+	defer cancel()
+	done := gs.SubscribeNamed("export")
+	defer done()
+
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGINFO))
+	assert.Equal(t, int32(1), (<-statusCh).Pending)
+	assert.NoError(t, ctx.Err())
+}
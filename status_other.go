@@ -0,0 +1,9 @@
+//go:build !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package gogs
+
+import "os"
+
+// statusSignals is the list of the signals requesting the status of the process, see
+// OnStatusRequest. There are no such signals on this platform.
+var statusSignals []os.Signal
@@ -0,0 +1,42 @@
+package gogs
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_writeStatus(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	writeStatus(&buf, Snapshot{Pending: 0}, nil)
+	assert.Equal(t, "gogs: running, 0 pending\n", buf.String())
+
+	buf.Reset()
+	since := time.Now().Add(-time.Minute)
+	writeStatus(&buf, Snapshot{Phase: PhaseDrain, Pending: 3, Elapsed: 1500 * time.Millisecond}, []Subscription{
+		{Name: "export", Since: since, Count: 1},
+		{Since: since, Count: 2},
+	})
+	assert.Regexp(t, `^gogs: phase drain for 1\.5s, 3 pending \(export x1 for 1m0(\.\d+)?s, unnamed x2 for 1m0(\.\d+)?s\)\n$`,
+		buf.String())
+
+	buf.Reset()
+	writeStatus(&buf, Snapshot{Phase: PhaseClose, Hook: "db", HooksLeft: 2}, nil)
+	assert.Equal(t, "gogs: phase close for 0s, 0 pending, hook db, 2 hooks left\n", buf.String())
+}
+
+func Test_OnStatusRequest(t *testing.T) {
+	t.Parallel()
+
+	var cfg config
+	OnStatusRequest(nil)(&cfg)
+	assert.NotNil(t, cfg.statusHandler)
+	for _, sig := range statusSignals {
+		assert.True(t, cfg.ignored[sig])
+		assert.Contains(t, NoiseSignals, sig)
+	}
+}
@@ -0,0 +1,271 @@
+package gogs
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Subscriber is a struct that describes an active shutdown event registered with
+// SubscribeNamed.
+type Subscriber struct {
+	// Name is the name of the subscriber.
+	Name string
+
+	// Since is the time the subscriber subscribed.
+	Since time.Time
+
+	// Critical reports whether the subscriber was registered with SubscribeCritical.
+	Critical bool
+
+	// Metadata describes the event, e.g. the method and the path of a request, see
+	// SubscribeWithMetadata.
+	Metadata map[string]string
+
+	// Duration is the time the event had been active when the drain gave up on it, zero
+	// while it is active.
+	Duration time.Duration
+}
+
+// DrainResult is a struct that describes the active shutdown events the drain gave up on
+// when its deadline was reached.
+type DrainResult struct {
+	// Remaining is the count of active shutdown events given up on, named or not,
+	// critical or not.
+	Remaining int32
+
+	// Critical is the count of critical events given up on at the deadline of the
+	// shutdown, see SubscribeCritical. The others were given up on at the deadline of the
+	// drain.
+	Critical int32
+
+	// Subscribers is the list of named subscribers given up on, oldest first within each
+	// class, the background ones first.
+	Subscribers []Subscriber
+}
+
+// Err is a method of the DrainResult struct. It returns ErrAbandonedSubscriptions listing
+// the events the drain gave up on, or nil if there is none.
+func (r DrainResult) Err() error {
+	if r.Remaining == 0 {
+		return nil
+	}
+
+	err := ErrAbandonedSubscriptions{Count: r.Remaining}
+	for _, s := range r.Subscribers {
+		err.Names = append(err.Names, s.Name)
+	}
+
+	return err
+}
+
+// SubscribeNamed is a method of the GracefulShutdown struct. It increments the count of
+// active shutdown events by one like Subscribe, and returns the function decrementing it.
+// The name identifies the event in the DrainResult if the drain gives up on it. The
+// returned function is idempotent and does nothing once the drain gave up on the event.
+//
+//	done := gs.SubscribeNamed("report-export")
+//	go func() {
+//		defer done()
+//		export(ctx)
+//	}()
+//
+// This example tracks the export under its name.
+func (gs *GracefulShutdown) SubscribeNamed(name string) func() {
+	return gs.subscribeNamed(Subscriber{Name: name, Since: time.Now()})
+}
+
+// SubscribeWithMetadata is a method of the GracefulShutdown struct. It behaves like
+// SubscribeNamed and records the provided metadata, which must not be modified afterwards,
+// in the DrainResult if the drain gives up on the event, so that the report tells which
+// requests were stuck rather than how many.
+//
+//	done := gs.SubscribeWithMetadata("request", map[string]string{
+//		"method": r.Method,
+//		"path":   r.URL.Path,
+//		"tenant": tenantFromContext(r.Context()),
+//	})
+//	defer done()
+//
+// This example tracks the request along with its endpoint and tenant.
+func (gs *GracefulShutdown) SubscribeWithMetadata(name string, metadata map[string]string) func() {
+	return gs.subscribeNamed(Subscriber{Name: name, Since: time.Now(), Metadata: metadata})
+}
+
+// SubscribeCritical is a method of the GracefulShutdown struct. It tracks a named active
+// shutdown event like SubscribeNamed, but as critical work, e.g. a payment finalization:
+// when the drain is limited by a timeout set with WithPhaseTimeout, the background events
+// are given up on at its deadline while the critical ones keep the drain waiting until
+// the deadline of the whole shutdown. The DrainResult tells both classes apart. Its count
+// is released by the returned function or once the drain gives up on it, never by
+// Unsubscribe.
+//
+//	done := gs.SubscribeCritical("payment-" + id)
+//	defer done()
+//	return payments.Finalize(gs.Shield(ctx), id)
+//
+// This example lets the payment finalize for as long as the shutdown allows.
+func (gs *GracefulShutdown) SubscribeCritical(name string) func() {
+	return gs.subscribeNamed(Subscriber{Name: name, Since: time.Now(), Critical: true})
+}
+
+// OnDrain is a method of the GracefulShutdown struct. It registers a named function
+// executed on its own goroutine once the drain starts, along with the other active
+// shutdown events rather than after them like a hook. The function is tracked as a named
+// active shutdown event from the registration until it returns, so that Wait waits for it
+// and the snapshots and the DrainResult name it while it is pending. The function
+// registered once the drain has started is executed immediately.
+//
+//	gs.OnDrain("consumer", func() {
+//		consumer.Stop()
+//	})
+//
+// This example stops the consumer once the drain starts, and the drain waits for it.
+func (gs *GracefulShutdown) OnDrain(name string, fn func()) {
+	f := drainFn{fn: fn, done: gs.SubscribeNamed(name)}
+
+	gs.mu.Lock()
+	if !gs.drainFnsStarted {
+		gs.onDrain = append(gs.onDrain, f)
+		gs.mu.Unlock()
+		return
+	}
+	gs.mu.Unlock()
+
+	go f.run()
+}
+
+// drainFn is a struct that holds a function registered with OnDrain.
+type drainFn struct {
+	// fn is the registered function.
+	fn func()
+
+	// done completes the active shutdown event tracking the function.
+	done func()
+}
+
+// run is a method of the drainFn struct. It executes the function and completes its
+// active shutdown event.
+func (f drainFn) run() {
+	defer f.done()
+	f.fn()
+}
+
+// subscribeNamed is a method of the GracefulShutdown struct. It tracks the provided
+// subscriber and returns the idempotent function completing it.
+func (gs *GracefulShutdown) subscribeNamed(sub Subscriber) func() {
+	gs.mu.Lock()
+	if gs.subscribers == nil {
+		gs.subscribers = make(map[uint64]Subscriber)
+	}
+	gs.subscriberID++
+	id := gs.subscriberID
+	gs.subscribers[id] = sub
+	gs.mu.Unlock()
+
+	count, _ := gs.admit(1, false, sub.Critical)
+	gs.notify(gs.cfg.observer.OnSubscribe, 1, count)
+
+	return func() {
+		gs.mu.Lock()
+		_, ok := gs.subscribers[id]
+		delete(gs.subscribers, id)
+		if ok && sub.Critical && gs.criticalCh != nil {
+			close(gs.criticalCh)
+			gs.criticalCh = nil
+		}
+		var list, released int32
+		switch {
+		case ok && sub.Critical:
+			list, released = gs.releaseCritical()
+		case ok:
+			list, released = gs.release(1)
+		}
+		gs.mu.Unlock()
+
+		if released > 0 {
+			gs.notify(gs.cfg.observer.OnUnsubscribe, -1, list)
+		}
+	}
+}
+
+// WaitWithTimeoutReport is a method of the GracefulShutdown struct. It behaves like
+// WaitWithTimeout and returns the active shutdown events it gave up on, so that drain
+// failures are not hidden from the caller.
+//
+//	if res := gs.WaitWithTimeoutReport(10 * time.Second); res.Remaining > 0 {
+//		log.Printf("gave up on %d events: %v", res.Remaining, res.Subscribers)
+//	}
+//
+// This example logs the events still active after ten seconds.
+func (gs *GracefulShutdown) WaitWithTimeoutReport(duration time.Duration) DrainResult {
+	gs.WaitWithTimeout(duration)
+	return gs.Report().Drain
+}
+
+// abandonSubscribers is a method of the GracefulShutdown struct. It records the active
+// shutdown events the drain gives up on in the report and forgets the named ones. Unless
+// the critical ones are given up on as well, they are kept, and so are their counts. The
+// counts are released with mu held, so that the named events completing meanwhile are
+// either abandoned or completed, never both.
+func (gs *GracefulShutdown) abandonSubscribers(critical bool) {
+	gs.mu.Lock()
+
+	ids := make([]uint64, 0, len(gs.subscribers))
+	kept := int32(0)
+	for id, sub := range gs.subscribers {
+		if sub.Critical && !critical {
+			kept++
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	now := time.Now()
+	for _, id := range ids {
+		sub := gs.subscribers[id]
+		sub.Duration = now.Sub(sub.Since)
+		gs.report.Drain.Subscribers = append(gs.report.Drain.Subscribers, sub)
+		if sub.Critical {
+			gs.report.Drain.Critical++
+		}
+		delete(gs.subscribers, id)
+	}
+
+	list, remaining := gs.releaseAbove(kept)
+	gs.report.Drain.Remaining += remaining
+	gs.mu.Unlock()
+
+	if remaining > 0 {
+		gs.ages.pop(remaining)
+		gs.notify(gs.cfg.observer.OnUnsubscribe, remaining*-1, list)
+	}
+}
+
+// awaitCritical is a method of the GracefulShutdown struct. It waits for the critical
+// events to complete, and reports false if the provided context is done first.
+func (gs *GracefulShutdown) awaitCritical(ctx context.Context) bool {
+	for {
+		gs.mu.Lock()
+		pending := false
+		for _, sub := range gs.subscribers {
+			pending = pending || sub.Critical
+		}
+		if !pending {
+			gs.mu.Unlock()
+			return true
+		}
+		if gs.criticalCh == nil {
+			gs.criticalCh = make(chan struct{})
+		}
+		changed := gs.criticalCh
+		gs.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package gogs
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GracefulShutdown_SubscribeNamed(t *testing.T) {
+	t.Parallel()
+	gs, _, _ := New(context.Background(), WithSignals(syscall.SIGINT))
+
+	done := gs.SubscribeNamed("export")
+	assert.Equal(t, int32(1), gs.Count())
+
+	done()
+	done()
+	assert.Equal(t, int32(0), gs.Count())
+
+	res := gs.WaitWithTimeoutReport(ShortDelay)
+	assert.Equal(t, DrainResult{}, res)
+}
+
+func Test_GracefulShutdown_OnDrain(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background(), WithSignals(syscall.SIGINT))
+
+	executed := make(chan string, 2)
+	release := make(chan struct{})
+	gs.OnDrain("cache", func() {
+		executed <- "cache"
+	})
+	gs.OnDrain("consumer", func() {
+		executed <- "consumer"
+		<-release
+	})
+	assert.Equal(t, int32(2), gs.Count())
+
+	time.Sleep(ShortDelay)
+	assert.Empty(t, executed)
+
+	cancel()
+	res := gs.WaitWithTimeoutReport(ShortDelay)
+	close(release)
+
+	assert.ElementsMatch(t, []string{"cache", "consumer"}, []string{<-executed, <-executed})
+	assert.Equal(t, int32(1), res.Remaining)
+	if assert.Len(t, res.Subscribers, 1) {
+		assert.Equal(t, "consumer", res.Subscribers[0].Name)
+	}
+
+	gs.OnDrain("late", func() {
+		executed <- "late"
+	})
+	assert.Equal(t, "late", <-executed)
+}
+
+func Test_GracefulShutdown_WaitWithTimeoutReport(t *testing.T) {
+	t.Parallel()
+	gs, _, _ := New(context.Background(), WithSignals(syscall.SIGINT))
+
+	gs.Subscribe()
+	first := gs.SubscribeNamed("export")
+	gs.SubscribeNamed("import")
+	finished := gs.SubscribeNamed("finished")
+	finished()
+
+	res := gs.WaitWithTimeoutReport(ShortDelay)
+
+	assert.Equal(t, int32(3), res.Remaining)
+	if assert.Len(t, res.Subscribers, 2) {
+		assert.Equal(t, "export", res.Subscribers[0].Name)
+		assert.Equal(t, "import", res.Subscribers[1].Name)
+		assert.False(t, res.Subscribers[0].Since.After(res.Subscribers[1].Since))
+	}
+	assert.Equal(t, res, gs.Report().Drain)
+	assert.Equal(t, int32(0), gs.Count())
+
+	err := res.Err()
+	assert.ErrorIs(t, err, ErrTimedOut)
+	assert.EqualError(t, err, "gogs: 3 active shutdown events abandoned: export, import")
+	var abandoned ErrAbandonedSubscriptions
+	if assert.ErrorAs(t, err, &abandoned) {
+		assert.Equal(t, []string{"export", "import"}, abandoned.Names)
+	}
+	assert.NoError(t, DrainResult{}.Err())
+
+	gs.Subscribe()
+	first()
+	assert.Equal(t, int32(1), gs.Count())
+	gs.Unsubscribe()
+}
+
+func Test_GracefulShutdown_SubscribeCritical(t *testing.T) {
+	t.Parallel()
+	gs, _, _ := New(context.Background(), WithPhaseTimeout(PhaseDrain, ShortDelay))
+
+	gs.Subscribe()
+	gs.SubscribeNamed("export")
+	payment := gs.SubscribeCritical("payment")
+	time.AfterFunc(2*ShortDelay, payment)
+
+	start := time.Now()
+	res := gs.WaitWithTimeoutReport(LongDelay)
+
+	assert.GreaterOrEqual(t, time.Since(start), 2*ShortDelay)
+	assert.Less(t, time.Since(start), LongDelay)
+	assert.Equal(t, int32(2), res.Remaining)
+	assert.Equal(t, int32(0), res.Critical)
+	if assert.Len(t, res.Subscribers, 1) {
+		assert.Equal(t, "export", res.Subscribers[0].Name)
+		assert.False(t, res.Subscribers[0].Critical)
+	}
+	assert.Equal(t, int32(0), gs.Count())
+}
+
+func Test_GracefulShutdown_SubscribeCritical_Abandoned(t *testing.T) {
+	t.Parallel()
+	gs, _, _ := New(context.Background(), WithPhaseTimeout(PhaseDrain, ShortDelay))
+
+	gs.Subscribe()
+	payment := gs.SubscribeCritical("payment")
+
+	res := gs.WaitWithTimeoutReport(3 * ShortDelay)
+
+	assert.Equal(t, int32(2), res.Remaining)
+	assert.Equal(t, int32(1), res.Critical)
+	if assert.Len(t, res.Subscribers, 1) {
+		assert.Equal(t, "payment", res.Subscribers[0].Name)
+		assert.True(t, res.Subscribers[0].Critical)
+	}
+	assert.Equal(t, int32(0), gs.Count())
+
+	payment()
+	assert.Equal(t, int32(0), gs.Count())
+}
+
+func Test_GracefulShutdown_abandonSubscribers_ConcurrentUnsubscribe(t *testing.T) {
+	t.Parallel()
+
+	const events = 50
+	for i := 0; i < 20; i++ {
+		gs, _, cancel := New(context.Background())
+
+		payment := gs.SubscribeCritical("payment")
+		exports := make([]func(), events)
+		for j := range exports {
+			exports[j] = gs.SubscribeNamed("export")
+		}
+		gs.SubscribeN(events)
+
+		var wg sync.WaitGroup
+		var completed atomic.Int32
+		start := make(chan struct{})
+		for j := 0; j < events; j++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				<-start
+				if gs.TryUnsubscribe() == nil {
+					completed.Add(1)
+				}
+			}()
+			go func(done func()) {
+				defer wg.Done()
+				<-start
+				done()
+			}(exports[j])
+		}
+		close(start)
+		gs.abandonSubscribers(false)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), gs.Count(), "the critical event is kept")
+		gs.mu.Lock()
+		remaining := gs.report.Drain.Remaining
+		gs.mu.Unlock()
+		assert.LessOrEqual(t, remaining+completed.Load(), int32(2*events))
+
+		payment()
+		assert.Equal(t, int32(0), gs.Count())
+		cancel()
+		gs.Wait()
+	}
+}
+
+func Test_GracefulShutdown_SubscribeWithMetadata(t *testing.T) {
+	t.Parallel()
+	gs, _, _ := New(context.Background())
+
+	metadata := map[string]string{"method": "GET", "path": "/export"}
+	gs.SubscribeWithMetadata("request", metadata)
+	done := gs.SubscribeWithMetadata("finished", nil)
+	done()
+
+	res := gs.WaitWithTimeoutReport(ShortDelay)
+
+	if assert.Len(t, res.Subscribers, 1) {
+		assert.Equal(t, "request", res.Subscribers[0].Name)
+		assert.Equal(t, metadata, res.Subscribers[0].Metadata)
+		assert.GreaterOrEqual(t, res.Subscribers[0].Duration, ShortDelay)
+	}
+}
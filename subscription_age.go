@@ -0,0 +1,118 @@
+package gogs
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Subscription is a struct that describes active shutdown events subscribed at the same
+// time, see Subscriptions.
+type Subscription struct {
+	// Name is the name of the subscriber registered with SubscribeNamed, empty for the
+	// events registered with Subscribe, SubscribeN or SubscribeCtx.
+	Name string
+
+	// Since is the time the events were subscribed.
+	Since time.Time
+
+	// Count is the count of the events.
+	Count int32
+}
+
+// subscriptionAges is a struct that tracks when the unnamed active shutdown events were
+// subscribed. Since Unsubscribe does not tell which event completed, the most recent ones
+// are considered completed first, so the age of the oldest event is the time the count has
+// not dropped below it since.
+type subscriptionAges struct {
+	// mu guards runs.
+	mu sync.Mutex
+
+	// runs is the list of the unnamed events subscribed at the same time, oldest first.
+	runs []Subscription
+}
+
+// push is a method of the subscriptionAges struct. It records the provided count of events
+// subscribed now.
+func (a *subscriptionAges) push(count int32) {
+	if count <= 0 {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.runs = append(a.runs, Subscription{Since: time.Now(), Count: count})
+}
+
+// pop is a method of the subscriptionAges struct. It forgets the provided count of events,
+// the most recent first.
+func (a *subscriptionAges) pop(count int32) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for count > 0 && len(a.runs) > 0 {
+		last := &a.runs[len(a.runs)-1]
+		if last.Count > count {
+			last.Count -= count
+			return
+		}
+		count -= last.Count
+		a.runs = a.runs[:len(a.runs)-1]
+	}
+}
+
+// list is a method of the subscriptionAges struct. It returns the events subscribed at or
+// before the provided time, oldest first.
+func (a *subscriptionAges) list(before time.Time) []Subscription {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var subs []Subscription
+	for _, run := range a.runs {
+		if run.Since.After(before) {
+			break
+		}
+		subs = append(subs, run)
+	}
+
+	return subs
+}
+
+// Subscriptions is a method of the GracefulShutdown struct. It returns the active shutdown
+// events subscribed at least the provided age ago, oldest first, helping to find the
+// components that subscribe at startup and never unsubscribe, blocking every shutdown
+// until its deadline. The named subscribers are reported individually; for the unnamed
+// events, the most recent ones are considered completed first on Unsubscribe.
+//
+//	for _, sub := range gs.Subscriptions(time.Hour) {
+//		log.Printf("%q holds %d events since %s", sub.Name, sub.Count, sub.Since)
+//	}
+//
+// This example logs the events held for more than an hour.
+func (gs *GracefulShutdown) Subscriptions(minAge time.Duration) []Subscription {
+	before := time.Now().Add(-minAge)
+	subs := gs.ages.list(before)
+
+	gs.mu.Lock()
+	for _, sub := range gs.subscribers {
+		if !sub.Since.After(before) {
+			subs = append(subs, Subscription{Name: sub.Name, Since: sub.Since, Count: 1})
+		}
+	}
+	gs.mu.Unlock()
+
+	sort.SliceStable(subs, func(i, j int) bool { return subs[i].Since.Before(subs[j].Since) })
+	return subs
+}
+
+// OldestSubscription is a method of the GracefulShutdown struct. It returns the age of the
+// oldest active shutdown event, or zero if there is none, see Subscriptions.
+func (gs *GracefulShutdown) OldestSubscription() time.Duration {
+	subs := gs.Subscriptions(0)
+	if len(subs) == 0 {
+		return 0
+	}
+
+	return time.Since(subs[0].Since)
+}
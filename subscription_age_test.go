@@ -0,0 +1,50 @@
+package gogs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GracefulShutdown_Subscriptions(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background())
+	defer cancel()
+
+	assert.Zero(t, gs.OldestSubscription())
+	assert.Empty(t, gs.Subscriptions(0))
+
+	gs.Subscribe()
+	done := gs.SubscribeNamed("export")
+	time.Sleep(ShortDelay)
+	gs.SubscribeN(3)
+
+	subs := gs.Subscriptions(ShortDelay)
+	if assert.Len(t, subs, 2) {
+		assert.Equal(t, "", subs[0].Name)
+		assert.Equal(t, int32(1), subs[0].Count)
+		assert.Equal(t, "export", subs[1].Name)
+	}
+	assert.Len(t, gs.Subscriptions(0), 3)
+	assert.GreaterOrEqual(t, gs.OldestSubscription(), ShortDelay)
+
+	gs.UnsubscribeN(2)
+	subs = gs.Subscriptions(0)
+	if assert.Len(t, subs, 3) {
+		assert.Equal(t, int32(1), subs[2].Count)
+	}
+
+	gs.Unsubscribe()
+	done()
+	subs = gs.Subscriptions(0)
+	if assert.Len(t, subs, 1) {
+		assert.Equal(t, "", subs[0].Name)
+		assert.Equal(t, int32(1), subs[0].Count)
+	}
+
+	gs.Unsubscribe()
+	assert.Zero(t, gs.OldestSubscription())
+	assert.Equal(t, int32(0), gs.Count())
+}
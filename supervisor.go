@@ -0,0 +1,181 @@
+package gogs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// Child is a struct that describes a process managed by Supervise.
+type Child struct {
+	// Name is the name of the child used in the errors.
+	Name string
+
+	// Cmd is the command of the child, not started yet.
+	Cmd *exec.Cmd
+
+	// DependsOn is the list of names of the children this one depends on. They are started
+	// before it and stopped after it.
+	DependsOn []string
+
+	// Budget is the time the child is given to exit after SIGTERM before it is killed with
+	// SIGKILL. Zero means until the deadline of the hook.
+	Budget time.Duration
+}
+
+// supervised is a started Child.
+type supervised struct {
+	Child
+
+	// done is closed once the child has exited, and err is then the error of its wait.
+	done chan struct{}
+	err  error
+}
+
+// Supervise is a function that starts the provided children in dependency order, each in
+// its own process group, and registers a named hook stopping them in reverse order, a
+// miniature init for containers running several processes. Each child is sent SIGTERM,
+// waited for up to its budget and then killed with SIGKILL along with the processes it
+// started. A child exiting on SIGTERM, with or without handling it, is stopped
+// gracefully; one exiting with a failure or killed is reported in the error of the hook.
+// If a child fails to start, the ones already started are killed and the error returned.
+//
+//	err := Supervise(gs, "children", []Child{
+//		{Name: "envoy", Cmd: exec.Command("envoy", "-c", "envoy.yaml"), Budget: 5 * time.Second},
+//		{Name: "app", Cmd: exec.Command("./app"), DependsOn: []string{"envoy"}, Budget: 20 * time.Second},
+//	})
+//
+// This example starts the proxy before the application and, on shutdown, stops the
+// application before the proxy it sends its traffic through.
+func Supervise(gs HookRegistrar, name string, children []Child, opts ...HookOption) error {
+	ordered, err := orderChildren(children)
+	if err != nil {
+		return err
+	}
+
+	started := make([]*supervised, 0, len(ordered))
+	for _, child := range ordered {
+		IsolateCommand(child.Cmd)
+		if err = child.Cmd.Start(); err != nil {
+			for i := len(started) - 1; i >= 0; i-- {
+				_ = SignalCommand(started[i].Cmd, os.Kill)
+				<-started[i].done
+			}
+			return fmt.Errorf("gogs: start %s: %w", child.Name, err)
+		}
+
+		s := &supervised{Child: child, done: make(chan struct{})}
+		go func() {
+			s.err = s.Cmd.Wait()
+			close(s.done)
+		}()
+		started = append(started, s)
+	}
+
+	gs.AddHook(name, func(ctx context.Context) error {
+		var errs multiError
+		for i := len(started) - 1; i >= 0; i-- {
+			if err := started[i].stop(ctx); err != nil {
+				errs = append(errs, ErrHookFailed{Name: started[i].Name, Err: err})
+			}
+		}
+
+		if len(errs) == 0 {
+			return nil
+		}
+
+		return errs
+	}, opts...)
+
+	return nil
+}
+
+// stop is a method of the supervised struct. It sends SIGTERM to the child and waits for
+// it to exit within its budget, or kills it.
+func (s *supervised) stop(ctx context.Context) error {
+	select {
+	case <-s.done:
+		return s.exitErr(nil)
+	default:
+	}
+
+	if s.Budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Budget)
+		defer cancel()
+	}
+
+	_ = SignalCommand(s.Cmd, syscall.SIGTERM)
+	select {
+	case <-s.done:
+		return s.exitErr(syscall.SIGTERM)
+	case <-ctx.Done():
+	}
+
+	_ = SignalCommand(s.Cmd, os.Kill)
+	<-s.done
+
+	return fmt.Errorf("%w, killed", ctx.Err())
+}
+
+// exitErr is a method of the supervised struct. It returns the error of the exit of the
+// child, ignoring the termination by the provided signal, if any.
+func (s *supervised) exitErr(sig os.Signal) error {
+	if sig != nil && signaled(s.err, sig) {
+		return nil
+	}
+
+	return s.err
+}
+
+// orderChildren is a function that returns the provided children ordered so that each one
+// follows the children it depends on, in order of declaration otherwise.
+func orderChildren(children []Child) ([]Child, error) {
+	index := make(map[string]int, len(children))
+	for i, child := range children {
+		index[child.Name] = i
+	}
+	for _, child := range children {
+		for _, dep := range child.DependsOn {
+			if _, ok := index[dep]; !ok {
+				return nil, fmt.Errorf("gogs: child %s depends on unknown %s", child.Name, dep)
+			}
+		}
+	}
+
+	ordered := make([]Child, 0, len(children))
+	placed := make(map[string]bool, len(children))
+	for len(ordered) < len(children) {
+		progressed := false
+		for _, child := range children {
+			if placed[child.Name] || !dependenciesPlaced(child, placed) {
+				continue
+			}
+
+			ordered = append(ordered, child)
+			placed[child.Name] = true
+			progressed = true
+		}
+
+		if !progressed {
+			return nil, fmt.Errorf("gogs: children depend on each other in a cycle")
+		}
+	}
+
+	return ordered, nil
+}
+
+// dependenciesPlaced is a function that reports whether all dependencies of the provided
+// child are placed.
+func dependenciesPlaced(child Child, placed map[string]bool) bool {
+	for _, dep := range child.DependsOn {
+		if !placed[dep] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,79 @@
+//go:build unix
+
+package gogs
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Supervise(t *testing.T) {
+	t.Parallel()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skipf("sh is not available: %v", err)
+	}
+
+	gs, _, cancel := New(context.Background())
+	err := Supervise(gs, "children", []Child{
+		{Name: "app", Cmd: exec.Command("sleep", "10"), DependsOn: []string{"proxy"}},
+		{Name: "proxy", Cmd: exec.Command("sh", "-c", `trap "" TERM; sleep 10`), Budget: ShortDelay},
+		{Name: "migrate", Cmd: exec.Command("sh", "-c", "exit 3")},
+	})
+	assert.NoError(t, err)
+
+	cancel()
+	start := time.Now()
+	gs.WaitWithTimeout(LongDelay)
+
+	assert.Less(t, time.Since(start), LongDelay)
+	assert.EqualError(t, gs.Report().Err(),
+		"children: migrate: exit status 3; proxy: context deadline exceeded, killed")
+}
+
+func Test_Supervise_StartFailure(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background())
+	defer cancel()
+
+	sleep := exec.Command("sleep", "10")
+	err := Supervise(gs, "children", []Child{
+		{Name: "sleep", Cmd: sleep},
+		{Name: "missing", Cmd: exec.Command("/nonexistent/binary")},
+	})
+
+	assert.ErrorContains(t, err, "gogs: start missing:")
+	if sleep.ProcessState != nil {
+		assert.False(t, sleep.ProcessState.Success())
+	}
+}
+
+func Test_orderChildren(t *testing.T) {
+	t.Parallel()
+
+	ordered, err := orderChildren([]Child{
+		{Name: "app", DependsOn: []string{"proxy", "cache"}},
+		{Name: "proxy"},
+		{Name: "cache", DependsOn: []string{"proxy"}},
+		{Name: "logs"},
+	})
+	assert.NoError(t, err)
+
+	names := make([]string, 0, len(ordered))
+	for _, child := range ordered {
+		names = append(names, child.Name)
+	}
+	assert.Equal(t, []string{"proxy", "cache", "logs", "app"}, names)
+
+	_, err = orderChildren([]Child{{Name: "app", DependsOn: []string{"db"}}})
+	assert.EqualError(t, err, "gogs: child app depends on unknown db")
+
+	_, err = orderChildren([]Child{
+		{Name: "a", DependsOn: []string{"b"}},
+		{Name: "b", DependsOn: []string{"a"}},
+	})
+	assert.EqualError(t, err, "gogs: children depend on each other in a cycle")
+}
@@ -0,0 +1,150 @@
+package gogs
+
+import (
+	"os"
+	"os/signal"
+	"time"
+)
+
+// suspendPoll is the interval at which the kill delay watchdog checks again whether the
+// process has resumed when it fires while the process is suspended.
+const suspendPoll = 10 * time.Millisecond
+
+// WithSuspendAware is an option that pauses the deadline of the shutdown, the deadlines of
+// the hooks and the kill delay watchdog while the process is suspended with SIGTSTP, e.g.
+// by Ctrl-Z, until it is resumed with SIGCONT, so that the resumed process does not hit
+// its deadlines right away. The process still stops on SIGTSTP, and neither signal
+// triggers the shutdown. The phase budgets set with WithPhaseTimeout and the timeouts of
+// the hooks are not paused. SIGSTOP cannot be caught, see WithFreezeAwareKill, and the
+// system sleep already pauses the monotonic clock the timers rely on. Suspension signals
+// are not available on every platform, where the option does nothing.
+//
+//	gs, ctx, cancel := New(
+//		context.Background(),
+//		WithGracePeriod(30*time.Second),
+//		WithKillDelay(5*time.Second),
+//		WithSuspendAware(),
+//	)
+//
+// This example gives the shutdown thirty seconds of running time, however long the
+// process was suspended in a terminal.
+func WithSuspendAware() Option {
+	return func(cfg *config) {
+		cfg.suspendAware = true
+		if cfg.ignored == nil {
+			cfg.ignored = make(map[os.Signal]bool)
+		}
+		for _, sig := range suspendSignals {
+			cfg.ignored[sig] = true
+		}
+	}
+}
+
+// watchSuspend is a method of the GracefulShutdown struct. It pauses the deadlines on
+// SIGTSTP before stopping the process, and resumes them on SIGCONT, until the shutdown
+// has completed.
+func (gs *GracefulShutdown) watchSuspend() {
+	if !gs.cfg.suspendAware || len(suspendSignals) == 0 {
+		return
+	}
+
+	sigCh := make(chan os.Signal, len(suspendSignals))
+	signal.Notify(sigCh, suspendSignals...)
+	done := gs.doneChan()
+
+	go func() {
+		defer signal.Stop(sigCh)
+
+		for {
+			select {
+			case sig := <-sigCh:
+				if sig == resumeSignal {
+					gs.resume()
+					continue
+				}
+				gs.pause()
+				gs.suspendProcess()
+			case <-done:
+				return
+			}
+		}
+	}()
+}
+
+// pause is a method of the GracefulShutdown struct. It records the time the process is
+// suspended at and stops the timers of the pausable contexts.
+func (gs *GracefulShutdown) pause() {
+	if !gs.suspendedAt.CompareAndSwap(0, time.Now().UnixNano()) {
+		return
+	}
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	for c := range gs.pausable {
+		c.pause()
+	}
+}
+
+// resume is a method of the GracefulShutdown struct. It postpones the deadlines of the
+// pausable contexts and the kill delay watchdog by the time the process was suspended.
+func (gs *GracefulShutdown) resume() {
+	at := gs.suspendedAt.Load()
+	if at == 0 {
+		return
+	}
+	d := time.Since(time.Unix(0, at))
+
+	gs.mu.Lock()
+	for c := range gs.pausable {
+		c.postpone(d)
+	}
+	gs.mu.Unlock()
+
+	if gs.cfg.freezeInterval <= 0 {
+		gs.postponedKill.Add(int64(d))
+	}
+	gs.suspendedAt.Store(0)
+}
+
+// suspended is a method of the GracefulShutdown struct. It reports whether the process is
+// suspended, in which case the kill delay watchdog waits for it to resume.
+func (gs *GracefulShutdown) suspended() bool {
+	return gs.suspendedAt.Load() != 0
+}
+
+// suspendProcess is a method of the GracefulShutdown struct. It stops the process until
+// it is resumed.
+func (gs *GracefulShutdown) suspendProcess() {
+	suspend := gs.cfg.suspend
+	if suspend == nil {
+		suspend = stopProcess
+	}
+	suspend()
+}
+
+// track is a method of the GracefulShutdown struct. It adds the provided context to the
+// pausable ones, if the option is enabled, and returns the function removing it.
+func (gs *GracefulShutdown) track(c *extendableCtx) func() {
+	if !gs.cfg.suspendAware {
+		return func() {}
+	}
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if gs.pausable == nil {
+		gs.pausable = make(map[*extendableCtx]struct{})
+	}
+	gs.pausable[c] = struct{}{}
+	if gs.suspended() {
+		c.pause()
+	}
+
+	return func() {
+		gs.mu.Lock()
+		defer gs.mu.Unlock()
+
+		delete(gs.pausable, c)
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package gogs
+
+import "os"
+
+// suspendSignals is the list of the signals suspending and resuming the process, see
+// WithSuspendAware, and resumeSignal is the one resuming it. There are no such signals on
+// this platform.
+var (
+	suspendSignals []os.Signal
+	resumeSignal   os.Signal
+)
+
+// stopProcess is a function that stops the process. Processes cannot be stopped on this
+// platform, so it does nothing.
+func stopProcess() {}
@@ -0,0 +1,64 @@
+//go:build unix
+
+package gogs
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WithSuspendAware(t *testing.T) {
+	gs, _, cancel := New(
+		context.Background(),
+		WithGracePeriod(ShortDelay),
+		WithSuspendAware(),
+		func(cfg *config) {
+			cfg.suspend = func() {
+				time.Sleep(2 * ShortDelay)
+				_ = syscall.Kill(os.Getpid(), syscall.SIGCONT)
+			}
+		},
+	)
+
+	gs.AddHook("stuck", func(ctx context.Context) error {
+		_ = syscall.Kill(os.Getpid(), syscall.SIGTSTP)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	start := time.Now()
+	cancel()
+	gs.Wait()
+
+	assert.GreaterOrEqual(t, time.Since(start), 3*ShortDelay)
+	assert.ErrorIs(t, gs.Report().Err(), context.DeadlineExceeded)
+	assert.Equal(t, ReasonCancel, gs.Reason())
+}
+
+func Test_GracefulShutdown_kill_Suspended(t *testing.T) {
+	t.Parallel()
+
+	exitCh := make(chan time.Time, 1)
+	gs := &GracefulShutdown{}
+	gs.cfg.exit = func(int) {
+		exitCh <- time.Now()
+	}
+
+	start := time.Now()
+	gs.suspendedAt.Store(start.Add(-ShortDelay).UnixNano())
+	gs.kill()
+
+	select {
+	case <-exitCh:
+		assert.Fail(t, "exited while suspended")
+	case <-time.After(5 * suspendPoll):
+	}
+
+	gs.resume()
+	assert.GreaterOrEqual(t, (<-exitCh).Sub(start), ShortDelay+5*suspendPoll)
+}
@@ -0,0 +1,21 @@
+//go:build unix
+
+package gogs
+
+import (
+	"os"
+	"syscall"
+)
+
+// suspendSignals is the list of the signals suspending and resuming the process, see
+// WithSuspendAware, and resumeSignal is the one resuming it.
+var (
+	suspendSignals = []os.Signal{syscall.SIGTSTP, syscall.SIGCONT}
+	resumeSignal   = os.Signal(syscall.SIGCONT)
+)
+
+// stopProcess is a function that stops the process with SIGSTOP, which cannot be caught,
+// as SIGTSTP would have done if it were not caught.
+func stopProcess() {
+	_ = syscall.Kill(os.Getpid(), syscall.SIGSTOP)
+}
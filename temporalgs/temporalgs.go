@@ -0,0 +1,153 @@
+// Package temporalgs provides the graceful stop of Temporal and Cadence workers. The
+// package depends on no SDK: worker.Worker is described by the interface of this package.
+package temporalgs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+// reportMargin is the time before the deadline of the hook at which the stop gives up
+// waiting, so that the error listing the running executions reaches the report.
+const reportMargin = 5 * time.Millisecond
+
+// Worker is an interface that describes a worker.Worker of the Temporal or Cadence SDK.
+type Worker interface {
+	// Stop stops the worker, waiting for the running activities up to the
+	// WorkerStopTimeout of the worker options.
+	Stop()
+}
+
+// Kind is a type that describes the kind of a tracked execution.
+type Kind string
+
+const (
+	// KindWorkflow is the kind of the workflow tasks.
+	KindWorkflow Kind = "workflow"
+
+	// KindActivity is the kind of the activities.
+	KindActivity Kind = "activity"
+)
+
+// Execution is a struct that describes a workflow task or an activity running on the
+// worker.
+type Execution struct {
+	// Kind is the kind of the execution.
+	Kind Kind
+
+	// Name is the type of the workflow or activity.
+	Name string
+
+	// Since is the time the execution started.
+	Since time.Time
+}
+
+// Stopper is a struct that stops a worker within the deadline of its hook, and reports the
+// executions still running at the deadline. The executions are tracked with Track, e.g.
+// from the interceptors of the worker.
+//
+//	s := Register(gs, "temporal", w, gogs.WithTimeout(30*time.Second))
+//
+//	func (a *activityInterceptor) ExecuteActivity(ctx context.Context, in *interceptor.ExecuteActivityInput) (any, error) {
+//		defer s.Track(KindActivity, activity.GetInfo(ctx).ActivityType.Name)()
+//		return a.Next.ExecuteActivity(ctx, in)
+//	}
+//
+// This example stops the worker for up to thirty seconds and lists the activities that
+// did not complete in time. The WorkerStopTimeout of the worker should not exceed the
+// timeout of the hook.
+type Stopper struct {
+	// worker is the stopped worker.
+	worker Worker
+
+	// running maps the IDs of the running executions to them. It is guarded by mu.
+	mu      sync.Mutex
+	running map[uint64]Execution
+	nextID  uint64
+}
+
+// Register is a function that creates a new Stopper for the provided worker and registers
+// its stop as a named hook configured with the provided hook options. The hook fails with
+// an error listing the running executions if the worker is not stopped before the
+// deadline of the hook.
+func Register(gs gogs.HookRegistrar, name string, w Worker, opts ...gogs.HookOption) *Stopper {
+	s := &Stopper{worker: w, running: make(map[uint64]Execution)}
+	gs.AddHook(name, s.stop, opts...)
+
+	return s
+}
+
+// Track is a method of the Stopper struct. It records an execution of the provided kind
+// and name as running and returns the function recording its completion.
+func (s *Stopper) Track(kind Kind, name string) func() {
+	s.mu.Lock()
+	s.nextID++
+	id := s.nextID
+	s.running[id] = Execution{Kind: kind, Name: name, Since: time.Now()}
+	s.mu.Unlock()
+
+	return func() {
+		s.mu.Lock()
+		delete(s.running, id)
+		s.mu.Unlock()
+	}
+}
+
+// Running is a method of the Stopper struct. It returns the running executions, oldest
+// first.
+func (s *Stopper) Running() []Execution {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]uint64, 0, len(s.running))
+	for id := range s.running {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	running := make([]Execution, 0, len(ids))
+	for _, id := range ids {
+		running = append(running, s.running[id])
+	}
+
+	return running
+}
+
+// stop is a method of the Stopper struct. It stops the worker and waits for it to stop,
+// giving up shortly before the deadline of the context.
+func (s *Stopper) stop(ctx context.Context) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline.Add(-reportMargin))
+		defer cancel()
+	}
+
+	doneCh := make(chan struct{})
+	go func() {
+		s.worker.Stop()
+		close(doneCh)
+	}()
+
+	select {
+	case <-doneCh:
+		return nil
+	case <-ctx.Done():
+	}
+
+	running := s.Running()
+	names := make([]string, 0, len(running))
+	for _, e := range running {
+		names = append(names, fmt.Sprintf("%s %s (%s)", e.Kind, e.Name, time.Since(e.Since).Round(time.Millisecond)))
+	}
+	if len(names) == 0 {
+		names = append(names, "none tracked")
+	}
+
+	return fmt.Errorf("temporalgs: %w, running: %s", ctx.Err(), strings.Join(names, ", "))
+}
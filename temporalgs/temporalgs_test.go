@@ -0,0 +1,64 @@
+package temporalgs
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	gogs "github.com/dsbasko/go-gs"
+)
+
+type testWorker struct {
+	stopCh chan struct{}
+}
+
+func (w testWorker) Stop() {
+	<-w.stopCh
+}
+
+func Test_Register(t *testing.T) {
+	t.Parallel()
+	gs, _, _ := gogs.New(context.Background())
+
+	w := testWorker{stopCh: make(chan struct{})}
+	s := Register(gs, "temporal", w)
+
+	done := s.Track(KindActivity, "ChargeCard")
+	assert.Len(t, s.Running(), 1)
+	done()
+	assert.Empty(t, s.Running())
+
+	close(w.stopCh)
+	gs.Wait()
+
+	assert.NoError(t, gs.Report().Err())
+}
+
+func Test_Register_Deadline(t *testing.T) {
+	t.Parallel()
+	gs, _, _ := gogs.New(context.Background())
+
+	w := testWorker{stopCh: make(chan struct{})}
+	defer close(w.stopCh)
+	s := Register(gs, "temporal", w, gogs.WithTimeout(20*time.Millisecond))
+
+	s.Track(KindWorkflow, "Checkout")
+	s.Track(KindActivity, "ChargeCard")
+	s.Track(KindActivity, "SendEmail")()
+
+	running := s.Running()
+	if assert.Len(t, running, 2) {
+		assert.Equal(t, Execution{Kind: KindWorkflow, Name: "Checkout", Since: running[0].Since}, running[0])
+		assert.Equal(t, "ChargeCard", running[1].Name)
+	}
+
+	gs.Wait()
+
+	err := gs.Report().Hooks[0].Err
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.True(t, strings.HasPrefix(err.Error(), "temporalgs: context deadline exceeded, running: workflow Checkout ("), err.Error())
+	assert.Contains(t, err.Error(), ", activity ChargeCard (")
+}
@@ -0,0 +1,53 @@
+package gogs
+
+import (
+	"context"
+	"log"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GracefulShutdown_SubscribeToken(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background())
+	defer cancel()
+
+	token := gs.SubscribeToken("upload")
+	assert.Equal(t, int32(1), gs.Count())
+	token.Done()
+	token.Done()
+	assert.Equal(t, int32(0), gs.Count())
+}
+
+func Test_WithLeakDetection(t *testing.T) {
+	t.Parallel()
+	var buf syncBuffer
+	gs, _, cancel := New(context.Background(), WithLeakDetection(), WithLogger(log.New(&buf, "", 0)))
+	defer cancel()
+
+	leakToken(gs)
+	done := gs.SubscribeToken("kept")
+	assert.Equal(t, int32(2), gs.Count())
+
+	assert.Eventually(t, func() bool {
+		runtime.GC()
+		return gs.Count() == 1
+	}, LongDelay, ShortDelay)
+	assert.Contains(t, buf.String(), `gogs: subscription "leaked" leaked, garbage collected without Done`)
+	assert.Contains(t, buf.String(), "leakToken")
+	assert.Contains(t, buf.String(), "token_test.go")
+
+	done.Done()
+	runtime.GC()
+	time.Sleep(ShortDelay)
+	assert.Equal(t, int32(0), gs.Count())
+	assert.NotContains(t, buf.String(), `"kept"`)
+}
+
+//go:noinline
+func leakToken(gs *GracefulShutdown) {
+	gs.SubscribeToken("leaked")
+}
@@ -0,0 +1,243 @@
+package gogs
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Topology is a struct that describes the configuration of the lifecycle: the signals, the
+// budgets, the phases with their timeouts and what each phase stops, in order, including
+// the child GracefulShutdowners created by Scope. It is returned by
+// GracefulShutdown.Topology and encodes to JSON with the durations as strings, e.g. "1.5s",
+// so that platform teams can audit the shutdown configuration across services.
+type Topology struct {
+	// Signals is the list of names of the signals triggering the shutdown. Empty means
+	// all signals but the ignored ones.
+	Signals []string `json:"signals"`
+
+	// StopOrder is the order of the intake stop and the context cancellation, see
+	// WithStopOrder: "cancel-first" or "intake-first".
+	StopOrder string `json:"stop_order"`
+
+	// GracePeriod limits the shutdown, and KillDelay is the delay after which the process
+	// exits past its deadline. Zero means no limit.
+	GracePeriod time.Duration `json:"grace_period"`
+	KillDelay   time.Duration `json:"kill_delay"`
+
+	// Policies maps the shutdown reasons to their grace periods, see WithPolicy.
+	Policies map[Reason]time.Duration `json:"policies,omitempty"`
+
+	// Phases is the list of phases in order of execution with their timeouts, see
+	// WithPhaseTimeout.
+	Phases []TopologyPhase `json:"phases"`
+
+	// Quotas maps the categories of hooks to their shares of the budget of the hooks, see
+	// WithCategoryQuota.
+	Quotas map[string]float64 `json:"quotas,omitempty"`
+
+	// Intake is the list of names of the functions stopping the intake, in order of
+	// execution, see AddIntake.
+	Intake []string `json:"intake"`
+
+	// Registrars is the list of names of the entries removed in PhaseDeregister, see
+	// AddRegistrar.
+	Registrars []string `json:"registrars"`
+
+	// Hooks is the list of hooks in order of execution, whatever the reason.
+	Hooks []TopologyHook `json:"hooks"`
+}
+
+// TopologyPhase is a struct that describes a phase of a Topology.
+type TopologyPhase struct {
+	// Phase is the phase.
+	Phase Phase `json:"phase"`
+
+	// Timeout limits the phase. Zero means no limit beyond the grace period.
+	Timeout time.Duration `json:"timeout"`
+}
+
+// TopologyHook is a struct that describes a hook of a Topology, see PlannedHook.
+type TopologyHook struct {
+	// Name is the name of the hook.
+	Name string `json:"name"`
+
+	// Reasons is the list of reasons the hook is executed for, see WithReasons. Empty
+	// means all.
+	Reasons []Reason `json:"reasons,omitempty"`
+
+	// Timeout limits the execution of the hook, see WithTimeout and WithHookTimeout.
+	Timeout time.Duration `json:"timeout"`
+
+	// BestEffort reports whether the hook is optional, and Estimate is the estimate of its
+	// duration, see WithBestEffort.
+	BestEffort bool          `json:"best_effort,omitempty"`
+	Estimate   time.Duration `json:"estimate,omitempty"`
+
+	// Category is the category of the hook, see WithCategory.
+	Category string `json:"category,omitempty"`
+
+	// Final reports whether the hook is executed after all other hooks, see WithFinal.
+	Final bool `json:"final,omitempty"`
+
+	// Conditional reports whether the hook is executed only if its condition is met, see
+	// WithCondition.
+	Conditional bool `json:"conditional,omitempty"`
+
+	// Early reports whether the hook starts along with the drain, see WithEarlyStart.
+	Early bool `json:"early,omitempty"`
+
+	// Module is the module of the hook, and Disabled reports whether the module is
+	// disabled, see WithModule.
+	Module   string `json:"module,omitempty"`
+	Disabled bool   `json:"disabled,omitempty"`
+
+	// Skipped reports whether the hook is skipped by the operator, see WithSkippedHooks.
+	Skipped bool `json:"skipped,omitempty"`
+
+	// Scope is the topology of the child created by Scope the hook shuts down, if any.
+	Scope *Topology `json:"scope,omitempty"`
+}
+
+// Topology is a method of the GracefulShutdown struct. It returns the configuration of the
+// lifecycle with the components registered so far, see Plan for the shutdown of a given
+// reason.
+//
+//	data, err := json.MarshalIndent(gs.Topology(), "", "  ")
+//
+// This example encodes the topology for an audit.
+func (gs *GracefulShutdown) Topology() Topology {
+	t := Topology{
+		Signals:     make([]string, 0, len(gs.cfg.triggering())),
+		StopOrder:   "cancel-first",
+		GracePeriod: gs.cfg.gracePeriod,
+		KillDelay:   gs.cfg.killDelay,
+		Quotas:      gs.cfg.quotas,
+	}
+	for _, sig := range gs.cfg.triggering() {
+		t.Signals = append(t.Signals, sig.String())
+	}
+	if gs.cfg.stopOrder == StopIntakeFirst {
+		t.StopOrder = "intake-first"
+	}
+	if len(gs.cfg.policies) > 0 {
+		t.Policies = make(map[Reason]time.Duration, len(gs.cfg.policies))
+		for reason, policy := range gs.cfg.policies {
+			t.Policies[reason] = policy.GracePeriod
+		}
+	}
+	for _, phase := range []Phase{PhaseDeregister, PhaseDrain, PhaseClose} {
+		t.Phases = append(t.Phases, TopologyPhase{Phase: phase, Timeout: gs.cfg.phaseTimeouts[phase]})
+	}
+
+	gs.intake.mu.Lock()
+	t.Intake = make([]string, 0, len(gs.intake.stops))
+	for i := len(gs.intake.stops) - 1; i >= 0; i-- {
+		t.Intake = append(t.Intake, gs.intake.stops[i].name)
+	}
+	gs.intake.mu.Unlock()
+
+	gs.mu.Lock()
+	t.Registrars = make([]string, 0, len(gs.registrars))
+	for _, r := range gs.registrars {
+		t.Registrars = append(t.Registrars, r.name)
+	}
+	hooks := orderHooks(gs.hooks, func(hook) bool { return true })
+	gs.mu.Unlock()
+
+	t.Hooks = make([]TopologyHook, 0, len(hooks))
+	for _, h := range hooks {
+		t.Hooks = append(t.Hooks, gs.topologyHook(h))
+	}
+
+	return t
+}
+
+// topologyHook is a method of the GracefulShutdown struct. It describes the provided
+// hook.
+func (gs *GracefulShutdown) topologyHook(h hook) TopologyHook {
+	timeout := h.timeout
+	if timeout <= 0 {
+		timeout = gs.cfg.hookTimeouts[h.name]
+	}
+
+	th := TopologyHook{
+		Name:        h.name,
+		Reasons:     h.reasons,
+		Timeout:     timeout,
+		BestEffort:  h.bestEffort,
+		Estimate:    h.estimate,
+		Category:    h.category,
+		Final:       h.final,
+		Conditional: h.condition != nil,
+		Early:       h.early,
+		Module:      h.module,
+		Disabled:    gs.moduleDisabled(h.module),
+		Skipped:     gs.cfg.skippedHooks[h.name],
+	}
+	if h.scope != nil {
+		scope := h.scope.Topology()
+		th.Scope = &scope
+	}
+
+	return th
+}
+
+// MarshalJSON is a method of the Topology struct. It encodes the durations as strings.
+func (t Topology) MarshalJSON() ([]byte, error) {
+	type plain Topology
+
+	var policies map[Reason]string
+	if len(t.Policies) > 0 {
+		policies = make(map[Reason]string, len(t.Policies))
+		for reason, grace := range t.Policies {
+			policies[reason] = grace.String()
+		}
+	}
+
+	return json.Marshal(struct {
+		plain
+		GracePeriod string            `json:"grace_period"`
+		KillDelay   string            `json:"kill_delay"`
+		Policies    map[Reason]string `json:"policies,omitempty"`
+	}{
+		plain:       plain(t),
+		GracePeriod: t.GracePeriod.String(),
+		KillDelay:   t.KillDelay.String(),
+		Policies:    policies,
+	})
+}
+
+// MarshalJSON is a method of the TopologyPhase struct. It encodes the timeout as a
+// string.
+func (p TopologyPhase) MarshalJSON() ([]byte, error) {
+	type plain TopologyPhase
+
+	return json.Marshal(struct {
+		plain
+		Timeout string `json:"timeout"`
+	}{
+		plain:   plain(p),
+		Timeout: p.Timeout.String(),
+	})
+}
+
+// MarshalJSON is a method of the TopologyHook struct. It encodes the durations as
+// strings.
+func (h TopologyHook) MarshalJSON() ([]byte, error) {
+	type plain TopologyHook
+
+	var estimate string
+	if h.Estimate > 0 {
+		estimate = h.Estimate.String()
+	}
+
+	return json.Marshal(struct {
+		plain
+		Timeout  string `json:"timeout"`
+		Estimate string `json:"estimate,omitempty"`
+	}{
+		plain:    plain(h),
+		Timeout:  h.Timeout.String(),
+		Estimate: estimate,
+	})
+}
@@ -0,0 +1,74 @@
+package gogs
+
+import (
+	"context"
+	"encoding/json"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GracefulShutdown_Topology(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(
+		context.Background(),
+		WithSignals(syscall.SIGTERM),
+		WithStopOrder(StopIntakeFirst),
+		WithGracePeriod(30*time.Second),
+		WithKillDelay(5*time.Second),
+		WithPolicy(ReasonParent, Policy{GracePeriod: time.Second}),
+		WithPhaseTimeout(PhaseDrain, 20*time.Second),
+		WithHookTimeout("db", 3*time.Second),
+	)
+	defer cancel()
+
+	noop := func(context.Context) error { return nil }
+	gs.AddIntake("http", func() error { return nil })
+	gs.AddIntake("grpc", func() error { return nil })
+	gs.AddRegistrar("consul", &testRegistrar{})
+	gs.AddHook("metrics", noop, WithFinal())
+	gs.AddHook("db", noop, WithReasons(ReasonSignal))
+	gs.AddHook("cache", noop, WithBestEffort(time.Second))
+	workers := gs.Scope("workers", WithGracePeriod(5*time.Second))
+	workers.AddHook("queue", noop, WithTimeout(time.Second))
+
+	topology := gs.Topology()
+	assert.Equal(t, []string{"terminated"}, topology.Signals)
+	assert.Equal(t, "intake-first", topology.StopOrder)
+	assert.Equal(t, map[Reason]time.Duration{ReasonParent: time.Second}, topology.Policies)
+	assert.Equal(t, []TopologyPhase{
+		{Phase: PhaseDeregister},
+		{Phase: PhaseDrain, Timeout: 20 * time.Second},
+		{Phase: PhaseClose},
+	}, topology.Phases)
+	assert.Equal(t, []string{"grpc", "http"}, topology.Intake)
+	assert.Equal(t, []string{"consul"}, topology.Registrars)
+
+	names := make([]string, 0, len(topology.Hooks))
+	for _, h := range topology.Hooks {
+		names = append(names, h.Name)
+	}
+	assert.Equal(t, []string{"workers", "db", "cache", "metrics"}, names)
+	assert.Equal(t, []Reason{ReasonSignal}, topology.Hooks[1].Reasons)
+	assert.Equal(t, 3*time.Second, topology.Hooks[1].Timeout)
+	if assert.NotNil(t, topology.Hooks[0].Scope) {
+		assert.Equal(t, 5*time.Second, topology.Hooks[0].Scope.GracePeriod)
+		assert.Equal(t, "queue", topology.Hooks[0].Scope.Hooks[0].Name)
+	}
+
+	data, err := json.Marshal(topology)
+	assert.NoError(t, err)
+	var decoded map[string]any
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, "30s", decoded["grace_period"])
+	assert.Equal(t, "5s", decoded["kill_delay"])
+	assert.Equal(t, map[string]any{"parent": "1s"}, decoded["policies"])
+	assert.Equal(t, "20s", decoded["phases"].([]any)[1].(map[string]any)["timeout"])
+	hooks := decoded["hooks"].([]any)
+	assert.Equal(t, "3s", hooks[1].(map[string]any)["timeout"])
+	assert.Equal(t, "1s", hooks[2].(map[string]any)["estimate"])
+	scope := hooks[0].(map[string]any)["scope"].(map[string]any)
+	assert.Equal(t, "1s", scope["hooks"].([]any)[0].(map[string]any)["timeout"])
+}
@@ -0,0 +1,243 @@
+package gogs
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// spinnerFrames is the list of frames of the spinner of the running phases and hooks.
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// ANSI escape sequences of the terminal trace.
+const (
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiDim    = "\033[2m"
+	ansiReset  = "\033[0m"
+	ansiClear  = "\033[2K"
+	ansiUp     = "\033[%dA"
+)
+
+// WithTerminalTrace is an option that prints a compact live tree of the phases and hooks
+// of the shutdown to the standard error, with spinners and durations, when it is a
+// terminal. It is a convenience for the CLI tools and local servers, and does nothing when
+// the standard error is redirected, e.g. to a log collector. Colors are disabled when the
+// NO_COLOR variable is set.
+//
+//	shutting down (signal) 1.2s
+//	├─ deregister ✓ 12ms
+//	├─ drain ✓ 840ms
+//	└─ close ⠹
+//	   ├─ kafka ✓ 310ms
+//	   └─ postgres ⠹
+//
+// This example shows the trace while the postgres hook is executed.
+func WithTerminalTrace() Option {
+	return func(cfg *config) {
+		cfg.terminalTrace = true
+	}
+}
+
+// tracer is a struct that renders the terminal trace.
+type tracer struct {
+	// out is the terminal the trace is written to, and color reports whether it is
+	// colorized.
+	out   io.Writer
+	color bool
+
+	// reason is the reason of the shutdown.
+	reason Reason
+
+	// phases and hooks are the phases and hooks seen so far, in order.
+	phases []tracedStep
+	hooks  []tracedStep
+
+	// frame is the current frame of the spinner, and lines the count of lines written by
+	// the last render.
+	frame int
+	lines int
+}
+
+// tracedStep is a struct that describes a phase or a hook of the trace.
+type tracedStep struct {
+	// name is the name of the phase or the hook.
+	name string
+
+	// start and end are the times the step started and ended at, since the start of the
+	// shutdown. A zero end means that the step is running.
+	start, end time.Duration
+
+	// result is the outcome of a completed hook, if known.
+	result *HookResult
+}
+
+// startTrace is a method of the GracefulShutdown struct. It starts rendering the terminal
+// trace, if enabled and the standard error is a terminal, exactly once.
+func (gs *GracefulShutdown) startTrace(reason Reason) {
+	if !gs.cfg.terminalTrace {
+		return
+	}
+
+	gs.traceOnce.Do(func() {
+		gs.trace(reason)
+	})
+}
+
+// trace is a method of the GracefulShutdown struct. It renders the terminal trace until
+// the shutdown has completed.
+func (gs *GracefulShutdown) trace(reason Reason) {
+	out := gs.cfg.traceOut
+	if out == nil {
+		if !isTerminal(os.Stderr) {
+			return
+		}
+		out = os.Stderr
+	}
+
+	t := &tracer{out: out, color: os.Getenv("NO_COLOR") == "", reason: reason}
+	progress := gs.Progress()
+	go func() {
+		var last Snapshot
+		for s := range progress {
+			t.observe(s)
+			t.render(s.Elapsed, true)
+			last = s
+		}
+
+		t.finish(gs.Report(), last.Elapsed)
+		t.render(last.Elapsed, false)
+	}()
+}
+
+// isTerminal is a function that reports whether the provided file is a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// observe is a method of the tracer struct. It records the phase and the hook of the
+// provided snapshot.
+func (t *tracer) observe(s Snapshot) {
+	if s.Phase != "" && s.Phase != PhaseDone {
+		t.advance(s.Phase, s.Elapsed)
+	}
+
+	if s.Hook != "" && lastStep(t.hooks) != s.Hook {
+		endStep(t.hooks, s.Elapsed)
+		t.hooks = append(t.hooks, tracedStep{name: s.Hook, start: s.Elapsed})
+	}
+
+	t.frame++
+}
+
+// advance is a method of the tracer struct. It starts the provided phase at the provided
+// time, after the phases preceding it. Snapshots are dropped when the tracer falls behind,
+// so the phases missed are started and ended at that time too.
+func (t *tracer) advance(phase Phase, at time.Duration) {
+	if lastStep(t.phases) == string(phase) {
+		return
+	}
+
+	for _, p := range timedPhases[len(t.phases):] {
+		endStep(t.phases, at)
+		t.phases = append(t.phases, tracedStep{name: string(p), start: at})
+		if p == phase {
+			return
+		}
+	}
+}
+
+// finish is a method of the tracer struct. It ends the running steps and replaces the
+// hooks seen with the executed ones listed in the provided report.
+func (t *tracer) finish(report Report, elapsed time.Duration) {
+	endStep(t.phases, elapsed)
+
+	t.hooks = t.hooks[:0]
+	for i := range report.Hooks {
+		res := report.Hooks[i]
+		t.hooks = append(t.hooks, tracedStep{name: res.Name, end: res.Duration, result: &res})
+	}
+}
+
+// lastStep is a function that returns the name of the last of the provided steps, if any.
+func lastStep(steps []tracedStep) string {
+	if len(steps) == 0 {
+		return ""
+	}
+
+	return steps[len(steps)-1].name
+}
+
+// endStep is a function that ends the last of the provided steps at the provided time,
+// if it is running.
+func endStep(steps []tracedStep, at time.Duration) {
+	if len(steps) > 0 && steps[len(steps)-1].end == 0 {
+		steps[len(steps)-1].end = at
+		if steps[len(steps)-1].end == steps[len(steps)-1].start {
+			steps[len(steps)-1].end++
+		}
+	}
+}
+
+// render is a method of the tracer struct. It rewrites the tree in place.
+func (t *tracer) render(elapsed time.Duration, running bool) {
+	var b strings.Builder
+	if t.lines > 0 {
+		fmt.Fprintf(&b, ansiUp, t.lines)
+	}
+
+	header := fmt.Sprintf("shutting down (%s) %s", t.reason, t.paint(ansiDim, elapsed.Round(time.Millisecond).String()))
+	lines := []string{header}
+	for i, phase := range t.phases {
+		branch, indent := "├─ ", "│  "
+		if i == len(t.phases)-1 {
+			branch, indent = "└─ ", "   "
+		}
+		lines = append(lines, branch+phase.name+" "+t.status(phase, running))
+
+		if phase.name != string(PhaseClose) {
+			continue
+		}
+		for j, h := range t.hooks {
+			hookBranch := "├─ "
+			if j == len(t.hooks)-1 {
+				hookBranch = "└─ "
+			}
+			lines = append(lines, indent+hookBranch+h.name+" "+t.status(h, running))
+		}
+	}
+
+	for _, line := range lines {
+		b.WriteString(ansiClear + line + "\n")
+	}
+	t.lines = len(lines)
+
+	_, _ = io.WriteString(t.out, b.String())
+}
+
+// status is a method of the tracer struct. It describes the state of the provided step.
+func (t *tracer) status(step tracedStep, running bool) string {
+	switch {
+	case step.result != nil && step.result.Skipped:
+		return t.paint(ansiYellow, "skipped")
+	case step.result != nil && step.result.Err != nil:
+		return t.paint(ansiRed, "✗ "+step.end.Round(time.Millisecond).String()+" "+step.result.Err.Error())
+	case step.end != 0 || !running:
+		return t.paint(ansiGreen, "✓") + " " + t.paint(ansiDim, (step.end-step.start).Round(time.Millisecond).String())
+	default:
+		return spinnerFrames[t.frame%len(spinnerFrames)]
+	}
+}
+
+// paint is a method of the tracer struct. It colors the provided text, if enabled.
+func (t *tracer) paint(color, text string) string {
+	if !t.color {
+		return text
+	}
+
+	return color + text + ansiReset
+}
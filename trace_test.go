@@ -0,0 +1,78 @@
+package gogs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func Test_WithTerminalTrace(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	out := &syncBuffer{}
+	gs, _, cancel := New(context.Background(), WithTerminalTrace(), WithProgressInterval(10*time.Millisecond))
+	gs.cfg.traceOut = out
+
+	gs.AddHook("postgres", func(context.Context) error {
+		time.Sleep(2 * ShortDelay)
+		return nil
+	})
+	gs.AddHook("kafka", func(context.Context) error {
+		return errors.New("broker down")
+	})
+	gs.AddHook("cache", func(context.Context) error { return nil }, WithCondition(func() bool { return false }))
+
+	cancel()
+	gs.Wait()
+
+	assert.Eventually(t, func() bool {
+		return bytes.Contains([]byte(out.String()), []byte("└─ postgres ✓"))
+	}, LongDelay, time.Millisecond)
+
+	output := out.String()
+	assert.Regexp(t, "[⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏]", output)
+
+	frames := regexp.MustCompile(`\x1b\[\d+A`).Split(output, -1)
+	final := regexp.MustCompile(`\d+(\.\d+)?m?s`).ReplaceAllString(frames[len(frames)-1], "T")
+	assert.Equal(t, ansiClear+"shutting down (cancel) T\n"+
+		ansiClear+"├─ deregister ✓ T\n"+
+		ansiClear+"├─ drain ✓ T\n"+
+		ansiClear+"└─ close ✓ T\n"+
+		ansiClear+"   ├─ cache skipped\n"+
+		ansiClear+"   ├─ kafka ✗ T broker down\n"+
+		ansiClear+"   └─ postgres ✓ T\n", final)
+}
+
+func Test_WithTerminalTrace_NotTerminal(t *testing.T) {
+	t.Parallel()
+	gs := &GracefulShutdown{}
+	WithTerminalTrace()(&gs.cfg)
+
+	gs.Wait()
+
+	assert.False(t, isTerminal(nil))
+}
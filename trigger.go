@@ -0,0 +1,183 @@
+package gogs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+)
+
+// Trigger is an interface that describes a source of shutdown requests, e.g. signals, an
+// admin API, a failing health check or a parent context, see WithTrigger.
+type Trigger interface {
+	// Wait blocks until the trigger fires and returns the reason of the shutdown, or until
+	// the context is done and returns the context error. It returns another error if the
+	// trigger cannot fire anymore.
+	Wait(ctx context.Context) (Reason, error)
+}
+
+// TriggerFunc is a function that implements the Trigger interface.
+type TriggerFunc func(ctx context.Context) (Reason, error)
+
+// Wait is a method of the TriggerFunc type. It calls the function.
+func (f TriggerFunc) Wait(ctx context.Context) (Reason, error) {
+	return f(ctx)
+}
+
+// SignalTrigger is a function that returns a Trigger firing with ReasonSignal once one of
+// the provided signals is received, or an interrupt or termination signal if none is
+// provided.
+func SignalTrigger(signals ...os.Signal) Trigger {
+	if len(signals) == 0 {
+		signals = defaultSignals
+	}
+
+	return TriggerFunc(func(ctx context.Context) (Reason, error) {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, signals...)
+		defer signal.Stop(sigCh)
+
+		select {
+		case <-sigCh:
+			return ReasonSignal, nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	})
+}
+
+// ContextTrigger is a function that returns a Trigger firing with ReasonParent once the
+// provided context is done, e.g. the context of a parent component.
+func ContextTrigger(parent context.Context) Trigger {
+	return TriggerFunc(func(ctx context.Context) (Reason, error) {
+		select {
+		case <-parent.Done():
+			return ReasonParent, nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	})
+}
+
+// AnyOf is a function that returns a Trigger firing with the reason of the first of the
+// provided triggers to fire, and stops waiting for the others. It returns an error once
+// all the triggers have failed, combining their errors, or once the context is done,
+// combining the context error with the errors of the triggers failed before. It blocks
+// until the context is done if no trigger is provided.
+//
+//	trigger := AnyOf(
+//		SignalTrigger(syscall.SIGTERM),
+//		TriggerFunc(func(ctx context.Context) (Reason, error) {
+//			return "admin", admin.WaitShutdownRequest(ctx)
+//		}),
+//	)
+//
+// This example fires on SIGTERM or on a request of the admin API.
+func AnyOf(triggers ...Trigger) Trigger {
+	return TriggerFunc(func(ctx context.Context) (Reason, error) {
+		if len(triggers) == 0 {
+			<-ctx.Done()
+			return "", ctx.Err()
+		}
+
+		waitCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		type fired struct {
+			reason Reason
+			err    error
+		}
+
+		firedCh := make(chan fired, len(triggers))
+		for _, t := range triggers {
+			go func(t Trigger) {
+				reason, err := t.Wait(waitCtx)
+				firedCh <- fired{reason: reason, err: err}
+			}(t)
+		}
+
+		var errs multiError
+		done := func() (Reason, error) {
+			if len(errs) == 0 {
+				return "", ctx.Err()
+			}
+			return "", append(errs, ctx.Err())
+		}
+
+		for range triggers {
+			select {
+			case f := <-firedCh:
+				if f.err == nil {
+					return f.reason, nil
+				}
+				if ctx.Err() != nil && errors.Is(f.err, ctx.Err()) {
+					return done()
+				}
+				errs = append(errs, f.err)
+			case <-ctx.Done():
+				return done()
+			}
+		}
+
+		return "", errs
+	})
+}
+
+// WithTrigger is an option that starts the shutdown of an instance created by New once one
+// of the provided triggers fires, with the reason it returns, as if Trigger were called.
+// It complements the signals, the parent context and the cancel function, and the option
+// can be repeated. A trigger failing for another reason than the start of the shutdown is
+// logged through the logger set with WithLogger or the standard logger. It panics if no
+// trigger is provided, so that a misconfiguration is noticed at startup rather than
+// waiting forever. NewRequests, NewChannel and NewChannelWithOptions panic if configured
+// with it, since they create no context to cancel.
+//
+//	gs, ctx, cancel := New(
+//		context.Background(),
+//		WithSignals(syscall.SIGINT, syscall.SIGTERM),
+//		WithTrigger(TriggerFunc(func(ctx context.Context) (Reason, error) {
+//			return "leadership", election.WaitLost(ctx)
+//		})),
+//		WithPolicy("leadership", Policy{GracePeriod: 5 * time.Second}),
+//	)
+//
+// This example also shuts down within five seconds once the leadership is lost.
+func WithTrigger(triggers ...Trigger) Option {
+	return func(cfg *config) {
+		if len(triggers) == 0 {
+			panic("gogs: WithTrigger requires at least one trigger")
+		}
+		cfg.sources = append(cfg.sources, triggers...)
+	}
+}
+
+// watchTriggers is a method of the GracefulShutdown struct. It starts the shutdown once
+// one of the triggers set with WithTrigger fires, until the shutdown starts otherwise. A
+// shutdown held by Trigger and canceled with Abort re-arms the triggers, see
+// WithAbortWindow.
+func (gs *GracefulShutdown) watchTriggers() {
+	if len(gs.cfg.sources) == 0 {
+		return
+	}
+
+	trigger := AnyOf(gs.cfg.sources...)
+	go func() {
+		for {
+			reason, err := trigger.Wait(gs.ctx)
+			if err != nil {
+				if gs.ctx.Err() == nil {
+					gs.logger().Printf("gogs: trigger failed: %v", err)
+				}
+				return
+			}
+
+			aborted := gs.abortedChan()
+			gs.Trigger(reason)
+			select {
+			case <-aborted:
+			case <-gs.ctx.Done():
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,134 @@
+package gogs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func chanTrigger(reason Reason, fire <-chan error) Trigger {
+	return TriggerFunc(func(ctx context.Context) (Reason, error) {
+		select {
+		case err := <-fire:
+			return reason, err
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	})
+}
+
+func Test_AnyOf(t *testing.T) {
+	t.Parallel()
+
+	errFailed := errors.New("failed")
+	admin, health := make(chan error, 1), make(chan error, 1)
+	trigger := AnyOf(chanTrigger("admin", admin), chanTrigger("health", health))
+
+	health <- errFailed
+	admin <- nil
+	reason, err := trigger.Wait(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, Reason("admin"), reason)
+
+	admin, health = make(chan error, 1), make(chan error, 1)
+	trigger = AnyOf(chanTrigger("admin", admin), chanTrigger("health", health))
+	admin <- errFailed
+	health <- errFailed
+	_, err = trigger.Wait(context.Background())
+	assert.EqualError(t, err, "failed; failed")
+
+	ctx, cancel := context.WithTimeout(context.Background(), ShortDelay)
+	defer cancel()
+	_, err = AnyOf().Wait(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	admin, health = make(chan error, 1), make(chan error, 1)
+	health <- errFailed
+	ctx, cancel = context.WithTimeout(context.Background(), ShortDelay)
+	defer cancel()
+	_, err = AnyOf(chanTrigger("admin", admin), chanTrigger("health", health)).Wait(ctx)
+	assert.ErrorIs(t, err, errFailed)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.EqualError(t, err, "failed; context deadline exceeded")
+
+	parent, cancelParent := context.WithCancel(context.Background())
+	cancelParent()
+	reason, err = AnyOf(ContextTrigger(parent), chanTrigger("admin", admin)).Wait(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, ReasonParent, reason)
+}
+
+func Test_WithTrigger(t *testing.T) {
+	t.Parallel()
+
+	health := make(chan error, 1)
+	gs, ctx, cancel := New(
+		context.Background(),
+		WithTrigger(chanTrigger("health", health)),
+		WithPolicy("health", Policy{GracePeriod: time.Second}),
+	)
+	defer cancel()
+
+	health <- nil
+	<-ctx.Done()
+	gs.Wait()
+	assert.Equal(t, Reason("health"), gs.Reason())
+}
+
+func Test_WithTrigger_Aborted(t *testing.T) {
+	t.Parallel()
+
+	health := make(chan error, 1)
+	gs, ctx, cancel := New(
+		context.Background(),
+		WithTrigger(chanTrigger("health", health)),
+		WithAbortWindow(LongDelay),
+	)
+	defer cancel()
+
+	for i := 0; i < 2; i++ {
+		health <- nil
+		assert.Eventually(t, func() bool {
+			return gs.Abort() == nil
+		}, LongDelay, time.Millisecond, "the trigger is re-armed after Abort")
+	}
+	assert.NoError(t, ctx.Err())
+
+	health <- nil
+	<-ctx.Done()
+	gs.Wait()
+	assert.Equal(t, Reason("health"), gs.Reason())
+}
+
+func Test_WithTrigger_Empty(t *testing.T) {
+	t.Parallel()
+
+	assert.PanicsWithValue(t, "gogs: WithTrigger requires at least one trigger", func() {
+		New(context.Background(), WithTrigger())
+	})
+}
+
+func Test_WithTrigger_Failed(t *testing.T) {
+	t.Parallel()
+
+	var buf syncBuffer
+	admin := make(chan error, 1)
+	gs, ctx, cancel := New(
+		context.Background(),
+		WithTrigger(chanTrigger("admin", admin)),
+		WithLogger(log.New(&buf, "", 0)),
+	)
+	defer cancel()
+
+	admin <- errors.New("listener closed")
+	assert.Eventually(t, func() bool {
+		return strings.Contains(buf.String(), "gogs: trigger failed: listener closed")
+	}, LongDelay, time.Millisecond)
+	assert.NoError(t, ctx.Err())
+	assert.Equal(t, Reason(""), gs.Reason())
+}
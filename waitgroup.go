@@ -0,0 +1,39 @@
+package gogs
+
+// WaitGroup is an interface that describes the synchronization the active shutdown
+// events are counted with. *sync.WaitGroup implements it and is used by default.
+type WaitGroup interface {
+	// Add adds the delta, which may be negative, to the counter.
+	Add(delta int)
+
+	// Done decrements the counter by one.
+	Done()
+
+	// Wait blocks until the counter is zero.
+	Wait()
+}
+
+// WithWaitGroup is an option that replaces the sync.WaitGroup counting the active
+// shutdown events, e.g. with an implementation driven by a deterministic scheduler, so
+// that the drain can be tested under chosen interleavings. The implementation must be
+// safe for concurrent use.
+//
+//	gs, ctx, cancel := New(context.Background(), WithWaitGroup(sched.NewWaitGroup()))
+//
+// This example counts the active shutdown events with the wait group of a model-checking
+// scheduler.
+func WithWaitGroup(wg WaitGroup) Option {
+	return func(cfg *config) {
+		cfg.waitGroup = wg
+	}
+}
+
+// waitGroup is a method of the GracefulShutdown struct. It returns the WaitGroup set with
+// WithWaitGroup, or the embedded sync.WaitGroup.
+func (gs *GracefulShutdown) waitGroup() WaitGroup {
+	if gs.cfg.waitGroup != nil {
+		return gs.cfg.waitGroup
+	}
+
+	return &gs.wg
+}
@@ -0,0 +1,53 @@
+package gogs
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testWaitGroup struct {
+	sync.WaitGroup
+
+	mu    sync.Mutex
+	calls []string
+}
+
+func (wg *testWaitGroup) Add(delta int) {
+	wg.record("add")
+	wg.WaitGroup.Add(delta)
+}
+
+func (wg *testWaitGroup) Done() {
+	wg.record("done")
+	wg.WaitGroup.Done()
+}
+
+func (wg *testWaitGroup) Wait() {
+	wg.record("wait")
+	wg.WaitGroup.Wait()
+}
+
+func (wg *testWaitGroup) record(call string) {
+	wg.mu.Lock()
+	defer wg.mu.Unlock()
+
+	wg.calls = append(wg.calls, call)
+}
+
+func Test_WithWaitGroup(t *testing.T) {
+	t.Parallel()
+	wg := &testWaitGroup{}
+	gs, _, cancel := New(context.Background(), WithWaitGroup(wg))
+
+	gs.Subscribe()
+	gs.Unsubscribe()
+	cancel()
+	gs.Wait()
+
+	wg.mu.Lock()
+	defer wg.mu.Unlock()
+	assert.Equal(t, []string{"add", "done", "wait"}, wg.calls)
+}
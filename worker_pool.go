@@ -0,0 +1,238 @@
+package gogs
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WorkerPool is a struct that executes the submitted tasks on a bounded count of workers
+// with a bounded queue, and is drained by the shutdown: it rejects new tasks as soon as the
+// shutdown is triggered, and its workers are active shutdown events, so the drain waits for
+// them to execute the queued tasks and exit.
+//
+//	pool := NewWorkerPool(gs, "thumbnails", 8, 100)
+//
+//	if err := pool.Submit(ctx, func() { resize(img) }); err != nil {
+//		return err
+//	}
+//
+// This example resizes the images on eight workers, queuing up to one hundred of them, and
+// resizes the queued ones before the shutdown completes.
+type WorkerPool struct {
+	// tasks is the queue of submitted tasks.
+	tasks chan func()
+
+	// mu is read-locked by the submissions and write-locked to stop the pool, so that no
+	// task is queued once the workers may have exited. stopped is guarded by mu.
+	mu      sync.RWMutex
+	stopped bool
+
+	// stopping is closed once the pool starts stopping, releasing the waiting submissions,
+	// and stoppedCh once no task can be queued anymore, making the workers exit once the
+	// queue is empty.
+	stopping  chan struct{}
+	stoppedCh chan struct{}
+	stopOnce  sync.Once
+
+	// workers is the count of workers, and busy the count of workers executing a task.
+	workers int
+	busy    atomic.Int32
+
+	// submitted, rejected and completed count the tasks, and drained the ones completed
+	// after the pool stopped.
+	submitted atomic.Int64
+	rejected  atomic.Int64
+	completed atomic.Int64
+	drained   atomic.Int64
+
+	// stoppedAt is the time the pool stopped, and exited the count of exited workers,
+	// the last one recording the duration of the drain.
+	stoppedAt  time.Time
+	exited     atomic.Int32
+	drainNanos atomic.Int64
+}
+
+// WorkerPoolStats is a struct that describes the activity of a WorkerPool.
+type WorkerPoolStats struct {
+	// Workers is the count of workers, and Busy the count of them executing a task.
+	Workers int
+	Busy    int
+
+	// Queued is the count of tasks waiting for a worker.
+	Queued int
+
+	// Submitted is the count of tasks accepted, and Rejected the count of tasks rejected
+	// because the pool stopped or the queue was full.
+	Submitted int64
+	Rejected  int64
+
+	// Completed is the count of tasks executed, and Drained the count of them executed
+	// once the pool stopped.
+	Completed int64
+	Drained   int64
+
+	// Stopped reports whether the pool stopped, and DrainDuration the time its workers took
+	// to execute the queued tasks and exit once it stopped, zero until all of them exited.
+	Stopped       bool
+	DrainDuration time.Duration
+}
+
+// NewWorkerPool is a function that creates a new WorkerPool with the provided count of
+// workers, at least one, and the provided size of the queue. The pool is registered as
+// intake with the provided name, see AddIntake, and each worker is an active shutdown event
+// under that name, see SubscribeNamed.
+func NewWorkerPool(gs *GracefulShutdown, name string, workers, queueSize int) *WorkerPool {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+
+	p := &WorkerPool{
+		tasks:     make(chan func(), queueSize),
+		stopping:  make(chan struct{}),
+		stoppedCh: make(chan struct{}),
+		workers:   workers,
+	}
+	for i := 0; i < workers; i++ {
+		go p.work(gs.SubscribeNamed(name))
+	}
+	gs.AddIntake(name, p.stop)
+
+	return p
+}
+
+// Submit is a method of the WorkerPool struct. It waits for room in the queue and queues
+// the provided task. It returns ErrShuttingDown if the shutdown has started, or the context
+// error if the context is done first.
+func (p *WorkerPool) Submit(ctx context.Context, task func()) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.stopped {
+		p.rejected.Add(1)
+		return ErrShuttingDown
+	}
+
+	select {
+	case p.tasks <- task:
+		p.submitted.Add(1)
+		return nil
+	case <-p.stopping:
+		p.rejected.Add(1)
+		return ErrShuttingDown
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TrySubmit is a method of the WorkerPool struct. It queues the provided task if there is
+// room in the queue right away. It returns ErrLimitExceeded if the queue is full, or
+// ErrShuttingDown if the shutdown has started.
+func (p *WorkerPool) TrySubmit(task func()) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.stopped {
+		p.rejected.Add(1)
+		return ErrShuttingDown
+	}
+
+	select {
+	case p.tasks <- task:
+		p.submitted.Add(1)
+		return nil
+	default:
+		p.rejected.Add(1)
+		return ErrLimitExceeded
+	}
+}
+
+// Stats is a method of the WorkerPool struct. It returns the current activity of the pool.
+func (p *WorkerPool) Stats() WorkerPoolStats {
+	p.mu.RLock()
+	stopped := p.stopped
+	p.mu.RUnlock()
+
+	return WorkerPoolStats{
+		Workers:       p.workers,
+		Busy:          int(p.busy.Load()),
+		Queued:        len(p.tasks),
+		Submitted:     p.submitted.Load(),
+		Rejected:      p.rejected.Load(),
+		Completed:     p.completed.Load(),
+		Drained:       p.drained.Load(),
+		Stopped:       stopped,
+		DrainDuration: time.Duration(p.drainNanos.Load()),
+	}
+}
+
+// work is a method of the WorkerPool struct. It executes the queued tasks until the pool
+// stopped and the queue is empty, then completes the active shutdown event with the
+// provided function.
+func (p *WorkerPool) work(done func()) {
+	defer done()
+
+	for {
+		select {
+		case task := <-p.tasks:
+			p.run(task)
+		case <-p.stoppedCh:
+			for {
+				select {
+				case task := <-p.tasks:
+					p.run(task)
+				default:
+					if p.exited.Add(1) == int32(p.workers) {
+						p.drainNanos.Store(int64(time.Since(p.stoppedAt)))
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+// run is a method of the WorkerPool struct. It executes the provided task and counts it.
+func (p *WorkerPool) run(task func()) {
+	p.busy.Add(1)
+	defer func() {
+		p.busy.Add(-1)
+		p.completed.Add(1)
+		if p.isStopped() {
+			p.drained.Add(1)
+		}
+	}()
+
+	task()
+}
+
+// stop is a method of the WorkerPool struct. It rejects the new tasks and makes the
+// workers exit once the queue is empty.
+func (p *WorkerPool) stop() error {
+	p.stopOnce.Do(func() {
+		close(p.stopping)
+
+		p.mu.Lock()
+		p.stopped = true
+		p.stoppedAt = time.Now()
+		p.mu.Unlock()
+
+		close(p.stoppedCh)
+	})
+
+	return nil
+}
+
+// isStopped is a method of the WorkerPool struct. It reports whether the pool stopped.
+func (p *WorkerPool) isStopped() bool {
+	select {
+	case <-p.stoppedCh:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,77 @@
+package gogs
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WorkerPool(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background())
+
+	pool := NewWorkerPool(gs, "jobs", 2, 10)
+	assert.Equal(t, int32(2), gs.Count())
+
+	release := make(chan struct{})
+	var executed atomic.Int32
+	for i := 0; i < 6; i++ {
+		assert.NoError(t, pool.Submit(context.Background(), func() {
+			<-release
+			executed.Add(1)
+		}))
+	}
+
+	cancel()
+	go func() {
+		shortDelay()
+		stats := pool.Stats()
+		assert.True(t, stats.Stopped)
+		assert.Equal(t, 2, stats.Busy)
+		assert.Equal(t, 4, stats.Queued)
+		assert.ErrorIs(t, pool.Submit(context.Background(), func() {}), ErrShuttingDown)
+		assert.ErrorIs(t, pool.TrySubmit(func() {}), ErrShuttingDown)
+		close(release)
+	}()
+	gs.Wait()
+
+	assert.Equal(t, int32(6), executed.Load())
+	assert.Equal(t, int32(0), gs.Count())
+
+	stats := pool.Stats()
+	assert.Equal(t, int64(6), stats.Submitted)
+	assert.Equal(t, int64(2), stats.Rejected)
+	assert.Equal(t, int64(6), stats.Completed)
+	assert.Equal(t, int64(6), stats.Drained)
+	assert.Zero(t, stats.Busy)
+	assert.Positive(t, stats.DrainDuration)
+}
+
+func Test_WorkerPool_TrySubmit(t *testing.T) {
+	t.Parallel()
+	gs, _, cancel := New(context.Background())
+	defer cancel()
+
+	pool := NewWorkerPool(gs, "jobs", 1, 1)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	assert.NoError(t, pool.TrySubmit(func() {
+		close(started)
+		<-release
+	}))
+	<-started
+	assert.NoError(t, pool.TrySubmit(func() {}))
+	assert.ErrorIs(t, pool.TrySubmit(func() {}), ErrLimitExceeded)
+
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	ctxCancel()
+	assert.ErrorIs(t, pool.Submit(ctx, func() {}), context.Canceled)
+	close(release)
+
+	stats := pool.Stats()
+	assert.Equal(t, 1, stats.Workers)
+	assert.Equal(t, int64(1), stats.Rejected)
+	assert.False(t, stats.Stopped)
+}